@@ -0,0 +1,349 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package adminrpc defines the pool's gRPC admin service. The messages and
+// service descriptions below mirror admin.proto and are maintained by hand,
+// keep both in sync when changing the service.
+package adminrpc
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// ServiceName is the full name of the admin service.
+const ServiceName = "adminrpc.AdminService"
+
+// TokenMetadataKey is the request metadata key of the admin token.
+const TokenMetadataKey = "authorization"
+
+type ListClientsRequest struct{}
+
+func (m *ListClientsRequest) Reset()         { *m = ListClientsRequest{} }
+func (m *ListClientsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListClientsRequest) ProtoMessage()    {}
+
+type Client struct {
+	Id         string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Ip         string `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	AccountId  string `protobuf:"bytes,3,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Account    string `protobuf:"bytes,4,opt,name=account,proto3" json:"account,omitempty"`
+	Worker     string `protobuf:"bytes,5,opt,name=worker,proto3" json:"worker,omitempty"`
+	Miner      string `protobuf:"bytes,6,opt,name=miner,proto3" json:"miner,omitempty"`
+	Difficulty string `protobuf:"bytes,7,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	Hashrate   string `protobuf:"bytes,8,opt,name=hashrate,proto3" json:"hashrate,omitempty"`
+	Accepted   uint32 `protobuf:"varint,9,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected   uint32 `protobuf:"varint,10,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	Region     string `protobuf:"bytes,11,opt,name=region,proto3" json:"region,omitempty"`
+}
+
+func (m *Client) Reset()         { *m = Client{} }
+func (m *Client) String() string { return proto.CompactTextString(m) }
+func (*Client) ProtoMessage()    {}
+
+type ListClientsResponse struct {
+	Clients []*Client `protobuf:"bytes,1,rep,name=clients,proto3" json:"clients,omitempty"`
+}
+
+func (m *ListClientsResponse) Reset()         { *m = ListClientsResponse{} }
+func (m *ListClientsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListClientsResponse) ProtoMessage()    {}
+
+type BanIPRequest struct {
+	Ip     string `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (m *BanIPRequest) Reset()         { *m = BanIPRequest{} }
+func (m *BanIPRequest) String() string { return proto.CompactTextString(m) }
+func (*BanIPRequest) ProtoMessage()    {}
+
+type BanIPResponse struct {
+	Disconnected uint32 `protobuf:"varint,1,opt,name=disconnected,proto3" json:"disconnected,omitempty"`
+}
+
+func (m *BanIPResponse) Reset()         { *m = BanIPResponse{} }
+func (m *BanIPResponse) String() string { return proto.CompactTextString(m) }
+func (*BanIPResponse) ProtoMessage()    {}
+
+type UnbanIPRequest struct {
+	Ip string `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+}
+
+func (m *UnbanIPRequest) Reset()         { *m = UnbanIPRequest{} }
+func (m *UnbanIPRequest) String() string { return proto.CompactTextString(m) }
+func (*UnbanIPRequest) ProtoMessage()    {}
+
+type UnbanIPResponse struct{}
+
+func (m *UnbanIPResponse) Reset()         { *m = UnbanIPResponse{} }
+func (m *UnbanIPResponse) String() string { return proto.CompactTextString(m) }
+func (*UnbanIPResponse) ProtoMessage()    {}
+
+type ListBansRequest struct{}
+
+func (m *ListBansRequest) Reset()         { *m = ListBansRequest{} }
+func (m *ListBansRequest) String() string { return proto.CompactTextString(m) }
+func (*ListBansRequest) ProtoMessage()    {}
+
+type Ban struct {
+	Ip        string `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	Reason    string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Operator  string `protobuf:"bytes,3,opt,name=operator,proto3" json:"operator,omitempty"`
+	CreatedOn int64  `protobuf:"varint,4,opt,name=created_on,json=createdOn,proto3" json:"created_on,omitempty"`
+}
+
+func (m *Ban) Reset()         { *m = Ban{} }
+func (m *Ban) String() string { return proto.CompactTextString(m) }
+func (*Ban) ProtoMessage()    {}
+
+type ListBansResponse struct {
+	Bans []*Ban `protobuf:"bytes,1,rep,name=bans,proto3" json:"bans,omitempty"`
+}
+
+func (m *ListBansResponse) Reset()         { *m = ListBansResponse{} }
+func (m *ListBansResponse) String() string { return proto.CompactTextString(m) }
+func (*ListBansResponse) ProtoMessage()    {}
+
+type TriggerPayoutsRequest struct{}
+
+func (m *TriggerPayoutsRequest) Reset()         { *m = TriggerPayoutsRequest{} }
+func (m *TriggerPayoutsRequest) String() string { return proto.CompactTextString(m) }
+func (*TriggerPayoutsRequest) ProtoMessage()    {}
+
+type TriggerPayoutsResponse struct {
+	Height uint32 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (m *TriggerPayoutsResponse) Reset()         { *m = TriggerPayoutsResponse{} }
+func (m *TriggerPayoutsResponse) String() string { return proto.CompactTextString(m) }
+func (*TriggerPayoutsResponse) ProtoMessage()    {}
+
+type BackupDBRequest struct{}
+
+func (m *BackupDBRequest) Reset()         { *m = BackupDBRequest{} }
+func (m *BackupDBRequest) String() string { return proto.CompactTextString(m) }
+func (*BackupDBRequest) ProtoMessage()    {}
+
+type BackupDBResponse struct {
+	Chunk []byte `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk,omitempty"`
+}
+
+func (m *BackupDBResponse) Reset()         { *m = BackupDBResponse{} }
+func (m *BackupDBResponse) String() string { return proto.CompactTextString(m) }
+func (*BackupDBResponse) ProtoMessage()    {}
+
+// AdminServiceServer is the server API of the admin service.
+type AdminServiceServer interface {
+	ListClients(context.Context, *ListClientsRequest) (*ListClientsResponse, error)
+	BanIP(context.Context, *BanIPRequest) (*BanIPResponse, error)
+	UnbanIP(context.Context, *UnbanIPRequest) (*UnbanIPResponse, error)
+	ListBans(context.Context, *ListBansRequest) (*ListBansResponse, error)
+	TriggerPayouts(context.Context, *TriggerPayoutsRequest) (*TriggerPayoutsResponse, error)
+	BackupDB(*BackupDBRequest, AdminService_BackupDBServer) error
+}
+
+// AdminService_BackupDBServer is the server stream of database backups.
+type AdminService_BackupDBServer interface {
+	Send(*BackupDBResponse) error
+	grpc.ServerStream
+}
+
+type adminServiceBackupDBServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminServiceBackupDBServer) Send(m *BackupDBResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// RegisterAdminServiceServer registers the provided admin service
+// implementation with the provided grpc server.
+func RegisterAdminServiceServer(s *grpc.Server, srv AdminServiceServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+// unaryHandler returns the grpc method handler of a unary admin call.
+func unaryHandler(method string, newReq func() interface{}, call func(AdminServiceServer, context.Context, interface{}) (interface{}, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := newReq()
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(srv.(AdminServiceServer), ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/" + ServiceName + "/" + method,
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(AdminServiceServer), ctx, req)
+		}
+		return interceptor(ctx, req, info, handler)
+	}
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListClients",
+			Handler: unaryHandler("ListClients",
+				func() interface{} { return new(ListClientsRequest) },
+				func(s AdminServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.ListClients(ctx, req.(*ListClientsRequest))
+				}),
+		},
+		{
+			MethodName: "BanIP",
+			Handler: unaryHandler("BanIP",
+				func() interface{} { return new(BanIPRequest) },
+				func(s AdminServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.BanIP(ctx, req.(*BanIPRequest))
+				}),
+		},
+		{
+			MethodName: "UnbanIP",
+			Handler: unaryHandler("UnbanIP",
+				func() interface{} { return new(UnbanIPRequest) },
+				func(s AdminServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.UnbanIP(ctx, req.(*UnbanIPRequest))
+				}),
+		},
+		{
+			MethodName: "ListBans",
+			Handler: unaryHandler("ListBans",
+				func() interface{} { return new(ListBansRequest) },
+				func(s AdminServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.ListBans(ctx, req.(*ListBansRequest))
+				}),
+		},
+		{
+			MethodName: "TriggerPayouts",
+			Handler: unaryHandler("TriggerPayouts",
+				func() interface{} { return new(TriggerPayoutsRequest) },
+				func(s AdminServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.TriggerPayouts(ctx, req.(*TriggerPayoutsRequest))
+				}),
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "BackupDB",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				m := new(BackupDBRequest)
+				if err := stream.RecvMsg(m); err != nil {
+					return err
+				}
+				return srv.(AdminServiceServer).BackupDB(m,
+					&adminServiceBackupDBServer{stream})
+			},
+			ServerStreams: true,
+		},
+	},
+	Metadata: "admin.proto",
+}
+
+// AdminServiceClient is the client API of the admin service.
+type AdminServiceClient interface {
+	ListClients(ctx context.Context, in *ListClientsRequest, opts ...grpc.CallOption) (*ListClientsResponse, error)
+	BanIP(ctx context.Context, in *BanIPRequest, opts ...grpc.CallOption) (*BanIPResponse, error)
+	UnbanIP(ctx context.Context, in *UnbanIPRequest, opts ...grpc.CallOption) (*UnbanIPResponse, error)
+	ListBans(ctx context.Context, in *ListBansRequest, opts ...grpc.CallOption) (*ListBansResponse, error)
+	TriggerPayouts(ctx context.Context, in *TriggerPayoutsRequest, opts ...grpc.CallOption) (*TriggerPayoutsResponse, error)
+	BackupDB(ctx context.Context, in *BackupDBRequest, opts ...grpc.CallOption) (AdminService_BackupDBClient, error)
+}
+
+type adminServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewAdminServiceClient creates an admin service client using the provided
+// connection.
+func NewAdminServiceClient(cc *grpc.ClientConn) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) ListClients(ctx context.Context, in *ListClientsRequest, opts ...grpc.CallOption) (*ListClientsResponse, error) {
+	out := new(ListClientsResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/ListClients", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) BanIP(ctx context.Context, in *BanIPRequest, opts ...grpc.CallOption) (*BanIPResponse, error) {
+	out := new(BanIPResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/BanIP", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) UnbanIP(ctx context.Context, in *UnbanIPRequest, opts ...grpc.CallOption) (*UnbanIPResponse, error) {
+	out := new(UnbanIPResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/UnbanIP", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListBans(ctx context.Context, in *ListBansRequest, opts ...grpc.CallOption) (*ListBansResponse, error) {
+	out := new(ListBansResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/ListBans", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) TriggerPayouts(ctx context.Context, in *TriggerPayoutsRequest, opts ...grpc.CallOption) (*TriggerPayoutsResponse, error) {
+	out := new(TriggerPayoutsResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/TriggerPayouts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminService_BackupDBClient is the client stream of database backups.
+type AdminService_BackupDBClient interface {
+	Recv() (*BackupDBResponse, error)
+	grpc.ClientStream
+}
+
+type adminServiceBackupDBClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminServiceBackupDBClient) Recv() (*BackupDBResponse, error) {
+	m := new(BackupDBResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminServiceClient) BackupDB(ctx context.Context, in *BackupDBRequest, opts ...grpc.CallOption) (AdminService_BackupDBClient, error) {
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0],
+		"/"+ServiceName+"/BackupDB", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminServiceBackupDBClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package adminrpc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func TestMessageEncoding(t *testing.T) {
+	resp := &ListClientsResponse{
+		Clients: []*Client{{
+			Id:         "127.0.0.1:5550/1",
+			Ip:         "127.0.0.1:5550",
+			AccountId:  "a1",
+			Difficulty: "256",
+			Accepted:   12,
+			Rejected:   1,
+		}},
+	}
+
+	b, err := proto.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded ListClientsResponse
+	err = proto.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !proto.Equal(resp, &decoded) {
+		t.Fatalf("expected %v, got %v", resp, &decoded)
+	}
+
+	chunk := &BackupDBResponse{Chunk: []byte{0, 1, 2, 3}}
+	b, err = proto.Marshal(chunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decodedChunk BackupDBResponse
+	err = proto.Unmarshal(b, &decodedChunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(chunk.Chunk, decodedChunk.Chunk) {
+		t.Fatalf("expected chunk %x, got %x", chunk.Chunk,
+			decodedChunk.Chunk)
+	}
+}
@@ -314,13 +314,9 @@ func loadConfig() (*config, []string, error) {
 	}
 
 	// Set the active network.
-	switch cfg.ActiveNet {
-	case chaincfg.SimNetParams.Name:
-		cfg.net = &chaincfg.SimNetParams
-	case chaincfg.TestNet3Params.Name:
-		cfg.net = &chaincfg.TestNet3Params
-	case chaincfg.MainNetParams.Name:
-		cfg.net = &chaincfg.MainNetParams
+	cfg.net, err = util.NetParams(cfg.ActiveNet)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Warn about missing config file only after all other configuration is
@@ -6,8 +6,12 @@ package main
 
 import (
 	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -23,6 +27,7 @@ import (
 	flags "github.com/jessevdk/go-flags"
 
 	"github.com/dnldd/dcrpool/dividend"
+	"github.com/dnldd/dcrpool/network"
 	"github.com/dnldd/dcrpool/util"
 )
 
@@ -35,6 +40,8 @@ const (
 	defaultDBFilename      = "dcrpool.kv"
 	defaultTLSCertFilename = "dcrpool.cert"
 	defaultTLSKeyFilename  = "dcrpool.key"
+	defaultTokenFilename   = "admin.token"
+	defaultCertsDirname    = "certs"
 	defaultRPCCertFilename = "rpc.cert"
 	defaultRPCUser         = "dcrp"
 	defaultRPCPass         = "dcrppass"
@@ -45,9 +52,33 @@ const (
 	defaultPoolFee         = 0.01
 	defaultLastNPeriod     = 86400 // 1 day
 	defaultWalletPass      = ""
+	defaultPayoutAccount   = "default"
 	defaultMaxTxFeeReserve = 0.1
+	defaultMinFeeRate      = 0.0001
+	defaultMaxFeeRate      = 0.001
+	maxFeeRateLimit        = 1.0
 	defaultSoloPool        = false
 	defaultAPIPort         = 8080
+	defaultSMTPFrom        = "dcrpool@localhost"
+	defaultAddrChangeDelay = 172800 // 2 days
+	defaultWorkerOffline   = 600    // 10 minutes
+	defaultSessionLifetime = 604800 // 7 days
+	defaultReadyLatency    = 2000   // 2 seconds
+	defaultHAFailover      = 90     // 90 seconds
+	defaultSubmitRetries   = 3
+	maxSubmitRetries       = 10
+	defaultACMEHTTPPort    = 80
+	defaultListenHost      = "0.0.0.0"
+	defaultProfileListen   = "127.0.0.1"
+	defaultAPIRate         = 1
+	defaultAPIBurst        = 5
+	defaultAPIKeyRate      = 5
+	defaultAPIKeyBurst     = 20
+	defaultLogSize         = 10 // 10 megabytes
+	defaultLogInterval     = 24 // 1 day
+	defaultLogRolls        = 10
+	logFormatText          = "text"
+	logFormatJSON          = "json"
 )
 
 var (
@@ -65,8 +96,19 @@ var (
 	defaultLogDir        = filepath.Join(dcrpoolHomeDir, defaultLogDirname)
 	defaultTLSCertFile   = filepath.Join(dcrpoolHomeDir, defaultTLSCertFilename)
 	defaultTLSKeyFile    = filepath.Join(dcrpoolHomeDir, defaultTLSKeyFilename)
+	defaultTokenFile     = filepath.Join(dcrpoolHomeDir, defaultTokenFilename)
+	defaultCertsDir      = filepath.Join(dcrpoolHomeDir, defaultCertsDirname)
+	defaultCORSOrigins   = []string{"*"}
+	defaultCORSMethods   = []string{"GET", "POST", "OPTIONS"}
+	defaultCORSHeaders   = []string{"Content-Type", "Authorization", "X-API-Key"}
 )
 
+// defaultExplorerURLs are the public dcrdata block explorers of networks.
+var defaultExplorerURLs = map[string]string{
+	chaincfg.MainNetParams.Name:  "https://explorer.dcrdata.org",
+	chaincfg.TestNet3Params.Name: "https://testnet.dcrdata.org",
+}
+
 // runServiceCommand is only set to a real function on Windows.  It is used
 // to parse and execute service commands specified via the -s flag.
 var runServiceCommand func(string) error
@@ -78,27 +120,118 @@ type config struct {
 	DataDir         string   `long:"datadir" description:"The data directory."`
 	ActiveNet       string   `long:"activenet" description:"The active network being mined on. {testnet3, mainnet, simnet}"`
 	APIPort         uint32   `long:"apiport" description:"The pool API port."`
+	APIListen       string   `long:"apilisten" description:"The interface the pool API and web interface listen on, eg. 127.0.0.1 to only serve a local reverse proxy."`
+	NoAPI           bool     `long:"noapi" description:"Disable the pool API and web interface, for stratum-only instances."`
+	CompatAPI       bool     `long:"compatapi" description:"Serve pool and miner statistics by payout address in the format of the Miningcore pool api, for existing monitoring apps."`
+	StratumListen   string   `long:"stratumlisten" description:"The interface stratum endpoints listen on."`
+	LogFormat       string   `long:"logformat" description:"The format of log entries, text or json. Json entries are objects with the time, level, subsystem and message of the entry, one per line."`
 	DebugLevel      string   `long:"debuglevel" description:"Logging level for all subsystems. {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
 	LogDir          string   `long:"logdir" description:"Directory to log output."`
+	LogSize         uint32   `long:"logsize" description:"The size in megabytes the log file is rotated at. Set to 0 to disable size based rotation."`
+	LogInterval     uint32   `long:"loginterval" description:"The period in hours the log file is rotated at. Set to 0 to disable time based rotation."`
+	LogRolls        uint32   `long:"logrolls" description:"The number of rotated log files retained. Set to 0 to retain all rotated log files."`
+	LogRetention    uint32   `long:"logretention" description:"The period in days rotated log files are retained for. Set to 0 to retain rotated log files regardless of their age."`
+	NoLogCompress   bool     `long:"nologcompress" description:"Disable gzip compression of rotated log files."`
 	DBFile          string   `long:"dbfile" description:"Path to the database file."`
 	DcrdRPCHost     string   `long:"dcrdrpchost" description:"The ip:port to establish an RPC connection for dcrd."`
 	DcrdRPCCert     string   `long:"dcrdrpccert" description:"The dcrd RPC certificate."`
+	DcrdBackups     []string `long:"dcrdbackuphost" description:"The ip:port of a backup dcrd node failed over to when dcrd disconnects or lags behind, may be repeated. Backup nodes share the RPC credentials of dcrd."`
+	DcrdBackupCerts []string `long:"dcrdbackupcert" description:"The RPC certificate of a backup dcrd node, may be repeated."`
 	WalletGRPCHost  string   `long:"walletgrpchost" description:"The ip:port to establish a GRPC connection for the wallet."`
 	WalletRPCCert   string   `long:"walletrpccert" description:"The wallet RPC certificate."`
+	WalletTLSCert   string   `long:"walletclientcert" description:"The client certificate presented to the wallet, for wallets requiring grpc client certificates."`
+	WalletTLSKey    string   `long:"walletclientkey" description:"The key of the wallet client certificate."`
 	RPCUser         string   `long:"rpcuser" description:"Username for RPC connections."`
 	RPCPass         string   `long:"rpcpass" default-mask:"-" description:"Password for RPC connections."`
 	PoolFeeAddrs    []string `long:"poolfeeaddrs" description:"Payment addresses to use for pool fee transactions. These addresses should be generated from a dedicated wallet account for pool fees."`
 	PoolFee         float64  `long:"poolfee" description:"The fee charged for pool participation. eg. 0.01 (1%), 0.05 (5%)."`
 	MaxTxFeeReserve float64  `long:"maxtxfeereserve" description:"The maximum amount reserved for transaction fees, in DCR."`
+	MinFeeRate      float64  `long:"minfeerate" description:"The minimum fee rate of payout transactions, in DCR/kB. Payouts use the fee rate estimated by dcrd within the fee rate bounds, or the minimum fee rate when dcrd cannot estimate it."`
+	MaxFeeRate      float64  `long:"maxfeerate" description:"The maximum fee rate of payout transactions, in DCR/kB."`
 	MaxGenTime      uint64   `long:"maxgentime" description:"The share creation target time for the pool in seconds."`
 	PaymentMethod   string   `long:"paymentmethod" description:"The payment method of the pool. {pps, pplns}"`
 	LastNPeriod     uint32   `long:"lastnperiod" description:"The period of interest when using the PPLNS payment scheme."`
 	WalletPass      string   `long:"walletpass" description:"The wallet passphrase."`
+	PayoutAccount   string   `long:"payoutaccount" description:"The wallet account payouts are made from, keeping pool funds apart from other wallet balances. The mining address of dcrd should belong to the account."`
+	WalletPassFile  string   `long:"walletpassfile" description:"Path to a file holding the wallet passphrase, such as a mounted secret. It is read for every payout instead of held by the pool, the wallet is only unlocked while signing payouts."`
 	MinPayment      float64  `long:"minpayment" description:"The minimum payment to process for an account."`
 	SoloPool        bool     `long:"solopool" description:"Solo pool mode. This disables payment processing when enabled."`
 	BackupPass      string   `long:"backuppass" description:"The backup password, required for backup over api"`
+	AdminPass       string   `long:"adminpass" default-mask:"-" description:"The operator password, required for admin api access. The admin api is disabled when not set."`
+	SMTPHost        string   `long:"smtphost" description:"The host:port of the SMTP server used to send account emails. Emails are not sent when not set, only their recipients and subjects are logged."`
+	SMTPUser        string   `long:"smtpuser" description:"The SMTP server username."`
+	SMTPPass        string   `long:"smtppass" default-mask:"-" description:"The SMTP server password."`
+	SMTPFrom        string   `long:"smtpfrom" description:"The sender address of account emails."`
+	PublicURL       string   `long:"publicurl" description:"The public url of the pool's web interface, such as https://pool.example. Links of account emails are built from it, account registration and payout address changes are refused when not set."`
+	AddrChangeDelay uint32   `long:"addresschangedelay" description:"The delay in seconds before a confirmed payout address change takes effect."`
+	CaseInsensitive bool     `long:"caseinsensitivenames" description:"Treat account names as case-insensitive, account names only differing by case resolve to the same account."`
+	AccountMetrics  uint32   `long:"accountmetrics" description:"Export the hash rate and share counts of up to the provided number of accounts as metrics labeled by account, for private pools alerting on individual farms. Set to 0 to disable account metrics."`
+	AlertHashDrop   float64  `long:"alerthashratedrop" description:"The percentage the pool hash rate may drop by from its average over the previous hour before operators are alerted. Set to 0 to disable the alert."`
+	AlertRejects    float64  `long:"alertrejectrate" description:"The percentage of rejected shares per minute allowed before operators are alerted. Set to 0 to disable the alert."`
+	AlertDBSize     uint32   `long:"alertdbsize" description:"The database size in megabytes allowed before operators are alerted. Set to 0 to disable the alert."`
+	AlertLatency    uint32   `long:"alertlatency" description:"The response time in milliseconds allowed of dcrd and the wallet before operators are alerted. Set to 0 to disable the alert."`
+	AlertWorkAge    uint32   `long:"alertworkage" description:"The age in seconds allowed of the work served to clients before operators are alerted, stale work indicates the dcrd backend lags. Set to 0 to disable the alert."`
+	AlertEmail      string   `long:"alertemail" description:"The address operator alerts are emailed to. Alerts are only logged and shown on the admin dashboard when not set."`
+	BanIPs          []string `long:"banip" description:"An ip address or network (CIDR) banned from connecting to the pool endpoints, may be specified multiple times. Reloaded on SIGHUP."`
+	SubmitRetries   uint32   `long:"submitretries" description:"The number of times a block submission failing with an rpc error is retried, with exponential backoff from 250ms up to 4s between attempts. At most 10."`
+	ReadyLatency    uint32   `long:"readylatency" description:"The time in milliseconds dcrd may take to provide a work template before the pool reports not ready to serve miners. Set to 0 to disable the check."`
+	NoPreflight     bool     `long:"nopreflight" description:"Skip the startup preflight checks of the database, dcrd, the wallet and the pool fee addresses, starting the pool while dcrd syncs."`
+	ValidateConfig  bool     `long:"validateconfig" description:"Validate the configuration, print the effective configuration with secrets masked and exit, with a non-zero status when invalid."`
+	CheckBackends   bool     `long:"checkbackends" description:"Also check the dcrd backends and the wallet, or the pool of a frontend, are reachable and on the active network when validating the configuration."`
+	SessionLifetime uint32   `long:"sessionlifetime" description:"The period in seconds account sessions remain valid for without being refreshed. Web sessions are not refreshed and expire after the period."`
+	WorkerOffline   uint32   `long:"workerofflinealert" description:"The period in seconds a recently active worker must stop submitting shares for before its account is alerted. Set to 0 to disable worker offline alerts."`
+	CaptchaURL      string   `long:"captchaurl" description:"The siteverify endpoint of a reCAPTCHA or hCaptcha compatible service used to verify account registrations. Registrations are not captcha verified when not set."`
+	CaptchaSecret   string   `long:"captchasecret" default-mask:"-" description:"The secret key of the captcha service."`
+	AdminRPCPort    uint32   `long:"adminrpcport" description:"The port of the gRPC admin service. The service is disabled when not set."`
+	AdminTokenFile  string   `long:"admintokenfile" description:"Path to the admin token file, generated when missing. gRPC admin calls provide the token as their authorization metadata."`
+	ExplorerURL     string   `long:"explorerurl" description:"The base url of the dcrdata block explorer blocks found link to. Defaults to the public explorer of the active network, blocks are not linked on simnet unless set."`
+	Domains         []string `long:"domain" description:"A domain the pool api is served on, may be specified multiple times. Certificates for the domains are obtained and renewed from Let's Encrypt when set, the self-signed pool certificate is used otherwise."`
+	ACMEEmail       string   `long:"acmeemail" description:"The contact email registered with Let's Encrypt, notified of certificate problems."`
+	ACMEHTTPPort    uint32   `long:"acmehttpport" description:"The port Let's Encrypt http challenges are served on, it must be reachable on port 80 of the domains."`
+	APIRate         float64  `long:"apirate" description:"The api requests allowed per second for an ip address. Set to 0 to disable rate limiting."`
+	APIBurst        int      `long:"apiburst" description:"The api requests allowed at once for an ip address."`
+	APIKeyRate      float64  `long:"apikeyrate" description:"The api requests allowed per second for an api key. Set to 0 to disable rate limiting."`
+	APIKeyBurst     int      `long:"apikeyburst" description:"The api requests allowed at once for an api key."`
+	CORSOrigins     []string `long:"corsorigin" description:"An origin allowed to call the public api from browsers, may be specified multiple times. Defaults to all origins (*)."`
+	CORSMethods     []string `long:"corsmethod" description:"A method allowed for cross-origin api requests, may be specified multiple times. Defaults to GET, POST and OPTIONS."`
+	CORSHeaders     []string `long:"corsheader" description:"A request header allowed for cross-origin api requests, may be specified multiple times. Defaults to Content-Type, Authorization and X-API-Key."`
+	HashRateRetain  []string `long:"hashrateretention" description:"The retention of account and worker hash rate samples of a resolution as resolution:retention, eg. 5m:24h, may be specified multiple times. Resolutions are 5m (48h by default), 1h (720h by default) and 1d (8760h by default), a retention of 0 stops recording a resolution."`
+	LangDir         string   `long:"langdir" description:"Directory of message catalogs translating the web interface, json files named after their language code (eg. de.json)."`
+	LegacySunset    string   `long:"legacyapisunset" description:"The date (YYYY-MM-DD, UTC) unversioned api routes superseded by the versioned api are removed, announced to their clients in the Sunset header."`
+	TrustedProxies  []string `long:"trustedproxy" description:"A reverse proxy network (CIDR) or address trusted to report the ip address of api clients in the X-Forwarded-For or X-Real-IP headers, may be specified multiple times."`
+	GUIDir          string   `long:"guidir" description:"Directory of templates and static assets overriding the embedded web interface, laid out like network/gui."`
+	SimnetHarness   bool     `long:"simnetharness" description:"Enable the simnet harness admin routes generating blocks on demand and reporting the progress of the share, block and payout cycle. Only allowed on simnet."`
+	InstantMine     bool     `long:"instantmine" description:"Generate a block crediting the account of an accepted share that does not solve a block, for fast end to end tests of the payout pipeline. Only allowed on simnet."`
+	Standby         string   `long:"standby" description:"Run as the standby of the primary pool at the provided api url, replicating its database until the primary fails and then taking over its stratum and payout duties. The backup password and lease of the primary must be configured."`
+	StandbyCert     string   `long:"standbycert" description:"The TLS certificate of the api of the primary pool, for primaries serving a self-signed certificate."`
+	HALease         uint32   `long:"halease" description:"The period in seconds a primary pool may publish payouts for after its standby last replicated it. Payouts are only published once replicated by the standby. Set to 0 to disable standby replication."`
+	HAFailover      uint32   `long:"hafailover" description:"The period in seconds a standby fails to replicate its primary for before taking over, once the lease of the primary expired. Must exceed the lease."`
+	FrontendRPCPort uint32   `long:"frontendrpcport" description:"The port of the internal RPC stratum frontends share the pool through. Frontends are not served when not set."`
+	FrontendSecret  string   `long:"frontendsecret" description:"The secret authenticating stratum frontends with the pool, required to serve frontends and to run as one."`
+	Frontend        string   `long:"frontend" description:"Run as a stateless stratum frontend of the pool at the provided frontend RPC address (host:port). Frontends serve miners, the pool keeps the database, dcrd, the wallet and payouts."`
+	FrontendCert    string   `long:"frontendcert" description:"The TLS certificate of the pool a frontend connects to, for pools serving a self-signed certificate."`
+	FrontendName    string   `long:"frontendname" description:"The name a frontend registers with the pool as, frontends keep their extranonce1 prefix across reconnections by name. Defaults to the hostname."`
+	Regions         []string `long:"region" description:"A regional stratum host of the pool advertised to miners as <name>=<host>, eg. eu=eu.pool.example.com, may be specified multiple times. Regional hosts serve the stratum ports of the pool."`
+	ProfilePort     uint32   `long:"profileport" description:"The port runtime profiles are served on by net/http/pprof under /debug/pprof/, for profiling cpu and heap usage during incidents. Profiling is disabled when not set."`
+	ProfileListen   string   `long:"profilelisten" description:"The interface profiles are served on, localhost by default. Profiles served on other interfaces are served over TLS using the pool certificate and require the admin password."`
+	StratumRegion   string   `long:"stratumregion" description:"The region of the stratum endpoints of this instance, recorded with the connections of workers. Must be an advertised region when regions are set, except for frontends whose regions are advertised by the pool."`
+	SecretsCommand  string   `long:"secretscommand" description:"A command fetching secrets referenced as cmd:<name>, run with the name as its argument and printing the secret. Secret options also reference secrets as file:<path> and env:<name>."`
+	StratumPorts    []string `long:"stratumport" description:"The port of the stratum endpoint of a miner as <miner>=<port>, eg. cpu=5560, overriding its default port. May be specified multiple times."`
+	MaxClients      uint32   `long:"maxclients" description:"The maximum number of clients connected to the stratum endpoints, further connections are refused with a stratum error. Unlimited when 0."`
+	MaxIPClients    uint32   `long:"maxipclients" description:"The maximum number of clients connected to the stratum endpoints from a single ip address, further connections are refused with a stratum error. Unlimited when 0."`
 	poolFeeAddrs    []dcrutil.Address
 	dcrdRPCCerts    []byte
+	dcrdBackupCerts []byte
+	adminToken      string
+	hashRatePolicy  dividend.HashRatePolicy
+	legacySunset    time.Time
+	trustedProxies  []*net.IPNet
+	bannedNets      []*net.IPNet
+	standbyCerts    []byte
+	frontendCerts   []byte
+	regions         []*network.Region
+	stratumPorts    map[string]uint32
+	walletTLSKey    []byte
 	net             *chaincfg.Params
 }
 
@@ -189,6 +322,17 @@ func fileExists(name string) bool {
 	return true
 }
 
+// isLoopback reports whether the provided interface is a loopback
+// interface.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // genCertPair generates a key/cert pair to the paths provided.
 func genCertPair(certFile, keyFile string) error {
 	org := "dcrpool autogenerated cert"
@@ -211,6 +355,35 @@ func genCertPair(certFile, keyFile string) error {
 	return nil
 }
 
+// loadAdminToken reads the admin token from the provided file, generating a
+// random token readable only by the owner if the file does not exist.
+func loadAdminToken(tokenFile string) (string, error) {
+	if !fileExists(tokenFile) {
+		b := make([]byte, 32)
+		_, err := rand.Read(b)
+		if err != nil {
+			return "", err
+		}
+
+		err = ioutil.WriteFile(tokenFile, []byte(hex.EncodeToString(b)), 0600)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	b, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("admin token file (%v) is empty", tokenFile)
+	}
+
+	return token, nil
+}
+
 // newConfigParser returns a new command line flags parser.
 func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *flags.Parser {
 	parser := flags.NewParser(cfg, options)
@@ -225,13 +398,16 @@ func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *fl
 //
 // The configuration proceeds as follows:
 // 	1) Start with a default config with sane settings
-// 	2) Pre-parse the command line to check for an alternative config file
+// 	2) Pre-parse the environment and command line to check for an alternative
+// 	   config file
 // 	3) Load configuration file overwriting defaults with any specified options
-// 	4) Parse CLI options and overwrite/add any specified options
+// 	4) Load DCRPOOL_* environment variables overwriting the config file
+// 	5) Parse CLI options and overwrite/add any specified options
 //
 // The above results in dcrpool functioning properly without any config settings
-// while still allowing the user to override settings with config files and
-// command line options.  Command line options always take precedence.
+// while still allowing the user to override settings with config files,
+// the environment and command line options.  Command line options always take
+// precedence.
 func loadConfig() (*config, []string, error) {
 	// Default config.
 	cfg := config{
@@ -240,7 +416,11 @@ func loadConfig() (*config, []string, error) {
 		DataDir:         defaultDataDir,
 		DBFile:          defaultDBFile,
 		DebugLevel:      defaultLogLevel,
+		LogFormat:       logFormatText,
 		LogDir:          defaultLogDir,
+		LogSize:         defaultLogSize,
+		LogInterval:     defaultLogInterval,
+		LogRolls:        defaultLogRolls,
 		RPCUser:         defaultRPCUser,
 		RPCPass:         defaultRPCPass,
 		DcrdRPCHost:     defaultDcrdRPCHost,
@@ -250,14 +430,33 @@ func loadConfig() (*config, []string, error) {
 		PoolFeeAddrs:    []string{defaultPoolFeeAddr},
 		PoolFee:         defaultPoolFee,
 		MaxTxFeeReserve: defaultMaxTxFeeReserve,
+		MinFeeRate:      defaultMinFeeRate,
+		MaxFeeRate:      defaultMaxFeeRate,
 		MaxGenTime:      defaultMaxGenTime,
 		ActiveNet:       defaultActiveNet,
 		PaymentMethod:   defaultPaymentMethod,
 		LastNPeriod:     defaultLastNPeriod,
 		WalletPass:      defaultWalletPass,
+		PayoutAccount:   defaultPayoutAccount,
 		MinPayment:      defaultMinPayment,
 		SoloPool:        defaultSoloPool,
 		APIPort:         defaultAPIPort,
+		APIListen:       defaultListenHost,
+		StratumListen:   defaultListenHost,
+		SMTPFrom:        defaultSMTPFrom,
+		AddrChangeDelay: defaultAddrChangeDelay,
+		WorkerOffline:   defaultWorkerOffline,
+		SessionLifetime: defaultSessionLifetime,
+		ReadyLatency:    defaultReadyLatency,
+		HAFailover:      defaultHAFailover,
+		SubmitRetries:   defaultSubmitRetries,
+		AdminTokenFile:  defaultTokenFile,
+		ACMEHTTPPort:    defaultACMEHTTPPort,
+		APIRate:         defaultAPIRate,
+		APIBurst:        defaultAPIBurst,
+		APIKeyRate:      defaultAPIKeyRate,
+		APIKeyBurst:     defaultAPIKeyBurst,
+		ProfileListen:   defaultProfileListen,
 	}
 
 	// Service options which are only added on Windows.
@@ -269,6 +468,7 @@ func loadConfig() (*config, []string, error) {
 	// the final parse below.
 	preCfg := cfg
 	preParser := newConfigParser(&preCfg, &serviceOpts, flags.HelpFlag)
+	parseEnvironment(preParser, &preCfg)
 	_, err := preParser.Parse()
 	if err != nil {
 		if e, ok := err.(*flags.Error); ok && e.Type != flags.ErrHelp {
@@ -347,9 +547,13 @@ func loadConfig() (*config, []string, error) {
 	}
 
 	// Create a default config file when one does not exist and the user did
-	// not specify an override.
-	if !fileExists(preCfg.ConfigFile) {
-		preIni := flags.NewIniParser(preParser)
+	// not specify an override. Options set through the environment are left
+	// out of it, keeping secrets passed by the environment out of files.
+	if !fileExists(preCfg.ConfigFile) && !isStructuredConfig(preCfg.ConfigFile) {
+		fileCfg := cfg
+		fileParser := newConfigParser(&fileCfg, &serviceOptions{}, flags.None)
+		fileParser.Parse()
+		preIni := flags.NewIniParser(fileParser)
 		err = preIni.WriteFile(preCfg.ConfigFile, flags.IniDefault)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error creating a default "+
@@ -361,7 +565,7 @@ func loadConfig() (*config, []string, error) {
 	var configFileError error
 	parser := newConfigParser(&cfg, &serviceOpts, flags.Default)
 	if preCfg.ConfigFile != defaultConfigFile {
-		err := flags.NewIniParser(parser).ParseFile(preCfg.ConfigFile)
+		err := parseConfigFile(parser, preCfg.ConfigFile)
 		if err != nil {
 			if _, ok := err.(*os.PathError); !ok {
 				fmt.Fprintf(os.Stderr, "Error parsing config "+
@@ -373,6 +577,14 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Load config from the environment, overriding the config file.
+	err = parseEnvironment(parser, &cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing environment: %v\n", err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Parse command line options again to ensure they take precedence.
 	remainingArgs, err := parser.Parse()
 	if err != nil {
@@ -382,13 +594,46 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Resolve secrets referenced from files, the environment or the secrets
+	// command.
+	for _, secret := range secretOptions(&cfg) {
+		*secret.value, err = resolveSecret(*secret.value, cfg.SecretsCommand)
+		if err != nil {
+			str := "%s: unable to load the %s secret: %v"
+			err := fmt.Errorf(str, funcName, secret.name, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	cfg.DataDir = util.CleanAndExpandPath(cfg.DataDir)
 	cfg.LogDir = util.CleanAndExpandPath(cfg.LogDir)
 	logRotator = nil
 
+	switch cfg.LogFormat {
+	case logFormatText:
+	case logFormatJSON:
+		jsonLogs = true
+	default:
+		str := "%s: invalid log format %q, expected %s or %s"
+		err := fmt.Errorf(str, funcName, cfg.LogFormat, logFormatText,
+			logFormatJSON)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Initialize log rotation.  After log rotation has been initialized, the
 	// logger variables may be used.
-	initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename))
+	initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename),
+		util.LogRotation{
+			MaxSize:   int64(cfg.LogSize) * 1e6,
+			Interval:  time.Duration(cfg.LogInterval) * time.Hour,
+			Compress:  !cfg.NoLogCompress,
+			MaxRolls:  int(cfg.LogRolls),
+			Retention: time.Duration(cfg.LogRetention) * 24 * time.Hour,
+		})
 
 	// Ensure the backup password is set.
 	if cfg.BackupPass == "" {
@@ -419,21 +664,260 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// Set the mining active network. Testnet proof of work
-	// parameters are modified here to mirror that of mainnet in order to
-	// generate reasonable difficulties for asics. Simnet is currently reserved
-	// for cpu miner tests only.
-	switch cfg.ActiveNet {
-	case chaincfg.TestNet3Params.Name:
-		cfg.net = &chaincfg.TestNet3Params
+	// Set the mining active network, its parameters are used throughout
+	// the pool. Testnet proof of work parameters are modified here to
+	// mirror that of mainnet in order to generate reasonable difficulties
+	// for asics. Simnet is currently reserved for cpu miner tests only.
+	cfg.net, err = util.NetParams(cfg.ActiveNet)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.net.Name == chaincfg.TestNet3Params.Name {
 		cfg.net.PowLimit = chaincfg.MainNetParams.PowLimit
-	case chaincfg.MainNetParams.Name:
-		cfg.net = &chaincfg.MainNetParams
-	case chaincfg.SimNetParams.Name:
-		cfg.net = &chaincfg.SimNetParams
-	default:
-		return nil, nil, fmt.Errorf("unknown network provided %v",
-			cfg.ActiveNet)
+	}
+
+	if cfg.InstantMine && cfg.net.Name != chaincfg.SimNetParams.Name {
+		str := "%s: instant mining is only allowed on simnet"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	if cfg.Standby != "" {
+		u, err := url.Parse(cfg.Standby)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			str := "%s: invalid primary pool url %q"
+			err := fmt.Errorf(str, funcName, cfg.Standby)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		if cfg.HALease == 0 || cfg.HAFailover <= cfg.HALease {
+			str := "%s: standbys require a lease, and a failover period " +
+				"exceeding it"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		if cfg.StandbyCert != "" {
+			cfg.standbyCerts, err = ioutil.ReadFile(
+				util.CleanAndExpandPath(cfg.StandbyCert))
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read primary "+
+					"pool certificate: %v", err)
+			}
+		}
+	}
+
+	if (cfg.Frontend != "" || cfg.FrontendRPCPort != 0) &&
+		cfg.FrontendSecret == "" {
+		str := "%s: a frontend secret is required to serve stratum " +
+			"frontends or run as one"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	if cfg.Frontend != "" {
+		if cfg.Standby != "" || cfg.FrontendRPCPort != 0 {
+			str := "%s: stratum frontends cannot be standbys or serve " +
+				"frontends"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		_, _, err := net.SplitHostPort(cfg.Frontend)
+		if err != nil {
+			str := "%s: invalid pool frontend RPC address %q"
+			err := fmt.Errorf(str, funcName, cfg.Frontend)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		if cfg.FrontendName == "" {
+			cfg.FrontendName, err = os.Hostname()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to fetch hostname: %v",
+					err)
+			}
+		}
+
+		if cfg.FrontendCert != "" {
+			cfg.frontendCerts, err = ioutil.ReadFile(
+				util.CleanAndExpandPath(cfg.FrontendCert))
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read pool "+
+					"certificate: %v", err)
+			}
+		}
+	}
+
+	if cfg.ProfilePort != 0 && !isLoopback(cfg.ProfileListen) &&
+		cfg.AdminPass == "" {
+		str := "%s: profiles served beyond localhost require the admin " +
+			"password to be set"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	cfg.regions, err = network.ParseRegions(cfg.Regions)
+	if err != nil {
+		str := "%s: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	if cfg.Frontend == "" && len(cfg.regions) > 0 {
+		advertised := false
+		for _, region := range cfg.regions {
+			if region.Name == cfg.StratumRegion {
+				advertised = true
+				break
+			}
+		}
+
+		if !advertised {
+			str := "%s: the stratum region %q is not an advertised region"
+			err := fmt.Errorf(str, funcName, cfg.StratumRegion)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	cfg.stratumPorts, err = network.ParseStratumPorts(cfg.StratumPorts)
+	if err != nil {
+		str := "%s: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	if cfg.SimnetHarness {
+		if cfg.net.Name != chaincfg.SimNetParams.Name {
+			str := "%s: the simnet harness is only allowed on simnet"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		if cfg.AdminPass == "" {
+			str := "%s: the simnet harness requires the admin password " +
+				"to be set"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	// Default to the public block explorer of the active network.
+	if cfg.ExplorerURL == "" {
+		cfg.ExplorerURL = defaultExplorerURLs[cfg.ActiveNet]
+	}
+	cfg.ExplorerURL = strings.TrimSuffix(cfg.ExplorerURL, "/")
+
+	if cfg.PublicURL != "" {
+		u, err := url.Parse(cfg.PublicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" {
+			str := "%s: invalid public url %q"
+			err := fmt.Errorf(str, funcName, cfg.PublicURL)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+	}
+
+	cfg.hashRatePolicy, err = dividend.ParseHashRatePolicy(cfg.HashRateRetain)
+	if err != nil {
+		str := "%s: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	if cfg.LegacySunset != "" {
+		cfg.legacySunset, err = time.Parse("2006-01-02", cfg.LegacySunset)
+		if err != nil {
+			str := "%s: invalid legacy api sunset date: %v"
+			err := fmt.Errorf(str, funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	cfg.trustedProxies, err = util.ParseCIDRs(cfg.TrustedProxies)
+	if err != nil {
+		str := "%s: invalid trusted proxy: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	cfg.bannedNets, err = util.ParseCIDRs(cfg.BanIPs)
+	if err != nil {
+		str := "%s: invalid banned address: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	if cfg.SubmitRetries > maxSubmitRetries {
+		str := "%s: block submission retries must be at most %v"
+		err := fmt.Errorf(str, funcName, maxSubmitRetries)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Account sessions must outlive their access tokens.
+	if cfg.SessionLifetime < 900 {
+		str := "%s: session lifetime must be at least 900 seconds"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Rate limited api clients must be allowed at least a request at once.
+	if (cfg.APIRate > 0 && cfg.APIBurst < 1) ||
+		(cfg.APIKeyRate > 0 && cfg.APIKeyBurst < 1) {
+		str := "%s: api bursts must allow at least one request"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Default to allowing cross-origin api requests from all origins.
+	if len(cfg.CORSOrigins) == 0 {
+		cfg.CORSOrigins = defaultCORSOrigins
+	}
+	if len(cfg.CORSMethods) == 0 {
+		cfg.CORSMethods = defaultCORSMethods
+	}
+	if len(cfg.CORSHeaders) == 0 {
+		cfg.CORSHeaders = defaultCORSHeaders
 	}
 
 	if !cfg.SoloPool {
@@ -474,17 +958,45 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Load the admin token, generating it if it does not already exist.
+	if cfg.AdminRPCPort != 0 {
+		cfg.adminToken, err = loadAdminToken(cfg.AdminTokenFile)
+		if err != nil {
+			return nil, nil,
+				fmt.Errorf("failed to load admin token: %v", err)
+		}
+	}
+
+	// Stratum frontends relay the work of their clients to the pool, they
+	// do not connect to dcrd or the wallet.
+	if cfg.Frontend != "" {
+		return &cfg, remainingArgs, nil
+	}
+
 	// Load Dcrd RPC Certificate.
 	if !fileExists(cfg.DcrdRPCCert) {
 		return nil, nil,
 			fmt.Errorf("dcrd RPC certificate (%v) not found", cfg.DcrdRPCCert)
 	}
 
-	cfg.dcrdRPCCerts, err = ioutil.ReadFile(dcrdRPCCertFile)
+	cfg.dcrdRPCCerts, err = ioutil.ReadFile(cfg.DcrdRPCCert)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// Load the backup dcrd RPC certificates, backup connections accept
+	// any of the dcrd certificates.
+	cfg.dcrdBackupCerts = append([]byte{}, cfg.dcrdRPCCerts...)
+	for _, certFile := range cfg.DcrdBackupCerts {
+		cert, err := ioutil.ReadFile(util.CleanAndExpandPath(certFile))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read backup dcrd "+
+				"RPC certificate: %v", err)
+		}
+		cfg.dcrdBackupCerts = append(cfg.dcrdBackupCerts, '\n')
+		cfg.dcrdBackupCerts = append(cfg.dcrdBackupCerts, cert...)
+	}
+
 	if !cfg.SoloPool {
 		// Load the wallet RPC certificate.
 		if !fileExists(cfg.WalletRPCCert) {
@@ -492,7 +1004,144 @@ func loadConfig() (*config, []string, error) {
 				fmt.Errorf("wallet RPC certificate (%v) not found",
 					cfg.WalletRPCCert)
 		}
+
+		if cfg.WalletPassFile != "" {
+			if cfg.WalletPass != "" {
+				str := "%s: the wallet passphrase and passphrase file " +
+					"cannot be both set"
+				err := fmt.Errorf(str, funcName)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+
+			cfg.WalletPassFile = util.CleanAndExpandPath(cfg.WalletPassFile)
+			if !fileExists(cfg.WalletPassFile) {
+				return nil, nil,
+					fmt.Errorf("wallet passphrase file (%v) not found",
+						cfg.WalletPassFile)
+			}
+		}
+
+		// Validate the payout fee rate bounds.
+		if cfg.MinFeeRate <= 0 || cfg.MaxFeeRate < cfg.MinFeeRate ||
+			cfg.MaxFeeRate > maxFeeRateLimit {
+			str := "%s: the fee rate bounds must satisfy 0 < minfeerate " +
+				"<= maxfeerate <= %v DCR/kB"
+			err := fmt.Errorf(str, funcName, maxFeeRateLimit)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		// Load the wallet client certificate and key if provided.
+		if (cfg.WalletTLSCert == "") != (cfg.WalletTLSKey == "") {
+			str := "%s: the wallet client certificate and key must be " +
+				"provided together"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		if cfg.WalletTLSCert != "" {
+			cfg.WalletTLSCert = util.CleanAndExpandPath(cfg.WalletTLSCert)
+			files := []string{cfg.WalletTLSCert}
+
+			// Keys referenced as secrets are held rather than read
+			// from their file on connecting.
+			if isSecretRef(cfg.WalletTLSKey) {
+				key, err := resolveSecret(cfg.WalletTLSKey,
+					cfg.SecretsCommand)
+				if err != nil {
+					str := "%s: unable to load the walletclientkey " +
+						"secret: %v"
+					err := fmt.Errorf(str, funcName, err)
+					fmt.Fprintln(os.Stderr, err)
+					fmt.Fprintln(os.Stderr, usageMessage)
+					return nil, nil, err
+				}
+				cfg.walletTLSKey = []byte(key)
+				cfg.WalletTLSKey = ""
+			} else {
+				cfg.WalletTLSKey = util.CleanAndExpandPath(cfg.WalletTLSKey)
+				files = append(files, cfg.WalletTLSKey)
+			}
+
+			for _, file := range files {
+				if !fileExists(file) {
+					return nil, nil,
+						fmt.Errorf("wallet client certificate file (%v) "+
+							"not found", file)
+				}
+			}
+		}
 	}
 
 	return &cfg, remainingArgs, nil
 }
+
+// reloadConfig re-reads the configuration file, environment and command line
+// for the options reloadable while the pool runs: the log level, the share
+// creation target time, the minimum payment and the banned addresses.
+// Reloadable options not set revert to their defaults, the log level is
+// applied once the options are validated.
+func reloadConfig(cfg *config) (*config, error) {
+	funcName := "reloadConfig"
+	fresh := config{
+		DebugLevel: defaultLogLevel,
+		MaxGenTime: defaultMaxGenTime,
+		MinPayment: defaultMinPayment,
+	}
+
+	parser := newConfigParser(&fresh, &serviceOptions{}, flags.None)
+	if cfg.ConfigFile != defaultConfigFile {
+		err := parseConfigFile(parser, cfg.ConfigFile)
+		if err != nil {
+			if _, ok := err.(*os.PathError); !ok {
+				str := "%s: error parsing config file: %v"
+				return nil, fmt.Errorf(str, funcName, err)
+			}
+		}
+	}
+
+	err := parseEnvironment(parser, &fresh)
+	if err != nil {
+		str := "%s: error parsing environment: %v"
+		return nil, fmt.Errorf(str, funcName, err)
+	}
+
+	_, err = parser.Parse()
+	if err != nil {
+		str := "%s: error parsing command line: %v"
+		return nil, fmt.Errorf(str, funcName, err)
+	}
+
+	if fresh.MaxGenTime == 0 {
+		str := "%s: the share creation target time must be set"
+		return nil, fmt.Errorf(str, funcName)
+	}
+
+	if fresh.MinPayment < 0 {
+		str := "%s: the minimum payment must not be negative"
+		return nil, fmt.Errorf(str, funcName)
+	}
+
+	fresh.bannedNets, err = util.ParseCIDRs(fresh.BanIPs)
+	if err != nil {
+		str := "%s: invalid banned address: %v"
+		return nil, fmt.Errorf(str, funcName, err)
+	}
+
+	if fresh.DebugLevel == "show" {
+		str := "%s: the debug level cannot be shown on reload"
+		return nil, fmt.Errorf(str, funcName)
+	}
+
+	err = parseAndSetDebugLevels(fresh.DebugLevel)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", funcName, err)
+	}
+
+	return &fresh, nil
+}
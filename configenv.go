@@ -0,0 +1,92 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+const (
+	// envPrefix prefixes the environment variables setting options, the
+	// rest of the name is the upper cased long name of the option.
+	envPrefix = "DCRPOOL_"
+
+	// envDelim separates the values of environment variables setting
+	// options taking multiple values.
+	envDelim = ","
+)
+
+// envName returns the name of the environment variable setting the provided
+// option, eg. DCRPOOL_APIPORT for --apiport.
+func envName(option *flags.Option) string {
+	return envPrefix + strings.ToUpper(option.LongName)
+}
+
+// parseEnvironment sets the options of the provided parser from the DCRPOOL_*
+// environment variables. Values replace those of the config file, values of
+// options taking multiple values are separated by commas. Empty variables are
+// ignored.
+func parseEnvironment(parser *flags.Parser, cfg *config) error {
+	vars := make([]string, 0)
+	values := make(map[string]string)
+	for _, env := range os.Environ() {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], envPrefix) ||
+			parts[1] == "" {
+			continue
+		}
+		vars = append(vars, parts[0])
+		values[parts[0]] = parts[1]
+	}
+	sort.Strings(vars)
+
+	// The variables are set as the equivalent ini options, each value on
+	// its own line to report errors against the variable it came from.
+	var ini bytes.Buffer
+	lines := make([]string, 1)
+	for _, name := range vars {
+		longName := strings.ToLower(strings.TrimPrefix(name, envPrefix))
+		option := parser.FindOptionByLongName(longName)
+		if option == nil || envName(option) != name {
+			return fmt.Errorf("environment variable %v: unknown option "+
+				"--%v", name, longName)
+		}
+
+		entries := []string{values[name]}
+		field := reflect.ValueOf(cfg).Elem().FieldByName(option.Field().Name)
+		if field.IsValid() && field.Kind() == reflect.Slice {
+			// Values of the config file are replaced rather than
+			// appended to.
+			field.Set(reflect.Zero(field.Type()))
+			entries = strings.Split(values[name], envDelim)
+		}
+
+		for _, entry := range entries {
+			fmt.Fprintf(&ini, "%s = %s\n", longName,
+				strconv.Quote(strings.TrimSpace(entry)))
+			lines = append(lines, name)
+		}
+	}
+
+	err := flags.NewIniParser(parser).Parse(&ini)
+	if err != nil {
+		if iniErr, ok := err.(*flags.IniError); ok &&
+			iniErr.LineNumber > 0 && int(iniErr.LineNumber) < len(lines) {
+			return fmt.Errorf("environment variable %v: %v",
+				lines[iniErr.LineNumber], iniErr.Message)
+		}
+		return fmt.Errorf("environment: %v", err)
+	}
+
+	return nil
+}
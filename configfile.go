@@ -0,0 +1,199 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+
+	"github.com/dnldd/dcrpool/util"
+)
+
+// configSections maps the keys of sections of structured config files to the
+// options they set. Keys of sections not mapped here name the option of the
+// section name followed by the key, eg. the port key of the api section sets
+// the apiport option. Top level keys name options directly.
+var configSections = map[string]map[string]string{
+	"stratum": {
+		"listen":       "stratumlisten",
+		"region":       "stratumregion",
+		"maxclients":   "maxclients",
+		"maxipclients": "maxipclients",
+	},
+	"payments": {
+		"method":             "paymentmethod",
+		"fee":                "poolfee",
+		"feeaddrs":           "poolfeeaddrs",
+		"minimum":            "minpayment",
+		"lastnperiod":        "lastnperiod",
+		"account":            "payoutaccount",
+		"solo":               "solopool",
+		"maxtxfeereserve":    "maxtxfeereserve",
+		"minfeerate":         "minfeerate",
+		"maxfeerate":         "maxfeerate",
+		"addresschangedelay": "addresschangedelay",
+	},
+	"dcrd": {
+		"host":        "dcrdrpchost",
+		"cert":        "dcrdrpccert",
+		"user":        "rpcuser",
+		"pass":        "rpcpass",
+		"backups":     "dcrdbackuphost",
+		"backupcerts": "dcrdbackupcert",
+	},
+	"wallet": {
+		"host":       "walletgrpchost",
+		"cert":       "walletrpccert",
+		"clientcert": "walletclientcert",
+		"clientkey":  "walletclientkey",
+		"pass":       "walletpass",
+		"passfile":   "walletpassfile",
+	},
+}
+
+// configPairSections maps sections of structured config files to the
+// repeatable option each of their keys sets as <key>=<value>.
+var configPairSections = map[string]string{
+	"stratum.ports":   "stratumport",
+	"stratum.regions": "region",
+}
+
+// isStructuredConfig returns whether the provided config file is a
+// structured (TOML) config file rather than an ini config file.
+func isStructuredConfig(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// parseConfigFile parses the provided ini or structured config file into the
+// options of the provided parser.
+func parseConfigFile(parser *flags.Parser, path string) error {
+	if !isStructuredConfig(path) {
+		return flags.NewIniParser(parser).ParseFile(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entries, err := util.ParseTOML(f)
+	if err != nil {
+		return fmt.Errorf("%v %v", path, err)
+	}
+
+	// The entries are set as the equivalent ini options, each value on
+	// its own line to report errors against the entry it came from.
+	var ini bytes.Buffer
+	lines := make([]*util.TOMLEntry, 1)
+	for _, entry := range entries {
+		name, values, err := configEntryValues(parser, entry)
+		if err != nil {
+			return fmt.Errorf("%v line %v: %v: %v", path, entry.Line,
+				entry.Path(), err)
+		}
+
+		for _, value := range values {
+			fmt.Fprintf(&ini, "%s = %s\n", name, value)
+			lines = append(lines, entry)
+		}
+	}
+
+	err = flags.NewIniParser(parser).Parse(&ini)
+	if err != nil {
+		if iniErr, ok := err.(*flags.IniError); ok &&
+			iniErr.LineNumber > 0 && int(iniErr.LineNumber) < len(lines) {
+			entry := lines[iniErr.LineNumber]
+			return fmt.Errorf("%v line %v: %v: %v", path, entry.Line,
+				entry.Path(), iniErr.Message)
+		}
+		return fmt.Errorf("%v: %v", path, err)
+	}
+
+	return nil
+}
+
+// configEntryValues returns the name of the option the provided structured
+// config entry sets and the ini values it sets the option to.
+func configEntryValues(parser *flags.Parser, entry *util.TOMLEntry) (string, []string, error) {
+	table := strings.Join(entry.Table, ".")
+	if name, ok := configPairSections[table]; ok {
+		var value string
+		switch v := entry.Value.(type) {
+		case string:
+			value = v
+		case int64:
+			value = strconv.FormatInt(v, 10)
+		default:
+			return "", nil, fmt.Errorf("expected a string or an integer")
+		}
+		return name, []string{strconv.Quote(entry.Key + "=" + value)}, nil
+	}
+
+	var name string
+	switch {
+	case table == "":
+		name = entry.Key
+
+	case configSections[table][entry.Key] != "":
+		name = configSections[table][entry.Key]
+
+	case len(entry.Table) == 1:
+		name = table + entry.Key
+
+	default:
+		return "", nil, fmt.Errorf("unknown section [%v]", table)
+	}
+
+	option := parser.FindOptionByLongName(name)
+	if option == nil {
+		if table == "" {
+			return "", nil, fmt.Errorf("unknown option")
+		}
+		return "", nil, fmt.Errorf("unknown option of section [%v]", table)
+	}
+
+	values, ok := entry.Value.([]interface{})
+	if !ok {
+		values = []interface{}{entry.Value}
+	} else if reflect.TypeOf(option.Value()).Kind() != reflect.Slice {
+		return "", nil, fmt.Errorf("%v takes a single value", name)
+	}
+
+	iniValues := make([]string, 0, len(values))
+	for _, v := range values {
+		value, err := iniValue(v)
+		if err != nil {
+			return "", nil, err
+		}
+		iniValues = append(iniValues, value)
+	}
+
+	return name, iniValues, nil
+}
+
+// iniValue returns the ini representation of the provided structured config
+// value, strings are quoted.
+func iniValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+
+	return "", fmt.Errorf("unexpected value %v", value)
+}
@@ -7,6 +7,7 @@ package database
 import (
 	"encoding/binary"
 	"fmt"
+	"strings"
 	"time"
 
 	bolt "github.com/coreos/bbolt"
@@ -38,6 +39,48 @@ var (
 	// PaymentArchiveBkt stores all processed payments for auditing purposes.
 	PaymentArchiveBkt = []byte("paymentarchivebkt")
 
+	// EmailIdxBkt maps the email addresses of registered accounts to their
+	// account ids.
+	EmailIdxBkt = []byte("emailidxbkt")
+
+	// TokenBkt stores tokens issued to accounts, such as email verification
+	// and session tokens.
+	TokenBkt = []byte("tokenbkt")
+
+	// APIKeyBkt stores scoped api keys issued to accounts.
+	APIKeyBkt = []byte("apikeybkt")
+
+	// WorkerBkt stores the workers of accounts.
+	WorkerBkt = []byte("workerbkt")
+
+	// NameIdxBkt maps the normalized names of accounts, qualified by their
+	// payout addresses, to the json encoded list of ids of the accounts with
+	// names only differing by case, in the order they were indexed.
+	NameIdxBkt = []byte("nameidxbkt")
+
+	// ActivityBkt stores the activity log of accounts, keyed by account id and
+	// creation time.
+	ActivityBkt = []byte("activitybkt")
+
+	// PaymentIdxBkt indexes archived payments by account id and archive time,
+	// values are the keys of the payments in the payment archive bucket.
+	PaymentIdxBkt = []byte("paymentidxbkt")
+
+	// HashRateBkt stores hash rate samples of the pool, accounts and workers,
+	// keyed by series, resolution and interval start time.
+	HashRateBkt = []byte("hashratebkt")
+
+	// BanBkt stores the ip addresses banned from connecting to the pool
+	// endpoints.
+	BanBkt = []byte("banbkt")
+
+	// PayoutBkt stores the payout transactions published by the pool, keyed
+	// by creation time.
+	PayoutBkt = []byte("payoutbkt")
+
+	// EventBkt stores the event log of the pool, keyed by creation time.
+	EventBkt = []byte("eventbkt")
+
 	// VersionK is the key of the current version of the database.
 	VersionK = []byte("version")
 
@@ -52,13 +95,43 @@ var (
 	// LastPaymentHeight is the key of the last payment height.
 	LastPaymentHeight = []byte("lastpaymentheight")
 
+	// LastProcessedHeight is the key of the height of the last connected
+	// block processed by the pool.
+	LastProcessedHeight = []byte("lastprocessedheight")
+
 	// TxFeeReserve is the key of the tx fee reserve.
 	TxFeeReserve = []byte("txfeereserve")
 
+	// SessionKey is the key of the secret used to sign account session
+	// tokens.
+	SessionKey = []byte("sessionkey")
+
+	// RoundWork is the key of the work performed by the pool in the current
+	// round, the sum of the difficulties of the shares accepted since the
+	// last block found.
+	RoundWork = []byte("roundwork")
+
+	// HealthCheck is the key of the time of the last database write made
+	// by a health check.
+	HealthCheck = []byte("healthcheck")
+
 	// SoloPool is the solo pool mode key.
 	SoloPool = []byte("solopool")
 )
 
+// NameIndexKey returns the name index key of the account with the provided
+// name and address. Names are indexed case-insensitively.
+func NameIndexKey(name, address string) []byte {
+	return []byte(fmt.Sprintf("%s.%s", address, strings.ToLower(name)))
+}
+
+// PaymentIndexKey returns the payment index key of the archived payment with
+// the provided archive key, paid to the provided account. Archive keys are
+// prefixed by the hex encoded archive time.
+func PaymentIndexKey(account string, archiveKey []byte) []byte {
+	return []byte(account + "/" + string(archiveKey[:16]))
+}
+
 // ErrValueNotFound is returned when a provided database key does not map
 // to any value.
 func ErrValueNotFound(key []byte) error {
@@ -141,6 +214,72 @@ func CreateBuckets(db *bolt.DB) error {
 				string(PaymentArchiveBkt), err)
 		}
 
+		_, err = pbkt.CreateBucketIfNotExists(EmailIdxBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(EmailIdxBkt), err)
+		}
+
+		_, err = pbkt.CreateBucketIfNotExists(TokenBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(TokenBkt), err)
+		}
+
+		_, err = pbkt.CreateBucketIfNotExists(APIKeyBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(APIKeyBkt), err)
+		}
+
+		_, err = pbkt.CreateBucketIfNotExists(WorkerBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(WorkerBkt), err)
+		}
+
+		_, err = pbkt.CreateBucketIfNotExists(NameIdxBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(NameIdxBkt), err)
+		}
+
+		_, err = pbkt.CreateBucketIfNotExists(ActivityBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(ActivityBkt), err)
+		}
+
+		_, err = pbkt.CreateBucketIfNotExists(PaymentIdxBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(PaymentIdxBkt), err)
+		}
+
+		_, err = pbkt.CreateBucketIfNotExists(HashRateBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(HashRateBkt), err)
+		}
+
+		_, err = pbkt.CreateBucketIfNotExists(BanBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(BanBkt), err)
+		}
+
+		_, err = pbkt.CreateBucketIfNotExists(PayoutBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(PayoutBkt), err)
+		}
+
+		_, err = pbkt.CreateBucketIfNotExists(EventBkt)
+		if err != nil {
+			return fmt.Errorf("failed to create '%v' bucket: %v",
+				string(EventBkt), err)
+		}
+
 		return nil
 	})
 	return err
@@ -217,18 +356,96 @@ func Purge(db *bolt.DB) error {
 				string(PaymentArchiveBkt), err)
 		}
 
+		err = pbkt.DeleteBucket(EmailIdxBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(EmailIdxBkt), err)
+		}
+
+		err = pbkt.DeleteBucket(TokenBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(TokenBkt), err)
+		}
+
+		err = pbkt.DeleteBucket(APIKeyBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(APIKeyBkt), err)
+		}
+
+		err = pbkt.DeleteBucket(WorkerBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(WorkerBkt), err)
+		}
+
+		err = pbkt.DeleteBucket(NameIdxBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(NameIdxBkt), err)
+		}
+
+		err = pbkt.DeleteBucket(ActivityBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(ActivityBkt), err)
+		}
+
+		err = pbkt.DeleteBucket(PaymentIdxBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(PaymentIdxBkt), err)
+		}
+
+		err = pbkt.DeleteBucket(HashRateBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(HashRateBkt), err)
+		}
+
+		err = pbkt.DeleteBucket(BanBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(BanBkt), err)
+		}
+
+		err = pbkt.DeleteBucket(PayoutBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(PayoutBkt), err)
+		}
+
+		err = pbkt.DeleteBucket(EventBkt)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' bucket: %v",
+				string(EventBkt), err)
+		}
+
 		err = pbkt.Delete(TxFeeReserve)
 		if err != nil {
 			return fmt.Errorf("failed to delete '%v' k/v: %v",
 				string(TxFeeReserve), err)
 		}
 
+		err = pbkt.Delete(SessionKey)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' k/v: %v",
+				string(SessionKey), err)
+		}
+
 		err = pbkt.Delete(LastPaymentHeight)
 		if err != nil {
 			return fmt.Errorf("failed to delete '%v' k/v: %v",
 				string(LastPaymentHeight), err)
 		}
 
+		err = pbkt.Delete(LastProcessedHeight)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' k/v: %v",
+				string(LastProcessedHeight), err)
+		}
+
 		err = pbkt.Delete(LastPaymentPaidOn)
 		if err != nil {
 			return fmt.Errorf("failed to delete '%v' k/v: %v",
@@ -241,6 +458,18 @@ func Purge(db *bolt.DB) error {
 				string(LastPaymentCreatedOn), err)
 		}
 
+		err = pbkt.Delete(RoundWork)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' k/v: %v",
+				string(RoundWork), err)
+		}
+
+		err = pbkt.Delete(HealthCheck)
+		if err != nil {
+			return fmt.Errorf("failed to delete '%v' k/v: %v",
+				string(HealthCheck), err)
+		}
+
 		err = pbkt.Delete(SoloPool)
 		if err != nil {
 			return fmt.Errorf("failed to delete '%v' k/v: %v",
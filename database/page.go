@@ -0,0 +1,133 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"bytes"
+	"fmt"
+
+	bolt "github.com/coreos/bbolt"
+)
+
+// Range selects a page of the keys of a bucket sharing a prefix. Bounds
+// apply to the key remainder after the prefix.
+type Range struct {
+	Prefix []byte
+
+	// Min is the inclusive lower bound and Max the exclusive upper bound of
+	// the range, either is optional.
+	Min []byte
+	Max []byte
+
+	// After is the key of the last entry of the previous page, the scan
+	// continues from the entry following it.
+	After []byte
+
+	// Limit is the maximum number of entries of the page, zero for no limit.
+	Limit int
+
+	// Reverse scans the range in descending key order.
+	Reverse bool
+}
+
+// ErrInvalidCursor is returned when a page cursor does not belong to the
+// range scanned.
+func ErrInvalidCursor() error {
+	return fmt.Errorf("invalid cursor")
+}
+
+// prefixEnd returns the smallest key greater than all keys with the provided
+// prefix, or nil if there is none.
+func prefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// ScanRange calls the provided function for the entries of the bucket
+// within the provided range, in range order, until the page limit of
+// included entries is reached. It returns the key of the last entry of the
+// page if entries remain in the range, for use as the cursor of the next
+// page.
+func ScanRange(bkt *bolt.Bucket, r *Range, fn func(k, v []byte) (bool, error)) ([]byte, error) {
+	if r.After != nil && !bytes.HasPrefix(r.After, r.Prefix) {
+		return nil, ErrInvalidCursor()
+	}
+
+	lower := append(append([]byte{}, r.Prefix...), r.Min...)
+	upper := prefixEnd(r.Prefix)
+	if r.Max != nil {
+		upper = append(append([]byte{}, r.Prefix...), r.Max...)
+	}
+
+	inRange := func(k []byte) bool {
+		return bytes.HasPrefix(k, r.Prefix) && bytes.Compare(k, lower) >= 0 &&
+			(upper == nil || bytes.Compare(k, upper) < 0)
+	}
+
+	cursor := bkt.Cursor()
+	next := cursor.Next
+	var k, v []byte
+	if !r.Reverse {
+		start := lower
+		if r.After != nil && bytes.Compare(r.After, lower) >= 0 {
+			start = r.After
+		}
+
+		k, v = cursor.Seek(start)
+		if k != nil && r.After != nil && bytes.Equal(k, r.After) {
+			k, v = cursor.Next()
+		}
+	} else {
+		next = cursor.Prev
+		end := upper
+		if r.After != nil && (end == nil || bytes.Compare(r.After, end) < 0) {
+			end = r.After
+		}
+
+		if end == nil {
+			k, v = cursor.Last()
+		} else {
+			k, v = cursor.Seek(end)
+			if k == nil {
+				k, v = cursor.Last()
+			} else {
+				k, v = cursor.Prev()
+			}
+		}
+	}
+
+	count := 0
+	for ; k != nil && inRange(k); k, v = next() {
+		include, err := fn(k, v)
+		if err != nil {
+			return nil, err
+		}
+
+		if !include {
+			continue
+		}
+
+		count++
+		if r.Limit > 0 && count == r.Limit {
+			last := make([]byte, len(k))
+			copy(last, k)
+
+			k, _ = next()
+			if k != nil && inRange(k) {
+				return last, nil
+			}
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -6,6 +6,7 @@ package database
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 
 	bolt "github.com/coreos/bbolt"
@@ -14,15 +15,167 @@ import (
 const (
 	initialVersion = 0
 
+	// nameIndexVersion is the second version of the database. It indexes
+	// account names in the name index bucket.
+	nameIndexVersion = 1
+
+	// paymentIndexVersion is the third version of the database. It indexes
+	// archived payments by account in the payment index bucket.
+	paymentIndexVersion = 2
+
+	// nameIndexListVersion is the fourth version of the database. It indexes
+	// all accounts with names only differing by case under their shared
+	// name index key.
+	nameIndexListVersion = 3
+
 	// DBVersion is the latest version of the database that is understood by the
 	// program. Databases with recorded versions higher than this will fail to
 	// open (meaning any upgrades prevent reverting to older software).
-	DBVersion = initialVersion
+	DBVersion = nameIndexListVersion
 )
 
 // upgrades maps between old database versions and the upgrade function to
 // upgrade the database to the next version.
-var upgrades = [...]func(tx *bolt.Tx) error{}
+var upgrades = [...]func(tx *bolt.Tx) error{
+	nameIndexUpgrade,
+	paymentIndexUpgrade,
+	nameIndexListUpgrade,
+}
+
+// nameIndexUpgrade indexes the names of all existing accounts. Where account
+// names only differ by case the first account indexed is kept.
+func nameIndexUpgrade(tx *bolt.Tx) error {
+	pbkt := tx.Bucket(PoolBkt)
+	if pbkt == nil {
+		return ErrBucketNotFound(PoolBkt)
+	}
+	abkt := pbkt.Bucket(AccountBkt)
+	if abkt == nil {
+		return ErrBucketNotFound(AccountBkt)
+	}
+	nbkt := pbkt.Bucket(NameIdxBkt)
+	if nbkt == nil {
+		return ErrBucketNotFound(NameIdxBkt)
+	}
+
+	cursor := abkt.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		var account struct {
+			UUID    string `json:"uuid"`
+			Name    string `json:"name"`
+			Address string `json:"address"`
+		}
+		err := json.Unmarshal(v, &account)
+		if err != nil {
+			return err
+		}
+
+		key := NameIndexKey(account.Name, account.Address)
+		if nbkt.Get(key) != nil {
+			log.Warnf("Account (%v) name conflicts with an indexed account, "+
+				"skipping", account.UUID)
+			continue
+		}
+
+		err = nbkt.Put(key, []byte(account.UUID))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// paymentIndexUpgrade indexes all archived payments by account.
+func paymentIndexUpgrade(tx *bolt.Tx) error {
+	pbkt := tx.Bucket(PoolBkt)
+	if pbkt == nil {
+		return ErrBucketNotFound(PoolBkt)
+	}
+	abkt := pbkt.Bucket(PaymentArchiveBkt)
+	if abkt == nil {
+		return ErrBucketNotFound(PaymentArchiveBkt)
+	}
+	ibkt := pbkt.Bucket(PaymentIdxBkt)
+	if ibkt == nil {
+		return ErrBucketNotFound(PaymentIdxBkt)
+	}
+
+	cursor := abkt.Cursor()
+	for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+		if len(k) <= 16 {
+			continue
+		}
+
+		key := make([]byte, len(k))
+		copy(key, k)
+		err := ibkt.Put(PaymentIndexKey(string(k[16:]), k), key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nameIndexListUpgrade converts the account ids of the name index to lists
+// of account ids and indexes the accounts skipped for having names only
+// differing by case from an indexed account. Indexed accounts stay first in
+// their lists.
+func nameIndexListUpgrade(tx *bolt.Tx) error {
+	pbkt := tx.Bucket(PoolBkt)
+	if pbkt == nil {
+		return ErrBucketNotFound(PoolBkt)
+	}
+	abkt := pbkt.Bucket(AccountBkt)
+	if abkt == nil {
+		return ErrBucketNotFound(AccountBkt)
+	}
+	nbkt := pbkt.Bucket(NameIdxBkt)
+	if nbkt == nil {
+		return ErrBucketNotFound(NameIdxBkt)
+	}
+
+	index := make(map[string][]string)
+	cursor := nbkt.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		index[string(k)] = []string{string(v)}
+	}
+
+	cursor = abkt.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		var account struct {
+			UUID    string `json:"uuid"`
+			Name    string `json:"name"`
+			Address string `json:"address"`
+		}
+		err := json.Unmarshal(v, &account)
+		if err != nil {
+			return err
+		}
+
+		key := string(NameIndexKey(account.Name, account.Address))
+		ids := index[key]
+		if len(ids) > 0 && ids[0] == account.UUID {
+			continue
+		}
+		index[key] = append(ids, account.UUID)
+	}
+
+	for key, ids := range index {
+		idsBytes, err := json.Marshal(ids)
+		if err != nil {
+			return err
+		}
+
+		err = nbkt.Put([]byte(key), idsBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
 // Upgrade checks whether the any upgrades are necessary before the database is
 // ready for application usage.  If any are, they are performed.
@@ -30,7 +183,7 @@ func Upgrade(db *bolt.DB) error {
 	var version uint32
 	err := db.View(func(tx *bolt.Tx) error {
 		pbkt := tx.Bucket(PoolBkt)
-		if PoolBkt == nil {
+		if pbkt == nil {
 			return fmt.Errorf("'%s' bucket does not exist", string(PoolBkt))
 		}
 		v := pbkt.Get(VersionK)
@@ -54,7 +207,7 @@ func Upgrade(db *bolt.DB) error {
 
 	log.Infof("Upgrading database from version %d to %d", version, DBVersion)
 
-	return db.View(func(tx *bolt.Tx) error {
+	return db.Update(func(tx *bolt.Tx) error {
 		// Execute all necessary upgrades in order.
 		for _, upgrade := range upgrades[version:] {
 			err := upgrade(tx)
@@ -62,6 +215,10 @@ func Upgrade(db *bolt.DB) error {
 				return err
 			}
 		}
-		return nil
+
+		// Persist the upgraded database version.
+		vbytes := make([]byte, 4)
+		binary.LittleEndian.PutUint32(vbytes, uint32(DBVersion))
+		return tx.Bucket(PoolBkt).Put(VersionK, vbytes)
 	})
 }
@@ -5,38 +5,168 @@
 package dividend
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
 	bolt "github.com/coreos/bbolt"
-	"github.com/dchest/blake256"
+	"github.com/decred/dcrd/dcrutil"
+	"golang.org/x/crypto/bcrypt"
+
 	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/util"
+)
+
+const (
+	// backupCodeCount is the number of backup codes generated when two-factor
+	// authentication is enabled for an account.
+	backupCodeCount = 10
+
+	// backupCodeSize is the size of a backup code, in bytes.
+	backupCodeSize = 5
+
+	// maxLoginRecords is the maximum number of login records retained per
+	// account.
+	maxLoginRecords = 20
 )
 
-// Account represents an anonymous mining pool account.
+// Account represents a mining pool account. Accounts are anonymous unless
+// registered with an email address and password.
 type Account struct {
-	UUID      string `json:"uuid"`
-	Name      string `json:"name"`
-	Address   string `json:"address"`
-	CreatedOn uint64 `json:"createdon"`
+	UUID          string `json:"uuid"`
+	Name          string `json:"name"`
+	Address       string `json:"address"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"emailverified,omitempty"`
+	PassHash      []byte `json:"passhash,omitempty"`
+	CreatedOn     uint64 `json:"createdon"`
+
+	// Two-factor authentication details. Backup codes are stored as sha256
+	// hashes and are consumed on use. The time step of the last accepted
+	// one-time password is kept to reject replayed codes.
+	TOTPSecret   string   `json:"totpsecret,omitempty"`
+	TOTPEnabled  bool     `json:"totpenabled,omitempty"`
+	TOTPLastStep uint64   `json:"totplaststep,omitempty"`
+	BackupCodes  []string `json:"backupcodes,omitempty"`
+
+	// totpStep is the time step of a one-time password accepted since the
+	// account was last persisted, asserted to be unused when persisting.
+	totpStep uint64
+
+	// Pending payout address change details. The pending address replaces
+	// the payout address once the change takes effect, in unix time.
+	PendingAddress   string `json:"pendingaddress,omitempty"`
+	PendingAddressOn int64  `json:"pendingaddresson,omitempty"`
+
+	// Logins is the recent login history of the account, oldest first.
+	Logins []LoginRecord `json:"logins,omitempty"`
+
+	// Notifications are the notification preferences of the account, keyed
+	// by alert type. Defaults apply to alert types not set.
+	Notifications map[string][]string `json:"notifications,omitempty"`
+
+	// Operator labels and notes on the account.
+	Labels []string `json:"labels,omitempty"`
+	Notes  string   `json:"notes,omitempty"`
+
+	// ErasedOn is the time the personal data of the account was erased at
+	// the request of the account holder, in unix time.
+	ErasedOn int64 `json:"erasedon,omitempty"`
+
+	// Suspension details. Suspended accounts cannot authorize miners and
+	// their payouts are held until reinstated, banned accounts are suspended
+	// permanently.
+	SuspendedOn   int64  `json:"suspendedon,omitempty"`
+	SuspendedBy   string `json:"suspendedby,omitempty"`
+	SuspendReason string `json:"suspendreason,omitempty"`
+	Banned        bool   `json:"banned,omitempty"`
+
+	// Difficulty is the preferred starting difficulty of the account's
+	// workers, overriding the default difficulty of their miner type.
+	Difficulty *big.Int `json:"difficulty,omitempty"`
+
+	// PublicFinder is set when the account opts in to being named as the
+	// finder of the blocks it mines, public block listings otherwise show
+	// an anonymized finder.
+	PublicFinder bool `json:"publicfinder,omitempty"`
+
+	// PublicLeaderboard is set when the account opts in to being named on
+	// the hash rate leaderboard, the leaderboard otherwise lists the account
+	// anonymized.
+	PublicLeaderboard bool `json:"publicleaderboard,omitempty"`
+
+	// PayoutThreshold is the minimum payment of the account, payments below
+	// it are held. The pool minimum payment applies when lower.
+	PayoutThreshold dcrutil.Amount `json:"payoutthreshold,omitempty"`
+}
+
+// LoginRecord represents a successful account login.
+type LoginRecord struct {
+	Time int64  `json:"time"`
+	IP   string `json:"ip"`
+}
+
+// ErrAccountNotFound is returned when no account is found for the provided
+// reference.
+func ErrAccountNotFound(ref string) error {
+	return fmt.Errorf("account '%v' not found", ref)
+}
+
+// ErrAccountNameNotFound is returned when no account has the provided name
+// and payout address.
+func ErrAccountNameNotFound(name string, address string) error {
+	return ErrAccountNotFound(fmt.Sprintf("%v.%v", address, name))
 }
 
-// AccountID forms a unique id for an account using the provided name
-// and address.
-func AccountID(name, address string) *string {
-	hasher := blake256.New()
-	hasher.Write([]byte(fmt.Sprintf("%s.%s", address, name)))
-	id := hex.EncodeToString(hasher.Sum(nil))
-	return &id
+// ErrOTPUsed is returned when persisting an account whose accepted one-time
+// password was used concurrently.
+func ErrOTPUsed(account string) error {
+	return fmt.Errorf("one-time password of account '%v' already used",
+		account)
+}
+
+// ErrEmailInUse is returned when the provided email address is already
+// associated with a registered account.
+func ErrEmailInUse(email string) error {
+	return fmt.Errorf("email '%v' is already in use", email)
+}
+
+// NormalizeEmail returns the canonical form of the provided email address
+// used for indexing.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// newAccountID generates a random version 4 UUID to identify a new account.
+// Account ids are not derived from the account name or payout address so
+// they remain stable when either changes.
+func newAccountID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10],
+		id[10:]), nil
 }
 
 // NewAccount generates a new account.
 func NewAccount(name string, address string) (*Account, error) {
-	id := AccountID(name, address)
+	id, err := newAccountID()
+	if err != nil {
+		return nil, err
+	}
+
 	account := &Account{
-		UUID:      *id,
+		UUID:      id,
 		Name:      name,
 		Address:   address,
 		CreatedOn: uint64(time.Now().Unix()),
@@ -70,6 +200,84 @@ func FetchAccount(db *bolt.DB, id []byte) (*Account, error) {
 	return &account, err
 }
 
+// FilterAccounts iterates the accounts bucket, the result set is generated
+// based on the provided filter.
+func FilterAccounts(db *bolt.DB, filter func(account *Account) bool) ([]*Account, error) {
+	accounts := make([]*Account, 0)
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.AccountBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.AccountBkt)
+		}
+
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var account Account
+			err := json.Unmarshal(v, &account)
+			if err != nil {
+				return err
+			}
+
+			if filter(&account) {
+				accounts = append(accounts, &account)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// Redacted returns a copy of the account without its credentials, suitable
+// for display.
+func (acc *Account) Redacted() *Account {
+	redacted := *acc
+	redacted.PassHash = nil
+	redacted.TOTPSecret = ""
+	redacted.BackupCodes = nil
+	return &redacted
+}
+
+// Suspend suspends the account on behalf of the provided operator for the
+// provided reason. Banned accounts cannot be reinstated.
+func (acc *Account) Suspend(operator string, reason string, ban bool) {
+	acc.SuspendedOn = time.Now().Unix()
+	acc.SuspendedBy = operator
+	acc.SuspendReason = reason
+	acc.Banned = ban
+}
+
+// Suspended returns whether the account is suspended.
+func (acc *Account) Suspended() bool {
+	return acc.SuspendedOn != 0
+}
+
+// Reinstate lifts the suspension of the account.
+func (acc *Account) Reinstate() error {
+	if acc.Banned {
+		return fmt.Errorf("account (%v) is banned", acc.UUID)
+	}
+
+	acc.SuspendedOn = 0
+	acc.SuspendedBy = ""
+	acc.SuspendReason = ""
+	return nil
+}
+
+// HasLabel asserts the account was labelled with the provided label by the
+// operator.
+func (acc *Account) HasLabel(label string) bool {
+	return contains(acc.Labels, label)
+}
+
 // Create persists the account to the database.
 func (acc *Account) Create(db *bolt.DB) error {
 	err := db.Update(func(tx *bolt.Tx) error {
@@ -81,22 +289,609 @@ func (acc *Account) Create(db *bolt.DB) error {
 		if bkt == nil {
 			return database.ErrBucketNotFound(database.AccountBkt)
 		}
+		nbkt := pbkt.Bucket(database.NameIdxBkt)
+		if nbkt == nil {
+			return database.ErrBucketNotFound(database.NameIdxBkt)
+		}
+		existing, err := lookupAccountName(bkt, nbkt, acc.Name, acc.Address,
+			false)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return ErrNameInUse(acc.Name)
+		}
+
 		accBytes, err := json.Marshal(acc)
 		if err != nil {
 			return err
 		}
 		err = bkt.Put([]byte(acc.UUID), accBytes)
-		return err
+		if err != nil {
+			return err
+		}
+
+		return acc.indexName(nbkt)
 	})
 	return err
 }
 
-// Update is not supported for accounts.
+// Update persists modifications to an existing account.
 func (acc *Account) Update(db *bolt.DB) error {
-	return ErrNotSupported("account", "update")
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.AccountBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.AccountBkt)
+		}
+
+		// Assert the account exists before updating.
+		id := []byte(acc.UUID)
+		v := bkt.Get(id)
+		if v == nil {
+			return ErrAccountNotFound(acc.UUID)
+		}
+
+		var stored Account
+		err := json.Unmarshal(v, &stored)
+		if err != nil {
+			return err
+		}
+
+		// Assert an accepted one-time password was not used by a
+		// concurrent request for the account.
+		if acc.totpStep != 0 && stored.TOTPLastStep >= acc.totpStep {
+			return ErrOTPUsed(acc.UUID)
+		}
+
+		// Move the name index entry of the account when its payout address
+		// changes so miners can authorize with the new address.
+		if stored.Address != acc.Address {
+			nbkt := pbkt.Bucket(database.NameIdxBkt)
+			if nbkt == nil {
+				return database.ErrBucketNotFound(database.NameIdxBkt)
+			}
+
+			existing, err := lookupAccountName(bkt, nbkt, acc.Name,
+				acc.Address, false)
+			if err != nil {
+				return err
+			}
+
+			if existing != nil && existing.UUID != acc.UUID {
+				return ErrNameInUse(acc.Name)
+			}
+
+			err = stored.unindexName(nbkt)
+			if err != nil {
+				return err
+			}
+
+			err = acc.indexName(nbkt)
+			if err != nil {
+				return err
+			}
+		}
+
+		accBytes, err := json.Marshal(acc)
+		if err != nil {
+			return err
+		}
+		return bkt.Put(id, accBytes)
+	})
+	if err != nil {
+		return err
+	}
+
+	acc.totpStep = 0
+	return nil
+}
+
+// nameIndexIDs returns the ids of the accounts indexed under the provided
+// name index key, in the order they were indexed.
+func nameIndexIDs(nbkt *bolt.Bucket, key []byte) ([]string, error) {
+	v := nbkt.Get(key)
+	if v == nil {
+		return nil, nil
+	}
+
+	var ids []string
+	err := json.Unmarshal(v, &ids)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// indexName adds the account to the name index entry of its name and
+// payout address in the provided name index bucket. Accounts with names
+// only differing by case share the entry.
+func (acc *Account) indexName(nbkt *bolt.Bucket) error {
+	key := database.NameIndexKey(acc.Name, acc.Address)
+	ids, err := nameIndexIDs(nbkt, key)
+	if err != nil {
+		return err
+	}
+
+	if contains(ids, acc.UUID) {
+		return nil
+	}
+
+	idsBytes, err := json.Marshal(append(ids, acc.UUID))
+	if err != nil {
+		return err
+	}
+	return nbkt.Put(key, idsBytes)
+}
+
+// unindexName removes the account from the name index entry of its name and
+// payout address in the provided name index bucket, deleting the entry once
+// it references no accounts.
+func (acc *Account) unindexName(nbkt *bolt.Bucket) error {
+	key := database.NameIndexKey(acc.Name, acc.Address)
+	ids, err := nameIndexIDs(nbkt, key)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != acc.UUID {
+			remaining = append(remaining, id)
+		}
+	}
+
+	if len(remaining) == len(ids) {
+		return nil
+	}
+
+	if len(remaining) == 0 {
+		return nbkt.Delete(key)
+	}
+
+	idsBytes, err := json.Marshal(remaining)
+	if err != nil {
+		return err
+	}
+	return nbkt.Put(key, idsBytes)
 }
 
 // Delete purges the referenced account from the database.
 func (acc *Account) Delete(db *bolt.DB) error {
-	return database.Delete(db, database.AccountBkt, []byte(acc.UUID))
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.AccountBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.AccountBkt)
+		}
+		nbkt := pbkt.Bucket(database.NameIdxBkt)
+		if nbkt == nil {
+			return database.ErrBucketNotFound(database.NameIdxBkt)
+		}
+
+		err := acc.unindexName(nbkt)
+		if err != nil {
+			return err
+		}
+		return bkt.Delete([]byte(acc.UUID))
+	})
+	return err
+}
+
+// FetchAccountByName fetches the account with the provided name and payout
+// address through the name index. When case-insensitive, names only
+// differing by case resolve to the same account.
+func FetchAccountByName(db *bolt.DB, name string, address string, caseInsensitive bool) (*Account, error) {
+	var account *Account
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.AccountBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.AccountBkt)
+		}
+		nbkt := pbkt.Bucket(database.NameIdxBkt)
+		if nbkt == nil {
+			return database.ErrBucketNotFound(database.NameIdxBkt)
+		}
+
+		var err error
+		account, err = lookupAccountName(bkt, nbkt, name, address,
+			caseInsensitive)
+		if err != nil {
+			return err
+		}
+
+		if account == nil {
+			return ErrAccountNameNotFound(name, address)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// lookupAccountName returns the account with the provided name and payout
+// address, if any, using the provided account and name index buckets. When
+// case-insensitive, the first indexed account with a name only differing by
+// case is returned.
+func lookupAccountName(bkt *bolt.Bucket, nbkt *bolt.Bucket, name string, address string, caseInsensitive bool) (*Account, error) {
+	ids, err := nameIndexIDs(nbkt, database.NameIndexKey(name, address))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		v := bkt.Get([]byte(id))
+		if v == nil {
+			return nil, database.ErrValueNotFound([]byte(id))
+		}
+
+		var account Account
+		err := json.Unmarshal(v, &account)
+		if err != nil {
+			return nil, err
+		}
+
+		if caseInsensitive || account.Name == name {
+			return &account, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ErrNameInUse is returned when the provided account name is already in use
+// with the payout address of an account.
+func ErrNameInUse(name string) error {
+	return fmt.Errorf("account name '%v' is already in use", name)
+}
+
+// Rename changes the name of the account, updating the name index. The
+// account id is unchanged so its shares, payments and workers remain
+// associated. Names already in use with the payout address of the account,
+// including names only differing by case, are rejected.
+func (acc *Account) Rename(db *bolt.DB, name string) error {
+	prevName := acc.Name
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.AccountBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.AccountBkt)
+		}
+		nbkt := pbkt.Bucket(database.NameIdxBkt)
+		if nbkt == nil {
+			return database.ErrBucketNotFound(database.NameIdxBkt)
+		}
+
+		ids, err := nameIndexIDs(nbkt, database.NameIndexKey(name, acc.Address))
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if id != acc.UUID {
+				return ErrNameInUse(name)
+			}
+		}
+
+		err = acc.unindexName(nbkt)
+		if err != nil {
+			return err
+		}
+
+		acc.Name = name
+		accBytes, err := json.Marshal(acc)
+		if err != nil {
+			return err
+		}
+
+		err = bkt.Put([]byte(acc.UUID), accBytes)
+		if err != nil {
+			return err
+		}
+
+		return acc.indexName(nbkt)
+	})
+	if err != nil {
+		acc.Name = prevName
+	}
+	return err
+}
+
+// IsRegistered asserts the account has login credentials set.
+func (acc *Account) IsRegistered() bool {
+	return len(acc.PassHash) > 0
+}
+
+// SetPassword sets the login password of the account. Only the bcrypt hash
+// of the password is stored.
+func (acc *Account) SetPassword(pass string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	acc.PassHash = hash
+	return nil
+}
+
+// VerifyPassword asserts the provided password matches that of the account.
+func (acc *Account) VerifyPassword(pass string) bool {
+	if !acc.IsRegistered() {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(acc.PassHash, []byte(pass)) == nil
+}
+
+// hashBackupCode returns the hex encoded sha256 hash of the provided backup
+// code.
+func hashBackupCode(code string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateBackupCodes replaces the backup codes of the account with a new
+// set, returning the codes in plain text. Only hashes of the codes are
+// retained by the account.
+func (acc *Account) GenerateBackupCodes() ([]string, error) {
+	codes := make([]string, 0, backupCodeCount)
+	hashes := make([]string, 0, backupCodeCount)
+	for idx := 0; idx < backupCodeCount; idx++ {
+		b := make([]byte, backupCodeSize)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, err
+		}
+
+		code := hex.EncodeToString(b)
+		codes = append(codes, code)
+		hashes = append(hashes, hashBackupCode(code))
+	}
+
+	acc.BackupCodes = hashes
+	return codes, nil
+}
+
+// useBackupCode consumes the provided backup code if it belongs to the
+// account.
+func (acc *Account) useBackupCode(code string) bool {
+	hash := hashBackupCode(code)
+	for idx, entry := range acc.BackupCodes {
+		if entry == hash {
+			acc.BackupCodes = append(acc.BackupCodes[:idx],
+				acc.BackupCodes[idx+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// VerifyTOTP asserts the provided code is a valid one-time password of the
+// account not used before. The account must be persisted after a successful
+// verification to record the code as used.
+func (acc *Account) VerifyTOTP(code string) bool {
+	step, ok := util.ValidateTOTP(acc.TOTPSecret, code, time.Now(),
+		acc.TOTPLastStep)
+	if !ok {
+		return false
+	}
+
+	acc.TOTPLastStep = step
+	acc.totpStep = step
+	return true
+}
+
+// VerifySecondFactor asserts the provided code is either a valid one-time
+// password not used before or an unused backup code of the account.
+// Accounts without two-factor authentication enabled always pass. The
+// account must be persisted after a successful verification since one-time
+// passwords and backup codes are consumed on use.
+func (acc *Account) VerifySecondFactor(code string) bool {
+	if !acc.TOTPEnabled {
+		return true
+	}
+
+	if code == "" {
+		return false
+	}
+
+	if acc.VerifyTOTP(code) {
+		return true
+	}
+
+	return acc.useBackupCode(code)
+}
+
+// ResetSecondFactor disables two-factor authentication for the account and
+// discards its secret and backup codes.
+func (acc *Account) ResetSecondFactor() {
+	acc.TOTPSecret = ""
+	acc.TOTPEnabled = false
+	acc.TOTPLastStep = 0
+	acc.BackupCodes = nil
+}
+
+// Register sets the email address and password of the account and indexes
+// the email address, persisting the account in the process. The email
+// address is unverified until confirmed by the account owner.
+func (acc *Account) Register(db *bolt.DB, email string, pass string) error {
+	err := acc.SetPassword(pass)
+	if err != nil {
+		return err
+	}
+
+	acc.Email = NormalizeEmail(email)
+	acc.EmailVerified = false
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.AccountBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.AccountBkt)
+		}
+		ebkt := pbkt.Bucket(database.EmailIdxBkt)
+		if ebkt == nil {
+			return database.ErrBucketNotFound(database.EmailIdxBkt)
+		}
+
+		emailB := []byte(acc.Email)
+		if v := ebkt.Get(emailB); v != nil && string(v) != acc.UUID {
+			return ErrEmailInUse(acc.Email)
+		}
+
+		accBytes, err := json.Marshal(acc)
+		if err != nil {
+			return err
+		}
+
+		err = bkt.Put([]byte(acc.UUID), accBytes)
+		if err != nil {
+			return err
+		}
+
+		return ebkt.Put(emailB, []byte(acc.UUID))
+	})
+	return err
+}
+
+// FetchAccountByEmail fetches the registered account associated with the
+// provided email address.
+func FetchAccountByEmail(db *bolt.DB, email string) (*Account, error) {
+	var id []byte
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.EmailIdxBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.EmailIdxBkt)
+		}
+		v := bkt.Get([]byte(NormalizeEmail(email)))
+		if v == nil {
+			return ErrAccountNotFound(email)
+		}
+		id = make([]byte, len(v))
+		copy(id, v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return FetchAccount(db, id)
+}
+
+// ScheduleAddressChange schedules the payout address of the account to change
+// to the provided address once the provided delay elapses. Any previously
+// scheduled change is replaced.
+func (acc *Account) ScheduleAddressChange(address string, delay time.Duration) {
+	acc.PendingAddress = address
+	acc.PendingAddressOn = time.Now().Add(delay).Unix()
+}
+
+// CancelAddressChange discards the scheduled payout address change of the
+// account, if any.
+func (acc *Account) CancelAddressChange() {
+	acc.PendingAddress = ""
+	acc.PendingAddressOn = 0
+}
+
+// PayoutAddress returns the address payments to the account are made to at
+// the provided time. A scheduled address change only applies once it takes
+// effect, payments are made to the current address until then.
+func (acc *Account) PayoutAddress(now time.Time) string {
+	if acc.PendingAddress != "" && now.Unix() >= acc.PendingAddressOn {
+		return acc.PendingAddress
+	}
+	return acc.Address
+}
+
+// ApplyAddressChange replaces the payout address of the account with the
+// pending address if the scheduled change has taken effect at the provided
+// time. It returns true if the account was modified.
+func (acc *Account) ApplyAddressChange(now time.Time) bool {
+	if acc.PendingAddress == "" || now.Unix() < acc.PendingAddressOn {
+		return false
+	}
+
+	acc.Address = acc.PendingAddress
+	acc.CancelAddressChange()
+	return true
+}
+
+// ApplyAddressChanges persists the scheduled payout address changes which
+// have taken effect at the provided time, moving the name index entries of
+// the accounts to their new address. Changes to an address the account name
+// is already in use with are discarded. It returns the number of accounts
+// updated.
+func ApplyAddressChanges(db *bolt.DB, now time.Time) (int, error) {
+	accounts, err := FilterAccounts(db, func(account *Account) bool {
+		return account.PendingAddress != "" &&
+			now.Unix() >= account.PendingAddressOn
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var updated int
+	for _, account := range accounts {
+		pending := account.PendingAddress
+		account.ApplyAddressChange(now)
+		err := account.Update(db)
+		if err != nil {
+			if err.Error() != ErrNameInUse(account.Name).Error() {
+				return updated, err
+			}
+
+			log.Warnf("Discarding payout address change of account (%v) to "+
+				"%v: %v", account.UUID, pending, err)
+			account, err = FetchAccount(db, []byte(account.UUID))
+			if err != nil {
+				return updated, err
+			}
+
+			account.CancelAddressChange()
+			err = account.Update(db)
+			if err != nil {
+				return updated, err
+			}
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// RecordLogin appends a login from the provided ip to the login history of
+// the account, discarding the oldest records past the retention limit.
+func (acc *Account) RecordLogin(ip string) {
+	acc.Logins = append(acc.Logins, LoginRecord{
+		Time: time.Now().Unix(),
+		IP:   ip,
+	})
+
+	if len(acc.Logins) > maxLoginRecords {
+		acc.Logins = acc.Logins[len(acc.Logins)-maxLoginRecords:]
+	}
 }
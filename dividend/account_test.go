@@ -0,0 +1,488 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccountRegistration(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Error(err)
+	}
+
+	td := func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	defer td()
+
+	account, err := FetchAccount(db, []byte(xID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.IsRegistered() {
+		t.Fatalf("expected account %v to be unregistered", xID)
+	}
+
+	email := "X@Example.org"
+	pass := "correct horse"
+	err = account.Register(db, email, pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure the account can be fetched by its email address regardless of
+	// case.
+	fetched, err := FetchAccountByEmail(db, "x@example.ORG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fetched.UUID != xID {
+		t.Errorf("expected account %v, got %v", xID, fetched.UUID)
+	}
+
+	if !fetched.VerifyPassword(pass) {
+		t.Error("expected password to be verified")
+	}
+
+	if fetched.VerifyPassword("incorrect horse") {
+		t.Error("expected incorrect password to be rejected")
+	}
+
+	// Ensure an email address can only be registered to one account.
+	other, err := FetchAccount(db, []byte(yID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = other.Register(db, email, pass)
+	if err == nil {
+		t.Error("expected email in use error")
+	}
+}
+
+func TestAccountErase(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Error(err)
+	}
+
+	td := func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	defer td()
+
+	account, err := FetchAccount(db, []byte(xID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := "x@example.org"
+	err = account.Register(db, email, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _, err := NewAPIKey(xID, "miner", []string{ScopeReadStats})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = key.Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := account.Address
+	err = account.Erase(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure personal data is purged while the payout address is retained.
+	erased, err := FetchAccount(db, []byte(xID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if erased.Email != "" || erased.Name != "" || erased.IsRegistered() {
+		t.Errorf("expected personal data of account %v to be purged", xID)
+	}
+
+	if erased.Address != address {
+		t.Errorf("expected payout address %v, got %v", address,
+			erased.Address)
+	}
+
+	_, err = FetchAccountByEmail(db, email)
+	if err == nil {
+		t.Error("expected email index entry to be purged")
+	}
+
+	_, err = FetchAPIKey(db, []byte(key.UUID))
+	if err == nil {
+		t.Error("expected api key to be purged")
+	}
+}
+
+func TestFetchAccountByName(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Error(err)
+	}
+
+	td := func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	defer td()
+
+	// Ensure names only resolve case-insensitively when requested.
+	account, err := FetchAccountByName(db, "X", xAddr, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.UUID != xID {
+		t.Errorf("expected account %v, got %v", xID, account.UUID)
+	}
+
+	_, err = FetchAccountByName(db, "X", xAddr, false)
+	if err == nil {
+		t.Error("expected case-sensitive lookup to fail")
+	}
+
+	// Ensure deleted accounts are removed from the name index.
+	err = account.Delete(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = FetchAccountByName(db, "X", xAddr, true)
+	if err == nil {
+		t.Error("expected deleted account lookup to fail")
+	}
+}
+
+func TestNameIndexCaseVariants(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Error(err)
+	}
+
+	td := func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	defer td()
+
+	// Ensure accounts with names only differing by case are indexed.
+	variant, err := NewAccount("X", xAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = variant.Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := FetchAccountByName(db, "X", xAddr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.UUID != variant.UUID {
+		t.Errorf("expected account %v, got %v", variant.UUID, account.UUID)
+	}
+
+	account, err = FetchAccountByName(db, accX, xAddr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.UUID != xID {
+		t.Errorf("expected account %v, got %v", xID, account.UUID)
+	}
+
+	// Ensure case-insensitive lookups resolve to the account created first.
+	account, err = FetchAccountByName(db, "X", xAddr, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.UUID != xID {
+		t.Errorf("expected account %v, got %v", xID, account.UUID)
+	}
+
+	// Ensure deleting an account keeps its case variants indexed.
+	err = account.Delete(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err = FetchAccountByName(db, accX, xAddr, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.UUID != variant.UUID {
+		t.Errorf("expected account %v, got %v", variant.UUID, account.UUID)
+	}
+
+	_, err = FetchAccountByName(db, accX, xAddr, false)
+	if err == nil {
+		t.Error("expected lookup of the deleted account to fail")
+	}
+}
+
+func TestAccountRename(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Error(err)
+	}
+
+	td := func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	defer td()
+
+	account, err := FetchAccount(db, []byte(xID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = account.Rename(db, "farm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure the account resolves by its new name and keeps its id.
+	renamed, err := FetchAccountByName(db, "farm", xAddr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if renamed.UUID != xID {
+		t.Errorf("expected account %v, got %v", xID, renamed.UUID)
+	}
+
+	// Ensure renames colliding with another account are rejected.
+	other, err := NewAccount("rig", xAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = other.Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = other.Rename(db, "Farm")
+	if err == nil {
+		t.Error("expected name in use error")
+	}
+
+	if other.Name != "rig" {
+		t.Errorf("expected name of rejected rename to be kept, got %v",
+			other.Name)
+	}
+}
+
+func TestAccountAddressChange(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Error(err)
+	}
+
+	td := func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	defer td()
+
+	account, err := FetchAccount(db, []byte(xID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account.ScheduleAddressChange(yAddr, 0)
+	if !account.ApplyAddressChange(time.Now()) {
+		t.Fatal("expected the address change to take effect")
+	}
+
+	err = account.Update(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure the account resolves by its new address and keeps its id.
+	changed, err := FetchAccountByName(db, accX, yAddr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changed.UUID != xID {
+		t.Errorf("expected account %v, got %v", xID, changed.UUID)
+	}
+
+	_, err = FetchAccountByName(db, accX, xAddr, false)
+	if err == nil {
+		t.Error("expected lookup by the previous address to fail")
+	}
+
+	// Ensure accounts with the same name and address cannot be created.
+	dup, err := NewAccount(accX, yAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dup.Create(db)
+	if err == nil {
+		t.Error("expected name in use error")
+	}
+}
+
+func TestApplyAddressChanges(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Error(err)
+	}
+
+	td := func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	defer td()
+
+	account, err := FetchAccount(db, []byte(xID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account.ScheduleAddressChange(yAddr, time.Hour)
+	err = account.Update(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure scheduled changes are not applied before the delay elapses.
+	updated, err := ApplyAddressChanges(db, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated != 0 {
+		t.Fatalf("expected no address changes applied, got %v", updated)
+	}
+
+	_, err = FetchAccountByName(db, accX, xAddr, false)
+	if err != nil {
+		t.Fatalf("expected lookup by the current address: %v", err)
+	}
+
+	// Ensure changes are persisted and the name index entry moved once the
+	// delay elapses.
+	updated, err = ApplyAddressChanges(db, time.Now().Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated != 1 {
+		t.Fatalf("expected 1 address change applied, got %v", updated)
+	}
+
+	changed, err := FetchAccountByName(db, accX, yAddr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changed.UUID != xID || changed.Address != yAddr ||
+		changed.PendingAddress != "" {
+		t.Errorf("expected the address change of account %v applied", xID)
+	}
+
+	_, err = FetchAccountByName(db, accX, xAddr, false)
+	if err == nil {
+		t.Error("expected lookup by the previous address to fail")
+	}
+
+	// Ensure changes to an address the name is in use with are rejected
+	// and discarded when applied.
+	other, err := NewAccount(accY, xAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = other.Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err = FetchAccount(db, []byte(yID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account.ScheduleAddressChange(xAddr, 0)
+	account.ApplyAddressChange(time.Now())
+	err = account.Update(db)
+	if err == nil || err.Error() != ErrNameInUse(accY).Error() {
+		t.Fatalf("expected name in use error, got %v", err)
+	}
+
+	account, err = FetchAccount(db, []byte(yID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account.ScheduleAddressChange(xAddr, 0)
+	err = account.Update(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ApplyAddressChanges(db, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kept, err := FetchAccountByName(db, accY, yAddr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if kept.UUID != yID || kept.PendingAddress != "" {
+		t.Errorf("expected the address change of account %v discarded", yID)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+
+	"github.com/dnldd/dcrpool/database"
+)
+
+// Account activity types.
+const (
+	ActivityLogin          = "login"
+	ActivityLoginFailed    = "loginfailed"
+	ActivityLogout         = "logout"
+	ActivityAddressChange  = "addresschange"
+	ActivityAPIKeyCreated  = "apikeycreated"
+	ActivityAPIKeyRevoked  = "apikeyrevoked"
+	ActivitySettingsChange = "settingschange"
+	ActivityOperator       = "operator"
+)
+
+// Activity represents an entry of the activity log of an account. Operator
+// actions on the account record the operator as the actor.
+type Activity struct {
+	Account   string `json:"account"`
+	Type      string `json:"type"`
+	Detail    string `json:"detail"`
+	IP        string `json:"ip,omitempty"`
+	Actor     string `json:"actor,omitempty"`
+	CreatedOn int64  `json:"createdon"`
+}
+
+// NewActivity creates an activity log entry for the provided account.
+func NewActivity(account string, activityType string, detail string, ip string, actor string) *Activity {
+	return &Activity{
+		Account:   account,
+		Type:      activityType,
+		Detail:    detail,
+		IP:        ip,
+		Actor:     actor,
+		CreatedOn: time.Now().UnixNano(),
+	}
+}
+
+// activityPrefix returns the key prefix of the activity log entries of the
+// provided account.
+func activityPrefix(account string) []byte {
+	return []byte(account + "/")
+}
+
+// key returns the key of the activity log entry, entries of an account are
+// ordered by their creation time.
+func (a *Activity) key() []byte {
+	return append(activityPrefix(a.Account),
+		TimeKey(a.CreatedOn)...)
+}
+
+// Create persists the activity log entry to the database.
+func (a *Activity) Create(db *bolt.DB) error {
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.ActivityBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.ActivityBkt)
+		}
+		aBytes, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		return bkt.Put(a.key(), aBytes)
+	})
+	return err
+}
+
+// Update is not supported for activity log entries.
+func (a *Activity) Update(db *bolt.DB) error {
+	return ErrNotSupported("activity", "update")
+}
+
+// Delete removes the activity log entry from the database.
+func (a *Activity) Delete(db *bolt.DB) error {
+	return database.Delete(db, database.ActivityBkt, a.key())
+}
+
+// ListActivity returns the page of the activity log entries of the provided
+// account selected by the query, and the cursor of the next page if any.
+func ListActivity(db *bolt.DB, account string, query *PageQuery) ([]*Activity, string, error) {
+	entries := make([]*Activity, 0)
+	var next []byte
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.ActivityBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.ActivityBkt)
+		}
+
+		r, err := query.Range(activityPrefix(account), TimeKey)
+		if err != nil {
+			return err
+		}
+
+		next, err = database.ScanRange(bkt, r, func(k, v []byte) (bool, error) {
+			var entry Activity
+			err := json.Unmarshal(v, &entry)
+			if err != nil {
+				return false, err
+			}
+
+			entries = append(entries, &entry)
+			return true, nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return entries, EncodeCursor(next), nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"testing"
+)
+
+func TestActivity(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	details := []string{"first", "second", "third"}
+	for _, detail := range details {
+		err = NewActivity(xID, ActivitySettingsChange, detail, "", "").Create(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = NewActivity(yID, ActivityLogin, "other", "", "").Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _, err := ListActivity(db, xID, &PageQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != len(details) {
+		t.Fatalf("expected %v entries, got %v", len(details), len(entries))
+	}
+
+	if entries[0].Detail != "third" || entries[2].Detail != "first" {
+		t.Fatalf("expected entries newest first, got %v then %v",
+			entries[0].Detail, entries[2].Detail)
+	}
+
+	entries, next, err := ListActivity(db, xID, &PageQuery{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 || entries[0].Detail != "third" {
+		t.Fatalf("expected the 2 most recent entries, got %v", len(entries))
+	}
+
+	if next == "" {
+		t.Fatal("expected a cursor to the next page")
+	}
+
+	entries, next, err = ListActivity(db, xID,
+		&PageQuery{Limit: 2, Cursor: next})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].Detail != "first" || next != "" {
+		t.Fatalf("expected the last page to hold the oldest entry, got %v "+
+			"entries", len(entries))
+	}
+
+	entries, _, err = ListActivity(db, xID, &PageQuery{Ascending: true,
+		From: entries[0].CreatedOn + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 || entries[0].Detail != "second" {
+		t.Fatalf("expected the entries after the first oldest first, got %v",
+			len(entries))
+	}
+
+	// Cursors of other accounts are rejected.
+	_, _, err = ListActivity(db, xID, &PageQuery{
+		Cursor: EncodeCursor([]byte(yID + "/0"))})
+	if err == nil {
+		t.Fatal("expected a cursor of another account to be rejected")
+	}
+}
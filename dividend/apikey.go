@@ -0,0 +1,220 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+
+	"github.com/dnldd/dcrpool/database"
+)
+
+// API key scopes.
+const (
+	// ScopeReadStats grants read access to account mining statistics.
+	ScopeReadStats = "stats:read"
+
+	// ScopeReadPayments grants read access to account payments.
+	ScopeReadPayments = "payments:read"
+
+	// ScopeManageWorkers grants access to manage account workers.
+	ScopeManageWorkers = "workers:manage"
+)
+
+// Scopes lists all known api key scopes.
+var Scopes = []string{ScopeReadStats, ScopeReadPayments, ScopeManageWorkers}
+
+// ErrInvalidScope is returned when an unknown api key scope is provided.
+func ErrInvalidScope(scope string) error {
+	return fmt.Errorf("invalid api key scope '%v'", scope)
+}
+
+// APIKey represents a scoped api key issued to an account. Only the hash of
+// the key secret is stored, the full key is of the form `id.secret`.
+type APIKey struct {
+	UUID       string   `json:"uuid"`
+	Account    string   `json:"account"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	SecretHash string   `json:"secrethash"`
+	CreatedOn  int64    `json:"createdon"`
+}
+
+// hashSecret returns the hex encoded sha256 hash of the provided secret.
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// NewAPIKey creates an api key with the provided scopes for the provided
+// account. The full key is returned alongside the api key and is not
+// retrievable afterwards.
+func NewAPIKey(account string, name string, scopes []string) (*APIKey, string, error) {
+	for _, scope := range scopes {
+		valid := false
+		for _, known := range Scopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return nil, "", ErrInvalidScope(scope)
+		}
+	}
+
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &APIKey{
+		UUID:       hex.EncodeToString(id),
+		Account:    account,
+		Name:       name,
+		Scopes:     scopes,
+		SecretHash: hashSecret(hex.EncodeToString(secret)),
+		CreatedOn:  time.Now().Unix(),
+	}
+
+	full := fmt.Sprintf("%s.%s", key.UUID, hex.EncodeToString(secret))
+	return key, full, nil
+}
+
+// HasScope asserts the api key was granted the provided scope.
+func (key *APIKey) HasScope(scope string) bool {
+	for _, s := range key.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchAPIKey fetches the api key referenced by the provided id.
+func FetchAPIKey(db *bolt.DB, id []byte) (*APIKey, error) {
+	var key APIKey
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.APIKeyBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.APIKeyBkt)
+		}
+		v := bkt.Get(id)
+		if v == nil {
+			return database.ErrValueNotFound(id)
+		}
+		return json.Unmarshal(v, &key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// AuthenticateAPIKey fetches the api key matching the provided full key.
+func AuthenticateAPIKey(db *bolt.DB, full string) (*APIKey, error) {
+	parts := strings.SplitN(full, ".", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed api key")
+	}
+
+	key, err := FetchAPIKey(db, []byte(parts[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	hash := hashSecret(parts[1])
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(key.SecretHash)) != 1 {
+		return nil, fmt.Errorf("invalid api key")
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys returns all api keys issued to the provided account.
+func ListAPIKeys(db *bolt.DB, account string) ([]*APIKey, error) {
+	keys := make([]*APIKey, 0)
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.APIKeyBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.APIKeyBkt)
+		}
+
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var key APIKey
+			err := json.Unmarshal(v, &key)
+			if err != nil {
+				return err
+			}
+
+			if key.Account == account {
+				keys = append(keys, &key)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Create persists the api key to the database.
+func (key *APIKey) Create(db *bolt.DB) error {
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.APIKeyBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.APIKeyBkt)
+		}
+		keyBytes, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(key.UUID), keyBytes)
+	})
+	return err
+}
+
+// Update is not supported for api keys.
+func (key *APIKey) Update(db *bolt.DB) error {
+	return ErrNotSupported("api key", "update")
+}
+
+// Delete revokes the api key by removing it from the database.
+func (key *APIKey) Delete(db *bolt.DB) error {
+	return database.Delete(db, database.APIKeyBkt, []byte(key.UUID))
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+
+	"github.com/dnldd/dcrpool/database"
+)
+
+// Pool event types.
+const (
+	PoolEventBlockFound          = "blockfound"
+	PoolEventBlockDisapproved    = "blockdisapproved"
+	PoolEventReorg               = "reorg"
+	PoolEventPayout              = "payout"
+	PoolEventPayoutConfirmed     = "payoutconfirmed"
+	PoolEventBackendDisconnected = "backenddisconnected"
+	PoolEventBackendReconnected  = "backendreconnected"
+	PoolEventBackendFailover     = "backendfailover"
+	PoolEventIPBan               = "ipban"
+	PoolEventIPUnban             = "ipunban"
+	PoolEventAccountSuspended    = "accountsuspended"
+	PoolEventAccountReinstated   = "accountreinstated"
+	PoolEventAlert               = "alert"
+	PoolEventReconciliation      = "reconciliation"
+	PoolEventBlockSubmission     = "blocksubmission"
+	PoolEventConfigReload        = "configreload"
+)
+
+// publicPoolEvents are the pool event types listed publicly, the remaining
+// types are only listed to operators.
+var publicPoolEvents = map[string]bool{
+	PoolEventBlockFound:          true,
+	PoolEventBlockDisapproved:    true,
+	PoolEventReorg:               true,
+	PoolEventPayout:              true,
+	PoolEventPayoutConfirmed:     true,
+	PoolEventBackendDisconnected: true,
+	PoolEventBackendReconnected:  true,
+	PoolEventBackendFailover:     true,
+}
+
+// PoolEvent represents an entry of the event log of the pool. Operator
+// actions record the operator as the actor.
+type PoolEvent struct {
+	Type      string      `json:"type"`
+	Detail    string      `json:"detail"`
+	Data      interface{} `json:"data,omitempty"`
+	Actor     string      `json:"actor,omitempty"`
+	CreatedOn int64       `json:"createdon"`
+}
+
+// NewPoolEvent creates a pool event log entry.
+func NewPoolEvent(eventType string, detail string, data interface{}, actor string) *PoolEvent {
+	return &PoolEvent{
+		Type:      eventType,
+		Detail:    detail,
+		Data:      data,
+		Actor:     actor,
+		CreatedOn: time.Now().UnixNano(),
+	}
+}
+
+// Public returns whether the event is listed publicly.
+func (e *PoolEvent) Public() bool {
+	return publicPoolEvents[e.Type]
+}
+
+// key returns the key of the pool event, events are ordered by their
+// creation time.
+func (e *PoolEvent) key() []byte {
+	return TimeKey(e.CreatedOn)
+}
+
+// Create persists the pool event to the database.
+func (e *PoolEvent) Create(db *bolt.DB) error {
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.EventBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.EventBkt)
+		}
+		eBytes, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		return bkt.Put(e.key(), eBytes)
+	})
+	return err
+}
+
+// Update is not supported for pool events.
+func (e *PoolEvent) Update(db *bolt.DB) error {
+	return ErrNotSupported("pool event", "update")
+}
+
+// Delete removes the pool event from the database.
+func (e *PoolEvent) Delete(db *bolt.DB) error {
+	return database.Delete(db, database.EventBkt, e.key())
+}
+
+// ListPoolEvents returns the page of the pool events selected by the query
+// and the provided filter, and the cursor of the next page if any.
+func ListPoolEvents(db *bolt.DB, filter func(*PoolEvent) bool, query *PageQuery) ([]*PoolEvent, string, error) {
+	events := make([]*PoolEvent, 0)
+	var next []byte
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.EventBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.EventBkt)
+		}
+
+		r, err := query.Range(nil, TimeKey)
+		if err != nil {
+			return err
+		}
+
+		next, err = database.ScanRange(bkt, r, func(k, v []byte) (bool, error) {
+			var event PoolEvent
+			err := json.Unmarshal(v, &event)
+			if err != nil {
+				return false, err
+			}
+
+			if filter != nil && !filter(&event) {
+				return false, nil
+			}
+
+			events = append(events, &event)
+			return true, nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return events, EncodeCursor(next), nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"testing"
+)
+
+func TestPoolEvents(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	types := []string{PoolEventBlockFound, PoolEventIPBan, PoolEventPayout}
+	for idx, eventType := range types {
+		event := NewPoolEvent(eventType, eventType, nil, "")
+		event.CreatedOn = int64(idx + 1)
+		err = event.Create(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	public := func(event *PoolEvent) bool { return event.Public() }
+	events, _, err := ListPoolEvents(db, public, &PageQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0].Type != PoolEventPayout ||
+		events[1].Type != PoolEventBlockFound {
+		t.Fatalf("expected the public events newest first, got %v",
+			len(events))
+	}
+
+	events, _, err = ListPoolEvents(db, nil, &PageQuery{From: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[1].Type != PoolEventIPBan {
+		t.Fatalf("expected the events from the lower bound, got %v",
+			len(events))
+	}
+}
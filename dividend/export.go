@@ -0,0 +1,251 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+
+	"github.com/dnldd/dcrpool/database"
+)
+
+// ShareSummary represents the aggregate of the shares of an account.
+type ShareSummary struct {
+	Count  uint64   `json:"count"`
+	Weight *big.Rat `json:"weight"`
+	First  int64    `json:"first"`
+	Last   int64    `json:"last"`
+}
+
+// AccountExport represents all stored data associated with an account.
+type AccountExport struct {
+	Account          *Account      `json:"account"`
+	Shares           *ShareSummary `json:"shares"`
+	PendingPayments  []*Payment    `json:"pendingpayments"`
+	ArchivedPayments []*Payment    `json:"archivedpayments"`
+	APIKeys          []*APIKey     `json:"apikeys"`
+	Workers          []*Worker     `json:"workers"`
+	Activity         []*Activity   `json:"activity"`
+	ExportedOn       int64         `json:"exportedon"`
+}
+
+// FetchShareSummary aggregates the shares of the provided account.
+func FetchShareSummary(db *bolt.DB, account string) (*ShareSummary, error) {
+	summary := &ShareSummary{Weight: new(big.Rat)}
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.ShareBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.ShareBkt)
+		}
+
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var share Share
+			err := json.Unmarshal(v, &share)
+			if err != nil {
+				return err
+			}
+
+			if share.Account != account {
+				continue
+			}
+
+			if summary.Count == 0 {
+				summary.First = share.CreatedOn
+			}
+			summary.Last = share.CreatedOn
+			summary.Count++
+			summary.Weight.Add(summary.Weight, share.Weight)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// ExportAccount gathers all stored data associated with the provided account,
+// including operator labels and notes. Account credentials are excluded from
+// the export.
+func ExportAccount(db *bolt.DB, id string) (*AccountExport, error) {
+	account, err := FetchAccount(db, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := FetchShareSummary(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := FilterPayments(db, func(payment *Payment) bool {
+		return payment.Account == id && !payment.Voided
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	archived, _, err := FetchAccountPayments(db, id,
+		&PageQuery{Ascending: true})
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := ListAPIKeys(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		key.SecretHash = ""
+	}
+
+	workers, err := ListWorkers(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	activity, _, err := ListActivity(db, id, &PageQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	export := &AccountExport{
+		Account:          account.Redacted(),
+		Shares:           shares,
+		PendingPayments:  pending,
+		ArchivedPayments: archived,
+		APIKeys:          keys,
+		Workers:          workers,
+		Activity:         activity,
+		ExportedOn:       time.Now().Unix(),
+	}
+
+	return export, nil
+}
+
+// purgeAccountEntries removes all entries of the provided bucket associated
+// with the provided account. Entries are expected to be json objects
+// referencing their account by an `account` field.
+func purgeAccountEntries(bkt *bolt.Bucket, account string) error {
+	toDelete := [][]byte{}
+	cursor := bkt.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		var entry struct {
+			Account string `json:"account"`
+		}
+		err := json.Unmarshal(v, &entry)
+		if err != nil {
+			return err
+		}
+
+		if entry.Account == account {
+			toDelete = append(toDelete, k)
+		}
+	}
+
+	for _, k := range toDelete {
+		err := bkt.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Erase purges the personal data of the account: its name, email address,
+// credentials, login history, operator notes, tokens, api keys, workers and
+// activity log. The account id, payout address and accounting records
+// (shares and payments) are retained so dividends owed to the account can
+// still be paid out.
+func (acc *Account) Erase(db *bolt.DB) error {
+	email := acc.Email
+	named := &Account{UUID: acc.UUID, Name: acc.Name, Address: acc.Address}
+
+	acc.Name = ""
+	acc.Email = ""
+	acc.EmailVerified = false
+	acc.PassHash = nil
+	acc.ResetSecondFactor()
+	acc.CancelAddressChange()
+	acc.Logins = nil
+	acc.Notifications = nil
+	acc.Labels = nil
+	acc.Notes = ""
+	acc.ErasedOn = time.Now().Unix()
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.AccountBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.AccountBkt)
+		}
+		ebkt := pbkt.Bucket(database.EmailIdxBkt)
+		if ebkt == nil {
+			return database.ErrBucketNotFound(database.EmailIdxBkt)
+		}
+		tbkt := pbkt.Bucket(database.TokenBkt)
+		if tbkt == nil {
+			return database.ErrBucketNotFound(database.TokenBkt)
+		}
+		kbkt := pbkt.Bucket(database.APIKeyBkt)
+		if kbkt == nil {
+			return database.ErrBucketNotFound(database.APIKeyBkt)
+		}
+		wbkt := pbkt.Bucket(database.WorkerBkt)
+		if wbkt == nil {
+			return database.ErrBucketNotFound(database.WorkerBkt)
+		}
+		nbkt := pbkt.Bucket(database.NameIdxBkt)
+		if nbkt == nil {
+			return database.ErrBucketNotFound(database.NameIdxBkt)
+		}
+		abkt := pbkt.Bucket(database.ActivityBkt)
+		if abkt == nil {
+			return database.ErrBucketNotFound(database.ActivityBkt)
+		}
+
+		err := named.unindexName(nbkt)
+		if err != nil {
+			return err
+		}
+
+		if email != "" {
+			err = ebkt.Delete([]byte(NormalizeEmail(email)))
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, b := range []*bolt.Bucket{tbkt, kbkt, wbkt, abkt} {
+			err := purgeAccountEntries(b, acc.UUID)
+			if err != nil {
+				return err
+			}
+		}
+
+		accBytes, err := json.Marshal(acc)
+		if err != nil {
+			return err
+		}
+
+		return bkt.Put([]byte(acc.UUID), accBytes)
+	})
+	return err
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+
+	"github.com/dnldd/dcrpool/database"
+)
+
+// Hash rate sample resolutions.
+const (
+	Resolution5m = "5m"
+	Resolution1h = "1h"
+	Resolution1d = "1d"
+)
+
+// PoolSeries is the hash rate series of the pool.
+const PoolSeries = "pool"
+
+// resolutionIntervals are the sample intervals of the supported hash rate
+// resolutions.
+var resolutionIntervals = map[string]time.Duration{
+	Resolution5m: time.Minute * 5,
+	Resolution1h: time.Hour,
+	Resolution1d: time.Hour * 24,
+}
+
+// ErrInvalidResolution is returned when a hash rate resolution is not
+// supported.
+func ErrInvalidResolution(res string) error {
+	return fmt.Errorf("invalid resolution '%v', expected %v, %v or %v", res,
+		Resolution5m, Resolution1h, Resolution1d)
+}
+
+// ErrResolutionNotRecorded is returned when the samples of a hash rate
+// resolution are not recorded.
+func ErrResolutionNotRecorded(res string) error {
+	return fmt.Errorf("resolution '%v' is not recorded", res)
+}
+
+// HashRatePolicy is the downsampling and retention policy of hash rate
+// series, the retention of the samples of each resolution. Resolutions
+// without a retention are not recorded.
+type HashRatePolicy map[string]time.Duration
+
+// DefaultHashRatePolicy returns the default hash rate policy, which records
+// 5m samples for 48 hours, 1h samples for 30 days and 1d samples for a year.
+func DefaultHashRatePolicy() HashRatePolicy {
+	return HashRatePolicy{
+		Resolution5m: time.Hour * 48,
+		Resolution1h: time.Hour * 24 * 30,
+		Resolution1d: time.Hour * 24 * 365,
+	}
+}
+
+// ParseHashRatePolicy parses a hash rate policy from `resolution:retention`
+// entries, for instance `5m:24h`. Retentions are durations, resolutions
+// given a retention of zero are not recorded and resolutions not provided
+// keep their default retention.
+func ParseHashRatePolicy(entries []string) (HashRatePolicy, error) {
+	policy := DefaultHashRatePolicy()
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid hash rate retention '%v', "+
+				"expected resolution:retention", entry)
+		}
+
+		res := strings.TrimSpace(parts[0])
+		if _, ok := resolutionIntervals[res]; !ok {
+			return nil, ErrInvalidResolution(res)
+		}
+
+		retention, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || retention < 0 {
+			return nil, fmt.Errorf("invalid retention '%v' for "+
+				"resolution %v", parts[1], res)
+		}
+
+		if retention > 0 && retention < resolutionIntervals[res] {
+			return nil, fmt.Errorf("retention of resolution %v is shorter "+
+				"than its interval", res)
+		}
+
+		policy[res] = retention
+	}
+
+	return policy, nil
+}
+
+// Retention returns the retention of hash rate samples of the provided
+// resolution.
+func (p HashRatePolicy) Retention(res string) (time.Duration, error) {
+	if _, ok := resolutionIntervals[res]; !ok {
+		return 0, ErrInvalidResolution(res)
+	}
+
+	retention := p[res]
+	if retention <= 0 {
+		return 0, ErrResolutionNotRecorded(res)
+	}
+	return retention, nil
+}
+
+// SeriesPolicy returns the hash rate policy of the provided series, account
+// and worker series follow the provided policy while the pool series always
+// follows the default policy.
+func SeriesPolicy(series string, policy HashRatePolicy) HashRatePolicy {
+	if series == PoolSeries || policy == nil {
+		return DefaultHashRatePolicy()
+	}
+	return policy
+}
+
+// AccountSeries returns the hash rate series of the provided account.
+func AccountSeries(account string) string {
+	return "account/" + account
+}
+
+// WorkerSeries returns the hash rate series of the provided worker.
+func WorkerSeries(worker string) string {
+	return "worker/" + worker
+}
+
+// HashRateSample is the average hash rate, in TH/s, of a series over an
+// interval starting at the sample time, in unix time.
+type HashRateSample struct {
+	Time     int64   `json:"time"`
+	HashRate float64 `json:"hashrate"`
+	Samples  int     `json:"samples"`
+}
+
+// hashRatePrefix returns the key prefix of the samples of the provided series
+// at the provided resolution.
+func hashRatePrefix(series string, res string) []byte {
+	return []byte(series + "|" + res + "|")
+}
+
+// RecordHashRates records the provided hash rates of series sampled at the
+// provided time. Samples are averaged into the interval of each resolution
+// they fall in that is recorded by the provided account and worker policy.
+func RecordHashRates(db *bolt.DB, now time.Time, rates map[string]float64, policy HashRatePolicy) error {
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.HashRateBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.HashRateBkt)
+		}
+
+		for series, rate := range rates {
+			for res, retention := range SeriesPolicy(series, policy) {
+				if retention <= 0 {
+					continue
+				}
+
+				start := now.Truncate(resolutionIntervals[res])
+				key := append(hashRatePrefix(series, res),
+					TimeKey(start.UnixNano())...)
+
+				sample := HashRateSample{Time: start.Unix()}
+				if v := bkt.Get(key); v != nil {
+					err := json.Unmarshal(v, &sample)
+					if err != nil {
+						return err
+					}
+				}
+
+				// Update the running average of the interval.
+				sample.HashRate = (sample.HashRate*float64(sample.Samples) +
+					rate) / float64(sample.Samples+1)
+				sample.Samples++
+
+				sBytes, err := json.Marshal(sample)
+				if err != nil {
+					return err
+				}
+
+				err = bkt.Put(key, sBytes)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	return err
+}
+
+// PruneHashRates removes hash rate samples past the retention of their
+// resolution, account and worker samples are retained according to the
+// provided policy. Samples of resolutions no longer recorded are removed.
+func PruneHashRates(db *bolt.DB, now time.Time, policy HashRatePolicy) error {
+	minKeys := func(policy HashRatePolicy) map[string][]byte {
+		keys := make(map[string][]byte, len(resolutionIntervals))
+		for res := range resolutionIntervals {
+			keys[res] = TimeKey(now.Add(-policy[res]).UnixNano())
+		}
+		return keys
+	}
+	if policy == nil {
+		policy = DefaultHashRatePolicy()
+	}
+	poolMinKeys := minKeys(DefaultHashRatePolicy())
+	seriesMinKeys := minKeys(policy)
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.HashRateBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.HashRateBkt)
+		}
+
+		expired := make([][]byte, 0)
+		cursor := bkt.Cursor()
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+			parts := bytes.Split(k, []byte("|"))
+			if len(parts) != 3 {
+				continue
+			}
+
+			keys := seriesMinKeys
+			if string(parts[0]) == PoolSeries {
+				keys = poolMinKeys
+			}
+
+			min, ok := keys[string(parts[1])]
+			if ok && bytes.Compare(parts[2], min) < 0 {
+				key := make([]byte, len(k))
+				copy(key, k)
+				expired = append(expired, key)
+			}
+		}
+
+		for _, k := range expired {
+			err := bkt.Delete(k)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	return err
+}
+
+// FetchHashRates returns the hash rate samples of the provided series at the
+// provided resolution within the provided time range, oldest first.
+func FetchHashRates(db *bolt.DB, series string, res string, from time.Time, to time.Time) ([]*HashRateSample, error) {
+	interval, ok := resolutionIntervals[res]
+	if !ok {
+		return nil, ErrInvalidResolution(res)
+	}
+
+	// Include the interval the range starts in.
+	from = from.Truncate(interval)
+
+	samples := make([]*HashRateSample, 0)
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.HashRateBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.HashRateBkt)
+		}
+
+		r := &database.Range{
+			Prefix: hashRatePrefix(series, res),
+			Min:    TimeKey(from.UnixNano()),
+			Max:    TimeKey(to.UnixNano()),
+		}
+		_, err := database.ScanRange(bkt, r, func(k, v []byte) (bool, error) {
+			var sample HashRateSample
+			err := json.Unmarshal(v, &sample)
+			if err != nil {
+				return false, err
+			}
+
+			samples = append(samples, &sample)
+			return true, nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return samples, nil
+}
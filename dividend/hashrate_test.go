@@ -0,0 +1,155 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHashRates(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	start := time.Now().Truncate(time.Hour * 24)
+	account := AccountSeries(xID)
+	for i, rate := range []float64{2, 4} {
+		now := start.Add(time.Minute * 5 * time.Duration(i))
+		err = RecordHashRates(db, now, map[string]float64{
+			PoolSeries: rate,
+			account:    rate / 2,
+		}, DefaultHashRatePolicy())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	samples, err := FetchHashRates(db, PoolSeries, Resolution5m,
+		start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 2 || samples[0].HashRate != 2 ||
+		samples[1].HashRate != 4 {
+		t.Fatalf("expected two 5m samples oldest first, got %v", len(samples))
+	}
+
+	samples, err = FetchHashRates(db, account, Resolution1h,
+		start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 1 || samples[0].HashRate != 1.5 ||
+		samples[0].Samples != 2 {
+		t.Fatalf("expected an averaged 1h sample, got %v", samples)
+	}
+
+	_, err = FetchHashRates(db, PoolSeries, "2m", start, start)
+	if err == nil {
+		t.Fatal("expected an invalid resolution error")
+	}
+
+	err = PruneHashRates(db, start.Add(time.Hour*24*3),
+		DefaultHashRatePolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err = FetchHashRates(db, PoolSeries, Resolution5m,
+		start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 0 {
+		t.Fatalf("expected expired 5m samples pruned, got %v", len(samples))
+	}
+
+	samples, err = FetchHashRates(db, PoolSeries, Resolution1d,
+		start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 1 || samples[0].HashRate != 3 {
+		t.Fatalf("expected the 1d sample kept, got %v", samples)
+	}
+
+	// Account samples follow the configured policy, the pool series keeps
+	// the default policy.
+	policy, err := ParseHashRatePolicy([]string{"5m:0", "1h:2h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worker := WorkerSeries("w1")
+	err = RecordHashRates(db, start, map[string]float64{
+		PoolSeries: 1,
+		worker:     1,
+	}, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = FetchHashRates(db, PoolSeries, Resolution5m, start,
+		start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err = FetchHashRates(db, worker, Resolution5m, start,
+		start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 0 {
+		t.Fatalf("expected no 5m worker samples, got %v", len(samples))
+	}
+
+	_, err = SeriesPolicy(worker, policy).Retention(Resolution5m)
+	if err == nil {
+		t.Fatal("expected a resolution not recorded error")
+	}
+
+	retention, err := SeriesPolicy(PoolSeries, policy).Retention(Resolution5m)
+	if err != nil || retention != time.Hour*48 {
+		t.Fatalf("expected the default pool retention, got %v", retention)
+	}
+
+	err = PruneHashRates(db, start.Add(time.Hour*3), policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err = FetchHashRates(db, worker, Resolution1h, start,
+		start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != 0 {
+		t.Fatalf("expected 1h worker samples pruned, got %v", len(samples))
+	}
+
+	for _, entries := range [][]string{{"5m"}, {"2m:1h"}, {"1h:-1h"},
+		{"1d:1h"}} {
+		_, err := ParseHashRatePolicy(entries)
+		if err == nil {
+			t.Fatalf("expected an invalid policy error for %v", entries)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"fmt"
+)
+
+// Notification alert types.
+const (
+	// AlertWorkerOffline is raised when a worker of an account stops
+	// submitting shares.
+	AlertWorkerOffline = "workeroffline"
+
+	// AlertPaymentSent is raised when a payment to an account is published.
+	AlertPaymentSent = "paymentsent"
+
+	// AlertBlockFound is raised when a block mined by an account is
+	// confirmed.
+	AlertBlockFound = "blockfound"
+)
+
+// Notification delivery channels.
+const (
+	// ChannelEmail delivers notifications to the verified email address of
+	// an account.
+	ChannelEmail = "email"
+)
+
+// Alerts lists all known notification alert types.
+var Alerts = []string{AlertWorkerOffline, AlertPaymentSent, AlertBlockFound}
+
+// Channels lists all known notification delivery channels.
+var Channels = []string{ChannelEmail}
+
+// ErrInvalidAlert is returned when an unknown alert type is provided.
+func ErrInvalidAlert(alert string) error {
+	return fmt.Errorf("invalid alert type '%v'", alert)
+}
+
+// ErrInvalidChannel is returned when an unknown delivery channel is
+// provided.
+func ErrInvalidChannel(channel string) error {
+	return fmt.Errorf("invalid delivery channel '%v'", channel)
+}
+
+// DefaultNotifications returns the notification preferences of accounts
+// which have not set any.
+func DefaultNotifications() map[string][]string {
+	return map[string][]string{
+		AlertWorkerOffline: {ChannelEmail},
+		AlertPaymentSent:   {ChannelEmail},
+		AlertBlockFound:    {},
+	}
+}
+
+// contains asserts the provided set contains the provided entry.
+func contains(set []string, entry string) bool {
+	for _, s := range set {
+		if s == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationPreferences returns the notification preferences of the
+// account, keyed by alert type.
+func (acc *Account) NotificationPreferences() map[string][]string {
+	prefs := DefaultNotifications()
+	for alert, channels := range acc.Notifications {
+		prefs[alert] = channels
+	}
+	return prefs
+}
+
+// SetNotificationPreferences replaces the delivery channels of the provided
+// alert types. Alert types not provided keep their current preferences.
+func (acc *Account) SetNotificationPreferences(prefs map[string][]string) error {
+	for alert, channels := range prefs {
+		if !contains(Alerts, alert) {
+			return ErrInvalidAlert(alert)
+		}
+
+		for _, channel := range channels {
+			if !contains(Channels, channel) {
+				return ErrInvalidChannel(channel)
+			}
+		}
+	}
+
+	if acc.Notifications == nil {
+		acc.Notifications = make(map[string][]string, len(prefs))
+	}
+
+	for alert, channels := range prefs {
+		if channels == nil {
+			channels = []string{}
+		}
+		acc.Notifications[alert] = channels
+	}
+
+	return nil
+}
+
+// WantsNotification asserts the account opted to receive the provided alert
+// type over the provided delivery channel.
+func (acc *Account) WantsNotification(alert string, channel string) bool {
+	return contains(acc.NotificationPreferences()[alert], channel)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/util"
+)
+
+// PageQuery selects a page of a listing. Listings are ordered by time, in
+// unix nanoseconds, unless documented otherwise.
+type PageQuery struct {
+	// From is the inclusive lower bound and To the exclusive upper bound of
+	// the listing, zero for no bound.
+	From int64
+	To   int64
+
+	// Cursor continues the listing from the page it was returned with.
+	Cursor string
+
+	// Limit is the maximum number of entries of the page, zero for no limit.
+	Limit int
+
+	// Ascending lists the oldest entries first, entries are listed newest
+	// first by default.
+	Ascending bool
+}
+
+// EncodeCursor returns the page cursor of the provided key.
+func EncodeCursor(key []byte) string {
+	if key == nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(key)
+}
+
+// TimeKey returns the key remainder of the provided unix nano time, as used
+// by time ordered listings.
+func TimeKey(nano int64) []byte {
+	return []byte(hex.EncodeToString(util.NanoToBigEndianBytes(nano)))
+}
+
+// Range returns the range of keys with the provided prefix selected by the
+// query. The provided encoder returns the key remainder of a bound.
+func (q *PageQuery) Range(prefix []byte, encode func(int64) []byte) (*database.Range, error) {
+	r := &database.Range{
+		Prefix:  prefix,
+		Limit:   q.Limit,
+		Reverse: !q.Ascending,
+	}
+
+	if q.From > 0 {
+		r.Min = encode(q.From)
+	}
+
+	if q.To > 0 {
+		r.Max = encode(q.To)
+	}
+
+	if q.Cursor != "" {
+		after, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+		if err != nil {
+			return nil, database.ErrInvalidCursor()
+		}
+		r.After = after
+	}
+
+	return r, nil
+}
@@ -30,6 +30,20 @@ type Payment struct {
 	Amount            dcrutil.Amount `json:"amount"`
 	CreatedOn         int64          `json:"createdon"`
 	PaidOnHeight      uint32         `json:"paidonheight"`
+
+	// FeeRate is the fee rate per kB of the payout transaction paying the
+	// payment.
+	FeeRate dcrutil.Amount `json:"feerate,omitempty"`
+
+	// Provisional payments are earnings of a found block yet to be approved
+	// by the votes of the next block, they are not paid out until approved
+	// and are voided if the block is disapproved.
+	Provisional bool `json:"provisional,omitempty"`
+
+	// Voided payments are provisional payments of a disapproved block, they
+	// are kept until the block is past the reorg limit so they can be
+	// restored if the disapproving block is disconnected.
+	Voided bool `json:"voided,omitempty"`
 }
 
 // NewPayment creates a payment instance.
@@ -138,9 +152,10 @@ func (bundle *PaymentBundle) Total() dcrutil.Amount {
 
 // UpdateAsPaid updates all associated payments referenced by a payment bundle
 // as paid.
-func (bundle *PaymentBundle) UpdateAsPaid(db *bolt.DB, height uint32) {
+func (bundle *PaymentBundle) UpdateAsPaid(db *bolt.DB, height uint32, feeRate dcrutil.Amount) {
 	for idx := 0; idx < len(bundle.Payments); idx++ {
 		bundle.Payments[idx].PaidOnHeight = height
+		bundle.Payments[idx].FeeRate = feeRate
 	}
 }
 
@@ -162,6 +177,11 @@ func (bundle *PaymentBundle) ArchivePayments(db *bolt.DB) error {
 			return database.ErrBucketNotFound(database.PaymentArchiveBkt)
 		}
 
+		ibkt := pbkt.Bucket(database.PaymentIdxBkt)
+		if ibkt == nil {
+			return database.ErrBucketNotFound(database.PaymentIdxBkt)
+		}
+
 		for _, pmt := range bundle.Payments {
 			id := GeneratePaymentID(pmt.CreatedOn, pmt.Height, pmt.Account)
 			err := pmtbkt.Delete(id)
@@ -180,6 +200,11 @@ func (bundle *PaymentBundle) ArchivePayments(db *bolt.DB) error {
 			if err != nil {
 				return err
 			}
+
+			err = ibkt.Put(database.PaymentIndexKey(pmt.Account, id), id)
+			if err != nil {
+				return err
+			}
 		}
 
 		return nil
@@ -248,10 +273,47 @@ func FilterPayments(db *bolt.DB, filter func(payment *Payment) bool) ([]*Payment
 	return payments, nil
 }
 
-// FetchPendingPayments fetches all unpaid payments.
+// FilterArchivedPayments iterates the payment archive bucket, the result set
+// is generated based on the provided filter.
+func FilterArchivedPayments(db *bolt.DB, filter func(payment *Payment) bool) ([]*Payment, error) {
+	payments := make([]*Payment, 0)
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		abkt := pbkt.Bucket(database.PaymentArchiveBkt)
+		if abkt == nil {
+			return database.ErrBucketNotFound(database.PaymentArchiveBkt)
+		}
+
+		cursor := abkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var payment Payment
+			err := json.Unmarshal(v, &payment)
+			if err != nil {
+				return err
+			}
+
+			if filter(&payment) {
+				payments = append(payments, &payment)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// FetchPendingPayments fetches all unpaid payments, voided payments
+// excluded.
 func FetchPendingPayments(db *bolt.DB) ([]*Payment, error) {
 	filter := func(payment *Payment) bool {
-		return payment.PaidOnHeight == 0
+		return payment.PaidOnHeight == 0 && !payment.Voided
 	}
 
 	payments, err := FilterPayments(db, filter)
@@ -263,11 +325,11 @@ func FetchPendingPayments(db *bolt.DB) ([]*Payment, error) {
 }
 
 // FetchMaturePendingPayments fetches all payments past their estimated
-// maturities which have not been paid yet.
+// maturities which have not been paid yet, provisional payments excluded.
 func FetchMaturePendingPayments(db *bolt.DB, height uint32) ([]*Payment, error) {
 	filter := func(payment *Payment) bool {
-		return payment.PaidOnHeight == 0 &&
-			payment.EstimatedMaturity <= height
+		return payment.PaidOnHeight == 0 && !payment.Provisional &&
+			!payment.Voided && payment.EstimatedMaturity <= height
 	}
 
 	payments, err := FilterPayments(db, filter)
@@ -279,10 +341,11 @@ func FetchMaturePendingPayments(db *bolt.DB, height uint32) ([]*Payment, error)
 }
 
 // FetchPendingPaymentsAtHeight fetches all pending payments at the provided
-// height.
+// height, voided payments excluded.
 func FetchPendingPaymentsAtHeight(db *bolt.DB, height uint32) ([]*Payment, error) {
 	filter := func(payment *Payment) bool {
-		return payment.PaidOnHeight == 0 && payment.Height == height
+		return payment.PaidOnHeight == 0 && !payment.Voided &&
+			payment.Height == height
 	}
 
 	payments, err := FilterPayments(db, filter)
@@ -293,8 +356,82 @@ func FetchPendingPaymentsAtHeight(db *bolt.DB, height uint32) ([]*Payment, error
 	return payments, nil
 }
 
+// SetProvisionalPayments marks the pending payments at the provided height
+// as provisional or credited, it returns the number of payments updated.
+func SetProvisionalPayments(db *bolt.DB, height uint32, provisional bool) (int, error) {
+	payments, err := FetchPendingPaymentsAtHeight(db, height)
+	if err != nil {
+		return 0, err
+	}
+
+	var updated int
+	for _, payment := range payments {
+		if payment.Provisional == provisional {
+			continue
+		}
+
+		payment.Provisional = provisional
+		err := payment.Update(db)
+		if err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// VoidProvisionalPayments marks the provisional payments at the provided
+// height as voided or restores them, it returns the number of payments
+// updated.
+func VoidProvisionalPayments(db *bolt.DB, height uint32, voided bool) (int, error) {
+	payments, err := FilterPayments(db, func(payment *Payment) bool {
+		return payment.PaidOnHeight == 0 && payment.Provisional &&
+			payment.Height == height
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var updated int
+	for _, payment := range payments {
+		if payment.Voided == voided {
+			continue
+		}
+
+		payment.Voided = voided
+		err := payment.Update(db)
+		if err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// PruneVoidedPayments deletes voided payments below the provided height.
+func PruneVoidedPayments(db *bolt.DB, height uint32) error {
+	payments, err := FilterPayments(db, func(payment *Payment) bool {
+		return payment.Voided && payment.Height < height
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, payment := range payments {
+		err := payment.Delete(db)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // FetchEligiblePaymentBundles fetches payment bundles greater than the
-// configured minimum payment.
+// configured minimum payment and the payout threshold of their account.
+// Bundles of suspended accounts are excluded.
 func FetchEligiblePaymentBundles(db *bolt.DB, height uint32, minPayment dcrutil.Amount) ([]*PaymentBundle, error) {
 	maturePayments, err := FetchMaturePendingPayments(db, height)
 	if err != nil {
@@ -308,7 +445,32 @@ func FetchEligiblePaymentBundles(db *bolt.DB, height uint32, minPayment dcrutil.
 		}
 	}
 
-	return bundles, nil
+	// Payments of suspended accounts are held until they are reinstated,
+	// payments below the payout threshold of their account are held until
+	// they reach it.
+	eligible := make([]*PaymentBundle, 0, len(bundles))
+	for _, bundle := range bundles {
+		if bundle.Account != PoolFeesK {
+			acc, err := FetchAccount(db, []byte(bundle.Account))
+			if err != nil {
+				return nil, err
+			}
+
+			if acc.Suspended() {
+				log.Infof("Holding payment of suspended account (%v)",
+					acc.UUID)
+				continue
+			}
+
+			if bundle.Total() < acc.PayoutThreshold {
+				continue
+			}
+		}
+
+		eligible = append(eligible, bundle)
+	}
+
+	return eligible, nil
 }
 
 // replenishTxFeeReserve adjusts the pool fee amount supplied to leave the
@@ -536,13 +698,14 @@ func GeneratePaymentDetails(db *bolt.DB, poolFeeAddrs []dcrutil.Address, eligibl
 		}
 
 		// For a dividend payment, fetch the corresponding account address.
+		// Pending address changes only apply once they take effect.
 		acc, err := FetchAccount(db, []byte(p.Account))
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to fetch account: %v", err)
 		}
 
 		bundleAmt := p.Total()
-		pmts[acc.Address] = bundleAmt
+		pmts[acc.PayoutAddress(time.Now())] = bundleAmt
 		targetAmt += bundleAmt
 	}
 
@@ -577,7 +740,7 @@ func FetchArchivedPaymentsForAccount(db *bolt.DB, account []byte, minNano []byte
 			accountE := k[16:]
 			minNanoE := k[:16]
 			minNanoB := make([]byte, hex.DecodedLen(len(minNanoE)))
-			hex.Decode(minNano, minNanoE)
+			hex.Decode(minNanoB, minNanoE)
 			if bytes.Equal(accountE, account) &&
 				bytes.Compare(minNanoB, minNano) > 0 {
 				var payment Payment
@@ -598,3 +761,52 @@ func FetchArchivedPaymentsForAccount(db *bolt.DB, account []byte, minNano []byte
 
 	return pmts, nil
 }
+
+// FetchAccountPayments returns the page of archived payments made to the
+// provided account selected by the query, and the cursor of the next page if
+// any. Payments are ordered by the time they were archived.
+func FetchAccountPayments(db *bolt.DB, account string, query *PageQuery) ([]*Payment, string, error) {
+	pmts := make([]*Payment, 0)
+	var next []byte
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		abkt := pbkt.Bucket(database.PaymentArchiveBkt)
+		if abkt == nil {
+			return database.ErrBucketNotFound(database.PaymentArchiveBkt)
+		}
+		ibkt := pbkt.Bucket(database.PaymentIdxBkt)
+		if ibkt == nil {
+			return database.ErrBucketNotFound(database.PaymentIdxBkt)
+		}
+
+		r, err := query.Range([]byte(account+"/"), TimeKey)
+		if err != nil {
+			return err
+		}
+
+		next, err = database.ScanRange(ibkt, r, func(k, v []byte) (bool, error) {
+			pBytes := abkt.Get(v)
+			if pBytes == nil {
+				return false, nil
+			}
+
+			var payment Payment
+			err := json.Unmarshal(pBytes, &payment)
+			if err != nil {
+				return false, err
+			}
+
+			pmts = append(pmts, &payment)
+			return true, nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return pmts, EncodeCursor(next), nil
+}
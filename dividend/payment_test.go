@@ -20,12 +20,13 @@ import (
 
 // createPersistedAccount creates a pool account with the provided parameters
 // and persists it to the database.
-func createPersistedAccount(db *bolt.DB, name string, address string) error {
+func createPersistedAccount(db *bolt.DB, id string, name string, address string) error {
 	account, err := NewAccount(name, address)
 	if err != nil {
 		return err
 	}
 
+	account.UUID = id
 	return account.Create(db)
 }
 
@@ -504,6 +505,71 @@ func TestPaymentsMaturity(t *testing.T) {
 	}
 }
 
+func TestProvisionalPayments(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	weight := new(big.Rat).SetFloat64(1.0)
+	height := uint32(30)
+	amt, err := dcrutil.NewAmount(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = createMultiplePersistedShares(db, xID, weight,
+		time.Now().UnixNano(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = PayPerShare(db, amt, 0.1, height, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := SetProvisionalPayments(db, height, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated != 2 {
+		t.Fatalf("expected 2 provisional payments, got %v", updated)
+	}
+
+	bundles, err := FetchEligiblePaymentBundles(db, height, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bundles) != 0 {
+		t.Fatalf("expected provisional payments to be held, got %v "+
+			"bundles", len(bundles))
+	}
+
+	_, err = SetProvisionalPayments(db, height, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundles, err = FetchEligiblePaymentBundles(db, height, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bundles) != 2 {
+		t.Fatalf("expected 2 credited bundles, got %v", len(bundles))
+	}
+}
+
 func TestArchivedPaymentsFiltering(t *testing.T) {
 	db, err := setupDB()
 	if err != nil {
@@ -523,7 +589,7 @@ func TestArchivedPaymentsFiltering(t *testing.T) {
 	amt, _ := dcrutil.NewAmount(5)
 
 	bx := CreatePaymentBundle(xID, count, amt)
-	bx.UpdateAsPaid(db, 10)
+	bx.UpdateAsPaid(db, 10, 0)
 	bx.ArchivePayments(db)
 
 	now := time.Now()
@@ -533,7 +599,7 @@ func TestArchivedPaymentsFiltering(t *testing.T) {
 	time.Sleep(time.Second * 10)
 
 	bx = CreatePaymentBundle(yID, count, amt)
-	bx.UpdateAsPaid(db, 10)
+	bx.UpdateAsPaid(db, 10, 0)
 	bx.ArchivePayments(db)
 
 	// Fetch archived payments for account x.
@@ -559,4 +625,118 @@ func TestArchivedPaymentsFiltering(t *testing.T) {
 		t.Logf("Expected %v archived payments for account x"+
 			" (per filter criteria), got %v", expectedPmts, len(pmts))
 	}
+
+	// Fetch indexed archived payments by page.
+	pmts, _, err = FetchAccountPayments(db, xID, &PageQuery{From: minNano})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pmts) != 0 {
+		t.Fatalf("expected no indexed payments for account x, got %v",
+			len(pmts))
+	}
+
+	pmts, next, err := FetchAccountPayments(db, yID,
+		&PageQuery{From: minNano, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pmts) != 1 || next == "" {
+		t.Fatalf("expected a page of 1 indexed payment for account y, got %v",
+			len(pmts))
+	}
+
+	pmts, next, err = FetchAccountPayments(db, yID,
+		&PageQuery{From: minNano, Limit: 1, Cursor: next})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pmts) != 1 || next != "" {
+		t.Fatalf("expected a last page of 1 indexed payment for account y, "+
+			"got %v", len(pmts))
+	}
+}
+
+func TestSuspendedAccountPayments(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Error(err)
+	}
+
+	td := func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	defer td()
+
+	weight := new(big.Rat).SetFloat64(1.0)
+	height := uint32(20)
+	amt, err := dcrutil.NewAmount(100.25)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = createMultiplePersistedShares(db, xID, weight,
+		time.Now().UnixNano(), 10)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = PayPerShare(db, amt, 0.1, height, 0)
+	if err != nil {
+		t.Error(err)
+	}
+
+	account, err := FetchAccount(db, []byte(xID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account.Suspend("op", "abuse", false)
+	err = account.Update(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the pool fee bundle is eligible while account X is suspended.
+	pmts, err := FetchEligiblePaymentBundles(db, height, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pmts) != 1 || pmts[0].Account != PoolFeesK {
+		t.Fatalf("Expected only the pool fee bundle, got %v bundles",
+			len(pmts))
+	}
+
+	err = account.Reinstate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = account.Update(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pmts, err = FetchEligiblePaymentBundles(db, height, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pmts) != 2 {
+		t.Fatalf("Expected 2 payment bundles, got %v", len(pmts))
+	}
+
+	account.Suspend("op", "fraud", true)
+	err = account.Reinstate()
+	if err == nil {
+		t.Fatal("Expected banned account reinstatement to fail")
+	}
 }
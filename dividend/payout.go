@@ -0,0 +1,179 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/database"
+)
+
+// PayoutConfirmations is the number of confirmations after which a payout
+// transaction is considered confirmed and is no longer tracked.
+const PayoutConfirmations = 6
+
+// Payout represents a transaction published by the pool paying mature
+// payments to accounts. Its confirmations are tracked as blocks connect
+// until it is confirmed. Payouts of high availability primaries are recorded
+// unpublished with their signed transaction, which is published once the
+// standby replicated the payout.
+type Payout struct {
+	TxHash        string         `json:"txhash"`
+	Height        uint32         `json:"height"`
+	Accounts      int            `json:"accounts"`
+	Amount        dcrutil.Amount `json:"amount"`
+	FeeRate       dcrutil.Amount `json:"feerate"`
+	Confirmations int64          `json:"confirmations"`
+	Unpublished   bool           `json:"unpublished,omitempty"`
+	RawTx         string         `json:"rawtx,omitempty"`
+	CreatedOn     int64          `json:"createdon"`
+}
+
+// NewPayout creates a payout of the provided transaction, published at the
+// provided height and fee rate per kB.
+func NewPayout(txHash string, height uint32, accounts int, amount dcrutil.Amount, feeRate dcrutil.Amount) *Payout {
+	return &Payout{
+		TxHash:    txHash,
+		Height:    height,
+		Accounts:  accounts,
+		Amount:    amount,
+		FeeRate:   feeRate,
+		CreatedOn: time.Now().UnixNano(),
+	}
+}
+
+// key returns the key of the payout, payouts are ordered by their creation
+// time.
+func (p *Payout) key() []byte {
+	return TimeKey(p.CreatedOn)
+}
+
+// Confirmed returns whether the payout transaction has reached the required
+// number of confirmations.
+func (p *Payout) Confirmed() bool {
+	return p.Confirmations >= PayoutConfirmations
+}
+
+// Create persists the payout to the database.
+func (p *Payout) Create(db *bolt.DB) error {
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.PayoutBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.PayoutBkt)
+		}
+		pBytes, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		return bkt.Put(p.key(), pBytes)
+	})
+	return err
+}
+
+// Update persists the updated payout to the database.
+func (p *Payout) Update(db *bolt.DB) error {
+	return p.Create(db)
+}
+
+// Delete removes the payout from the database.
+func (p *Payout) Delete(db *bolt.DB) error {
+	return database.Delete(db, database.PayoutBkt, p.key())
+}
+
+// ListPayouts returns the page of the payouts selected by the query, and the
+// cursor of the next page if any.
+func ListPayouts(db *bolt.DB, query *PageQuery) ([]*Payout, string, error) {
+	payouts := make([]*Payout, 0)
+	var next []byte
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.PayoutBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.PayoutBkt)
+		}
+
+		r, err := query.Range(nil, TimeKey)
+		if err != nil {
+			return err
+		}
+
+		next, err = database.ScanRange(bkt, r, func(k, v []byte) (bool, error) {
+			var payout Payout
+			err := json.Unmarshal(v, &payout)
+			if err != nil {
+				return false, err
+			}
+
+			payouts = append(payouts, &payout)
+			return true, nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return payouts, EncodeCursor(next), nil
+}
+
+// FetchUnconfirmedPayouts returns all payouts yet to be confirmed.
+func FetchUnconfirmedPayouts(db *bolt.DB) ([]*Payout, error) {
+	return FilterPayouts(db, func(payout *Payout) bool {
+		return !payout.Confirmed()
+	})
+}
+
+// FetchUnpublishedPayouts returns all payouts yet to be published, oldest
+// first.
+func FetchUnpublishedPayouts(db *bolt.DB) ([]*Payout, error) {
+	return FilterPayouts(db, func(payout *Payout) bool {
+		return payout.Unpublished
+	})
+}
+
+// FilterPayouts iterates the payouts bucket, the result set is generated
+// based on the provided filter.
+func FilterPayouts(db *bolt.DB, filter func(payout *Payout) bool) ([]*Payout, error) {
+	payouts := make([]*Payout, 0)
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.PayoutBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.PayoutBkt)
+		}
+
+		return bkt.ForEach(func(k, v []byte) error {
+			var payout Payout
+			err := json.Unmarshal(v, &payout)
+			if err != nil {
+				return err
+			}
+
+			if filter(&payout) {
+				payouts = append(payouts, &payout)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payouts, nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil"
+)
+
+func TestPayouts(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	hashes := []string{"a", "b", "c"}
+	for idx, hash := range hashes {
+		payout := NewPayout(hash, uint32(100+idx), 2, dcrutil.Amount(1e8),
+			dcrutil.Amount(1e4))
+		payout.CreatedOn = int64(idx + 1)
+		err = payout.Create(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	payouts, next, err := ListPayouts(db, &PageQuery{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payouts) != 2 || payouts[0].TxHash != "c" || next == "" {
+		t.Fatalf("expected the 2 most recent payouts and a cursor, got %v",
+			len(payouts))
+	}
+
+	payouts[0].Confirmations = PayoutConfirmations
+	err = payouts[0].Update(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unconfirmed, err := FetchUnconfirmedPayouts(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unconfirmed) != 2 {
+		t.Fatalf("expected 2 unconfirmed payouts, got %v", len(unconfirmed))
+	}
+
+	for _, payout := range unconfirmed {
+		if payout.TxHash == "c" {
+			t.Fatal("expected the confirmed payout to be excluded")
+		}
+	}
+}
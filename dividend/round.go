@@ -0,0 +1,223 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"math/big"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/database"
+)
+
+// updateRoundWork applies the provided modification to the work of the
+// current round within a single transaction.
+func updateRoundWork(db *bolt.DB, modify func(work *big.Int) *big.Int) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+
+		work := new(big.Int).SetBytes(pbkt.Get(database.RoundWork))
+		return pbkt.Put(database.RoundWork, modify(work).Bytes())
+	})
+}
+
+// AddRoundWork adds the difficulty of an accepted share to the work of the
+// current round.
+func AddRoundWork(db *bolt.DB, difficulty *big.Int) error {
+	return updateRoundWork(db, func(work *big.Int) *big.Int {
+		return work.Add(work, difficulty)
+	})
+}
+
+// FetchRoundWork returns the work of the current round.
+func FetchRoundWork(db *bolt.DB) (*big.Int, error) {
+	work := new(big.Int)
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+
+		work.SetBytes(pbkt.Get(database.RoundWork))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return work, nil
+}
+
+// EndRound returns the work of the current round and starts a new round. It
+// is called when the pool finds a block.
+func EndRound(db *bolt.DB) (*big.Int, error) {
+	var roundWork *big.Int
+	err := updateRoundWork(db, func(work *big.Int) *big.Int {
+		roundWork = work
+		return new(big.Int)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return roundWork, nil
+}
+
+// RoundEffort returns the provided round work relative to the work expected
+// to find a block at the provided compact network target, the network
+// difficulty. An effort of 1 means the block took exactly the expected
+// work, lower efforts are lucky.
+func RoundEffort(net *chaincfg.Params, work *big.Int, bits uint32) float64 {
+	target := blockchain.CompactToBig(bits)
+	if target.Sign() <= 0 {
+		return 0
+	}
+
+	// The network difficulty is pow_limit / target, so the effort is
+	// calculated as:
+	//
+	//    effort = work * target / pow_limit
+	effort := new(big.Rat).SetFrac(new(big.Int).Mul(work, target),
+		net.PowLimit)
+	f, _ := effort.Float64()
+	return f
+}
+
+// TimeToBlock returns the average time in seconds expected to find a block at
+// the provided compact network target with the provided hash rate, in hashes
+// per second. It is zero when the target or hash rate is unknown.
+func TimeToBlock(bits uint32, hashRate *big.Rat) float64 {
+	if bits == 0 || hashRate.Sign() <= 0 {
+		return 0
+	}
+
+	// The work expected to find a block is the number of hashes performed
+	// on average to find a hash below the target.
+	work := new(big.Rat).SetInt(blockchain.CalcWork(bits))
+	f, _ := work.Quo(work, hashRate).Float64()
+	return f
+}
+
+// NetworkDifficulty returns the network difficulty of the provided compact
+// network target, pow_limit / target. It is zero when the target is unknown.
+func NetworkDifficulty(net *chaincfg.Params, bits uint32) float64 {
+	target := blockchain.CompactToBig(bits)
+	if target.Sign() <= 0 {
+		return 0
+	}
+
+	diff, _ := new(big.Rat).SetFrac(net.PowLimit, target).Float64()
+	return diff
+}
+
+// NetworkHashRate returns the hash rate of the network, in hashes per second,
+// estimated from the work expected to find a block at the provided compact
+// network target every target block time.
+func NetworkHashRate(net *chaincfg.Params, bits uint32) *big.Rat {
+	if bits == 0 {
+		return new(big.Rat)
+	}
+
+	return new(big.Rat).SetFrac(blockchain.CalcWork(bits),
+		big.NewInt(int64(net.TargetTimePerBlock/time.Second)))
+}
+
+// BlockSubsidy is the split of the subsidy of a block between proof of work,
+// the votes of the block and the treasury.
+type BlockSubsidy struct {
+	Work     dcrutil.Amount `json:"work"`
+	Stake    dcrutil.Amount `json:"stake"`
+	Treasury dcrutil.Amount `json:"treasury"`
+	Total    dcrutil.Amount `json:"total"`
+}
+
+// subsidySplitScale is the scale of subsidy split proportions, splits are
+// in thousandths of the block subsidy.
+const subsidySplitScale = 1000
+
+// SubsidySplit is the proportions of the block subsidy paid to the miner,
+// the votes and the treasury, in thousandths of the subsidy.
+type SubsidySplit struct {
+	Work     int64 `json:"work"`
+	Stake    int64 `json:"stake"`
+	Treasury int64 `json:"treasury"`
+}
+
+// NewSubsidySplit returns the split of the provided subsidy amounts or
+// proportions.
+func NewSubsidySplit(work, stake, treasury int64) *SubsidySplit {
+	total := work + stake + treasury
+	scale := func(v int64) int64 {
+		return (v*subsidySplitScale + total/2) / total
+	}
+
+	return &SubsidySplit{
+		Work:     scale(work),
+		Stake:    scale(stake),
+		Treasury: scale(treasury),
+	}
+}
+
+// ParamsSubsidySplit returns the subsidy split defined by the provided chain
+// parameters.
+func ParamsSubsidySplit(net *chaincfg.Params) *SubsidySplit {
+	return NewSubsidySplit(int64(net.WorkRewardProportion),
+		int64(net.StakeRewardProportion), int64(net.BlockTaxProportion))
+}
+
+// CalcBlockSubsidy returns the subsidy of a block at the provided height
+// split per the provided proportions, assuming the block includes all of
+// its votes.
+func CalcBlockSubsidy(cache *blockchain.SubsidyCache, net *chaincfg.Params, split *SubsidySplit, height uint32) *BlockSubsidy {
+	h := int64(height)
+	full := cache.CalcBlockSubsidy(h)
+	total := split.Work + split.Stake + split.Treasury
+	subsidy := &BlockSubsidy{
+		Work:     dcrutil.Amount(full * split.Work / total),
+		Treasury: dcrutil.Amount(full * split.Treasury / total),
+	}
+
+	// Votes are only included from the stake validation height.
+	if h >= net.StakeValidationHeight {
+		perVote := full * split.Stake / total / int64(net.TicketsPerBlock)
+		subsidy.Stake = dcrutil.Amount(perVote * int64(net.TicketsPerBlock))
+	}
+
+	subsidy.Total = subsidy.Work + subsidy.Stake + subsidy.Treasury
+	return subsidy
+}
+
+// CalcWorkSubsidy returns the proof of work subsidy of a block at the
+// provided height including the provided number of votes, split per the
+// provided proportions. From the stake validation height the work subsidy
+// is reduced in proportion to the votes missing from the block.
+func CalcWorkSubsidy(cache *blockchain.SubsidyCache, net *chaincfg.Params, split *SubsidySplit, height uint32, voters uint16) dcrutil.Amount {
+	h := int64(height)
+	full := cache.CalcBlockSubsidy(h)
+	total := split.Work + split.Stake + split.Treasury
+	work := full * split.Work / total
+	if h >= net.StakeValidationHeight {
+		work = work * int64(voters) / int64(net.TicketsPerBlock)
+	}
+
+	return dcrutil.Amount(work)
+}
+
+// Luck returns the luck of the provided effort, the inverse of the effort.
+// Luck above 1 means blocks were found with less work than expected.
+func Luck(effort float64) float64 {
+	if effort <= 0 {
+		return 0
+	}
+
+	return 1 / effort
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/blockchain"
+	"github.com/decred/dcrd/chaincfg"
+)
+
+func TestRoundEffort(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	net := &chaincfg.MainNetParams
+	for _, diff := range []int64{100, 300} {
+		err = AddRoundWork(db, big.NewInt(diff))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	work, err := EndRound(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if work.Int64() != 400 {
+		t.Fatalf("expected round work of 400, got %v", work)
+	}
+
+	work, err = FetchRoundWork(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if work.Sign() != 0 {
+		t.Fatalf("expected a new round, got round work of %v", work)
+	}
+
+	// A network difficulty of 800 expects twice the work of the round.
+	target, err := DifficultyToTarget(net, big.NewInt(800))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	effort := RoundEffort(net, big.NewInt(400),
+		blockchain.BigToCompact(target))
+	if effort < 0.49 || effort > 0.51 {
+		t.Fatalf("expected an effort of 0.5, got %v", effort)
+	}
+
+	if luck := Luck(effort); luck < 1.9 || luck > 2.1 {
+		t.Fatalf("expected a luck of 2, got %v", luck)
+	}
+}
+
+func TestTimeToBlock(t *testing.T) {
+	// A target of 2^224 expects 2^32 hashes per block.
+	bits := blockchain.BigToCompact(new(big.Int).Lsh(big.NewInt(1), 224))
+	hashRate := new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 31))
+	ttb := TimeToBlock(bits, hashRate)
+	if ttb < 1.99 || ttb > 2.01 {
+		t.Fatalf("expected a time to block of 2 seconds, got %v", ttb)
+	}
+
+	if ttb := TimeToBlock(bits, new(big.Rat)); ttb != 0 {
+		t.Fatalf("expected no estimate without hash rate, got %v", ttb)
+	}
+}
+
+func TestNetworkStats(t *testing.T) {
+	net := &chaincfg.MainNetParams
+
+	// A target of 2^224 is a difficulty of 1 on mainnet and expects 2^32
+	// hashes per block.
+	bits := blockchain.BigToCompact(new(big.Int).Lsh(big.NewInt(1), 224))
+	if diff := NetworkDifficulty(net, bits); diff < 0.99 || diff > 1.01 {
+		t.Fatalf("expected a difficulty of 1, got %v", diff)
+	}
+
+	hashRate, _ := NetworkHashRate(net, bits).Float64()
+	want := float64(uint64(1)<<32) / net.TargetTimePerBlock.Seconds()
+	if hashRate < want*0.99 || hashRate > want*1.01 {
+		t.Fatalf("expected a network hash rate of %v, got %v", want, hashRate)
+	}
+
+	cache := blockchain.NewSubsidyCache(0, net)
+	height := uint32(net.StakeValidationHeight)
+	split := ParamsSubsidySplit(net)
+	subsidy := CalcBlockSubsidy(cache, net, split, height)
+	if subsidy.Work <= 0 || subsidy.Stake <= 0 || subsidy.Treasury <= 0 ||
+		subsidy.Total != subsidy.Work+subsidy.Stake+subsidy.Treasury {
+		t.Fatalf("unexpected subsidy %+v", subsidy)
+	}
+
+	// The split of the chain parameters pays the subsidy of dcrd.
+	work := blockchain.CalcBlockWorkSubsidy(cache, int64(height),
+		net.TicketsPerBlock, net)
+	tax := blockchain.CalcBlockTaxSubsidy(cache, int64(height),
+		net.TicketsPerBlock, net)
+	if int64(subsidy.Work) != work || int64(subsidy.Treasury) != tax {
+		t.Fatalf("expected work and treasury subsidies of %v and %v, got "+
+			"%v and %v", work, tax, subsidy.Work, subsidy.Treasury)
+	}
+
+	// Splits derived from subsidy amounts round to thousandths.
+	derived := NewSubsidySplit(int64(subsidy.Work), int64(subsidy.Stake),
+		int64(subsidy.Treasury))
+	if *derived != *split {
+		t.Fatalf("expected split %+v, got %+v", split, derived)
+	}
+
+	// The work subsidy follows the subsidy reductions of dcrd and is reduced
+	// by missing votes.
+	for _, h := range []int64{net.StakeValidationHeight,
+		net.SubsidyReductionInterval * 3, net.SubsidyReductionInterval*3 + 1} {
+		for _, voters := range []uint16{3, 4, 5} {
+			want := blockchain.CalcBlockWorkSubsidy(cache, h, voters, net)
+			got := CalcWorkSubsidy(cache, net, split, uint32(h), voters)
+			if int64(got) != want {
+				t.Fatalf("expected a work subsidy of %v at height %v with "+
+					"%v votes, got %v", want, h, voters, got)
+			}
+		}
+	}
+
+	// Blocks before the stake validation height have no votes.
+	subsidy = CalcBlockSubsidy(cache, net, split, height-1)
+	if subsidy.Stake != 0 {
+		t.Fatalf("expected no stake subsidy, got %v", subsidy.Stake)
+	}
+}
@@ -26,13 +26,13 @@ var (
 	// Account X address.
 	xAddr = "SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc"
 	// Account X id.
-	xID = *AccountID(accX, xAddr)
+	xID = "8e6f3b5a-1c2d-4e7f-9a0b-3c4d5e6f7a8b"
 	// Account Y.
 	accY = "y"
 	// Account Y address.
 	yAddr = "Ssp7J7TUmi5iPhoQnWYNGQbeGhu6V3otJcS"
 	// Account Y id.
-	yID = *AccountID(accY, yAddr)
+	yID = "2b4c6d8e-0f1a-4b3c-8d5e-7f9a0b1c2d3e"
 	// Pool fee address.
 	poolFeeAddrs, _ = dcrutil.DecodeAddress("SsnbEmxCVXskgTHXvf3rEa17NA39qQuGHwQ")
 )
@@ -56,12 +56,12 @@ func setupDB() (*bolt.DB, error) {
 		return nil, err
 	}
 
-	err = createPersistedAccount(db, accX, xAddr)
+	err = createPersistedAccount(db, xID, accX, xAddr)
 	if err != nil {
 		return nil, err
 	}
 
-	err = createPersistedAccount(db, accY, yAddr)
+	err = createPersistedAccount(db, yID, accY, yAddr)
 	if err != nil {
 		return nil, err
 	}
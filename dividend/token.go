@@ -0,0 +1,213 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+
+	"github.com/dnldd/dcrpool/database"
+)
+
+// Token purposes.
+const (
+	// EmailVerification tokens confirm ownership of an account's email
+	// address.
+	EmailVerification = "emailverification"
+
+	// Session tokens track account sessions, they serve as refresh tokens
+	// and are referenced by the signed access tokens issued for them.
+	Session = "session"
+
+	// AddressChange tokens confirm a payout address change requested by an
+	// account holder.
+	AddressChange = "addresschange"
+
+	// LoginChallenge tokens are signed with the key of an account's payout
+	// address to log in without a password.
+	LoginChallenge = "loginchallenge"
+
+	// RegistrationChallenge tokens are signed with the key of a payout
+	// address to register an account paying to it, their data is the
+	// `address.name` username of the account.
+	RegistrationChallenge = "registrationchallenge"
+)
+
+// ErrTokenExpired is returned when a token is used past its expiry.
+func ErrTokenExpired(id string) error {
+	return fmt.Errorf("token '%v' has expired", id)
+}
+
+// Token represents a token issued to an account. Verification tokens are
+// single-use while session tokens remain valid until they expire or are
+// revoked.
+type Token struct {
+	UUID      string `json:"uuid"`
+	Account   string `json:"account"`
+	Purpose   string `json:"purpose"`
+	Data      string `json:"data"`
+	ExpiresOn int64  `json:"expireson"`
+}
+
+// NewToken creates a random token for the provided account and purpose,
+// valid for the provided lifetime.
+func NewToken(account string, purpose string, data string, lifetime time.Duration) (*Token, error) {
+	id := make([]byte, 32)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Token{
+		UUID:      hex.EncodeToString(id),
+		Account:   account,
+		Purpose:   purpose,
+		Data:      data,
+		ExpiresOn: time.Now().Add(lifetime).Unix(),
+	}, nil
+}
+
+// Expired asserts the token is past its expiry.
+func (t *Token) Expired() bool {
+	return time.Now().Unix() > t.ExpiresOn
+}
+
+// FetchToken fetches the token referenced by the provided id.
+func FetchToken(db *bolt.DB, id []byte) (*Token, error) {
+	var token Token
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.TokenBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.TokenBkt)
+		}
+		v := bkt.Get(id)
+		if v == nil {
+			return database.ErrValueNotFound(id)
+		}
+		return json.Unmarshal(v, &token)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Create persists the token to the database.
+func (t *Token) Create(db *bolt.DB) error {
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.TokenBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.TokenBkt)
+		}
+		tBytes, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(t.UUID), tBytes)
+	})
+	return err
+}
+
+// Update is not supported for tokens.
+func (t *Token) Update(db *bolt.DB) error {
+	return ErrNotSupported("token", "update")
+}
+
+// Delete removes the token from the database.
+func (t *Token) Delete(db *bolt.DB) error {
+	return database.Delete(db, database.TokenBkt, []byte(t.UUID))
+}
+
+// DeleteAccountTokens removes all tokens of the provided purpose issued to
+// the provided account.
+func DeleteAccountTokens(db *bolt.DB, account string, purpose string) error {
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.TokenBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.TokenBkt)
+		}
+
+		toDelete := [][]byte{}
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var token Token
+			err := json.Unmarshal(v, &token)
+			if err != nil {
+				return err
+			}
+
+			if token.Account == account && token.Purpose == purpose {
+				toDelete = append(toDelete, k)
+			}
+		}
+
+		for _, entry := range toDelete {
+			err := bkt.Delete(entry)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	return err
+}
+
+// PruneTokens removes all expired tokens from the database.
+func PruneTokens(db *bolt.DB) error {
+	now := time.Now().Unix()
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.TokenBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.TokenBkt)
+		}
+
+		toDelete := [][]byte{}
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var token Token
+			err := json.Unmarshal(v, &token)
+			if err != nil {
+				return err
+			}
+
+			if now > token.ExpiresOn {
+				toDelete = append(toDelete, k)
+			}
+		}
+
+		for _, entry := range toDelete {
+			err := bkt.Delete(entry)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	return err
+}
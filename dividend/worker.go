@@ -0,0 +1,569 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+
+	"github.com/dnldd/dcrpool/database"
+)
+
+const (
+	// DefaultWorkerName is the name of the worker of clients which do not
+	// provide one when authorizing.
+	DefaultWorkerName = "default"
+
+	// recentWorkerActivity is the period a worker must have submitted shares
+	// within to be considered recently active.
+	recentWorkerActivity = time.Hour * 24
+
+	// maxWorkerHistory is the maximum number of difficulty changes and
+	// connections kept per worker, older entries are dropped.
+	maxWorkerHistory = 50
+)
+
+// ErrWorkerNameInUse is returned when an account already has a worker with
+// the provided name.
+func ErrWorkerNameInUse(name string) error {
+	return fmt.Errorf("worker name '%v' is already in use", name)
+}
+
+// DifficultyChange records the difficulty of a worker from the provided time.
+type DifficultyChange struct {
+	Time       int64    `json:"time"`
+	Difficulty *big.Int `json:"difficulty"`
+}
+
+// WorkerConnection records a client connection of a worker. The
+// disconnection time is zero while the client is connected, the region is
+// that of the stratum endpoint the client connected to.
+type WorkerConnection struct {
+	IP             string `json:"ip"`
+	UserAgent      string `json:"useragent"`
+	Region         string `json:"region,omitempty"`
+	ConnectedOn    int64  `json:"connectedon"`
+	DisconnectedOn int64  `json:"disconnectedon"`
+}
+
+// Worker represents a named mining client of an account. Workers are created
+// when a client first authorizes with a new worker name and are updated as
+// the client submits shares.
+type Worker struct {
+	UUID        string   `json:"uuid"`
+	Account     string   `json:"account"`
+	Name        string   `json:"name"`
+	CreatedOn   int64    `json:"createdon"`
+	LastShareOn int64    `json:"lastshareon"`
+	HashRate    *big.Rat `json:"hashrate"`
+	Difficulty  *big.Int `json:"difficulty"`
+	UserAgent   string   `json:"useragent"`
+
+	// Share counts of the worker. Stale shares reference jobs no longer
+	// known to the pool, invalid shares are malformed or above the
+	// worker's target.
+	AcceptedShares uint64 `json:"acceptedshares"`
+	StaleShares    uint64 `json:"staleshares"`
+	InvalidShares  uint64 `json:"invalidshares"`
+
+	// DifficultyHistory and Connections are ordered oldest first.
+	DifficultyHistory []*DifficultyChange `json:"difficultyhistory,omitempty"`
+	Connections       []*WorkerConnection `json:"connections,omitempty"`
+
+	// OfflineAlerted is set once the account has been alerted of the worker
+	// going offline and cleared when the worker recovers.
+	OfflineAlerted bool `json:"offlinealerted,omitempty"`
+}
+
+// NewWorker creates a worker with the provided name for the provided
+// account.
+func NewWorker(account string, name string) (*Worker, error) {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	worker := &Worker{
+		UUID:       hex.EncodeToString(id),
+		Account:    account,
+		Name:       name,
+		CreatedOn:  time.Now().Unix(),
+		HashRate:   new(big.Rat),
+		Difficulty: new(big.Int),
+	}
+
+	return worker, nil
+}
+
+// FetchWorker fetches the worker referenced by the provided id.
+func FetchWorker(db *bolt.DB, id []byte) (*Worker, error) {
+	var worker Worker
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.WorkerBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.WorkerBkt)
+		}
+		v := bkt.Get(id)
+		if v == nil {
+			return database.ErrValueNotFound(id)
+		}
+		return json.Unmarshal(v, &worker)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &worker, nil
+}
+
+// findWorker returns the worker of the provided account with the provided
+// name in the provided worker bucket, if any.
+func findWorker(bkt *bolt.Bucket, account string, name string) (*Worker, error) {
+	cursor := bkt.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		var worker Worker
+		err := json.Unmarshal(v, &worker)
+		if err != nil {
+			return nil, err
+		}
+
+		if worker.Account == account && worker.Name == name {
+			return &worker, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// FetchWorkerByName fetches the worker of the provided account with the
+// provided name.
+func FetchWorkerByName(db *bolt.DB, account string, name string) (*Worker, error) {
+	var worker *Worker
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.WorkerBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.WorkerBkt)
+		}
+
+		var err error
+		worker, err = findWorker(bkt, account, name)
+		if err != nil {
+			return err
+		}
+
+		if worker == nil {
+			return database.ErrValueNotFound([]byte(name))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return worker, nil
+}
+
+// ListWorkers returns all workers of the provided account.
+func ListWorkers(db *bolt.DB, account string) ([]*Worker, error) {
+	workers := make([]*Worker, 0)
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.WorkerBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.WorkerBkt)
+		}
+
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var worker Worker
+			err := json.Unmarshal(v, &worker)
+			if err != nil {
+				return err
+			}
+
+			if worker.Account == account {
+				workers = append(workers, &worker)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return workers, nil
+}
+
+// Create persists the worker to the database. Worker names are unique per
+// account.
+func (w *Worker) Create(db *bolt.DB) error {
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.WorkerBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.WorkerBkt)
+		}
+
+		existing, err := findWorker(bkt, w.Account, w.Name)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			return ErrWorkerNameInUse(w.Name)
+		}
+
+		wBytes, err := json.Marshal(w)
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(w.UUID), wBytes)
+	})
+	return err
+}
+
+// Update is not supported for workers, they are modified through Rename and
+// RecordWorkerShare.
+func (w *Worker) Update(db *bolt.DB) error {
+	return ErrNotSupported("worker", "update")
+}
+
+// Delete removes the worker from the database.
+func (w *Worker) Delete(db *bolt.DB) error {
+	return database.Delete(db, database.WorkerBkt, []byte(w.UUID))
+}
+
+// updateWorker applies the provided modification to the referenced worker
+// within a single transaction.
+func updateWorker(db *bolt.DB, id string, modify func(bkt *bolt.Bucket, worker *Worker) error) error {
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.WorkerBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.WorkerBkt)
+		}
+
+		v := bkt.Get([]byte(id))
+		if v == nil {
+			return database.ErrValueNotFound([]byte(id))
+		}
+
+		var worker Worker
+		err := json.Unmarshal(v, &worker)
+		if err != nil {
+			return err
+		}
+
+		err = modify(bkt, &worker)
+		if err != nil {
+			return err
+		}
+
+		wBytes, err := json.Marshal(worker)
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(id), wBytes)
+	})
+	return err
+}
+
+// Rename changes the name of the worker, worker names are unique per
+// account.
+func (w *Worker) Rename(db *bolt.DB, name string) error {
+	err := updateWorker(db, w.UUID, func(bkt *bolt.Bucket, worker *Worker) error {
+		existing, err := findWorker(bkt, worker.Account, name)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil && existing.UUID != worker.UUID {
+			return ErrWorkerNameInUse(name)
+		}
+
+		worker.Name = name
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Name = name
+	return nil
+}
+
+// ShareRatios are the fractions of the shares submitted by a worker which
+// were accepted, stale or invalid.
+type ShareRatios struct {
+	Accepted float64 `json:"accepted"`
+	Stale    float64 `json:"stale"`
+	Invalid  float64 `json:"invalid"`
+}
+
+// ShareRatios returns the share ratios of the worker, all zero until it
+// submits a share.
+func (w *Worker) ShareRatios() ShareRatios {
+	total := w.AcceptedShares + w.StaleShares + w.InvalidShares
+	if total == 0 {
+		return ShareRatios{}
+	}
+
+	return ShareRatios{
+		Accepted: float64(w.AcceptedShares) / float64(total),
+		Stale:    float64(w.StaleShares) / float64(total),
+		Invalid:  float64(w.InvalidShares) / float64(total),
+	}
+}
+
+// RecordWorkerShare updates the last share time, hash rate and difficulty of
+// the referenced worker following an accepted share. It returns true if the
+// worker recovered from being alerted as offline.
+func RecordWorkerShare(db *bolt.DB, id string, hashRate *big.Rat, difficulty *big.Int) (bool, error) {
+	var recovered bool
+	err := updateWorker(db, id, func(bkt *bolt.Bucket, worker *Worker) error {
+		now := time.Now().Unix()
+		recovered = worker.OfflineAlerted
+		worker.LastShareOn = now
+		worker.HashRate = hashRate
+		worker.Difficulty = difficulty
+		worker.AcceptedShares++
+		worker.OfflineAlerted = false
+
+		history := worker.DifficultyHistory
+		if len(history) == 0 ||
+			history[len(history)-1].Difficulty.Cmp(difficulty) != 0 {
+			history = append(history, &DifficultyChange{
+				Time:       now,
+				Difficulty: difficulty,
+			})
+			if len(history) > maxWorkerHistory {
+				history = history[len(history)-maxWorkerHistory:]
+			}
+			worker.DifficultyHistory = history
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return recovered, nil
+}
+
+// RecordWorkerRejectedShare counts a rejected share of the referenced worker
+// as either stale or invalid.
+func RecordWorkerRejectedShare(db *bolt.DB, id string, stale bool) error {
+	return updateWorker(db, id, func(bkt *bolt.Bucket, worker *Worker) error {
+		if stale {
+			worker.StaleShares++
+		} else {
+			worker.InvalidShares++
+		}
+		return nil
+	})
+}
+
+// Region returns the region of the stratum endpoint the worker last
+// connected to.
+func (w *Worker) Region() string {
+	if len(w.Connections) == 0 {
+		return ""
+	}
+
+	return w.Connections[len(w.Connections)-1].Region
+}
+
+// LastSeen returns the last time, in unix seconds, the worker was connected
+// or submitted a share. Connected workers are seen at the provided time.
+func (w *Worker) LastSeen(now int64, connected bool) int64 {
+	if connected {
+		return now
+	}
+
+	seen := w.LastShareOn
+	for _, conn := range w.Connections {
+		if conn.DisconnectedOn > seen {
+			seen = conn.DisconnectedOn
+		}
+		if conn.ConnectedOn > seen {
+			seen = conn.ConnectedOn
+		}
+	}
+
+	return seen
+}
+
+// Uptime returns the fraction of the provided window ending at the provided
+// time, in unix seconds, the worker had a connected client. Connections left
+// open by a pool restart are counted up to the last share of the worker
+// unless it is connected. Only the recorded connection history is
+// considered.
+func (w *Worker) Uptime(window time.Duration, now int64, connected bool) float64 {
+	start := now - int64(window.Seconds())
+	if start >= now {
+		return 0
+	}
+
+	type interval struct{ from, to int64 }
+	intervals := make([]interval, 0, len(w.Connections))
+	for _, conn := range w.Connections {
+		to := conn.DisconnectedOn
+		if to == 0 {
+			to = now
+			if !connected {
+				to = w.LastShareOn
+			}
+		}
+
+		from := conn.ConnectedOn
+		if from < start {
+			from = start
+		}
+		if to > now {
+			to = now
+		}
+		if to > from {
+			intervals = append(intervals, interval{from, to})
+		}
+	}
+
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].from < intervals[j].from
+	})
+
+	var up, end int64
+	for _, iv := range intervals {
+		if iv.from < end {
+			iv.from = end
+		}
+		if iv.to > iv.from {
+			up += iv.to - iv.from
+			end = iv.to
+		}
+	}
+
+	return float64(up) / float64(now-start)
+}
+
+// RecordWorkerConnect records a client connection of the referenced worker
+// from the provided ip and user agent through an endpoint of the provided
+// region.
+func RecordWorkerConnect(db *bolt.DB, id string, ip string, userAgent string, region string) error {
+	return updateWorker(db, id, func(bkt *bolt.Bucket, worker *Worker) error {
+		worker.UserAgent = userAgent
+		conns := append(worker.Connections, &WorkerConnection{
+			IP:          ip,
+			UserAgent:   userAgent,
+			Region:      region,
+			ConnectedOn: time.Now().Unix(),
+		})
+		if len(conns) > maxWorkerHistory {
+			conns = conns[len(conns)-maxWorkerHistory:]
+		}
+		worker.Connections = conns
+		return nil
+	})
+}
+
+// RecordWorkerDisconnect records the disconnection of the most recent
+// connection of the referenced worker from the provided ip.
+func RecordWorkerDisconnect(db *bolt.DB, id string, ip string) error {
+	return updateWorker(db, id, func(bkt *bolt.Bucket, worker *Worker) error {
+		for i := len(worker.Connections) - 1; i >= 0; i-- {
+			conn := worker.Connections[i]
+			if conn.IP == ip && conn.DisconnectedOn == 0 {
+				conn.DisconnectedOn = time.Now().Unix()
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// MarkOfflineWorkers flags recently active workers which have not submitted
+// shares within the provided period as alerted offline and returns them.
+// Workers already flagged are not returned again until they recover.
+func MarkOfflineWorkers(db *bolt.DB, period time.Duration) ([]*Worker, error) {
+	now := time.Now()
+	cutoff := now.Add(-period).Unix()
+	activeCutoff := now.Add(-(period + recentWorkerActivity)).Unix()
+	workers := make([]*Worker, 0)
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.WorkerBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.WorkerBkt)
+		}
+
+		cursor := bkt.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			var worker Worker
+			err := json.Unmarshal(v, &worker)
+			if err != nil {
+				return err
+			}
+
+			if worker.OfflineAlerted || worker.LastShareOn > cutoff ||
+				worker.LastShareOn < activeCutoff {
+				continue
+			}
+
+			workers = append(workers, &worker)
+		}
+
+		// Persist the flags after iterating since bolt cursors do not
+		// support modifying the bucket during iteration.
+		for _, worker := range workers {
+			worker.OfflineAlerted = true
+			wBytes, err := json.Marshal(worker)
+			if err != nil {
+				return err
+			}
+
+			err = bkt.Put([]byte(worker.UUID), wBytes)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return workers, nil
+}
@@ -0,0 +1,263 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dividend
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestWorker(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Error(err)
+	}
+
+	td := func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	defer td()
+
+	rig, err := NewWorker(xID, "rig")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rig.Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure worker names are unique per account.
+	dup, err := NewWorker(xID, "rig")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dup.Create(db)
+	if err == nil {
+		t.Error("expected worker name in use error")
+	}
+
+	other, err := NewWorker(yID, "rig")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = other.Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rig.Rename(db, "rig2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = RecordWorkerShare(db, rig.UUID, big.NewRat(3, 2), big.NewInt(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetched, err := FetchWorkerByName(db, xID, "rig2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fetched.UUID != rig.UUID {
+		t.Errorf("expected worker %v, got %v", rig.UUID, fetched.UUID)
+	}
+
+	if fetched.LastShareOn == 0 || fetched.Difficulty.Int64() != 8 {
+		t.Error("expected worker share details to be recorded")
+	}
+
+	workers, err := ListWorkers(db, xID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(workers) != 1 {
+		t.Errorf("expected 1 worker for account %v, got %v", xID,
+			len(workers))
+	}
+
+	// Ensure share counts and worker history are recorded.
+	err = RecordWorkerConnect(db, rig.UUID, "127.0.0.1", "cgminer/4.10.0",
+		"eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diff := range []int64{8, 16} {
+		_, err = RecordWorkerShare(db, rig.UUID, big.NewRat(3, 2),
+			big.NewInt(diff))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, stale := range []bool{true, false, false} {
+		err = RecordWorkerRejectedShare(db, rig.UUID, stale)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = RecordWorkerDisconnect(db, rig.UUID, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetched, err = FetchWorker(db, []byte(rig.UUID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fetched.AcceptedShares != 3 || fetched.StaleShares != 1 ||
+		fetched.InvalidShares != 2 {
+		t.Errorf("expected 3 accepted, 1 stale and 2 invalid shares, got "+
+			"%v, %v and %v", fetched.AcceptedShares, fetched.StaleShares,
+			fetched.InvalidShares)
+	}
+
+	if len(fetched.DifficultyHistory) != 2 ||
+		fetched.DifficultyHistory[1].Difficulty.Int64() != 16 {
+		t.Errorf("expected 2 difficulty changes, got %v",
+			len(fetched.DifficultyHistory))
+	}
+
+	if fetched.UserAgent != "cgminer/4.10.0" ||
+		len(fetched.Connections) != 1 ||
+		fetched.Connections[0].DisconnectedOn == 0 ||
+		fetched.Region() != "eu" {
+		t.Error("expected the worker connection to be recorded")
+	}
+}
+
+func TestMarkOfflineWorkers(t *testing.T) {
+	db, err := setupDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err = teardownDB(db)
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	rig, err := NewWorker(xID, "rig")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rig.Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Workers which never submitted shares are not alerted.
+	workers, err := MarkOfflineWorkers(db, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(workers) != 0 {
+		t.Fatalf("expected no offline workers, got %v", len(workers))
+	}
+
+	_, err = RecordWorkerShare(db, rig.UUID, big.NewRat(1, 1), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workers, err = MarkOfflineWorkers(db, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(workers) != 1 || workers[0].UUID != rig.UUID {
+		t.Fatalf("expected the worker to be offline, got %v workers",
+			len(workers))
+	}
+
+	// Workers are only alerted once until they recover.
+	workers, err = MarkOfflineWorkers(db, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(workers) != 0 {
+		t.Fatalf("expected no offline workers, got %v", len(workers))
+	}
+
+	recovered, err := RecordWorkerShare(db, rig.UUID, big.NewRat(1, 1),
+		big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !recovered {
+		t.Fatal("expected the worker to have recovered")
+	}
+}
+
+func TestWorkerShareRatios(t *testing.T) {
+	worker := &Worker{}
+	if ratios := worker.ShareRatios(); ratios != (ShareRatios{}) {
+		t.Fatalf("expected zero ratios without shares, got %+v", ratios)
+	}
+
+	worker.AcceptedShares = 6
+	worker.StaleShares = 3
+	worker.InvalidShares = 1
+	ratios := worker.ShareRatios()
+	if ratios.Accepted != 0.6 || ratios.Stale != 0.3 || ratios.Invalid != 0.1 {
+		t.Fatalf("unexpected share ratios %+v", ratios)
+	}
+}
+
+func TestWorkerUptime(t *testing.T) {
+	now := int64(1000000)
+	hour := int64(time.Hour.Seconds())
+	worker := &Worker{
+		LastShareOn: now - hour,
+		Connections: []*WorkerConnection{
+			// Outside of the window.
+			{ConnectedOn: now - 30*hour, DisconnectedOn: now - 26*hour},
+			// Overlapping connections from two clients.
+			{ConnectedOn: now - 12*hour, DisconnectedOn: now - 8*hour},
+			{ConnectedOn: now - 10*hour, DisconnectedOn: now - 6*hour},
+			// Left open by a restart.
+			{ConnectedOn: now - 3*hour},
+		},
+	}
+
+	uptime := worker.Uptime(time.Hour*24, now, false)
+	if uptime != 8.0/24 {
+		t.Fatalf("expected an uptime of 8h over 24h, got %v", uptime)
+	}
+
+	uptime = worker.Uptime(time.Hour*24, now, true)
+	if uptime != 9.0/24 {
+		t.Fatalf("expected an uptime of 9h over 24h, got %v", uptime)
+	}
+
+	if seen := worker.LastSeen(now, false); seen != now-hour {
+		t.Fatalf("expected the worker to be last seen at its last share, "+
+			"got %v", seen)
+	}
+
+	if seen := worker.LastSeen(now, true); seen != now {
+		t.Fatalf("expected a connected worker to be seen now, got %v", seen)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/dnldd/dcrpool/network"
+)
+
+// NewFrontend initializes a stateless stratum frontend of the configured
+// pool. Frontends serve miners and relay their work to the pool, which
+// keeps the database, dcrd, the wallet and payouts.
+func NewFrontend(cfg *config) (*Pool, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(cfg.frontendCerts) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(cfg.frontendCerts) {
+			return nil, fmt.Errorf("invalid pool certificate")
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	conn, err := grpc.Dial(cfg.Frontend,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)))
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(Pool)
+	p.cfg = cfg
+	p.limiter = network.NewRateLimiter(cfg.APIRate, cfg.APIBurst,
+		cfg.APIKeyRate, cfg.APIKeyBurst)
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	// The share creation target time and pool mode are those of the pool,
+	// adopted as the frontend registers.
+	hcfg := &network.HubConfig{
+		ActiveNet:      cfg.net,
+		MaxGenTime:     new(big.Int).SetUint64(cfg.MaxGenTime),
+		StratumListen:  cfg.StratumListen,
+		AccountMetrics: cfg.AccountMetrics,
+		BannedNets:     cfg.bannedNets,
+		AdminPass:      cfg.AdminPass,
+		Region:         cfg.StratumRegion,
+		StratumPorts:   cfg.stratumPorts,
+		MaxClients:     cfg.MaxClients,
+		MaxIPClients:   cfg.MaxIPClients,
+	}
+
+	p.hub, err = network.NewFrontendHub(p.ctx, p.cancel, conn,
+		cfg.FrontendName, cfg.FrontendSecret, hcfg, p.limiter)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	pLog.Infof("Stratum frontend of pool %v.", cfg.Frontend)
+
+	return p, nil
+}
@@ -0,0 +1,479 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package frontendrpc defines the internal gRPC service stateless stratum
+// frontends share the pool backend through. The messages and service
+// descriptions below mirror frontend.proto and are maintained by hand, keep
+// both in sync when changing the service.
+package frontendrpc
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// ServiceName is the full name of the frontend service.
+const ServiceName = "frontendrpc.FrontendService"
+
+// SecretMetadataKey is the request metadata key of the frontend secret.
+const SecretMetadataKey = "secret"
+
+type RegisterRequest struct {
+	Name   string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Region string `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`
+}
+
+func (m *RegisterRequest) Reset()         { *m = RegisterRequest{} }
+func (m *RegisterRequest) String() string { return proto.CompactTextString(m) }
+func (*RegisterRequest) ProtoMessage()    {}
+
+type RegisterResponse struct {
+	Id         uint32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Network    string `protobuf:"bytes,2,opt,name=network,proto3" json:"network,omitempty"`
+	SoloPool   bool   `protobuf:"varint,3,opt,name=solo_pool,json=soloPool,proto3" json:"solo_pool,omitempty"`
+	MaxGenTime uint64 `protobuf:"varint,4,opt,name=max_gen_time,json=maxGenTime,proto3" json:"max_gen_time,omitempty"`
+}
+
+func (m *RegisterResponse) Reset()         { *m = RegisterResponse{} }
+func (m *RegisterResponse) String() string { return proto.CompactTextString(m) }
+func (*RegisterResponse) ProtoMessage()    {}
+
+type WorkRequest struct {
+	Id uint32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (m *WorkRequest) Reset()         { *m = WorkRequest{} }
+func (m *WorkRequest) String() string { return proto.CompactTextString(m) }
+func (*WorkRequest) ProtoMessage()    {}
+
+type WorkUpdate struct {
+	JobId      string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Header     string `protobuf:"bytes,2,opt,name=header,proto3" json:"header,omitempty"`
+	Height     uint32 `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Syncing    bool   `protobuf:"varint,4,opt,name=syncing,proto3" json:"syncing,omitempty"`
+	MaxGenTime uint64 `protobuf:"varint,5,opt,name=max_gen_time,json=maxGenTime,proto3" json:"max_gen_time,omitempty"`
+}
+
+func (m *WorkUpdate) Reset()         { *m = WorkUpdate{} }
+func (m *WorkUpdate) String() string { return proto.CompactTextString(m) }
+func (*WorkUpdate) ProtoMessage()    {}
+
+type AuthorizeRequest struct {
+	Name      string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Address   string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Worker    string `protobuf:"bytes,3,opt,name=worker,proto3" json:"worker,omitempty"`
+	Ip        string `protobuf:"bytes,4,opt,name=ip,proto3" json:"ip,omitempty"`
+	UserAgent string `protobuf:"bytes,5,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	Region    string `protobuf:"bytes,6,opt,name=region,proto3" json:"region,omitempty"`
+}
+
+func (m *AuthorizeRequest) Reset()         { *m = AuthorizeRequest{} }
+func (m *AuthorizeRequest) String() string { return proto.CompactTextString(m) }
+func (*AuthorizeRequest) ProtoMessage()    {}
+
+type AuthorizeResponse struct {
+	Account      string `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Worker       string `protobuf:"bytes,2,opt,name=worker,proto3" json:"worker,omitempty"`
+	Difficulty   string `protobuf:"bytes,3,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	ErrorCode    uint32 `protobuf:"varint,4,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ErrorMessage string `protobuf:"bytes,5,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+}
+
+func (m *AuthorizeResponse) Reset()         { *m = AuthorizeResponse{} }
+func (m *AuthorizeResponse) String() string { return proto.CompactTextString(m) }
+func (*AuthorizeResponse) ProtoMessage()    {}
+
+type DisconnectRequest struct {
+	Worker string `protobuf:"bytes,1,opt,name=worker,proto3" json:"worker,omitempty"`
+	Ip     string `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+}
+
+func (m *DisconnectRequest) Reset()         { *m = DisconnectRequest{} }
+func (m *DisconnectRequest) String() string { return proto.CompactTextString(m) }
+func (*DisconnectRequest) ProtoMessage()    {}
+
+type DisconnectResponse struct{}
+
+func (m *DisconnectResponse) Reset()         { *m = DisconnectResponse{} }
+func (m *DisconnectResponse) String() string { return proto.CompactTextString(m) }
+func (*DisconnectResponse) ProtoMessage()    {}
+
+type BannedRequest struct {
+	Ip string `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+}
+
+func (m *BannedRequest) Reset()         { *m = BannedRequest{} }
+func (m *BannedRequest) String() string { return proto.CompactTextString(m) }
+func (*BannedRequest) ProtoMessage()    {}
+
+type BannedResponse struct {
+	Banned bool `protobuf:"varint,1,opt,name=banned,proto3" json:"banned,omitempty"`
+}
+
+func (m *BannedResponse) Reset()         { *m = BannedResponse{} }
+func (m *BannedResponse) String() string { return proto.CompactTextString(m) }
+func (*BannedResponse) ProtoMessage()    {}
+
+type FetchJobRequest struct {
+	JobId string `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+}
+
+func (m *FetchJobRequest) Reset()         { *m = FetchJobRequest{} }
+func (m *FetchJobRequest) String() string { return proto.CompactTextString(m) }
+func (*FetchJobRequest) ProtoMessage()    {}
+
+type FetchJobResponse struct {
+	Header string `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Height uint32 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (m *FetchJobResponse) Reset()         { *m = FetchJobResponse{} }
+func (m *FetchJobResponse) String() string { return proto.CompactTextString(m) }
+func (*FetchJobResponse) ProtoMessage()    {}
+
+type ShareRequest struct {
+	Account    string `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Worker     string `protobuf:"bytes,2,opt,name=worker,proto3" json:"worker,omitempty"`
+	Miner      string `protobuf:"bytes,3,opt,name=miner,proto3" json:"miner,omitempty"`
+	Status     uint32 `protobuf:"varint,4,opt,name=status,proto3" json:"status,omitempty"`
+	Hashrate   string `protobuf:"bytes,5,opt,name=hashrate,proto3" json:"hashrate,omitempty"`
+	Difficulty string `protobuf:"bytes,6,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	Height     uint32 `protobuf:"varint,7,opt,name=height,proto3" json:"height,omitempty"`
+	Block      bool   `protobuf:"varint,8,opt,name=block,proto3" json:"block,omitempty"`
+}
+
+func (m *ShareRequest) Reset()         { *m = ShareRequest{} }
+func (m *ShareRequest) String() string { return proto.CompactTextString(m) }
+func (*ShareRequest) ProtoMessage()    {}
+
+type ShareResponse struct{}
+
+func (m *ShareResponse) Reset()         { *m = ShareResponse{} }
+func (m *ShareResponse) String() string { return proto.CompactTextString(m) }
+func (*ShareResponse) ProtoMessage()    {}
+
+type SubmitBlockRequest struct {
+	Header  string `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Account string `protobuf:"bytes,2,opt,name=account,proto3" json:"account,omitempty"`
+	Miner   string `protobuf:"bytes,3,opt,name=miner,proto3" json:"miner,omitempty"`
+}
+
+func (m *SubmitBlockRequest) Reset()         { *m = SubmitBlockRequest{} }
+func (m *SubmitBlockRequest) String() string { return proto.CompactTextString(m) }
+func (*SubmitBlockRequest) ProtoMessage()    {}
+
+type SubmitBlockResponse struct {
+	Accepted  bool `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Duplicate bool `protobuf:"varint,2,opt,name=duplicate,proto3" json:"duplicate,omitempty"`
+}
+
+func (m *SubmitBlockResponse) Reset()         { *m = SubmitBlockResponse{} }
+func (m *SubmitBlockResponse) String() string { return proto.CompactTextString(m) }
+func (*SubmitBlockResponse) ProtoMessage()    {}
+
+type Client struct {
+	Id          string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Ip          string `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	Miner       string `protobuf:"bytes,3,opt,name=miner,proto3" json:"miner,omitempty"`
+	Account     string `protobuf:"bytes,4,opt,name=account,proto3" json:"account,omitempty"`
+	Worker      string `protobuf:"bytes,5,opt,name=worker,proto3" json:"worker,omitempty"`
+	UserAgent   string `protobuf:"bytes,6,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	ConnectedOn int64  `protobuf:"varint,7,opt,name=connected_on,json=connectedOn,proto3" json:"connected_on,omitempty"`
+	Difficulty  string `protobuf:"bytes,8,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	Hashrate    string `protobuf:"bytes,9,opt,name=hashrate,proto3" json:"hashrate,omitempty"`
+	Accepted    uint32 `protobuf:"varint,10,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected    uint32 `protobuf:"varint,11,opt,name=rejected,proto3" json:"rejected,omitempty"`
+}
+
+func (m *Client) Reset()         { *m = Client{} }
+func (m *Client) String() string { return proto.CompactTextString(m) }
+func (*Client) ProtoMessage()    {}
+
+type ReportRequest struct {
+	Id      uint32    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Clients []*Client `protobuf:"bytes,2,rep,name=clients,proto3" json:"clients,omitempty"`
+}
+
+func (m *ReportRequest) Reset()         { *m = ReportRequest{} }
+func (m *ReportRequest) String() string { return proto.CompactTextString(m) }
+func (*ReportRequest) ProtoMessage()    {}
+
+type ReportResponse struct {
+	Disconnect []string `protobuf:"bytes,1,rep,name=disconnect,proto3" json:"disconnect,omitempty"`
+}
+
+func (m *ReportResponse) Reset()         { *m = ReportResponse{} }
+func (m *ReportResponse) String() string { return proto.CompactTextString(m) }
+func (*ReportResponse) ProtoMessage()    {}
+
+// FrontendServiceServer is the server API of the frontend service.
+type FrontendServiceServer interface {
+	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	Work(*WorkRequest, FrontendService_WorkServer) error
+	Authorize(context.Context, *AuthorizeRequest) (*AuthorizeResponse, error)
+	Disconnect(context.Context, *DisconnectRequest) (*DisconnectResponse, error)
+	Banned(context.Context, *BannedRequest) (*BannedResponse, error)
+	FetchJob(context.Context, *FetchJobRequest) (*FetchJobResponse, error)
+	RecordShare(context.Context, *ShareRequest) (*ShareResponse, error)
+	SubmitBlock(context.Context, *SubmitBlockRequest) (*SubmitBlockResponse, error)
+	Report(context.Context, *ReportRequest) (*ReportResponse, error)
+}
+
+// FrontendService_WorkServer is the server stream of work updates.
+type FrontendService_WorkServer interface {
+	Send(*WorkUpdate) error
+	grpc.ServerStream
+}
+
+type frontendServiceWorkServer struct {
+	grpc.ServerStream
+}
+
+func (x *frontendServiceWorkServer) Send(m *WorkUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// RegisterFrontendServiceServer registers the provided frontend service
+// implementation with the provided grpc server.
+func RegisterFrontendServiceServer(s *grpc.Server, srv FrontendServiceServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+// unaryHandler returns the grpc method handler of a unary frontend call.
+func unaryHandler(method string, newReq func() interface{}, call func(FrontendServiceServer, context.Context, interface{}) (interface{}, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := newReq()
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(srv.(FrontendServiceServer), ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/" + ServiceName + "/" + method,
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(FrontendServiceServer), ctx, req)
+		}
+		return interceptor(ctx, req, info, handler)
+	}
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*FrontendServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler: unaryHandler("Register",
+				func() interface{} { return new(RegisterRequest) },
+				func(s FrontendServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.Register(ctx, req.(*RegisterRequest))
+				}),
+		},
+		{
+			MethodName: "Authorize",
+			Handler: unaryHandler("Authorize",
+				func() interface{} { return new(AuthorizeRequest) },
+				func(s FrontendServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.Authorize(ctx, req.(*AuthorizeRequest))
+				}),
+		},
+		{
+			MethodName: "Disconnect",
+			Handler: unaryHandler("Disconnect",
+				func() interface{} { return new(DisconnectRequest) },
+				func(s FrontendServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.Disconnect(ctx, req.(*DisconnectRequest))
+				}),
+		},
+		{
+			MethodName: "Banned",
+			Handler: unaryHandler("Banned",
+				func() interface{} { return new(BannedRequest) },
+				func(s FrontendServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.Banned(ctx, req.(*BannedRequest))
+				}),
+		},
+		{
+			MethodName: "FetchJob",
+			Handler: unaryHandler("FetchJob",
+				func() interface{} { return new(FetchJobRequest) },
+				func(s FrontendServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.FetchJob(ctx, req.(*FetchJobRequest))
+				}),
+		},
+		{
+			MethodName: "RecordShare",
+			Handler: unaryHandler("RecordShare",
+				func() interface{} { return new(ShareRequest) },
+				func(s FrontendServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.RecordShare(ctx, req.(*ShareRequest))
+				}),
+		},
+		{
+			MethodName: "SubmitBlock",
+			Handler: unaryHandler("SubmitBlock",
+				func() interface{} { return new(SubmitBlockRequest) },
+				func(s FrontendServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.SubmitBlock(ctx, req.(*SubmitBlockRequest))
+				}),
+		},
+		{
+			MethodName: "Report",
+			Handler: unaryHandler("Report",
+				func() interface{} { return new(ReportRequest) },
+				func(s FrontendServiceServer, ctx context.Context, req interface{}) (interface{}, error) {
+					return s.Report(ctx, req.(*ReportRequest))
+				}),
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Work",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				m := new(WorkRequest)
+				if err := stream.RecvMsg(m); err != nil {
+					return err
+				}
+				return srv.(FrontendServiceServer).Work(m,
+					&frontendServiceWorkServer{stream})
+			},
+			ServerStreams: true,
+		},
+	},
+	Metadata: "frontend.proto",
+}
+
+// FrontendServiceClient is the client API of the frontend service.
+type FrontendServiceClient interface {
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	Work(ctx context.Context, in *WorkRequest, opts ...grpc.CallOption) (FrontendService_WorkClient, error)
+	Authorize(ctx context.Context, in *AuthorizeRequest, opts ...grpc.CallOption) (*AuthorizeResponse, error)
+	Disconnect(ctx context.Context, in *DisconnectRequest, opts ...grpc.CallOption) (*DisconnectResponse, error)
+	Banned(ctx context.Context, in *BannedRequest, opts ...grpc.CallOption) (*BannedResponse, error)
+	FetchJob(ctx context.Context, in *FetchJobRequest, opts ...grpc.CallOption) (*FetchJobResponse, error)
+	RecordShare(ctx context.Context, in *ShareRequest, opts ...grpc.CallOption) (*ShareResponse, error)
+	SubmitBlock(ctx context.Context, in *SubmitBlockRequest, opts ...grpc.CallOption) (*SubmitBlockResponse, error)
+	Report(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (*ReportResponse, error)
+}
+
+type frontendServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewFrontendServiceClient creates a frontend service client using the
+// provided connection.
+func NewFrontendServiceClient(cc *grpc.ClientConn) FrontendServiceClient {
+	return &frontendServiceClient{cc}
+}
+
+func (c *frontendServiceClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/Register", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendServiceClient) Authorize(ctx context.Context, in *AuthorizeRequest, opts ...grpc.CallOption) (*AuthorizeResponse, error) {
+	out := new(AuthorizeResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/Authorize", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendServiceClient) Disconnect(ctx context.Context, in *DisconnectRequest, opts ...grpc.CallOption) (*DisconnectResponse, error) {
+	out := new(DisconnectResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/Disconnect", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendServiceClient) Banned(ctx context.Context, in *BannedRequest, opts ...grpc.CallOption) (*BannedResponse, error) {
+	out := new(BannedResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/Banned", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendServiceClient) FetchJob(ctx context.Context, in *FetchJobRequest, opts ...grpc.CallOption) (*FetchJobResponse, error) {
+	out := new(FetchJobResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/FetchJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendServiceClient) RecordShare(ctx context.Context, in *ShareRequest, opts ...grpc.CallOption) (*ShareResponse, error) {
+	out := new(ShareResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/RecordShare", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendServiceClient) SubmitBlock(ctx context.Context, in *SubmitBlockRequest, opts ...grpc.CallOption) (*SubmitBlockResponse, error) {
+	out := new(SubmitBlockResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/SubmitBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendServiceClient) Report(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (*ReportResponse, error) {
+	out := new(ReportResponse)
+	err := c.cc.Invoke(ctx, "/"+ServiceName+"/Report", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FrontendService_WorkClient is the client stream of work updates.
+type FrontendService_WorkClient interface {
+	Recv() (*WorkUpdate, error)
+	grpc.ClientStream
+}
+
+type frontendServiceWorkClient struct {
+	grpc.ClientStream
+}
+
+func (x *frontendServiceWorkClient) Recv() (*WorkUpdate, error) {
+	m := new(WorkUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *frontendServiceClient) Work(ctx context.Context, in *WorkRequest, opts ...grpc.CallOption) (FrontendService_WorkClient, error) {
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0],
+		"/"+ServiceName+"/Work", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &frontendServiceWorkClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
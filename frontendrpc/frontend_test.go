@@ -0,0 +1,67 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package frontendrpc
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func TestMessageEncoding(t *testing.T) {
+	report := &ReportRequest{
+		Id: 3,
+		Clients: []*Client{{
+			Id:          "03a1b2c3/cpu",
+			Ip:          "127.0.0.1:5550",
+			Miner:       "cpu",
+			Account:     "a1",
+			Worker:      "w1",
+			UserAgent:   "cpuminer/1.0.0",
+			ConnectedOn: 1540000000,
+			Difficulty:  "256",
+			Hashrate:    "0.000120000000",
+			Accepted:    12,
+			Rejected:    1,
+		}},
+	}
+
+	b, err := proto.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded ReportRequest
+	err = proto.Unmarshal(b, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !proto.Equal(report, &decoded) {
+		t.Fatalf("expected %v, got %v", report, &decoded)
+	}
+
+	update := &WorkUpdate{
+		JobId:      "0000002a0000016aa0b1c2d3",
+		Header:     "05000000",
+		Height:     42,
+		Syncing:    true,
+		MaxGenTime: 15,
+	}
+	b, err = proto.Marshal(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decodedUpdate WorkUpdate
+	err = proto.Unmarshal(b, &decodedUpdate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !proto.Equal(update, &decodedUpdate) {
+		t.Fatalf("expected %v, got %v", update, &decodedUpdate)
+	}
+}
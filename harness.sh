@@ -14,6 +14,7 @@ SOLO_POOL=0
 MAX_GEN_TIME=20
 PAYMENT_METHOD="pplns"
 LAST_N_PERIOD=300 # PPLNS range, 5 minutes.
+ADMIN_PASS="h@rness"
 
 if [ "${NETWORK}" = "simnet" ]; then
  POOL_MINING_ADDR="SspUvSyDGSzvPz2NfdZ5LW15uq6rmuGZyhL"
@@ -118,6 +119,8 @@ poolfeeaddrs=${PFEE_ADDR}
 paymentmethod=${PAYMENT_METHOD}
 lastnperiod=${LAST_N_PERIOD}
 backuppass=b@ckUp
+adminpass=${ADMIN_PASS}
+simnetharness=1
 EOF
 
 cat > "${NODES_ROOT}/dcrwctl.conf" <<EOF
@@ -271,6 +274,53 @@ fi
 # Setup dcrpool.
 ################################################################################
 sleep 4
+if [ "${NETWORK}" = "simnet" ]; then
+# The cycle script exercises the share, block and payout cycle of the pool
+# through the simnet harness routes. It waits for the miners to get a block
+# of the pool confirmed, generates blocks until its payments are paid out and
+# the payout confirms, and exits non-zero when a stage times out.
+cat > "${NODES_ROOT}/pool/cycle" <<EOF
+#!/bin/sh
+API="https://127.0.0.1:8080/admin/harness"
+TIMEOUT=\${TIMEOUT:-300}
+
+status() {
+  curl -sk -u "harness:${ADMIN_PASS}" "\${API}/status" |
+    grep -o "\"\$1\":[0-9]*" | cut -d: -f2
+}
+
+generate() {
+  curl -sk -u "harness:${ADMIN_PASS}" -d "{\"blocks\":\$1}" \\
+    "\${API}/generate" > /dev/null
+}
+
+# await polls the status field provided until it is non-zero, generating
+# the number of blocks provided between polls if any.
+await() {
+  for i in \$(seq \${TIMEOUT}); do
+    COUNT=\$(status \$1)
+    if [ -n "\${COUNT}" ] && [ "\${COUNT}" -gt 0 ]; then
+      echo "\$1: \${COUNT}"
+      return 0
+    fi
+    if [ -n "\$2" ]; then
+      generate \$2
+    fi
+    sleep 1
+  done
+  echo "timed out waiting for \$1"
+  exit 1
+}
+
+await sharesaccepted
+await blocksmined
+await payouts 1
+await confirmedpayouts 1
+echo "share, block and payout cycle complete"
+EOF
+chmod +x "${NODES_ROOT}/pool/cycle"
+fi
+
 tmux new-window -t $SESSION:4 -n 'pool'
 tmux send-keys "cd ${NODES_ROOT}/pool" C-m
 tmux send-keys "dcrpool --configfile pool.conf --homedir=${NODES_ROOT}/pool" C-m
@@ -6,25 +6,93 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/decred/slog"
-	"github.com/jrick/logrotate/rotator"
 
 	"github.com/dnldd/dcrpool/database"
 	"github.com/dnldd/dcrpool/dividend"
 	"github.com/dnldd/dcrpool/network"
+	"github.com/dnldd/dcrpool/util"
 )
 
+// logTimeFormat is the time format of log entry headers.
+const logTimeFormat = "2006-01-02 15:04:05.000"
+
+// jsonLogs formats log entries as json objects when set, it must be set
+// before the log rotator is initialized.
+var jsonLogs bool
+
+// logLevels maps the levels of log entry headers to their names.
+var logLevels = map[string]string{
+	"TRC": "trace",
+	"DBG": "debug",
+	"INF": "info",
+	"WRN": "warn",
+	"ERR": "error",
+	"CRT": "critical",
+}
+
+// jsonLogEntry is a log entry formatted as json.
+type jsonLogEntry struct {
+	Time      string `json:"time"`
+	Level     string `json:"level"`
+	Subsystem string `json:"subsystem"`
+	Message   string `json:"message"`
+}
+
+// formatJSONLog formats the provided log entry as a json object on a single
+// line. Entries with an unexpected header are logged as their message.
+func formatJSONLog(entry []byte) []byte {
+	line := strings.TrimSuffix(string(entry), "\n")
+	var msg jsonLogEntry
+	msg.Message = line
+
+	// Entries are formatted as "<time> [<level>] <subsystem>: <message>".
+	if len(line) > len(logTimeFormat)+7 && line[len(logTimeFormat)+1] == '[' {
+		t, err := time.ParseInLocation(logTimeFormat,
+			line[:len(logTimeFormat)], time.Local)
+		rest := line[len(logTimeFormat)+2:]
+		sep := strings.Index(rest, ": ")
+		if err == nil && len(rest) > 5 && rest[3] == ']' && sep > 5 {
+			msg = jsonLogEntry{
+				Time:      t.Format(time.RFC3339Nano),
+				Level:     logLevels[rest[:3]],
+				Subsystem: rest[5:sep],
+				Message:   rest[sep+2:],
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(msg)
+	return buf.Bytes()
+}
+
 // logWriter implements an io.Writer that outputs to both standard output and
 // the write-end pipe of an initialized log rotator.
 type logWriter struct{}
 
 func (logWriter) Write(p []byte) (n int, err error) {
-	os.Stdout.Write(p)
-	return logRotator.Write(p)
+	entry := p
+	if jsonLogs {
+		entry = formatJSONLog(p)
+	}
+
+	os.Stdout.Write(entry)
+	_, err = logRotator.Write(entry)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
 // Loggers per subsystem.  A single backend logger is created and all subsytem
@@ -43,12 +111,15 @@ var (
 
 	// logRotator is one of the logging outputs.  It should be closed on
 	// application shutdown.
-	logRotator *rotator.Rotator
-
-	pLog   = backendLog.Logger("MP")
-	divLog = backendLog.Logger("DIV")
-	netLog = backendLog.Logger("NET")
-	dbLog  = backendLog.Logger("DB")
+	logRotator *util.LogRotator
+
+	pLog    = backendLog.Logger("MP")
+	divLog  = backendLog.Logger("DIV")
+	netLog  = backendLog.Logger("NET")
+	dbLog   = backendLog.Logger("DB")
+	strmLog = backendLog.Logger("STRM")
+	chnLog  = backendLog.Logger("CHN")
+	pmtLog  = backendLog.Logger("PMT")
 )
 
 // Initialize package-global logger variables.
@@ -56,27 +127,33 @@ func init() {
 	database.UseLogger(dbLog)
 	dividend.UseLogger(divLog)
 	network.UseLogger(netLog)
+	network.UseStratumLogger(strmLog)
+	network.UseChainLogger(chnLog)
+	network.UsePaymentLogger(pmtLog)
 }
 
 // subsystemLoggers maps each subsystem identifier to its associated logger.
 var subsystemLoggers = map[string]slog.Logger{
-	"MP":  pLog,
-	"DIV": divLog,
-	"NET": netLog,
-	"DB":  dbLog,
+	"MP":   pLog,
+	"DIV":  divLog,
+	"NET":  netLog,
+	"DB":   dbLog,
+	"STRM": strmLog,
+	"CHN":  chnLog,
+	"PMT":  pmtLog,
 }
 
 // initLogRotator initializes the logging rotater to write logs to logFile and
-// create roll files in the same directory.  It must be called before the
-// package-global log rotater variables are used.
-func initLogRotator(logFile string) {
+// create roll files in the same directory per the provided rotation settings.
+// It must be called before the package-global log rotater variables are used.
+func initLogRotator(logFile string, rotation util.LogRotation) {
 	logDir, _ := filepath.Split(logFile)
 	err := os.MkdirAll(logDir, 0700)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create log directory: %v\n", err)
 		os.Exit(1)
 	}
-	r, err := rotator.New(logFile, 10*1024, false, 3)
+	r, err := util.NewLogRotator(logFile, rotation)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create file rotator: %v\n", err)
 		os.Exit(1)
@@ -10,10 +10,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	bolt "github.com/coreos/bbolt"
+	"github.com/decred/dcrd/dcrutil"
 
 	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
 	"github.com/dnldd/dcrpool/util"
 )
 
@@ -36,10 +39,27 @@ type AcceptedWork struct {
 	Height    uint32 `json:"height"`
 	MinedBy   string `json:"minedby"`
 	Miner     string `json:"miner"`
+	CreatedOn int64  `json:"createdon"`
+
+	// Reward is the coinbase of the block, set once the work is confirmed.
+	Reward dcrutil.Amount `json:"reward,omitempty"`
+
+	// Effort is the work of the round the block ended relative to the
+	// network difficulty of the block, it is set once the work is accepted
+	// by the network.
+	Effort float64 `json:"effort,omitempty"`
 
 	// An accepted work becomes mined work once it is confirmed by an incoming
 	// work as the parent block it was built on.
 	Confirmed bool `json:"confirmed"`
+
+	// Status is the stage of the work in the confirmation pipeline, one of
+	// pending, confirmed, disapproved or mature.
+	Status string `json:"status"`
+
+	// Confirmations is the number of confirmations of the confirmed work,
+	// tracked until its reward matures.
+	Confirmations uint32 `json:"confirmations"`
 }
 
 // AcceptedWorkID generates a unique id for the work accepted by the network.
@@ -59,6 +79,8 @@ func NewAcceptedWork(blockHash string, prevHash string, height uint32, minedBy s
 		Height:    height,
 		MinedBy:   minedBy,
 		Miner:     miner,
+		CreatedOn: time.Now().Unix(),
+		Status:    WorkPending,
 	}
 }
 
@@ -225,6 +247,60 @@ func ListMinedWorkByAccount(db *bolt.DB, accountID string) ([]*AcceptedWork, err
 	return minedWork, nil
 }
 
+// heightKey returns the work key remainder of the provided height, as used by
+// mined work listings.
+func heightKey(height int64) []byte {
+	return []byte(hex.EncodeToString(
+		util.HeightToBigEndianBytes(uint32(height))))
+}
+
+// ListMinedWorkPage returns the page of mined work selected by the query,
+// and the cursor of the next page if any. Work is ordered by height and
+// query bounds are heights. If an account id is provided only work mined by
+// the account is listed.
+func ListMinedWorkPage(db *bolt.DB, accountID string, query *dividend.PageQuery) ([]*AcceptedWork, string, error) {
+	minedWork := make([]*AcceptedWork, 0)
+	var next []byte
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+
+		bkt := pbkt.Bucket(database.WorkBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.WorkBkt)
+		}
+
+		r, err := query.Range(nil, heightKey)
+		if err != nil {
+			return err
+		}
+
+		next, err = database.ScanRange(bkt, r, func(k, v []byte) (bool, error) {
+			var work AcceptedWork
+			err := json.Unmarshal(v, &work)
+			if err != nil {
+				return false, err
+			}
+
+			if accountID == "" && !work.Confirmed ||
+				accountID != "" && work.MinedBy != accountID {
+				return false, nil
+			}
+
+			minedWork = append(minedWork, &work)
+			return true, nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return minedWork, dividend.EncodeCursor(next), nil
+}
+
 // FilterParentAcceptedWork locates the accepted work associated with the
 // previous block hash of the provided accepted work. It also removes all
 // invalidated accepted work at the same height.
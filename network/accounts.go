@@ -0,0 +1,1639 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+	"github.com/dnldd/dcrpool/util"
+)
+
+const (
+	// minPasswordLength is the minimum length of an account password.
+	minPasswordLength = 8
+
+	// emailVerificationLifetime is the duration an email verification token
+	// remains valid for.
+	emailVerificationLifetime = time.Hour * 24
+
+	// addressChangeLifetime is the duration an address change confirmation
+	// token remains valid for.
+	addressChangeLifetime = time.Hour
+
+	// loginChallengeLifetime is the duration login and registration
+	// challenges remain valid for.
+	loginChallengeLifetime = time.Minute * 5
+)
+
+// validateAccountName asserts the provided account name can be used as part
+// of a stratum username, which is of the form `address.name`.
+func validateAccountName(name string) error {
+	if name == "" {
+		return fmt.Errorf("account name cannot be empty")
+	}
+
+	if strings.ContainsAny(name, ". \t") {
+		return fmt.Errorf("account name cannot contain periods or spaces")
+	}
+
+	return nil
+}
+
+// validateAddress asserts the provided address is valid and associated with
+// the active network.
+func (h *Hub) validateAddress(address string) error {
+	addr, err := dcrutil.DecodeAddress(address)
+	if err != nil {
+		return fmt.Errorf("unable to decode address: %v", err)
+	}
+
+	if !addr.IsForNet(h.cfg.ActiveNet) {
+		return fmt.Errorf("address (%v) is not associated with the active "+
+			"network (%v)", address, h.cfg.ActiveNet.Name)
+	}
+
+	return nil
+}
+
+// tokenLink returns the link account holders visit to use the provided
+// token. Links are built from the configured public url rather than the
+// request host, which is set by the client.
+func (h *Hub) tokenLink(path string, token *dividend.Token) (string, error) {
+	if h.cfg.PublicURL == "" {
+		return "", fmt.Errorf("no public url set for account email links")
+	}
+
+	return fmt.Sprintf("%s%s?token=%s", h.cfg.PublicURL, path, token.UUID),
+		nil
+}
+
+// sendEmailVerification issues an email verification token for the provided
+// account and mails it to the account's email address.
+func (h *Hub) sendEmailVerification(account *dividend.Account) error {
+	token, err := dividend.NewToken(account.UUID, dividend.EmailVerification,
+		account.Email, emailVerificationLifetime)
+	if err != nil {
+		return err
+	}
+
+	err = token.Create(h.db)
+	if err != nil {
+		return err
+	}
+
+	link, err := h.tokenLink("/account/verify", token)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Confirm the email address of your %s mining account "+
+		"by visiting:\n\n%s\n\nThe link expires in %v.", account.Name, link,
+		emailVerificationLifetime)
+
+	return h.mailer.Send(account.Email, "Verify your email address", body)
+}
+
+// RegisterAccount handles self-service account registration. A new account
+// is created for the provided name and payout address, or an existing
+// anonymous account created by mining is claimed, and a verification email
+// is sent to the provided email address. Registrations answer a
+// registration challenge with a signature by the payout address key.
+func (h *Hub) RegisterAccount(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.SoloPool {
+		RespondWithError(w, http.StatusBadRequest,
+			"account registration is disabled in solo pool mode")
+		return
+	}
+
+	// Registered accounts are mailed a verification link, which requires
+	// the public url of the pool.
+	if h.cfg.PublicURL == "" {
+		RespondWithError(w, http.StatusServiceUnavailable,
+			"account registration requires a public url to be configured")
+		return
+	}
+
+	// Throttle registration attempts per ip address to prevent automated
+	// account creation.
+	ip := remoteIP(r)
+	if !h.limiter.WithinLimit(registrationKey(ip), RegistrationClient) {
+		RespondWithError(w, http.StatusTooManyRequests,
+			"too many registration attempts, try again later")
+		return
+	}
+
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	err = h.verifyCaptcha(params["captcha"], ip)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name := strings.TrimSpace(params["name"])
+	address := strings.TrimSpace(params["address"])
+	email := strings.TrimSpace(params["email"])
+	pass := params["pass"]
+
+	err = validateAccountName(name)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.validateAddress(address)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := mail.ParseAddress(email); err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"provided 'email' parameter is not a valid email address")
+		return
+	}
+
+	if len(pass) < minPasswordLength {
+		RespondWithError(w, http.StatusBadRequest,
+			fmt.Sprintf("provided 'pass' parameter must be at least %v "+
+				"characters long", minPasswordLength))
+		return
+	}
+
+	// Registrations prove control of the payout address, otherwise the
+	// public name and address of a miner could be registered by anyone
+	// before or after the miner starts mining.
+	status, err := h.verifyRegistrationSignature(name, address,
+		params["challenge"], params["signature"])
+	if err != nil {
+		RespondWithError(w, status, err.Error())
+		return
+	}
+
+	account, err := dividend.FetchAccountByName(h.db, name, address,
+		h.cfg.CaseInsensitive)
+	if err != nil {
+		if err.Error() != dividend.ErrAccountNameNotFound(name,
+			address).Error() {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		account, err = dividend.NewAccount(name, address)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		err = account.Create(h.db)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if account.IsRegistered() {
+		RespondWithError(w, http.StatusConflict, "account already registered")
+		return
+	}
+
+	err = account.Register(h.db, email, pass)
+	if err != nil {
+		if err.Error() == dividend.ErrEmailInUse(account.Email).Error() {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = h.sendEmailVerification(account)
+	if err != nil {
+		log.Errorf("Failed to send verification email for account (%v): %v",
+			account.UUID, err)
+		RespondWithError(w, http.StatusInternalServerError,
+			"failed to send verification email")
+		return
+	}
+
+	resp := map[string]interface{}{
+		"accountid": account.UUID,
+		"response":  "verification email sent",
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// VerifyEmail handles email verification requests for registered accounts.
+func (h *Hub) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	tokenID := r.URL.Query().Get("token")
+	if tokenID == "" {
+		RespondWithError(w, http.StatusBadRequest,
+			"provided 'token' parameter is empty")
+		return
+	}
+
+	token, err := dividend.FetchToken(h.db, []byte(tokenID))
+	if err != nil || token.Purpose != dividend.EmailVerification {
+		RespondWithError(w, http.StatusBadRequest, "invalid token")
+		return
+	}
+
+	if token.Expired() {
+		token.Delete(h.db)
+		RespondWithError(w, http.StatusBadRequest,
+			dividend.ErrTokenExpired(tokenID).Error())
+		return
+	}
+
+	account, err := dividend.FetchAccount(h.db, []byte(token.Account))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The token is only valid for the email address it was issued for.
+	if account.Email != token.Data {
+		token.Delete(h.db)
+		RespondWithError(w, http.StatusBadRequest, "invalid token")
+		return
+	}
+
+	account.EmailVerified = true
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = token.Delete(h.db)
+	if err != nil {
+		log.Errorf("Failed to delete used token: %v", err)
+	}
+
+	resp := map[string]interface{}{
+		"accountid": account.UUID,
+		"response":  "email verified",
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// Login handles account login requests, issuing a session token on success.
+// Accounts with two-factor authentication enabled must also provide a
+// one-time password or an unused backup code. Web logins requesting a
+// session cookie are issued a web session instead.
+func (h *Hub) Login(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	account, err := dividend.FetchAccountByEmail(h.db, params["email"])
+	if err != nil {
+		RespondWithError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	if !account.VerifyPassword(params["pass"]) {
+		h.recordActivity(r, account.UUID, dividend.ActivityLoginFailed,
+			"invalid password")
+		RespondWithError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	if !account.EmailVerified {
+		RespondWithError(w, http.StatusForbidden,
+			"email address not verified")
+		return
+	}
+
+	if account.TOTPEnabled {
+		otp := params["otp"]
+		if otp == "" {
+			RespondWithError(w, http.StatusUnauthorized,
+				"second factor required")
+			return
+		}
+
+		if !account.VerifySecondFactor(otp) {
+			h.recordActivity(r, account.UUID, dividend.ActivityLoginFailed,
+				"invalid second factor")
+			RespondWithError(w, http.StatusUnauthorized,
+				"invalid second factor")
+			return
+		}
+	}
+
+	h.startSession(w, r, account, "password", params["cookie"] == "true")
+}
+
+// startSession records a login by the provided method for the provided
+// account and responds with a new session, or a new web session if a
+// session cookie is requested.
+func (h *Hub) startSession(w http.ResponseWriter, r *http.Request, account *dividend.Account, method string, cookie bool) {
+	// Persisting the account also records consumed backup codes.
+	account.RecordLogin(remoteIP(r))
+	err := account.Update(h.db)
+	if err != nil {
+		if err.Error() == dividend.ErrOTPUsed(account.UUID).Error() {
+			RespondWithError(w, http.StatusUnauthorized,
+				"invalid second factor")
+			return
+		}
+
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordActivity(r, account.UUID, dividend.ActivityLogin,
+		fmt.Sprintf("logged in by %v", method))
+
+	if cookie {
+		h.issueWebSession(w, account.UUID)
+		return
+	}
+
+	h.issueSession(w, account.UUID)
+}
+
+// issueWebSession creates a session for the provided account, sets its
+// session cookie and responds with its csrf token. The session id is only
+// held by the cookie, web sessions are not refreshed.
+func (h *Hub) issueWebSession(w http.ResponseWriter, accountID string) {
+	session, err := dividend.NewToken(accountID, dividend.Session, "",
+		time.Duration(h.cfg.SessionLifetime)*time.Second)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = session.Create(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	setSessionCookie(w, session)
+
+	resp := map[string]interface{}{
+		"accountid": accountID,
+		"csrftoken": h.csrfToken(session.UUID),
+		"expireson": session.ExpiresOn,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// issueSession creates a session for the provided account and responds with
+// its refresh token and a signed access token. Access tokens are short-lived
+// and are renewed using the refresh token.
+func (h *Hub) issueSession(w http.ResponseWriter, accountID string) {
+	session, err := dividend.NewToken(accountID, dividend.Session, "",
+		time.Duration(h.cfg.SessionLifetime)*time.Second)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = session.Create(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	accessToken, expiresOn, err := h.issueAccessToken(session)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := map[string]interface{}{
+		"accountid":        accountID,
+		"token":            accessToken,
+		"expireson":        expiresOn,
+		"refreshtoken":     session.UUID,
+		"refreshexpireson": session.ExpiresOn,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// RefreshSession handles requests to renew a session using its refresh
+// token. Refresh tokens are single-use, the session is replaced by a new one
+// and access tokens of the previous session are revoked.
+func (h *Hub) RefreshSession(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	session, err := dividend.FetchToken(h.db, []byte(params["refreshtoken"]))
+	if err != nil || session.Purpose != dividend.Session {
+		RespondWithError(w, http.StatusUnauthorized, "invalid refresh token")
+		return
+	}
+
+	err = session.Delete(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if session.Expired() {
+		RespondWithError(w, http.StatusUnauthorized, "session expired")
+		return
+	}
+
+	h.issueSession(w, session.Account)
+}
+
+// challengeMessage returns the message to be signed to answer the provided
+// challenge. The message names the configured public url of the pool rather
+// than the requested host, which is set by the client.
+func (h *Hub) challengeMessage(token *dividend.Token) string {
+	return fmt.Sprintf("dcrpool login to %s: %s", h.cfg.PublicURL, token.UUID)
+}
+
+// signatureLoginEnabled asserts signature challenges can be issued and
+// answered, responding with an error if not. Challenge messages require the
+// public url of the pool.
+func (h *Hub) signatureLoginEnabled(w http.ResponseWriter) bool {
+	if h.cfg.PublicURL == "" {
+		RespondWithError(w, http.StatusServiceUnavailable,
+			"signature login requires a public url to be configured")
+		return false
+	}
+	return true
+}
+
+// LoginChallenge issues a login challenge for the account of the provided
+// name and payout address. The challenge message is to be signed with the
+// payout address key using dcrwallet's signmessage.
+func (h *Hub) LoginChallenge(w http.ResponseWriter, r *http.Request) {
+	if !h.signatureLoginEnabled(w) {
+		return
+	}
+
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	account, err := dividend.FetchAccountByName(h.db, params["name"],
+		params["address"], h.cfg.CaseInsensitive)
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound,
+			dividend.ErrAccountNotFound(params["name"]).Error())
+		return
+	}
+
+	token, err := dividend.NewToken(account.UUID, dividend.LoginChallenge,
+		account.Address, loginChallengeLifetime)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = token.Create(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := map[string]interface{}{
+		"challenge": token.UUID,
+		"message":   h.challengeMessage(token),
+		"address":   account.Address,
+		"expireson": token.ExpiresOn,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// RegistrationChallenge issues a registration challenge for the provided
+// account name and payout address, whether or not mining created the
+// account already. The challenge message is to be signed with the payout
+// address key using dcrwallet's signmessage.
+func (h *Hub) RegistrationChallenge(w http.ResponseWriter, r *http.Request) {
+	if !h.signatureLoginEnabled(w) {
+		return
+	}
+
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	name := strings.TrimSpace(params["name"])
+	address := strings.TrimSpace(params["address"])
+	err = validateAccountName(name)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.validateAddress(address)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, err := dividend.NewToken("", dividend.RegistrationChallenge,
+		fmt.Sprintf("%v.%v", address, name), loginChallengeLifetime)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = token.Create(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := map[string]interface{}{
+		"challenge": token.UUID,
+		"message":   h.challengeMessage(token),
+		"address":   address,
+		"expireson": token.ExpiresOn,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// consumeChallenge fetches and deletes the challenge of the provided id and
+// purpose, challenges are single-use regardless of the outcome. The status
+// code of the response is returned along with the error on failure.
+func (h *Hub) consumeChallenge(tokenID string, purpose string) (*dividend.Token, int, error) {
+	token, err := dividend.FetchToken(h.db, []byte(tokenID))
+	if err != nil || token.Purpose != purpose {
+		return nil, http.StatusUnauthorized, fmt.Errorf("invalid challenge")
+	}
+
+	err = token.Delete(h.db)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if token.Expired() {
+		return nil, http.StatusUnauthorized, dividend.ErrTokenExpired(tokenID)
+	}
+
+	return token, http.StatusOK, nil
+}
+
+// verifyRegistrationSignature asserts the provided signature answers the
+// registration challenge of the provided id issued for the provided account
+// name and payout address, proving control of the address. The status code
+// of the response is returned along with the error on failure.
+func (h *Hub) verifyRegistrationSignature(name string, address string, tokenID string, signature string) (int, error) {
+	token, status, err := h.consumeChallenge(tokenID,
+		dividend.RegistrationChallenge)
+	if err != nil {
+		return status, err
+	}
+
+	// The challenge is only valid for the name and address it was issued
+	// for.
+	if token.Data != fmt.Sprintf("%v.%v", address, name) {
+		return http.StatusUnauthorized, fmt.Errorf("invalid challenge")
+	}
+
+	valid, err := util.VerifyMessage(address, signature,
+		h.challengeMessage(token), h.cfg.ActiveNet)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if !valid {
+		return http.StatusUnauthorized, fmt.Errorf("invalid signature")
+	}
+
+	return http.StatusOK, nil
+}
+
+// SignatureLogin handles password-less login requests answering a login
+// challenge with a signature by the account's payout address key, issuing
+// a session token on success. Accounts with two-factor authentication
+// enabled must also provide a one-time password or an unused backup code.
+func (h *Hub) SignatureLogin(w http.ResponseWriter, r *http.Request) {
+	if !h.signatureLoginEnabled(w) {
+		return
+	}
+
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	token, status, err := h.consumeChallenge(params["challenge"],
+		dividend.LoginChallenge)
+	if err != nil {
+		RespondWithError(w, status, err.Error())
+		return
+	}
+
+	valid, err := util.VerifyMessage(token.Data, params["signature"],
+		h.challengeMessage(token), h.cfg.ActiveNet)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !valid {
+		h.recordActivity(r, token.Account, dividend.ActivityLoginFailed,
+			"invalid signature")
+		RespondWithError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	account, err := dividend.FetchAccount(h.db, []byte(token.Account))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The challenge is only valid for the address it was issued for.
+	if account.Address != token.Data {
+		RespondWithError(w, http.StatusUnauthorized, "invalid challenge")
+		return
+	}
+
+	if !account.VerifySecondFactor(params["otp"]) {
+		h.recordActivity(r, account.UUID, dividend.ActivityLoginFailed,
+			"invalid second factor")
+		RespondWithError(w, http.StatusUnauthorized, "invalid second factor")
+		return
+	}
+
+	h.startSession(w, r, account, "signature", params["cookie"] == "true")
+}
+
+// Logout handles requests to end the current account session, revoking its
+// refresh and access tokens or its session cookie.
+func (h *Hub) Logout(w http.ResponseWriter, r *http.Request) {
+	err := database.Delete(h.db, database.TokenBkt,
+		[]byte(requestSessionID(r)))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	clearSessionCookie(w)
+
+	h.recordActivity(r, requestAccountID(r), dividend.ActivityLogout,
+		"logged out")
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]string{"response": "logged out"})
+}
+
+// RevokeSessions handles requests to end all sessions of the authenticated
+// account, including the current one.
+func (h *Hub) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	err := dividend.DeleteAccountTokens(h.db, requestAccountID(r),
+		dividend.Session)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	clearSessionCookie(w)
+	h.recordActivity(r, requestAccountID(r), dividend.ActivityLogout,
+		"all sessions revoked")
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]string{"response": "sessions revoked"})
+}
+
+// remoteIP returns the ip address of the client of the provided request.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// difficultyResponse returns the preferred difficulty of the provided
+// account along with the pool difficulty limits.
+func (h *Hub) difficultyResponse(account *dividend.Account) map[string]interface{} {
+	min, max := h.difficultyLimits()
+	return map[string]interface{}{
+		"difficulty": account.Difficulty,
+		"min":        min,
+		"max":        max,
+	}
+}
+
+// FetchDifficultyPreference returns the preferred starting difficulty of
+// the authenticated account.
+func (h *Hub) FetchDifficultyPreference(w http.ResponseWriter, r *http.Request) {
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, h.difficultyResponse(account))
+}
+
+// UpdateDifficultyPreference sets the preferred starting difficulty of the
+// authenticated account, applied to its workers when they next connect. An
+// empty or zero difficulty clears the preference.
+func (h *Hub) UpdateDifficultyPreference(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	var difficulty *big.Int
+	if v := strings.TrimSpace(params["difficulty"]); v != "" && v != "0" {
+		diff, ok := new(big.Int).SetString(v, 10)
+		if !ok || diff.Sign() < 0 {
+			RespondWithError(w, http.StatusBadRequest,
+				"provided difficulty is not a positive integer")
+			return
+		}
+
+		min, max := h.difficultyLimits()
+		if diff.Cmp(min) < 0 || diff.Cmp(max) > 0 {
+			RespondWithError(w, http.StatusBadRequest,
+				fmt.Sprintf("difficulty must be between %v and %v", min, max))
+			return
+		}
+
+		difficulty = diff
+	}
+
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	account.Difficulty = difficulty
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	detail := "preferred difficulty cleared"
+	if difficulty != nil {
+		detail = fmt.Sprintf("preferred difficulty set to %v", difficulty)
+	}
+	h.recordActivity(r, account.UUID, dividend.ActivitySettingsChange, detail)
+
+	RespondWithJSON(w, http.StatusOK, h.difficultyResponse(account))
+}
+
+// FetchFinderPreference returns whether the authenticated account is named
+// as the finder of its blocks in public block listings.
+func (h *Hub) FetchFinderPreference(w http.ResponseWriter, r *http.Request) {
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]bool{"public": account.PublicFinder})
+}
+
+// UpdateFinderPreference sets whether the authenticated account is named as
+// the finder of its blocks in public block listings, blocks are credited to
+// an anonymized finder otherwise.
+func (h *Hub) UpdateFinderPreference(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	public, err := strconv.ParseBool(params["public"])
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"provided 'public' parameter is not a boolean")
+		return
+	}
+
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	account.PublicFinder = public
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	detail := "blocks found credited anonymously"
+	if public {
+		detail = "blocks found credited to the account name"
+	}
+	h.recordActivity(r, account.UUID, dividend.ActivitySettingsChange, detail)
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]bool{"public": account.PublicFinder})
+}
+
+// FetchLeaderboardPreference returns whether the authenticated account is
+// named on the hash rate leaderboard.
+func (h *Hub) FetchLeaderboardPreference(w http.ResponseWriter, r *http.Request) {
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]bool{"public": account.PublicLeaderboard})
+}
+
+// UpdateLeaderboardPreference sets whether the authenticated account is
+// named on the hash rate leaderboard, the account is listed anonymized
+// otherwise.
+func (h *Hub) UpdateLeaderboardPreference(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	public, err := strconv.ParseBool(params["public"])
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"provided 'public' parameter is not a boolean")
+		return
+	}
+
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	account.PublicLeaderboard = public
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	detail := "listed anonymously on the leaderboard"
+	if public {
+		detail = "listed by name on the leaderboard"
+	}
+	h.recordActivity(r, account.UUID, dividend.ActivitySettingsChange, detail)
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]bool{"public": account.PublicLeaderboard})
+}
+
+// RenameAccount handles requests to rename the authenticated account. The
+// account keeps its id, shares, payments and workers, miners authorize with
+// the new name afterwards.
+func (h *Hub) RenameAccount(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	name := strings.TrimSpace(params["name"])
+	err = validateAccountName(name)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	prevName := account.Name
+	err = account.Rename(h.db, name)
+	if err != nil {
+		if err.Error() == dividend.ErrNameInUse(name).Error() {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordActivity(r, account.UUID, dividend.ActivitySettingsChange,
+		fmt.Sprintf("account renamed from %v to %v", prevName, name))
+
+	resp := map[string]interface{}{
+		"accountid": account.UUID,
+		"name":      account.Name,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// requestAccount fetches the account authenticated for the provided request.
+func (h *Hub) requestAccount(r *http.Request) (*dividend.Account, error) {
+	return dividend.FetchAccount(h.db, []byte(requestAccountID(r)))
+}
+
+// SetupTOTP generates a new two-factor authentication secret for the
+// authenticated account. Two-factor authentication is not enforced until
+// enabled with a valid one-time password generated from the secret.
+func (h *Hub) SetupTOTP(w http.ResponseWriter, r *http.Request) {
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if account.TOTPEnabled {
+		RespondWithError(w, http.StatusConflict,
+			"two-factor authentication already enabled")
+		return
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	account.TOTPSecret = secret
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := map[string]interface{}{
+		"secret": secret,
+		"uri":    util.TOTPURI(secret, "dcrpool", account.Email),
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// EnableTOTP enables two-factor authentication for the authenticated
+// account once a valid one-time password is provided. A set of single-use
+// backup codes is returned, they are not retrievable afterwards.
+func (h *Hub) EnableTOTP(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if account.TOTPEnabled {
+		RespondWithError(w, http.StatusConflict,
+			"two-factor authentication already enabled")
+		return
+	}
+
+	if account.TOTPSecret == "" {
+		RespondWithError(w, http.StatusBadRequest,
+			"two-factor authentication has not been set up")
+		return
+	}
+
+	if !account.VerifyTOTP(params["otp"]) {
+		RespondWithError(w, http.StatusUnauthorized, "invalid second factor")
+		return
+	}
+
+	codes, err := account.GenerateBackupCodes()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	account.TOTPEnabled = true
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordActivity(r, account.UUID, dividend.ActivitySettingsChange,
+		"two-factor authentication enabled")
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]interface{}{"backupcodes": codes})
+}
+
+// DisableTOTP disables two-factor authentication for the authenticated
+// account, a valid one-time password or backup code is required.
+func (h *Hub) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !account.TOTPEnabled {
+		RespondWithError(w, http.StatusBadRequest,
+			"two-factor authentication is not enabled")
+		return
+	}
+
+	if !account.VerifySecondFactor(params["otp"]) {
+		RespondWithError(w, http.StatusUnauthorized, "invalid second factor")
+		return
+	}
+
+	account.ResetSecondFactor()
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordActivity(r, account.UUID, dividend.ActivitySettingsChange,
+		"two-factor authentication disabled")
+
+	RespondWithJSON(w, http.StatusOK, map[string]string{
+		"response": "two-factor authentication disabled"})
+}
+
+// ResetTOTP handles operator requests to reset two-factor authentication for
+// an account, for account holders who lost access to their second factor.
+func (h *Hub) ResetTOTP(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	id := params["accountid"]
+	account, err := dividend.FetchAccount(h.db, []byte(id))
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound,
+			dividend.ErrAccountNotFound(id).Error())
+		return
+	}
+
+	account.ResetSecondFactor()
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Infof("Two-factor authentication of account (%v) reset by "+
+		"operator (%v)", id, requestOperator(r))
+	h.recordActivity(r, id, dividend.ActivityOperator,
+		"two-factor authentication reset")
+
+	RespondWithJSON(w, http.StatusOK, map[string]string{
+		"response": "two-factor authentication reset"})
+}
+
+// RevokeAccountSessions handles operator requests to end all sessions of an
+// account, web sessions included.
+func (h *Hub) RevokeAccountSessions(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	id := params["accountid"]
+	_, err = dividend.FetchAccount(h.db, []byte(id))
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound,
+			dividend.ErrAccountNotFound(id).Error())
+		return
+	}
+
+	err = dividend.DeleteAccountTokens(h.db, id, dividend.Session)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Infof("Sessions of account (%v) revoked by operator (%v)", id,
+		requestOperator(r))
+	h.recordActivity(r, id, dividend.ActivityOperator,
+		"all sessions revoked")
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]string{"response": "sessions revoked"})
+}
+
+// CreateAPIKey issues a scoped api key for the authenticated account. The
+// full key is only returned once.
+func (h *Hub) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	if len(params.Scopes) == 0 {
+		RespondWithError(w, http.StatusBadRequest,
+			fmt.Sprintf("at least one scope is required, valid scopes are %v",
+				dividend.Scopes))
+		return
+	}
+
+	key, full, err := dividend.NewAPIKey(requestAccountID(r), params.Name,
+		params.Scopes)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = key.Create(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordActivity(r, key.Account, dividend.ActivityAPIKeyCreated,
+		fmt.Sprintf("api key %v (%v) created with scopes %v", key.Name,
+			key.UUID, key.Scopes))
+
+	resp := map[string]interface{}{
+		"id":     key.UUID,
+		"key":    full,
+		"scopes": key.Scopes,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// ListAPIKeys lists the api keys issued to the authenticated account.
+func (h *Hub) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := dividend.ListAPIKeys(h.db, requestAccountID(r))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, map[string]interface{}{
+			"id":        key.UUID,
+			"name":      key.Name,
+			"scopes":    key.Scopes,
+			"createdon": key.CreatedOn,
+		})
+	}
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]interface{}{"results": results})
+}
+
+// RevokeAPIKey revokes an api key issued to the authenticated account.
+func (h *Hub) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	key, err := dividend.FetchAPIKey(h.db, []byte(params["id"]))
+	if err != nil || key.Account != requestAccountID(r) {
+		RespondWithError(w, http.StatusNotFound, "api key not found")
+		return
+	}
+
+	err = key.Delete(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordActivity(r, key.Account, dividend.ActivityAPIKeyRevoked,
+		fmt.Sprintf("api key %v (%v) revoked", key.Name, key.UUID))
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]string{"response": "api key revoked"})
+}
+
+// FetchAccountMinedWork returns a page of the work mined by the authenticated
+// account, highest first by default. Bounds are block heights.
+func (h *Hub) FetchAccountMinedWork(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r, false)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id := requestAccountID(r)
+	work, next, err := ListMinedWorkPage(h.db, id, query)
+	if err != nil {
+		respondWithListError(w, err)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"accountid": id,
+		"results":   work,
+		"next":      next,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// FetchAccountPayments returns a page of the payments made to the
+// authenticated account, most recent first by default. The legacy `min`
+// parameter lists payments made after the provided unix time.
+func (h *Hub) FetchAccountPayments(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r, true)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if v := r.URL.Query().Get("min"); v != "" && query.From == 0 {
+		min, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest,
+				"provided 'min' parameter is not numeric")
+			return
+		}
+		query.From = time.Unix(min+1, 0).UnixNano()
+	}
+
+	id := requestAccountID(r)
+	payments, next, err := dividend.FetchAccountPayments(h.db, id, query)
+	if err != nil {
+		respondWithListError(w, err)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"accountid": id,
+		"results":   payments,
+		"next":      next,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// RequestAddressChange handles payout address change requests for the
+// authenticated account. The account password, and the second factor if
+// enabled, are required. The change is only scheduled once confirmed via
+// the link emailed to the account's email address.
+func (h *Hub) RequestAddressChange(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.PublicURL == "" {
+		RespondWithError(w, http.StatusServiceUnavailable,
+			"address changes require a public url to be configured")
+		return
+	}
+
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	address := strings.TrimSpace(params["address"])
+	err = h.validateAddress(address)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !account.VerifyPassword(params["pass"]) {
+		RespondWithError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	if !account.VerifySecondFactor(params["otp"]) {
+		RespondWithError(w, http.StatusUnauthorized, "invalid second factor")
+		return
+	}
+
+	// Persist the account since a backup code may have been consumed.
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if address == account.PayoutAddress(time.Now()) {
+		RespondWithError(w, http.StatusBadRequest,
+			"provided address is the current payout address")
+		return
+	}
+
+	token, err := dividend.NewToken(account.UUID, dividend.AddressChange,
+		address, addressChangeLifetime)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = token.Create(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	link, err := h.tokenLink("/account/address/confirm", token)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	delay := time.Duration(h.cfg.AddrChangeDelay) * time.Second
+	body := fmt.Sprintf("A payout address change to %s was requested for your "+
+		"%s mining account. Confirm the change by visiting:\n\n%s\n\n"+
+		"Payments continue to be made to your current address for %v after "+
+		"confirmation. The link expires in %v.", address, account.Name,
+		link, delay,
+		addressChangeLifetime)
+
+	err = h.mailer.Send(account.Email, "Confirm your payout address change",
+		body)
+	if err != nil {
+		log.Errorf("Failed to send address change email for account (%v): %v",
+			account.UUID, err)
+		RespondWithError(w, http.StatusInternalServerError,
+			"failed to send confirmation email")
+		return
+	}
+
+	h.recordActivity(r, account.UUID, dividend.ActivityAddressChange,
+		fmt.Sprintf("payout address change to %v requested", address))
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]string{"response": "confirmation email sent"})
+}
+
+// ConfirmAddressChange handles payout address change confirmations. The
+// change takes effect once the configured address change delay elapses,
+// payments are made to the current address until then.
+func (h *Hub) ConfirmAddressChange(w http.ResponseWriter, r *http.Request) {
+	tokenID := r.URL.Query().Get("token")
+	if tokenID == "" {
+		RespondWithError(w, http.StatusBadRequest,
+			"provided 'token' parameter is empty")
+		return
+	}
+
+	token, err := dividend.FetchToken(h.db, []byte(tokenID))
+	if err != nil || token.Purpose != dividend.AddressChange {
+		RespondWithError(w, http.StatusBadRequest, "invalid token")
+		return
+	}
+
+	if token.Expired() {
+		token.Delete(h.db)
+		RespondWithError(w, http.StatusBadRequest,
+			dividend.ErrTokenExpired(tokenID).Error())
+		return
+	}
+
+	account, err := dividend.FetchAccount(h.db, []byte(token.Account))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Apply a previously scheduled change which has already taken effect
+	// before scheduling the new one.
+	account.ApplyAddressChange(time.Now())
+
+	delay := time.Duration(h.cfg.AddrChangeDelay) * time.Second
+	account.ScheduleAddressChange(token.Data, delay)
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = token.Delete(h.db)
+	if err != nil {
+		log.Errorf("Failed to delete used token: %v", err)
+	}
+
+	h.recordActivity(r, account.UUID, dividend.ActivityAddressChange,
+		fmt.Sprintf("payout address change to %v confirmed, effective %v",
+			account.PendingAddress,
+			time.Unix(account.PendingAddressOn, 0).UTC()))
+
+	body := fmt.Sprintf("The payout address of your %s mining account will "+
+		"change to %s on %v. If you did not request this change, log in and "+
+		"cancel it before then.", account.Name, account.PendingAddress,
+		time.Unix(account.PendingAddressOn, 0).UTC())
+	err = h.mailer.Send(account.Email, "Payout address change scheduled", body)
+	if err != nil {
+		log.Errorf("Failed to send address change notice for account (%v): %v",
+			account.UUID, err)
+	}
+
+	resp := map[string]interface{}{
+		"accountid":        account.UUID,
+		"pendingaddress":   account.PendingAddress,
+		"pendingaddresson": account.PendingAddressOn,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// CancelAddressChange handles requests to cancel the scheduled payout address
+// change of the authenticated account.
+func (h *Hub) CancelAddressChange(w http.ResponseWriter, r *http.Request) {
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if account.ApplyAddressChange(time.Now()) {
+		// Persist the address change since it has already taken effect.
+		err = account.Update(h.db)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		RespondWithError(w, http.StatusBadRequest,
+			"address change has already taken effect")
+		return
+	}
+
+	if account.PendingAddress == "" {
+		RespondWithError(w, http.StatusBadRequest,
+			"no pending address change to cancel")
+		return
+	}
+
+	pending := account.PendingAddress
+	account.CancelAddressChange()
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordActivity(r, account.UUID, dividend.ActivityAddressChange,
+		fmt.Sprintf("payout address change to %v cancelled", pending))
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]string{"response": "address change cancelled"})
+}
+
+// ExportAccount returns all stored data associated with the authenticated
+// account.
+func (h *Hub) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	export, err := dividend.ExportAccount(h.db, requestAccountID(r))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Disposition",
+		`attachment; filename="account-export.json"`)
+	RespondWithJSON(w, http.StatusOK, export)
+}
+
+// DeleteAccount handles requests to delete the authenticated account. The
+// account password, and the second factor if enabled, are required. Personal
+// data is purged while accounting records are retained.
+func (h *Hub) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !account.VerifyPassword(params["pass"]) {
+		RespondWithError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	if !account.VerifySecondFactor(params["otp"]) {
+		RespondWithError(w, http.StatusUnauthorized, "invalid second factor")
+		return
+	}
+
+	err = account.Erase(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Infof("Personal data of account (%v) erased", account.UUID)
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]string{"response": "account deleted"})
+}
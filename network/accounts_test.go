@@ -0,0 +1,266 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrec/secp256k1"
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+	"github.com/dnldd/dcrpool/util"
+)
+
+func TestRegisterAccount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "register")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := database.OpenDB(filepath.Join(dir, "test.kv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = database.CreateBuckets(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	net := &chaincfg.SimNetParams
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pubKey := (*secp256k1.PublicKey)(&key.PublicKey)
+	addr, err := dcrutil.NewAddressSecpPubKey(pubKey.SerializeCompressed(),
+		net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := addr.EncodeAddress()
+
+	// Create the account as mining does.
+	account, err := dividend.NewAccount("x", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = account.Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Hub{
+		db: db,
+		cfg: &HubConfig{
+			ActiveNet: net,
+			PublicURL: "https://pool.example",
+		},
+		limiter: NewRateLimiter(5, 5, 5, 5),
+		mailer:  NewMailer("", "", "", ""),
+	}
+
+	// Requests are made from distinct addresses to stay within the
+	// registration limit.
+	var reqs int
+	post := func(handler http.HandlerFunc, params map[string]string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reqs++
+		req := httptest.NewRequest(http.MethodPost, "/",
+			strings.NewReader(string(body)))
+		req.RemoteAddr = fmt.Sprintf("192.0.2.%d:1234", reqs)
+		req.Host = "phish.example"
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	challenge := func(name string) (string, string) {
+		rec := post(h.RegistrationChallenge, map[string]string{
+			"name":    name,
+			"address": address,
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected a challenge, got %v: %v", rec.Code,
+				rec.Body.String())
+		}
+
+		var resp map[string]interface{}
+		err := json.Unmarshal(rec.Body.Bytes(), &resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Ensure the message names the public url, not the requested host.
+		id, message := resp["challenge"].(string), resp["message"].(string)
+		if message != "dcrpool login to https://pool.example: "+id {
+			t.Fatalf("unexpected challenge message %v", message)
+		}
+		return id, message
+	}
+
+	sign := func(key *secp256k1.PrivateKey, message string) string {
+		sig, err := secp256k1.SignCompact(key,
+			util.SignedMessageHash(message), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	params := map[string]string{
+		"name":    "x",
+		"address": address,
+		"email":   "x@pool.example",
+		"pass":    "correct horse",
+	}
+
+	// Ensure claiming the account without a signature is rejected.
+	rec := post(h.RegisterAccount, params)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected claiming without a signature to be "+
+			"unauthorized, got %v", rec.Code)
+	}
+
+	// Ensure claiming the account with a signature by another key is
+	// rejected.
+	other, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, message := challenge("x")
+	params["challenge"] = id
+	params["signature"] = sign(other, message)
+	rec = post(h.RegisterAccount, params)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected claiming with another key to be "+
+			"unauthorized, got %v", rec.Code)
+	}
+
+	account, err = dividend.FetchAccount(db, []byte(account.UUID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.IsRegistered() {
+		t.Fatal("expected the account to remain unregistered")
+	}
+
+	// Ensure a challenge is single-use.
+	params["signature"] = sign(key, message)
+	rec = post(h.RegisterAccount, params)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a reused challenge to be unauthorized, got %v",
+			rec.Code)
+	}
+
+	id, message = challenge("x")
+	params["challenge"] = id
+	params["signature"] = sign(key, message)
+	rec = post(h.RegisterAccount, params)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the account to be claimed, got %v: %v",
+			rec.Code, rec.Body.String())
+	}
+
+	// Ensure registering a new account without a signature is rejected
+	// and creates no account.
+	params = map[string]string{
+		"name":    "y",
+		"address": address,
+		"email":   "y@pool.example",
+		"pass":    "correct horse",
+	}
+	rec = post(h.RegisterAccount, params)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected registering without a signature to be "+
+			"unauthorized, got %v", rec.Code)
+	}
+
+	_, err = dividend.FetchAccountByName(db, "y", address, false)
+	if err == nil {
+		t.Fatal("expected no account to be created without a signature")
+	}
+
+	// Ensure challenges are only valid for the name they were issued for.
+	id, message = challenge("x")
+	params["challenge"] = id
+	params["signature"] = sign(key, message)
+	rec = post(h.RegisterAccount, params)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a challenge of another name to be "+
+			"unauthorized, got %v", rec.Code)
+	}
+
+	id, message = challenge("y")
+	params["challenge"] = id
+	params["signature"] = sign(key, message)
+	rec = post(h.RegisterAccount, params)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the account to be registered, got %v: %v",
+			rec.Code, rec.Body.String())
+	}
+}
+
+func TestTokenLink(t *testing.T) {
+	h := &Hub{cfg: &HubConfig{}}
+	token := &dividend.Token{UUID: "x"}
+	_, err := h.tokenLink("/account/verify", token)
+	if err == nil {
+		t.Fatal("expected links to require a public url")
+	}
+
+	h.cfg.PublicURL = "https://pool.example"
+	link, err := h.tokenLink("/account/verify", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if link != "https://pool.example/account/verify?token=x" {
+		t.Fatalf("unexpected link %v", link)
+	}
+}
+
+func TestSignatureLoginPublicURL(t *testing.T) {
+	h := &Hub{cfg: &HubConfig{}}
+	handlers := []http.HandlerFunc{
+		h.LoginChallenge,
+		h.RegistrationChallenge,
+		h.SignatureLogin,
+	}
+
+	// Ensure signature challenges are refused without a public url.
+	for _, handler := range handlers {
+		req := httptest.NewRequest(http.MethodPost, "/",
+			strings.NewReader("{}"))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected signature login to be unavailable, got %v",
+				rec.Code)
+		}
+	}
+}
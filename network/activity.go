@@ -0,0 +1,49 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// recordActivity appends an entry to the activity log of the provided
+// account for the provided request. Requests made by operators record the
+// operator as the actor. Failures are logged.
+func (h *Hub) recordActivity(r *http.Request, accountID string, activityType string, detail string) {
+	entry := dividend.NewActivity(accountID, activityType, detail,
+		remoteIP(r), requestOperator(r))
+	err := entry.Create(h.db)
+	if err != nil {
+		log.Errorf("Failed to record %v activity of account (%v): %v",
+			activityType, accountID, err)
+	}
+}
+
+// FetchAccountActivity returns a page of the activity log entries of the
+// authenticated account, newest first by default.
+func (h *Hub) FetchAccountActivity(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r, true)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id := requestAccountID(r)
+	entries, next, err := dividend.ListActivity(h.db, id, query)
+	if err != nil {
+		respondWithListError(w, err)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"accountid": id,
+		"results":   entries,
+		"next":      next,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,569 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// FetchAccountDetails handles operator requests for the details of an
+// account, including operator labels and notes.
+func (h *Hub) FetchAccountDetails(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	account, err := dividend.FetchAccount(h.db, []byte(id))
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound,
+			dividend.ErrAccountNotFound(id).Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, account.Redacted())
+}
+
+// ListAccounts handles operator requests to list accounts, optionally
+// filtered by the provided label.
+func (h *Hub) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	label := r.URL.Query().Get("label")
+	accounts, err := dividend.FilterAccounts(h.db,
+		func(account *dividend.Account) bool {
+			return label == "" || account.HasLabel(label)
+		})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := make([]*dividend.Account, 0, len(accounts))
+	for _, account := range accounts {
+		results = append(results, account.Redacted())
+	}
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]interface{}{"results": results})
+}
+
+// AnnotateAccount handles operator requests to set the labels and notes of
+// an account.
+func (h *Hub) AnnotateAccount(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		AccountID string   `json:"accountid"`
+		Labels    []string `json:"labels"`
+		Notes     string   `json:"notes"`
+	}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	account, err := dividend.FetchAccount(h.db, []byte(params.AccountID))
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound,
+			dividend.ErrAccountNotFound(params.AccountID).Error())
+		return
+	}
+
+	labels := make([]string, 0, len(params.Labels))
+	for _, label := range params.Labels {
+		label = strings.TrimSpace(label)
+		if label != "" {
+			labels = append(labels, label)
+		}
+	}
+
+	account.Labels = labels
+	account.Notes = strings.TrimSpace(params.Notes)
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Infof("Account (%v) annotated by operator (%v)", account.UUID,
+		requestOperator(r))
+
+	RespondWithJSON(w, http.StatusOK, account.Redacted())
+}
+
+// Impersonate wraps read-only operator views of an account as request
+// middleware. The account viewed is provided by the account query parameter
+// and is served to the wrapped account handlers as if it were authenticated.
+// Only GET requests are served and every view is logged with the operator.
+func (h *Hub) Impersonate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			RespondWithError(w, http.StatusMethodNotAllowed,
+				"account views are read-only")
+			return
+		}
+
+		id := r.URL.Query().Get("account")
+		_, err := dividend.FetchAccount(h.db, []byte(id))
+		if err != nil {
+			RespondWithError(w, http.StatusNotFound,
+				dividend.ErrAccountNotFound(id).Error())
+			return
+		}
+
+		log.Infof("Operator (%v) viewed %v as account (%v)",
+			requestOperator(r), r.URL.Path, id)
+
+		ctx := context.WithValue(r.Context(), accountIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// disconnectAccount disconnects all connected clients of the provided
+// account.
+func (h *Hub) disconnectAccount(accountID string) {
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			if client.account == accountID {
+				client.cancel()
+			}
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+}
+
+// connectedClient returns the connected client of the provided id, or nil if
+// no such client is connected.
+func (h *Hub) connectedClient(id string) *Client {
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		client, ok := endpoint.clients[id]
+		endpoint.clientsMtx.Unlock()
+		if ok {
+			return client
+		}
+	}
+
+	return nil
+}
+
+// suspendAccount suspends or bans the account referenced by the provided id
+// on behalf of the operator of the provided request. It returns the http
+// status code to respond with on failure.
+func (h *Hub) suspendAccount(r *http.Request, id string, reason string, ban bool) (*dividend.Account, int, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, http.StatusBadRequest,
+			fmt.Errorf("a suspension reason is required")
+	}
+
+	account, err := dividend.FetchAccount(h.db, []byte(id))
+	if err != nil {
+		return nil, http.StatusNotFound, dividend.ErrAccountNotFound(id)
+	}
+
+	account.Suspend(requestOperator(r), reason, ban)
+	err = account.Update(h.db)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	h.disconnectAccount(account.UUID)
+	h.recordActivity(r, account.UUID, dividend.ActivityOperator,
+		fmt.Sprintf("account suspended: %v", reason))
+	h.logPoolEvent(dividend.PoolEventAccountSuspended,
+		fmt.Sprintf("account %v suspended: %v", account.UUID, reason),
+		map[string]interface{}{
+			"account": account.UUID,
+			"reason":  reason,
+			"banned":  account.Banned,
+		}, requestOperator(r))
+
+	log.Infof("Account (%v) suspended by operator (%v), banned: %v, "+
+		"reason: %v", account.UUID, requestOperator(r), account.Banned,
+		reason)
+
+	return account, http.StatusOK, nil
+}
+
+// triggerPayouts processes mature payments at the chain tip on request of the
+// provided operator and returns the height payments were processed at.
+func (h *Hub) triggerPayouts(operator string) (uint32, error) {
+	if h.cfg.SoloPool {
+		return 0, fmt.Errorf("payment processing is disabled in solo " +
+			"pool mode")
+	}
+
+	// The current work builds on the chain tip.
+	height := atomic.LoadUint32(&h.lastWorkHeight)
+	if height == 0 {
+		return 0, fmt.Errorf("no work received yet")
+	}
+	height--
+
+	err := h.ProcessPayments(height)
+	if err != nil {
+		return 0, fmt.Errorf("failed to process payouts: %v", err)
+	}
+
+	log.Infof("Payouts at height %v requested by operator (%v)", height,
+		operator)
+
+	return height, nil
+}
+
+// regenerateWork fetches a fresh block template from the consensus daemon on
+// request of the provided operator and broadcasts its work to all connected
+// clients, replacing their current jobs. It returns the height of the work.
+func (h *Hub) regenerateWork(operator string) (uint32, error) {
+	headerE, target, err := h.GetWork()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch work: %v", err)
+	}
+
+	h.processWork(headerE, target)
+	height := atomic.LoadUint32(&h.lastWorkHeight)
+
+	log.Infof("Work at height %v regenerated by operator (%v)", height,
+		operator)
+
+	return height, nil
+}
+
+// RegenerateWork handles operator requests to fetch a fresh block template
+// and broadcast its work to all connected clients, for when templates are
+// suspected to be stale.
+func (h *Hub) RegenerateWork(w http.ResponseWriter, r *http.Request) {
+	height, err := h.regenerateWork(requestOperator(r))
+	if err != nil {
+		RespondWithError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]uint32{"height": height})
+}
+
+// ListClients handles operator requests to list the clients connected to the
+// pool endpoints.
+func (h *Hub) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients := h.dashboardClients()
+	results := make([]map[string]interface{}, 0, len(clients))
+	for _, c := range clients {
+		results = append(results, map[string]interface{}{
+			"id":          c.ID,
+			"ip":          c.IP,
+			"accountid":   c.AccountID,
+			"account":     c.Account,
+			"worker":      c.Worker,
+			"miner":       c.Miner,
+			"region":      c.Region,
+			"difficulty":  c.Difficulty,
+			"hashrate":    c.HashRate,
+			"accepted":    c.Accepted,
+			"rejected":    c.Rejected,
+			"connectedon": c.ConnectedOn,
+		})
+	}
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]interface{}{"results": results})
+}
+
+// DisconnectClient handles operator requests to disconnect a connected
+// client. Banning the client also bans its ip address and disconnects all
+// other clients of the address.
+func (h *Hub) DisconnectClient(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		ID     string `json:"id"`
+		Ban    bool   `json:"ban"`
+		Reason string `json:"reason"`
+	}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	reason := strings.TrimSpace(params.Reason)
+	if params.Ban && reason == "" {
+		RespondWithError(w, http.StatusBadRequest,
+			"a ban reason is required")
+		return
+	}
+
+	client := h.connectedClient(params.ID)
+	if client == nil {
+		RespondWithError(w, http.StatusNotFound,
+			fmt.Sprintf("client '%v' is not connected", params.ID))
+		return
+	}
+
+	operator := requestOperator(r)
+	ip := hostIP(client.ip)
+	disconnected := uint32(1)
+	if params.Ban {
+		err = NewIPBan(ip, reason, operator).Create(h.db)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		disconnected = h.disconnectIP(ip)
+		h.logPoolEvent(dividend.PoolEventIPBan,
+			fmt.Sprintf("ip address %v banned: %v", ip, reason),
+			map[string]string{"ip": ip, "reason": reason}, operator)
+		log.Infof("IP address (%v) of client (%v) banned by operator "+
+			"(%v): %v", ip, params.ID, operator, reason)
+	} else {
+		client.cancel()
+		log.Infof("Client (%v) disconnected by operator (%v)", params.ID,
+			operator)
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"ip":           ip,
+		"banned":       params.Ban,
+		"disconnected": disconnected,
+	})
+}
+
+// SuspendAccount handles operator requests to suspend or ban an account.
+// Connected clients of the account are disconnected and payouts are held
+// until the account is reinstated.
+func (h *Hub) SuspendAccount(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		AccountID string `json:"accountid"`
+		Reason    string `json:"reason"`
+		Ban       bool   `json:"ban"`
+	}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	account, code, err := h.suspendAccount(r, params.AccountID,
+		params.Reason, params.Ban)
+	if err != nil {
+		RespondWithError(w, code, err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, account.Redacted())
+}
+
+// reinstateAccount lifts the suspension of the account referenced by the
+// provided id on behalf of the operator of the provided request. It returns
+// the http status code to respond with on failure.
+func (h *Hub) reinstateAccount(r *http.Request, id string) (*dividend.Account, int, error) {
+	account, err := dividend.FetchAccount(h.db, []byte(id))
+	if err != nil {
+		return nil, http.StatusNotFound, dividend.ErrAccountNotFound(id)
+	}
+
+	err = account.Reinstate()
+	if err != nil {
+		return nil, http.StatusConflict, err
+	}
+
+	err = account.Update(h.db)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	log.Infof("Account (%v) reinstated by operator (%v)", id,
+		requestOperator(r))
+	h.recordActivity(r, id, dividend.ActivityOperator, "account reinstated")
+	h.logPoolEvent(dividend.PoolEventAccountReinstated,
+		fmt.Sprintf("account %v reinstated", id),
+		map[string]string{"account": id}, requestOperator(r))
+
+	return account, http.StatusOK, nil
+}
+
+// ReinstateAccount handles operator requests to lift the suspension of an
+// account. Banned accounts cannot be reinstated.
+func (h *Hub) ReinstateAccount(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	account, code, err := h.reinstateAccount(r, params["accountid"])
+	if err != nil {
+		RespondWithError(w, code, err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, account.Redacted())
+}
+
+// accountImport is an account entry of a bulk account import.
+type accountImport struct {
+	Name      string  `json:"name"`
+	Address   string  `json:"address"`
+	Threshold float64 `json:"threshold"`
+}
+
+// parseAccountImport parses the account entries of a bulk account import
+// request. Requests with a text/csv content type provide one
+// `name,address,threshold` record per line with an optional header, other
+// requests provide a json array of entries.
+func parseAccountImport(r *http.Request) ([]accountImport, error) {
+	entries := make([]accountImport, 0)
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		dc := json.NewDecoder(r.Body)
+		err := dc.Decode(&entries)
+		if err != nil {
+			return nil, fmt.Errorf("request body is invalid json")
+		}
+		return entries, nil
+	}
+
+	reader := csv.NewReader(r.Body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("request body is invalid csv: %v", err)
+		}
+
+		if line == 1 && strings.EqualFold(record[0], "name") {
+			continue
+		}
+
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %v: expected name, address and an "+
+				"optional threshold", line)
+		}
+
+		entry := accountImport{Name: record[0], Address: record[1]}
+		if len(record) == 3 && strings.TrimSpace(record[2]) != "" {
+			entry.Threshold, err = strconv.ParseFloat(
+				strings.TrimSpace(record[2]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: threshold is not numeric",
+					line)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// importAccount creates an account for the provided import entry. It returns
+// false if an account with the same name and payout address already exists.
+func (h *Hub) importAccount(entry accountImport) (bool, error) {
+	name := strings.TrimSpace(entry.Name)
+	address := strings.TrimSpace(entry.Address)
+	err := validateAccountName(name)
+	if err != nil {
+		return false, err
+	}
+
+	err = h.validateAddress(address)
+	if err != nil {
+		return false, err
+	}
+
+	if entry.Threshold < 0 {
+		return false, fmt.Errorf("threshold cannot be negative")
+	}
+
+	threshold, err := dcrutil.NewAmount(entry.Threshold)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = dividend.FetchAccountByName(h.db, name, address,
+		h.cfg.CaseInsensitive)
+	if err == nil {
+		return false, nil
+	}
+
+	if err.Error() != dividend.ErrAccountNameNotFound(name, address).Error() {
+		return false, err
+	}
+
+	account, err := dividend.NewAccount(name, address)
+	if err != nil {
+		return false, err
+	}
+
+	account.PayoutThreshold = threshold
+	err = account.Create(h.db)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ImportAccounts handles operator requests to create accounts in bulk, for
+// operators migrating from another pool. Existing accounts are skipped and
+// invalid entries are reported without aborting the import.
+func (h *Hub) ImportAccounts(w http.ResponseWriter, r *http.Request) {
+	entries, err := parseAccountImport(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	imported := 0
+	skipped := make([]string, 0)
+	failed := make([]map[string]interface{}, 0)
+	for idx, entry := range entries {
+		created, err := h.importAccount(entry)
+		if err != nil {
+			failed = append(failed, map[string]interface{}{
+				"entry": idx + 1,
+				"name":  entry.Name,
+				"error": err.Error(),
+			})
+			continue
+		}
+
+		if !created {
+			skipped = append(skipped, entry.Name)
+			continue
+		}
+
+		imported++
+	}
+
+	log.Infof("%v accounts imported by operator (%v), %v skipped, %v failed",
+		imported, requestOperator(r), len(skipped), len(failed))
+
+	resp := map[string]interface{}{
+		"imported": imported,
+		"skipped":  skipped,
+		"failed":   failed,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
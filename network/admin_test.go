@@ -0,0 +1,77 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseAccountImport(t *testing.T) {
+	body := "name,address,threshold\nx,SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc,0.5\n" +
+		"y,Ssp7J7TUmi5iPhoQnWYNGQbeGhu6V3otJcS\n"
+	r := httptest.NewRequest("POST", "/admin/accounts/import",
+		strings.NewReader(body))
+	r.Header.Set("Content-Type", "text/csv")
+	entries, err := parseAccountImport(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %v", len(entries))
+	}
+
+	if entries[0].Name != "x" || entries[0].Threshold != 0.5 ||
+		entries[1].Threshold != 0 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	body = `[{"name":"x","address":"SsWKp7wtdTZYabYFYSc9cnxhwFEjA5g4pFc",` +
+		`"threshold":1}]`
+	r = httptest.NewRequest("POST", "/admin/accounts/import",
+		strings.NewReader(body))
+	entries, err = parseAccountImport(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].Threshold != 1 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	r = httptest.NewRequest("POST", "/admin/accounts/import",
+		strings.NewReader("x,y,z,w\n"))
+	r.Header.Set("Content-Type", "text/csv")
+	_, err = parseAccountImport(r)
+	if err == nil {
+		t.Error("expected malformed record to be rejected")
+	}
+}
+
+func TestDisconnectClient(t *testing.T) {
+	h := &Hub{}
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"id":"x"`, http.StatusBadRequest},
+		{`{"id":"00000000/cpu","ban":true}`, http.StatusBadRequest},
+		{`{"id":"00000000/cpu"}`, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		h.DisconnectClient(w, httptest.NewRequest("POST",
+			"/admin/clients/disconnect", strings.NewReader(test.body)))
+		if w.Code != test.code {
+			t.Errorf("expected status %v for %v, got %v", test.code,
+				test.body, w.Code)
+		}
+	}
+}
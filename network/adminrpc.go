@@ -0,0 +1,259 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+
+	bolt "github.com/coreos/bbolt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/dnldd/dcrpool/adminrpc"
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+const (
+	// backupChunkSize is the size of the database chunks streamed by admin
+	// backups.
+	backupChunkSize = 1 << 16
+
+	// operatorMetadataKey is the request metadata key identifying the
+	// operator of admin calls.
+	operatorMetadataKey = "operator"
+
+	// defaultRPCOperator identifies admin calls which do not provide an
+	// operator.
+	defaultRPCOperator = "adminrpc"
+)
+
+// AdminRPCServer implements the gRPC admin service over the hub. Calls are
+// authenticated by the admin token.
+type AdminRPCServer struct {
+	hub   *Hub
+	token string
+}
+
+// NewAdminRPCServer creates an admin service of the provided hub which
+// accepts the provided admin token.
+func NewAdminRPCServer(hub *Hub, token string) *AdminRPCServer {
+	return &AdminRPCServer{
+		hub:   hub,
+		token: token,
+	}
+}
+
+// authenticate asserts the admin token of the provided call context and
+// returns the context with the operator of the call.
+func (s *AdminRPCServer) authenticate(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "admin token required")
+	}
+
+	tokens := md.Get(adminrpc.TokenMetadataKey)
+	if len(tokens) != 1 || subtle.ConstantTimeCompare(
+		[]byte(strings.TrimPrefix(tokens[0], "Bearer ")),
+		[]byte(s.token)) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "invalid admin token")
+	}
+
+	operator := defaultRPCOperator
+	if ops := md.Get(operatorMetadataKey); len(ops) == 1 && ops[0] != "" {
+		operator = ops[0]
+	}
+
+	return context.WithValue(ctx, operatorKey, operator), nil
+}
+
+// contextOperator returns the operator of the provided admin call context.
+func contextOperator(ctx context.Context) string {
+	op, _ := ctx.Value(operatorKey).(string)
+	return op
+}
+
+// UnaryInterceptor authenticates unary admin calls.
+func (s *AdminRPCServer) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// authenticatedStream is a server stream with an authenticated context.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the authenticated context of the stream.
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamInterceptor authenticates streaming admin calls.
+func (s *AdminRPCServer) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// ListClients lists the clients connected to the pool endpoints.
+func (s *AdminRPCServer) ListClients(ctx context.Context, req *adminrpc.ListClientsRequest) (*adminrpc.ListClientsResponse, error) {
+	clients := s.hub.dashboardClients()
+	resp := &adminrpc.ListClientsResponse{
+		Clients: make([]*adminrpc.Client, 0, len(clients)),
+	}
+	for _, c := range clients {
+		resp.Clients = append(resp.Clients, &adminrpc.Client{
+			Id:         c.ID,
+			Ip:         c.IP,
+			AccountId:  c.AccountID,
+			Account:    c.Account,
+			Worker:     c.Worker,
+			Miner:      c.Miner,
+			Difficulty: c.Difficulty,
+			Hashrate:   c.HashRate,
+			Accepted:   c.Accepted,
+			Rejected:   c.Rejected,
+			Region:     c.Region,
+		})
+	}
+
+	return resp, nil
+}
+
+// BanIP bans an ip address from connecting to the pool endpoints and
+// disconnects its connected clients.
+func (s *AdminRPCServer) BanIP(ctx context.Context, req *adminrpc.BanIPRequest) (*adminrpc.BanIPResponse, error) {
+	if net.ParseIP(req.Ip) == nil {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"invalid ip address '%v'", req.Ip)
+	}
+
+	operator := contextOperator(ctx)
+	err := NewIPBan(req.Ip, req.Reason, operator).Create(s.hub.db)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	disconnected := s.hub.disconnectIP(req.Ip)
+	s.hub.logPoolEvent(dividend.PoolEventIPBan,
+		fmt.Sprintf("ip address %v banned: %v", req.Ip, req.Reason),
+		map[string]string{"ip": req.Ip, "reason": req.Reason}, operator)
+	log.Infof("IP address (%v) banned by operator (%v): %v", req.Ip,
+		operator, req.Reason)
+
+	return &adminrpc.BanIPResponse{Disconnected: disconnected}, nil
+}
+
+// UnbanIP lifts the ban of an ip address.
+func (s *AdminRPCServer) UnbanIP(ctx context.Context, req *adminrpc.UnbanIPRequest) (*adminrpc.UnbanIPResponse, error) {
+	ban, err := FetchIPBan(s.hub.db, req.Ip)
+	if err != nil {
+		if err.Error() == ErrIPNotBanned(req.Ip).Error() {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	err = ban.Delete(s.hub.db)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	s.hub.logPoolEvent(dividend.PoolEventIPUnban,
+		fmt.Sprintf("ip address %v unbanned", req.Ip),
+		map[string]string{"ip": req.Ip}, contextOperator(ctx))
+	log.Infof("IP address (%v) unbanned by operator (%v)", req.Ip,
+		contextOperator(ctx))
+
+	return &adminrpc.UnbanIPResponse{}, nil
+}
+
+// ListBans lists the banned ip addresses.
+func (s *AdminRPCServer) ListBans(ctx context.Context, req *adminrpc.ListBansRequest) (*adminrpc.ListBansResponse, error) {
+	bans, err := ListIPBans(s.hub.db)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &adminrpc.ListBansResponse{
+		Bans: make([]*adminrpc.Ban, 0, len(bans)),
+	}
+	for _, ban := range bans {
+		resp.Bans = append(resp.Bans, &adminrpc.Ban{
+			Ip:        ban.IP,
+			Reason:    ban.Reason,
+			Operator:  ban.Operator,
+			CreatedOn: ban.CreatedOn,
+		})
+	}
+
+	return resp, nil
+}
+
+// TriggerPayouts processes mature payments at the chain tip.
+func (s *AdminRPCServer) TriggerPayouts(ctx context.Context, req *adminrpc.TriggerPayoutsRequest) (*adminrpc.TriggerPayoutsResponse, error) {
+	height, err := s.hub.triggerPayouts(contextOperator(ctx))
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	return &adminrpc.TriggerPayoutsResponse{Height: height}, nil
+}
+
+// backupWriter streams the database in chunks.
+type backupWriter struct {
+	stream adminrpc.AdminService_BackupDBServer
+}
+
+// Write sends the provided bytes as backup chunks.
+func (w *backupWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := len(p)
+		if n > backupChunkSize {
+			n = backupChunkSize
+		}
+
+		err := w.stream.Send(&adminrpc.BackupDBResponse{Chunk: p[:n]})
+		if err != nil {
+			return written, err
+		}
+
+		written += n
+		p = p[n:]
+	}
+
+	return written, nil
+}
+
+// BackupDB streams a consistent copy of the pool database.
+func (s *AdminRPCServer) BackupDB(req *adminrpc.BackupDBRequest, stream adminrpc.AdminService_BackupDBServer) error {
+	log.Infof("Database backup requested by operator (%v)",
+		contextOperator(stream.Context()))
+
+	err := s.hub.db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(&backupWriter{stream: stream})
+		return err
+	})
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return nil
+}
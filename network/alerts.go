@@ -0,0 +1,395 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/decred/dcrwallet/rpc/walletrpc"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+const (
+	// alertCheckInterval is the interval between checks of the operator
+	// alert thresholds.
+	alertCheckInterval = time.Minute
+
+	// hashRateBaseline is the period the pool hash rate is averaged over
+	// when checking for hash rate drops.
+	hashRateBaseline = time.Hour
+)
+
+// Operator alert kinds.
+const (
+	alertHashRateDrop  = "hashratedrop"
+	alertRejectRate    = "rejectrate"
+	alertDBSize        = "dbsize"
+	alertDcrdLatency   = "dcrdlatency"
+	alertWalletLatency = "walletlatency"
+	alertStaleWork     = "stalework"
+)
+
+// AlertThresholds are the operator configured thresholds pool alerts are
+// raised at, zero thresholds disable their alert.
+type AlertThresholds struct {
+	// HashRateDrop is the percentage the pool hash rate may drop by from
+	// its average over the previous hour.
+	HashRateDrop float64
+
+	// RejectRate is the percentage of rejected shares allowed between
+	// checks.
+	RejectRate float64
+
+	// DBSize is the database size allowed, in bytes.
+	DBSize int64
+
+	// BackendLatency is the response time allowed of dcrd and the wallet.
+	BackendLatency time.Duration
+
+	// WorkAge is the age allowed of the work served to clients, work is
+	// refreshed with every chain tip change and at least every 30 seconds.
+	WorkAge time.Duration
+
+	// Email is the address alerts are sent to, alerts are only logged and
+	// shown on the dashboard when not set.
+	Email string
+}
+
+// Enabled returns whether any alert threshold is set.
+func (t *AlertThresholds) Enabled() bool {
+	return t.HashRateDrop > 0 || t.RejectRate > 0 || t.DBSize > 0 ||
+		t.BackendLatency > 0 || t.WorkAge > 0
+}
+
+// operatorAlert is an active operator alert. Value and Threshold are
+// formatted for display.
+type operatorAlert struct {
+	Kind      string
+	Value     string
+	Threshold string
+	Since     time.Time
+}
+
+// message returns the description of the alert.
+func (a *operatorAlert) message() string {
+	return fmt.Sprintf(englishCatalog["alert."+a.Kind], a.Value, a.Threshold)
+}
+
+// alertCheck is the outcome of checking an alert threshold.
+type alertCheck struct {
+	kind      string
+	active    bool
+	value     string
+	threshold string
+}
+
+// checkHashRateDrop checks the pool hash rate against its average over the
+// previous hour.
+func (h *Hub) checkHashRateDrop(now time.Time) *alertCheck {
+	samples, err := dividend.FetchHashRates(h.db, dividend.PoolSeries,
+		dividend.Resolution5m, now.Add(-hashRateBaseline), now)
+	if err != nil {
+		log.Errorf("Failed to fetch pool hash rates: %v", err)
+		return nil
+	}
+
+	var total float64
+	for _, sample := range samples {
+		total += sample.HashRate
+	}
+	if total == 0 {
+		return nil
+	}
+
+	baseline := total / float64(len(samples))
+	current, _ := h.hashRate("").Float64()
+	drop := (baseline - current) / baseline * 100
+	return &alertCheck{
+		kind:      alertHashRateDrop,
+		active:    drop > h.cfg.Alerts.HashRateDrop,
+		value:     fmt.Sprintf("%.1f%%", drop),
+		threshold: fmt.Sprintf("%.1f%%", h.cfg.Alerts.HashRateDrop),
+	}
+}
+
+// checkRejectRate checks the percentage of rejected shares since the
+// provided share counts, it returns the current share counts.
+func (h *Hub) checkRejectRate(accepted, rejected uint64) (*alertCheck, uint64, uint64) {
+	currAccepted := atomic.LoadUint64(&h.metrics.sharesAccepted)
+	currRejected := atomic.LoadUint64(&h.metrics.sharesRejected)
+	total := (currAccepted - accepted) + (currRejected - rejected)
+	var rate float64
+	if total > 0 {
+		rate = float64(currRejected-rejected) / float64(total) * 100
+	}
+
+	return &alertCheck{
+		kind:      alertRejectRate,
+		active:    rate > h.cfg.Alerts.RejectRate,
+		value:     fmt.Sprintf("%.1f%%", rate),
+		threshold: fmt.Sprintf("%.1f%%", h.cfg.Alerts.RejectRate),
+	}, currAccepted, currRejected
+}
+
+// checkDBSize checks the size of the database.
+func (h *Hub) checkDBSize() *alertCheck {
+	var size int64
+	err := h.db.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to read database size: %v", err)
+		return nil
+	}
+
+	return &alertCheck{
+		kind:      alertDBSize,
+		active:    size > h.cfg.Alerts.DBSize,
+		value:     fmt.Sprintf("%.1f MB", float64(size)/1e6),
+		threshold: fmt.Sprintf("%.1f MB", float64(h.cfg.Alerts.DBSize)/1e6),
+	}
+}
+
+// checkLatency times the provided backend call against the latency
+// threshold, failed calls exceed the threshold.
+func (h *Hub) checkLatency(kind string, call func() error) *alertCheck {
+	start := time.Now()
+	err := call()
+	latency := time.Since(start)
+
+	value := latency.Round(time.Millisecond).String()
+	if err != nil {
+		value = err.Error()
+	}
+
+	return &alertCheck{
+		kind:      kind,
+		active:    err != nil || latency > h.cfg.Alerts.BackendLatency,
+		value:     value,
+		threshold: h.cfg.Alerts.BackendLatency.String(),
+	}
+}
+
+// workAge returns the age of the work served to clients, the time since it
+// was received from dcrd, zero if no work was received yet.
+func (h *Hub) workAge(now time.Time) time.Duration {
+	received := atomic.LoadInt64(&h.lastWorkTime)
+	if received == 0 {
+		return 0
+	}
+
+	return now.Sub(time.Unix(0, received))
+}
+
+// chainTip returns the highest chain tip of the reachable dcrd backends.
+func (h *Hub) chainTip() (uint32, error) {
+	h.rpccMtx.Lock()
+	defer h.rpccMtx.Unlock()
+
+	var tip int64
+	var err error
+	for _, b := range h.backends {
+		if b.client == nil || b.client.Disconnected() {
+			continue
+		}
+
+		height, cerr := b.client.GetBlockCount()
+		if cerr != nil {
+			err = cerr
+			continue
+		}
+
+		if height > tip {
+			tip = height
+		}
+	}
+
+	if tip == 0 {
+		if err == nil {
+			err = fmt.Errorf("no reachable dcrd backend")
+		}
+		return 0, err
+	}
+
+	return uint32(tip), nil
+}
+
+// checkStaleWork checks the age of the work served to clients. Work behind
+// the highest chain tip of the backends is reported as such, the active
+// backend lags.
+func (h *Hub) checkStaleWork(now time.Time) *alertCheck {
+	age := h.workAge(now)
+	if age == 0 {
+		return nil
+	}
+
+	value := age.Round(time.Second).String()
+	height := atomic.LoadUint32(&h.lastWorkHeight)
+	tip, err := h.chainTip()
+	if err != nil {
+		log.Errorf("Failed to fetch chain tip: %v", err)
+	}
+	if err == nil && height <= tip {
+		value = fmt.Sprintf("%v (height %v, chain tip %v)", value, height,
+			tip)
+	}
+
+	return &alertCheck{
+		kind:      alertStaleWork,
+		active:    age > h.cfg.Alerts.WorkAge,
+		value:     value,
+		threshold: h.cfg.Alerts.WorkAge.String(),
+	}
+}
+
+// updateAlert raises or resolves the operator alert of the provided check.
+// Operators are notified of raised and resolved alerts.
+func (h *Hub) updateAlert(check *alertCheck, now time.Time) {
+	h.alertsMtx.Lock()
+	alert, active := h.alerts[check.kind]
+	switch {
+	case check.active && active:
+		alert.Value = check.value
+		h.alertsMtx.Unlock()
+		return
+
+	case check.active:
+		alert = &operatorAlert{
+			Kind:      check.kind,
+			Value:     check.value,
+			Threshold: check.threshold,
+			Since:     now,
+		}
+		h.alerts[check.kind] = alert
+
+	case active:
+		delete(h.alerts, check.kind)
+
+	default:
+		h.alertsMtx.Unlock()
+		return
+	}
+	h.alertsMtx.Unlock()
+
+	subject := "Pool alert: " + alert.Kind
+	msg := alert.message()
+	if !check.active {
+		subject = "Pool alert resolved: " + alert.Kind
+		msg = "Resolved: " + msg
+		log.Infof("Operator alert resolved: %v", msg)
+	} else {
+		log.Warnf("Operator alert raised: %v", msg)
+	}
+
+	h.logPoolEvent(dividend.PoolEventAlert, msg, map[string]interface{}{
+		"kind":      alert.Kind,
+		"active":    check.active,
+		"value":     check.value,
+		"threshold": alert.Threshold,
+	}, "")
+
+	if h.cfg.Alerts.Email != "" {
+		go func() {
+			err := h.mailer.Send(h.cfg.Alerts.Email, subject, msg)
+			if err != nil {
+				log.Errorf("Failed to send operator alert: %v", err)
+			}
+		}()
+	}
+}
+
+// activeAlerts returns the active operator alerts, oldest first.
+func (h *Hub) activeAlerts() []operatorAlert {
+	h.alertsMtx.Lock()
+	alerts := make([]operatorAlert, 0, len(h.alerts))
+	for _, alert := range h.alerts {
+		alerts = append(alerts, *alert)
+	}
+	h.alertsMtx.Unlock()
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Since.Before(alerts[j].Since)
+	})
+
+	return alerts
+}
+
+// handleOperatorAlerts periodically checks the configured alert thresholds,
+// raising and resolving operator alerts. It must be run as a goroutine.
+func (h *Hub) handleOperatorAlerts(ctx context.Context) {
+	ticker := time.NewTicker(alertCheckInterval)
+	defer ticker.Stop()
+	h.wg.Add(1)
+	log.Trace("Started operator alerts handler.")
+
+	accepted := atomic.LoadUint64(&h.metrics.sharesAccepted)
+	rejected := atomic.LoadUint64(&h.metrics.sharesRejected)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Trace("Operator alerts handler done.")
+			h.wg.Done()
+			return
+		case now := <-ticker.C:
+			checks := make([]*alertCheck, 0)
+			thresholds := h.cfg.Alerts
+			if thresholds.HashRateDrop > 0 {
+				checks = append(checks, h.checkHashRateDrop(now))
+			}
+
+			if thresholds.RejectRate > 0 {
+				var check *alertCheck
+				check, accepted, rejected = h.checkRejectRate(accepted,
+					rejected)
+				checks = append(checks, check)
+			}
+
+			if thresholds.DBSize > 0 {
+				checks = append(checks, h.checkDBSize())
+			}
+
+			if thresholds.BackendLatency > 0 {
+				checks = append(checks, h.checkLatency(alertDcrdLatency,
+					func() error {
+						h.rpccMtx.Lock()
+						defer h.rpccMtx.Unlock()
+						_, err := h.rpcc.GetBlockCount()
+						return err
+					}))
+
+				if !h.cfg.SoloPool {
+					checks = append(checks, h.checkLatency(alertWalletLatency,
+						func() error {
+							ctx, cancel := context.WithTimeout(ctx,
+								alertCheckInterval/2)
+							defer cancel()
+							h.grpcMtx.Lock()
+							defer h.grpcMtx.Unlock()
+							_, err := h.grpc.Ping(ctx,
+								&walletrpc.PingRequest{})
+							return err
+						}))
+				}
+			}
+
+			if thresholds.WorkAge > 0 {
+				checks = append(checks, h.checkStaleWork(now))
+			}
+
+			for _, check := range checks {
+				if check != nil {
+					h.updateAlert(check, now)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRejectRateAlert(t *testing.T) {
+	h := &Hub{
+		cfg:     &HubConfig{Alerts: AlertThresholds{RejectRate: 10}},
+		metrics: newMetrics(0),
+	}
+
+	if !h.cfg.Alerts.Enabled() {
+		t.Fatal("expected alerts to be enabled")
+	}
+
+	for i := 0; i < 8; i++ {
+		h.metrics.recordShare("x", true)
+	}
+	h.metrics.recordShare("x", false)
+	h.metrics.recordShare("x", false)
+
+	check, accepted, rejected := h.checkRejectRate(0, 0)
+	if !check.active || check.value != "20.0%" {
+		t.Fatalf("expected an active 20%% reject rate alert, got %+v", check)
+	}
+
+	if accepted != 8 || rejected != 2 {
+		t.Fatalf("expected 8 accepted and 2 rejected shares, got %v and %v",
+			accepted, rejected)
+	}
+
+	h.metrics.recordShare("x", true)
+	check, _, _ = h.checkRejectRate(accepted, rejected)
+	if check.active || check.value != "0.0%" {
+		t.Fatalf("expected the reject rate alert to resolve, got %+v", check)
+	}
+
+	check, _, _ = h.checkRejectRate(accepted+1, rejected)
+	if check.active {
+		t.Fatal("expected no alert without submitted shares")
+	}
+}
+
+func TestStaleWorkAlert(t *testing.T) {
+	h := &Hub{cfg: &HubConfig{Alerts: AlertThresholds{WorkAge: time.Minute}}}
+	now := time.Now()
+	if check := h.checkStaleWork(now); check != nil {
+		t.Fatalf("expected no check without work, got %+v", check)
+	}
+
+	atomic.StoreInt64(&h.lastWorkTime, now.Add(-time.Second*30).UnixNano())
+	check := h.checkStaleWork(now)
+	if check == nil || check.active || check.value != "30s" {
+		t.Fatalf("expected an inactive 30s work age check, got %+v", check)
+	}
+
+	check = h.checkStaleWork(now.Add(time.Minute))
+	if check == nil || !check.active || check.value != "1m30s" {
+		t.Fatalf("expected an active 1m30s work age alert, got %+v", check)
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+const (
+	// APIVersion is the version of the stats api, it prefixes all api
+	// routes. Response formats of a version do not change.
+	APIVersion = "v1"
+
+	// hashRateUnit is the unit of all hash rates reported by the api.
+	hashRateUnit = "TH/s"
+
+	// defaultPageLimit is the number of entries listed per page when no
+	// limit is requested, maxPageLimit is the largest limit accepted.
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// parsePageQuery parses the pagination parameters of a list request: the
+// `limit` and `cursor` of the page, the `order` of entries (asc or desc) and
+// the `from` (inclusive) and `to` (exclusive) bounds of the listing. Bounds of
+// time ordered listings are unix times in seconds, bounds of block listings
+// are heights.
+func parsePageQuery(r *http.Request, timeOrdered bool) (*dividend.PageQuery, error) {
+	values := r.URL.Query()
+	query := &dividend.PageQuery{
+		Cursor: values.Get("cursor"),
+		Limit:  defaultPageLimit,
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("provided 'limit' parameter is not a " +
+				"positive number")
+		}
+
+		if limit > maxPageLimit {
+			return nil, fmt.Errorf("provided 'limit' parameter exceeds %v",
+				maxPageLimit)
+		}
+
+		if limit > 0 {
+			query.Limit = limit
+		}
+	}
+
+	switch values.Get("order") {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		return nil, fmt.Errorf("provided 'order' parameter is neither " +
+			"'asc' nor 'desc'")
+	}
+
+	bound := func(name string) (int64, error) {
+		v := values.Get(name)
+		if v == "" {
+			return 0, nil
+		}
+
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("provided '%v' parameter is not a "+
+				"positive number", name)
+		}
+
+		if timeOrdered {
+			n = time.Unix(n, 0).UnixNano()
+		}
+		return n, nil
+	}
+
+	var err error
+	query.From, err = bound("from")
+	if err != nil {
+		return nil, err
+	}
+
+	query.To, err = bound("to")
+	if err != nil {
+		return nil, err
+	}
+
+	return query, nil
+}
+
+// hashRate returns the combined hash rate of the connected clients of the
+// provided account, or of all connected clients if no account is provided.
+func (h *Hub) hashRate(accountID string) *big.Rat {
+	total := new(big.Rat)
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			if accountID != "" && client.account != accountID {
+				continue
+			}
+
+			client.hashRateMtx.RLock()
+			total = total.Add(total, client.hashRate)
+			client.hashRateMtx.RUnlock()
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	return total
+}
+
+// allowedOrigin returns the value of the Access-Control-Allow-Origin header
+// for a request from the provided origin, an empty string if the origin is
+// not allowed.
+func allowedOrigin(origins []string, origin string) string {
+	for _, allowed := range origins {
+		if allowed == "*" {
+			return "*"
+		}
+
+		if origin != "" && strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+
+	return ""
+}
+
+// APIHeaders allows the stats api to be consumed by browser based apps
+// hosted on the configured origins.
+func (h *Hub) APIHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := allowedOrigin(h.cfg.CORSOrigins, r.Header.Get("Origin"))
+		if origin != "" {
+			if origin != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods",
+				strings.Join(h.cfg.CORSMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers",
+				strings.Join(h.cfg.CORSHeaders, ", "))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIPreflight responds to cross-origin preflight requests of the stats
+// api, the allowed origins, methods and headers are set by APIHeaders.
+func (h *Hub) APIPreflight(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// APIPoolStats returns the pool hash rate, its connected clients and the
+// blocks it found.
+func (h *Hub) APIPoolStats(w http.ResponseWriter, r *http.Request) {
+	work, err := ListMinedWork(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	connections := 0
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		connections += len(endpoint.clients)
+		endpoint.clientsMtx.Unlock()
+	}
+
+	var lastBlock *AcceptedWork
+	if len(work) > 0 {
+		lastBlock = work[len(work)-1]
+	}
+
+	effort, err := h.roundEffort()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := map[string]interface{}{
+		"version":        APIVersion,
+		"network":        h.cfg.ActiveNet.Name,
+		"hashrate":       h.hashRate("").FloatString(12),
+		"hashrateunit":   hashRateUnit,
+		"connections":    connections,
+		"blocksfound":    len(work),
+		"lastblock":      lastBlock,
+		"lastworkheight": atomic.LoadUint32(&h.lastWorkHeight),
+		"roundeffort":    effort,
+		"timetoblock":    h.timeToBlock(),
+		"solopool":       h.cfg.SoloPool,
+	}
+
+	if !h.cfg.SoloPool {
+		resp["paymentmethod"] = h.cfg.PaymentMethod
+		resp["poolfee"] = h.cfg.PoolFee
+		resp["lastpaymentheight"] = atomic.LoadUint32(&h.lastPaymentHeight)
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// respondWithListError responds with the error of a paged listing, invalid
+// cursors are client errors.
+func respondWithListError(w http.ResponseWriter, err error) {
+	if err.Error() == database.ErrInvalidCursor().Error() {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithError(w, http.StatusInternalServerError, err.Error())
+}
+
+// APIBlocks returns a page of the blocks found by the pool, most recent
+// first by default, with their rewards, anonymized or opted-in finders,
+// confirmation states and explorer links.
+func (h *Hub) APIBlocks(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r, false)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	work, err := ListMinedWork(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	blocks, next, err := ListMinedWorkPage(h.db, "", query)
+	if err != nil {
+		respondWithListError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"total":   len(work),
+		"results": h.foundBlocks(blocks),
+		"next":    next,
+	})
+}
+
+// APIAccountStats returns the hash rate, connected workers and blocks found
+// of the authenticated account.
+func (h *Hub) APIAccountStats(w http.ResponseWriter, r *http.Request) {
+	id := requestAccountID(r)
+	work, err := ListMinedWorkByAccount(h.db, id)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	workers := 0
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			if client.account == id {
+				workers++
+			}
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	resp := map[string]interface{}{
+		"accountid":    id,
+		"hashrate":     h.hashRate(id).FloatString(12),
+		"hashrateunit": hashRateUnit,
+		"connections":  workers,
+		"blocksfound":  len(work),
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
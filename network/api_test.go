@@ -0,0 +1,35 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+)
+
+func TestAllowedOrigin(t *testing.T) {
+	tests := []struct {
+		origins  []string
+		origin   string
+		expected string
+	}{
+		{[]string{"*"}, "https://stats.example.com", "*"},
+		{[]string{"*"}, "", "*"},
+		{[]string{"https://stats.example.com"}, "https://stats.example.com",
+			"https://stats.example.com"},
+		{[]string{"https://stats.example.com"}, "https://STATS.example.com",
+			"https://STATS.example.com"},
+		{[]string{"https://stats.example.com"}, "https://evil.example.com", ""},
+		{[]string{"https://stats.example.com"}, "", ""},
+		{nil, "https://stats.example.com", ""},
+	}
+
+	for i, test := range tests {
+		origin := allowedOrigin(test.origins, test.origin)
+		if origin != test.expected {
+			t.Fatalf("test %d: expected origin %q, got %q", i,
+				test.expected, origin)
+		}
+	}
+}
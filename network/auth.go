@@ -0,0 +1,297 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dnldd/dcrpool/dividend"
+	"github.com/dnldd/dcrpool/util"
+)
+
+const (
+	// accessTokenLifetime is the duration a signed session access token
+	// remains valid for.
+	accessTokenLifetime = time.Minute * 15
+
+	// sessionCookie is the cookie of web sessions, it holds the session id.
+	sessionCookie = "session"
+
+	// csrfHeader is the header requests authenticated by a web session
+	// must provide the csrf token of the session in, unless they are safe.
+	csrfHeader = "X-CSRF-Token"
+)
+
+// contextKey is the type of request context keys set by the api
+// middleware.
+type contextKey string
+
+const (
+	// accountIDKey is the request context key of the authenticated account
+	// id.
+	accountIDKey = contextKey("accountid")
+
+	// sessionIDKey is the request context key of the authenticated session
+	// id.
+	sessionIDKey = contextKey("sessionid")
+
+	// operatorKey is the request context key of the authenticated operator.
+	operatorKey = contextKey("operator")
+
+	// apiKeyKey is the request context key of the api key used to
+	// authenticate the request, if any.
+	apiKeyKey = contextKey("apikey")
+)
+
+// bearerToken returns the bearer token of the provided request, if any.
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+}
+
+// requestAccountID returns the id of the account authenticated for the
+// provided request.
+func requestAccountID(r *http.Request) string {
+	id, _ := r.Context().Value(accountIDKey).(string)
+	return id
+}
+
+// requestSessionID returns the id of the session authenticated for the
+// provided request, if any.
+func requestSessionID(r *http.Request) string {
+	id, _ := r.Context().Value(sessionIDKey).(string)
+	return id
+}
+
+// requestOperator returns the identity of the operator authenticated for the
+// provided request.
+func requestOperator(r *http.Request) string {
+	op, _ := r.Context().Value(operatorKey).(string)
+	return op
+}
+
+// sessionClaims are the claims of signed session access tokens. Access
+// tokens reference the session they were issued for, revoking the session
+// revokes its access tokens.
+type sessionClaims struct {
+	Subject   string `json:"sub"`
+	Session   string `json:"sid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// issueAccessToken issues a signed access token for the provided session.
+func (h *Hub) issueAccessToken(session *dividend.Token) (string, int64, error) {
+	now := time.Now()
+	claims := &sessionClaims{
+		Subject:   session.Account,
+		Session:   session.UUID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(accessTokenLifetime).Unix(),
+	}
+
+	token, err := util.SignJWT(claims, h.sessionKey)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return token, claims.ExpiresAt, nil
+}
+
+// csrfToken returns the csrf token of the provided web session, it protects
+// requests authenticated by the session cookie from cross-site requests.
+func (h *Hub) csrfToken(sessionID string) string {
+	mac := hmac.New(sha256.New, h.sessionKey)
+	mac.Write([]byte("csrf:" + sessionID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// setSessionCookie sets the cookie of the provided web session. The cookie
+// is not readable by scripts, only sent over tls and never sent with
+// cross-site requests.
+func setSessionCookie(w http.ResponseWriter, session *dividend.Token) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    session.UUID,
+		Path:     "/",
+		Expires:  time.Unix(session.ExpiresOn, 0),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// clearSessionCookie removes the web session cookie.
+func clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// authenticateWebSession returns the ids of the account and session of the
+// session cookie of the provided request. Requests other than GET, HEAD and
+// OPTIONS must provide the csrf token of the session.
+func (h *Hub) authenticateWebSession(r *http.Request) (string, string, int, string) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || cookie.Value == "" {
+		return "", "", http.StatusUnauthorized, "access token required"
+	}
+
+	session, err := dividend.FetchToken(h.db, []byte(cookie.Value))
+	if err != nil || session.Purpose != dividend.Session {
+		return "", "", http.StatusUnauthorized, "session revoked"
+	}
+
+	if session.Expired() {
+		session.Delete(h.db)
+		return "", "", http.StatusUnauthorized, "session expired"
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if !hmac.Equal([]byte(r.Header.Get(csrfHeader)),
+			[]byte(h.csrfToken(session.UUID))) {
+			return "", "", http.StatusForbidden, "invalid csrf token"
+		}
+	}
+
+	return session.Account, session.UUID, http.StatusOK, ""
+}
+
+// webSessionRequest asserts the provided request is authenticated by its web
+// session cookie rather than an access token or api key.
+func webSessionRequest(r *http.Request) bool {
+	return bearerToken(r) == "" && r.Header.Get("X-API-Key") == ""
+}
+
+// authenticateSession returns the ids of the account and session the access
+// token of the provided request was issued for. Requests without an access
+// token are authenticated by their web session cookie, if any.
+func (h *Hub) authenticateSession(r *http.Request) (string, string, int, string) {
+	accessToken := bearerToken(r)
+	if accessToken == "" {
+		return h.authenticateWebSession(r)
+	}
+
+	var claims sessionClaims
+	err := util.VerifyJWT(accessToken, h.sessionKey, &claims)
+	if err != nil {
+		return "", "", http.StatusUnauthorized, "invalid access token"
+	}
+
+	if time.Now().Unix() > claims.ExpiresAt {
+		return "", "", http.StatusUnauthorized, "access token expired"
+	}
+
+	session, err := dividend.FetchToken(h.db, []byte(claims.Session))
+	if err != nil || session.Purpose != dividend.Session ||
+		session.Account != claims.Subject {
+		return "", "", http.StatusUnauthorized, "session revoked"
+	}
+
+	if session.Expired() {
+		session.Delete(h.db)
+		return "", "", http.StatusUnauthorized, "session expired"
+	}
+
+	return session.Account, session.UUID, http.StatusOK, ""
+}
+
+// AccountAuth wraps account session authentication as request middleware.
+// Requests are expected to provide a session access token as a bearer
+// token, or the cookie and csrf token of a web session.
+func (h *Hub) AccountAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		account, session, code, msg := h.authenticateSession(r)
+		if code != http.StatusOK {
+			RespondWithError(w, code, msg)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), accountIDKey, account)
+		ctx = context.WithValue(ctx, sessionIDKey, session)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// KeyAuth wraps account authentication by either a session token or an api
+// key as request middleware. Api keys are expected in the X-API-Key header
+// and only grant access to handlers wrapped with a scope they were issued.
+func (h *Hub) KeyAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		full := r.Header.Get("X-API-Key")
+		if full == "" {
+			h.AccountAuth(next).ServeHTTP(w, r)
+			return
+		}
+
+		key, err := dividend.AuthenticateAPIKey(h.db, full)
+		if err != nil {
+			RespondWithError(w, http.StatusUnauthorized, "invalid api key")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), accountIDKey, key.Account)
+		ctx = context.WithValue(ctx, apiKeyKey, key)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// WithScope wraps the provided handler so requests authenticated by an api
+// key are only served if the key was issued the provided scope. Requests
+// authenticated by a session are always served.
+func (h *Hub) WithScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := r.Context().Value(apiKeyKey).(*dividend.APIKey)
+		if ok && !key.HasScope(scope) {
+			RespondWithError(w, http.StatusForbidden,
+				fmt.Sprintf("api key lacks the '%v' scope", scope))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// AdminAuth wraps operator authentication as request middleware. Operators
+// authenticate using basic auth with the configured admin password, the
+// username provided identifies the operator.
+func (h *Hub) AdminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.cfg.AdminPass == "" {
+			RespondWithError(w, http.StatusForbidden, "admin api disabled")
+			return
+		}
+
+		operator, pass, ok := r.BasicAuth()
+		if !ok || operator == "" || subtle.ConstantTimeCompare([]byte(pass),
+			[]byte(h.cfg.AdminPass)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="dcrpool admin"`)
+			RespondWithError(w, http.StatusUnauthorized, "unauthorized access")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), operatorKey, operator)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+func TestWebSessionCookie(t *testing.T) {
+	rec := httptest.NewRecorder()
+	setSessionCookie(rec, &dividend.Token{UUID: "x", ExpiresOn: 1})
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected a session cookie, got %v", len(cookies))
+	}
+
+	cookie := cookies[0]
+	if cookie.Name != sessionCookie || cookie.Value != "x" ||
+		!cookie.HttpOnly || !cookie.Secure ||
+		cookie.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("unexpected session cookie %+v", cookie)
+	}
+
+	h := &Hub{sessionKey: []byte("key")}
+	if h.csrfToken("x") == h.csrfToken("y") {
+		t.Fatal("expected csrf tokens to differ between sessions")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/account/logout", nil)
+	_, _, code, _ := h.authenticateSession(req)
+	if code != http.StatusUnauthorized {
+		t.Fatalf("expected requests without a session to be "+
+			"unauthorized, got %v", code)
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/wire"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// shareStatus is the outcome of a share submitted by a stratum client.
+type shareStatus uint32
+
+const (
+	// shareAccepted is the status of shares meeting the pool target.
+	shareAccepted shareStatus = iota
+
+	// shareRejected is the status of shares rejected without counting
+	// against the worker of the client, such as rate limited submissions.
+	shareRejected
+
+	// shareInvalid is the status of malformed or low difficulty shares.
+	shareInvalid
+
+	// shareStale is the status of shares of unknown or expired jobs.
+	shareStale
+)
+
+// errDuplicateWork is returned when a solved block was already submitted.
+var errDuplicateWork = errors.New("work already submitted")
+
+// clientShare is a share submitted by a stratum client.
+type clientShare struct {
+	account    string
+	worker     string
+	miner      string
+	status     shareStatus
+	hashRate   *big.Rat
+	difficulty *big.Int
+	height     uint32
+	block      bool
+}
+
+// workerAuth is the authorization of a worker of an account.
+type workerAuth struct {
+	account    string
+	worker     string
+	difficulty *big.Int
+}
+
+// stratumBackend persists the work of stratum clients and submits the
+// blocks they solve. The hub of a pool is the backend of its own clients,
+// the hub of a stratum frontend relays the work of its clients to the pool
+// backend over the frontend RPC.
+type stratumBackend interface {
+	// bannedIP returns whether the provided ip address is banned.
+	bannedIP(ip string) bool
+
+	// authorizeWorker authorizes the named worker of the account of the
+	// provided name and address, creating both if needed. The connection
+	// of the worker is recorded with the region of its endpoint.
+	authorizeWorker(name, address, workerName, ip, userAgent, region string) (*workerAuth, *StratumError)
+
+	// disconnectWorker records the disconnection of the provided worker.
+	disconnectWorker(worker, ip string)
+
+	// fetchJob fetches the job of the provided id.
+	fetchJob(id string) (*Job, error)
+
+	// recordShare records the provided share, it errors when an accepted
+	// share could not be persisted.
+	recordShare(share *clientShare) error
+
+	// submitBlock submits the provided solved block header found by the
+	// provided account, it returns whether the network accepted the block.
+	submitBlock(header *wire.BlockHeader, account, miner string) (bool, error)
+}
+
+// bannedIP returns whether the provided ip address is banned by an operator
+// or by configuration.
+func (h *Hub) bannedIP(ip string) bool {
+	return IsBanned(h.db, ip) || h.configBanned(ip)
+}
+
+// fetchWorker fetches the worker of the provided account with the provided
+// name, creating it if it does not already exist.
+func (h *Hub) fetchWorker(account string, name string) (*dividend.Worker, error) {
+	worker, err := dividend.FetchWorkerByName(h.db, account, name)
+	if err == nil {
+		return worker, nil
+	}
+
+	if err.Error() != database.ErrValueNotFound([]byte(name)).Error() {
+		return nil, err
+	}
+
+	worker, err = dividend.NewWorker(account, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = worker.Create(h.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return worker, nil
+}
+
+// authorizeWorker authorizes the named worker of the account of the provided
+// name and address, creating both if needed. Suspended accounts are refused.
+func (h *Hub) authorizeWorker(name, address, workerName, ip, userAgent, region string) (*workerAuth, *StratumError) {
+	account, err := dividend.FetchAccountByName(h.db, name, address,
+		h.cfg.CaseInsensitive)
+	if err != nil {
+		if err.Error() != dividend.ErrAccountNameNotFound(name,
+			address).Error() {
+			stratumLog.Errorf("unable to fetch account: %v", err)
+			return nil, NewStratumError(Unknown, nil)
+		}
+
+		// Create the account if it does not already exist.
+		account, err = dividend.NewAccount(name, address)
+		if err != nil {
+			stratumLog.Errorf("unable to create account: %v", err)
+			return nil, NewStratumError(Unknown, nil)
+		}
+
+		err = account.Create(h.db)
+		if err != nil {
+			stratumLog.Errorf("unable to persist account: %v", err)
+			return nil, NewStratumError(Unknown, nil)
+		}
+	}
+
+	if account.Suspended() {
+		stratumLog.Errorf("Rejecting authorization for suspended account (%v)",
+			account.UUID)
+		serr := NewStratumError(UnauthorizedWorker, nil)
+		serr.Message = fmt.Sprintf("Account suspended: %v",
+			account.SuspendReason)
+		return nil, serr
+	}
+
+	worker, err := h.fetchWorker(account.UUID, workerName)
+	if err != nil {
+		stratumLog.Errorf("unable to fetch worker: %v", err)
+		return nil, NewStratumError(Unknown, nil)
+	}
+
+	err = dividend.RecordWorkerConnect(h.db, worker.UUID, ip, userAgent,
+		region)
+	if err != nil {
+		stratumLog.Errorf("failed to record connection of worker (%v): %v",
+			worker.UUID, err)
+	}
+
+	return &workerAuth{
+		account:    account.UUID,
+		worker:     worker.UUID,
+		difficulty: account.Difficulty,
+	}, nil
+}
+
+// disconnectWorker records the disconnection of the provided worker.
+func (h *Hub) disconnectWorker(worker, ip string) {
+	err := dividend.RecordWorkerDisconnect(h.db, worker, ip)
+	if err != nil {
+		stratumLog.Errorf("failed to record disconnection of worker (%v): %v",
+			worker, err)
+	}
+}
+
+// fetchJob fetches the job of the provided id.
+func (h *Hub) fetchJob(id string) (*Job, error) {
+	return FetchJob(h.db, []byte(id))
+}
+
+// claimWeightedShare records a weighted share of the provided share's
+// account. This serves as proof of verifiable work contributed to the
+// mining pool.
+func (h *Hub) claimWeightedShare(share *clientShare) error {
+	if h.cfg.ActiveNet.Name == chaincfg.MainNetParams.Name &&
+		share.miner == dividend.CPU {
+		stratumLog.Error("CPU miners are reserved for only simnet testing purposes")
+		return nil
+	}
+
+	weight := dividend.ShareWeights[share.miner]
+	if weight == nil {
+		return fmt.Errorf("share weight not found for miner (%s)", share.miner)
+	}
+
+	// Shares of clients mining at a preferred account difficulty are
+	// weighted relative to the default difficulty of their miner type.
+	h.poolDiffMtx.RLock()
+	defaultDiff := h.poolDiff[share.miner]
+	h.poolDiffMtx.RUnlock()
+	if defaultDiff != nil &&
+		share.difficulty.Cmp(defaultDiff.difficulty) != 0 {
+		weight = new(big.Rat).Mul(weight, new(big.Rat).SetFrac(
+			share.difficulty, defaultDiff.difficulty))
+	}
+
+	err := dividend.NewShare(share.account, weight).Create(h.db)
+	if err != nil {
+		return err
+	}
+
+	stratumLog.Tracef("Weighted share of (%v) for account (%v) claimed",
+		weight, share.account)
+	return nil
+}
+
+// recordShare records the provided share. Accepted shares are claimed as
+// weighted shares of their account when not in solo pool mode and added to
+// the work of the round, rejected shares count against their worker.
+func (h *Hub) recordShare(share *clientShare) error {
+	if share.status != shareAccepted {
+		h.metrics.recordShare(share.account, false)
+		if share.worker == "" || share.status == shareRejected {
+			return nil
+		}
+
+		err := dividend.RecordWorkerRejectedShare(h.db, share.worker,
+			share.status == shareStale)
+		if err != nil {
+			stratumLog.Errorf("failed to update worker (%v): %v",
+				share.worker, err)
+		}
+		return nil
+	}
+
+	if !h.cfg.SoloPool {
+		err := h.claimWeightedShare(share)
+		if err != nil {
+			return fmt.Errorf("failed to persist weighted share: %v", err)
+		}
+
+		recovered, err := dividend.RecordWorkerShare(h.db, share.worker,
+			share.hashRate, share.difficulty)
+		if err != nil {
+			stratumLog.Errorf("failed to update worker (%v): %v",
+				share.worker, err)
+		}
+
+		if recovered {
+			go h.notifyWorkerRecovered(share.worker)
+		}
+
+		h.publish(share.account, EventShare, map[string]interface{}{
+			"worker":     share.worker,
+			"difficulty": share.difficulty,
+			"height":     share.height,
+		})
+	}
+
+	err := dividend.AddRoundWork(h.db, share.difficulty)
+	if err != nil {
+		stratumLog.Errorf("failed to update round work: %v", err)
+	}
+
+	h.metrics.recordShare(share.account, true)
+
+	if !share.block && h.cfg.InstantMine {
+		h.queueInstantMine(share.account, share.miner)
+	}
+
+	return nil
+}
+
+// submitBlock submits the provided solved block header found by the provided
+// account to the network and records the effort of the round it ends. It
+// returns whether the network accepted the block.
+func (h *Hub) submitBlock(header *wire.BlockHeader, account, miner string) (bool, error) {
+	// Persist the accepted work before submiting to the network. This is
+	// a workaround in order to have an accepted work record available
+	// when a block connected notification is received.
+	hash := header.BlockHash()
+	work := NewAcceptedWork(hash.String(), header.PrevBlock.String(),
+		header.Height, account, miner)
+	err := work.Create(h.db)
+	if err != nil {
+		// If the submitted accetped work already exists, ignore the submission.
+		if err.Error() == ErrWorkAlreadyExists([]byte(work.UUID)).Error() {
+			return false, errDuplicateWork
+		}
+
+		return false, fmt.Errorf("unable to persist accepted work: %v", err)
+	}
+
+	// Generate and send the work submission.
+	headerB, err := header.Bytes()
+	if err != nil {
+		return false, fmt.Errorf("unable to fetch block header bytes: %v", err)
+	}
+
+	submissionB := make([]byte, getworkDataLen)
+	copy(submissionB[:wire.MaxBlockHeaderPayload], headerB)
+	copy(submissionB[wire.MaxBlockHeaderPayload:], h.blake256Pad)
+	submission := hex.EncodeToString(submissionB)
+	accepted, err := h.SubmitWork(&submission)
+	if err != nil {
+		return false, fmt.Errorf("unable to submit work request: %v", err)
+	}
+
+	stratumLog.Tracef("Work accepted status is: %v", accepted)
+
+	// Remove the work record if it is not accepted by the network.
+	if !accepted {
+		work.Delete(h.db)
+		return false, nil
+	}
+
+	// Record the effort of the round ended by the accepted work.
+	roundWork, err := dividend.EndRound(h.db)
+	if err != nil {
+		stratumLog.Errorf("unable to end round: %v", err)
+		return true, nil
+	}
+
+	work.Effort = dividend.RoundEffort(h.cfg.ActiveNet, roundWork, header.Bits)
+	err = work.Update(h.db)
+	if err != nil {
+		stratumLog.Errorf("unable to record round effort: %v", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// ErrIPNotBanned is returned when the provided ip address is not banned.
+func ErrIPNotBanned(ip string) error {
+	return fmt.Errorf("ip address '%v' is not banned", ip)
+}
+
+// IPBan represents an ip address banned from connecting to the pool
+// endpoints by an operator.
+type IPBan struct {
+	IP        string `json:"ip"`
+	Reason    string `json:"reason"`
+	Operator  string `json:"operator"`
+	CreatedOn int64  `json:"createdon"`
+}
+
+// NewIPBan creates a ban of the provided ip address.
+func NewIPBan(ip string, reason string, operator string) *IPBan {
+	return &IPBan{
+		IP:        ip,
+		Reason:    reason,
+		Operator:  operator,
+		CreatedOn: time.Now().Unix(),
+	}
+}
+
+// hostIP returns the ip address of the provided host:port address.
+func hostIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// FetchIPBan fetches the ban of the provided ip address.
+func FetchIPBan(db *bolt.DB, ip string) (*IPBan, error) {
+	var ban IPBan
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.BanBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.BanBkt)
+		}
+		v := bkt.Get([]byte(ip))
+		if v == nil {
+			return ErrIPNotBanned(ip)
+		}
+		return json.Unmarshal(v, &ban)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ban, nil
+}
+
+// IsBanned returns whether the provided ip address is banned.
+func IsBanned(db *bolt.DB, ip string) bool {
+	_, err := FetchIPBan(db, ip)
+	return err == nil
+}
+
+// ListIPBans returns all banned ip addresses.
+func ListIPBans(db *bolt.DB) ([]*IPBan, error) {
+	bans := make([]*IPBan, 0)
+	err := db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.BanBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.BanBkt)
+		}
+
+		return bkt.ForEach(func(k, v []byte) error {
+			var ban IPBan
+			err := json.Unmarshal(v, &ban)
+			if err != nil {
+				return err
+			}
+
+			bans = append(bans, &ban)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bans, nil
+}
+
+// Create persists the ban to the database, replacing any existing ban of
+// the ip address.
+func (ban *IPBan) Create(db *bolt.DB) error {
+	err := db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+		bkt := pbkt.Bucket(database.BanBkt)
+		if bkt == nil {
+			return database.ErrBucketNotFound(database.BanBkt)
+		}
+
+		banBytes, err := json.Marshal(ban)
+		if err != nil {
+			return err
+		}
+
+		return bkt.Put([]byte(ban.IP), banBytes)
+	})
+	return err
+}
+
+// Update is not supported for bans.
+func (ban *IPBan) Update(db *bolt.DB) error {
+	return dividend.ErrNotSupported("ip ban", "update")
+}
+
+// Delete lifts the ban.
+func (ban *IPBan) Delete(db *bolt.DB) error {
+	return database.Delete(db, database.BanBkt, []byte(ban.IP))
+}
+
+// disconnectIP terminates all connected clients of the provided ip address
+// and returns the number of clients disconnected.
+func (h *Hub) disconnectIP(ip string) uint32 {
+	var disconnected uint32
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			if hostIP(client.ip) == ip {
+				client.cancel()
+				disconnected++
+			}
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	return disconnected
+}
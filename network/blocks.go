@@ -0,0 +1,135 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// foundBlock is a block found by the pool as listed publicly. The finder is
+// anonymized unless the account opted in to being named.
+type foundBlock struct {
+	Height        uint32         `json:"height"`
+	BlockHash     string         `json:"blockhash"`
+	CreatedOn     int64          `json:"createdon"`
+	Reward        dcrutil.Amount `json:"reward"`
+	Finder        string         `json:"finder"`
+	Confirmed     bool           `json:"confirmed"`
+	Status        string         `json:"status"`
+	Confirmations uint32         `json:"confirmations"`
+	Mature        bool           `json:"mature"`
+	Explorer      string         `json:"explorer,omitempty"`
+}
+
+// anonymizedFinder returns the pseudonym of the provided account in public
+// block listings. It is stable, so blocks found by the same account can be
+// told apart from others without revealing the account.
+func anonymizedFinder(accountID string) string {
+	sum := sha256.Sum256([]byte(accountID))
+	return "miner-" + hex.EncodeToString(sum[:4])
+}
+
+// foundBlocks returns the public listing of the provided mined work.
+func (h *Hub) foundBlocks(work []*AcceptedWork) []*foundBlock {
+	tip := atomic.LoadUint32(&h.lastWorkHeight)
+	if tip > 0 {
+		// Work is received for the block after the chain tip.
+		tip--
+	}
+
+	finders := make(map[string]string)
+	blocks := make([]*foundBlock, 0, len(work))
+	for _, w := range work {
+		finder, ok := finders[w.MinedBy]
+		if !ok && w.MinedBy != "" {
+			finder = anonymizedFinder(w.MinedBy)
+			account, err := dividend.FetchAccount(h.db, []byte(w.MinedBy))
+			if err == nil && account.PublicFinder && account.ErasedOn == 0 {
+				finder = account.Name
+			}
+			finders[w.MinedBy] = finder
+		}
+
+		block := &foundBlock{
+			Height:    w.Height,
+			BlockHash: w.BlockHash,
+			CreatedOn: w.CreatedOn,
+			Reward:    w.Reward,
+			Finder:    finder,
+			Confirmed: w.Confirmed,
+			Status:    w.Status,
+		}
+
+		if tip >= w.Height {
+			block.Confirmations = tip - w.Height + 1
+		}
+		block.Mature = block.Confirmations >
+			uint32(h.cfg.ActiveNet.CoinbaseMaturity)
+		if block.Mature {
+			block.Status = WorkMature
+		}
+
+		if h.cfg.ExplorerURL != "" {
+			block.Explorer = h.cfg.ExplorerURL + "/block/" + w.BlockHash
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
+
+// blocksPageData is the data rendered by the blocks found page.
+type blocksPageData struct {
+	Network string
+	Blocks  []*foundBlock
+	Next    string
+}
+
+// BlocksPage renders a page of the blocks found by the pool, most recent
+// first, accepting the pagination parameters of the blocks api.
+func (h *Hub) BlocksPage(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	work, next, err := ListMinedWorkPage(h.db, "", query)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if err.Error() == database.ErrInvalidCursor().Error() {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	data := blocksPageData{
+		Network: h.cfg.ActiveNet.Name,
+		Blocks:  h.foundBlocks(work),
+	}
+
+	if next != "" {
+		values := r.URL.Query()
+		values.Set("cursor", next)
+		data.Next = (&url.URL{Path: r.URL.Path,
+			RawQuery: values.Encode()}).String()
+	}
+
+	err = h.renderPage(w, r, blocksPage, data)
+	if err != nil {
+		log.Errorf("Failed to render blocks page: %v", err)
+	}
+}
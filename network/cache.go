@@ -0,0 +1,190 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// apiCacheTTL is how long stats api responses are cached and may be
+	// reused by clients.
+	apiCacheTTL = time.Second * 15
+
+	// maxCachedResponses is the number of distinct requests cached at once,
+	// responses of requests beyond it are not cached.
+	maxCachedResponses = 1000
+)
+
+// responseRecorder records the response of a handler.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header returns the recorded response headers.
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+// Write records the provided response body bytes.
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(b)
+}
+
+// WriteHeader records the response status code.
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+// cachedResponse is a response shared by requests for the same resource
+// until it expires. It is ready once the response is recorded.
+type cachedResponse struct {
+	ready   chan struct{}
+	status  int
+	header  http.Header
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+// expired returns whether the response is ready and has expired.
+func (c *cachedResponse) expired(now time.Time) bool {
+	select {
+	case <-c.ready:
+		return now.After(c.expires)
+	default:
+		return false
+	}
+}
+
+// responseETag returns the entity tag of the provided response body.
+func responseETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatch returns whether the provided If-None-Match header matches the
+// provided entity tag, weak comparison is used.
+func etagMatch(header string, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cachedResponse returns the cached response of the provided key and whether
+// the caller is expected to record it. Expired responses are replaced and
+// expired entries pruned when the cache is full.
+func (h *Hub) cachedResponse(key string) (*cachedResponse, bool) {
+	h.respCacheMtx.Lock()
+	defer h.respCacheMtx.Unlock()
+
+	now := time.Now()
+	entry, ok := h.respCache[key]
+	if ok && !entry.expired(now) {
+		return entry, false
+	}
+
+	if ok {
+		delete(h.respCache, key)
+	}
+
+	if len(h.respCache) >= maxCachedResponses {
+		for k, v := range h.respCache {
+			if v.expired(now) {
+				delete(h.respCache, k)
+			}
+		}
+	}
+
+	entry = &cachedResponse{ready: make(chan struct{})}
+	if len(h.respCache) < maxCachedResponses {
+		h.respCache[key] = entry
+	}
+
+	return entry, true
+}
+
+// Cached serves the responses of the provided stats handler from a short
+// lived cache shared by all clients, concurrent requests for an uncached
+// resource wait for a single response. Responses carry an entity tag and are
+// revalidated with If-None-Match. Only successful responses are cached.
+func (h *Hub) Cached(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.RequestURI()
+		entry, record := h.cachedResponse(key)
+		if record {
+			rec := &responseRecorder{header: make(http.Header)}
+			next(rec, r)
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			entry.status = rec.status
+			entry.header = rec.header
+			entry.body = rec.body.Bytes()
+			entry.etag = responseETag(entry.body)
+			entry.expires = time.Now().Add(apiCacheTTL)
+			close(entry.ready)
+
+			if entry.status != http.StatusOK {
+				h.respCacheMtx.Lock()
+				if h.respCache[key] == entry {
+					delete(h.respCache, key)
+				}
+				h.respCacheMtx.Unlock()
+			}
+		}
+
+		select {
+		case <-entry.ready:
+		case <-r.Context().Done():
+			return
+		}
+
+		for k, v := range entry.header {
+			w.Header()[k] = v
+		}
+
+		if entry.status != http.StatusOK {
+			w.WriteHeader(entry.status)
+			w.Write(entry.body)
+			return
+		}
+
+		maxAge := int(time.Until(entry.expires).Seconds())
+		if maxAge < 0 {
+			maxAge = 0
+		}
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d",
+			maxAge))
+		w.Header().Set("ETag", entry.etag)
+
+		if etagMatch(r.Header.Get("If-None-Match"), entry.etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(entry.status)
+		w.Write(entry.body)
+	}
+}
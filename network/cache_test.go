@@ -0,0 +1,90 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETagMatch(t *testing.T) {
+	etag := `"abc"`
+	tests := []struct {
+		header   string
+		expected bool
+	}{
+		{"", false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`"xyz"`, false},
+		{"*", true},
+	}
+
+	for i, test := range tests {
+		if etagMatch(test.header, etag) != test.expected {
+			t.Fatalf("test %d: expected match %v for %q", i,
+				test.expected, test.header)
+		}
+	}
+}
+
+func TestCached(t *testing.T) {
+	h := &Hub{respCache: make(map[string]*cachedResponse)}
+	calls := 0
+	handler := h.Cached(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("fail") != "" {
+			RespondWithError(w, http.StatusBadRequest, "failed")
+			return
+		}
+		RespondWithJSON(w, http.StatusOK, map[string]int{"calls": calls})
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/pool", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" ||
+		w.Header().Get("Cache-Control") == "" {
+		t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+	}
+	body := w.Body.String()
+
+	// Cached responses are reused.
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/pool", nil))
+	if calls != 1 || w.Body.String() != body {
+		t.Fatalf("expected a cached response, got %v calls", calls)
+	}
+
+	// Revalidated responses are not resent.
+	r := httptest.NewRequest("GET", "/api/v1/pool", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler(w, r)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("expected not modified, got %v", w.Code)
+	}
+
+	// Requests are cached by query.
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/v1/pool?limit=1", nil))
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %v", calls)
+	}
+
+	// Errors are not cached.
+	for i := 0; i < 2; i++ {
+		w = httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/api/v1/pool?fail=1", nil))
+		if w.Code != http.StatusBadRequest || w.Header().Get("ETag") != "" {
+			t.Fatalf("unexpected response %v %v", w.Code, w.Header())
+		}
+	}
+	if calls != 4 {
+		t.Fatalf("expected 4 calls, got %v", calls)
+	}
+}
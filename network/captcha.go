@@ -0,0 +1,55 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// verifyCaptcha asserts the provided captcha response is valid using the
+// configured verification endpoint, which is expected to implement the
+// siteverify api shared by reCAPTCHA and hCaptcha. Verification is skipped
+// when no endpoint is configured.
+func (h *Hub) verifyCaptcha(response string, ip string) error {
+	if h.cfg.CaptchaURL == "" {
+		return nil
+	}
+
+	if response == "" {
+		return fmt.Errorf("captcha response required")
+	}
+
+	resp, err := h.httpc.PostForm(h.cfg.CaptchaURL, url.Values{
+		"secret":   {h.cfg.CaptchaSecret},
+		"response": {response},
+		"remoteip": {ip},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to verify captcha: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to verify captcha: unexpected status %v",
+			resp.StatusCode)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return fmt.Errorf("unable to verify captcha: %v", err)
+	}
+
+	if !result.Success {
+		return fmt.Errorf("invalid captcha response")
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/binary"
+
+	bolt "github.com/coreos/bbolt"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// fetchProcessedHeight returns the height of the last connected block
+// processed by the pool, zero if none was processed yet.
+func (h *Hub) fetchProcessedHeight() (uint32, error) {
+	var height uint32
+	err := h.db.View(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+
+		v := pbkt.Get(database.LastProcessedHeight)
+		if v != nil {
+			height = binary.LittleEndian.Uint32(v)
+		}
+		return nil
+	})
+	return height, err
+}
+
+// storeProcessedHeight persists the height of the last connected block
+// processed by the pool.
+func (h *Hub) storeProcessedHeight(height uint32) error {
+	return h.db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+
+		hbytes := make([]byte, 4)
+		binary.LittleEndian.PutUint32(hbytes, height)
+		return pbkt.Put(database.LastProcessedHeight, hbytes)
+	})
+}
+
+// catchUp reconciles the blocks connected while the pool was down, from the
+// last processed height to the chain tip. Blocks found by the pool are
+// credited and confirmed or disapproved per the votes of the blocks
+// building on them, the confirmations of found blocks and payouts are
+// updated and matured payments are processed.
+func (h *Hub) catchUp() {
+	last, err := h.fetchProcessedHeight()
+	if err != nil {
+		chainLog.Errorf("Failed to fetch processed height: %v", err)
+		return
+	}
+
+	h.rpccMtx.Lock()
+	tip, err := h.rpcc.GetBlockCount()
+	h.rpccMtx.Unlock()
+	if err != nil {
+		chainLog.Errorf("Failed to fetch chain tip for catch up: %v", err)
+		return
+	}
+
+	// Nothing to reconcile on first start, blocks are processed from the
+	// current tip onwards.
+	if last == 0 || uint32(tip) <= last {
+		err := h.storeProcessedHeight(uint32(tip))
+		if err != nil {
+			chainLog.Errorf("Failed to persist processed height: %v", err)
+		}
+		return
+	}
+
+	chainLog.Infof("Catching up on blocks %v to %v connected while the pool "+
+		"was down", last+1, tip)
+
+	for height := int64(last + 1); height <= tip; height++ {
+		h.rpccMtx.Lock()
+		hash, err := h.rpcc.GetBlockHash(height)
+		if err != nil {
+			h.rpccMtx.Unlock()
+			chainLog.Errorf("Failed to fetch block hash at height %v: %v",
+				height, err)
+			return
+		}
+		header, err := h.rpcc.GetBlockHeader(hash)
+		h.rpccMtx.Unlock()
+		if err != nil {
+			chainLog.Errorf("Failed to fetch block header %v: %v", hash, err)
+			return
+		}
+
+		h.creditConnectedWork(header)
+		h.confirmParentWork(header)
+	}
+
+	h.trackMinedWork(uint32(tip))
+
+	if !h.cfg.SoloPool {
+		payouts, err := dividend.FetchUnconfirmedPayouts(h.db)
+		if err != nil {
+			chainLog.Errorf("Failed to fetch unconfirmed payouts: %v", err)
+		}
+		h.trackPayouts(payouts)
+
+		err = h.ProcessPayments(uint32(tip))
+		if err != nil {
+			chainLog.Errorf("Failed to process payments: %v", err)
+		}
+	}
+
+	err = h.storeProcessedHeight(uint32(tip))
+	if err != nil {
+		chainLog.Errorf("Failed to persist processed height: %v", err)
+		return
+	}
+
+	chainLog.Infof("Caught up on %v blocks connected while the pool was down",
+		uint32(tip)-last)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// networkStats are the statistics of the network at the height of the
+// current work.
+type networkStats struct {
+	Height       uint32                 `json:"height"`
+	Difficulty   float64                `json:"difficulty"`
+	HashRate     string                 `json:"hashrate"`
+	PoolHashRate string                 `json:"poolhashrate"`
+	HashRateUnit string                 `json:"hashrateunit"`
+	PoolShare    float64                `json:"poolshare"`
+	Subsidy      *dividend.BlockSubsidy `json:"subsidy,omitempty"`
+}
+
+// networkStats returns the network difficulty and hash rate estimated from
+// the target of the current work, the share of the network hash rate mined
+// by the pool and the subsidy split of the block being mined.
+func (h *Hub) networkStats() *networkStats {
+	height := atomic.LoadUint32(&h.lastWorkHeight)
+	bits := atomic.LoadUint32(&h.lastWorkBits)
+
+	tera := new(big.Rat).SetInt(teraHash)
+	netHashRate := dividend.NetworkHashRate(h.cfg.ActiveNet, bits)
+	poolHashRate := h.hashRate("")
+	stats := &networkStats{
+		Height:       height,
+		Difficulty:   dividend.NetworkDifficulty(h.cfg.ActiveNet, bits),
+		HashRate:     new(big.Rat).Quo(netHashRate, tera).FloatString(6),
+		PoolHashRate: poolHashRate.FloatString(6),
+		HashRateUnit: hashRateUnit,
+	}
+
+	if netHashRate.Sign() > 0 {
+		share := new(big.Rat).Mul(poolHashRate, tera)
+		stats.PoolShare, _ = share.Quo(share, netHashRate).Float64()
+	}
+
+	if height > 0 {
+		stats.Subsidy = dividend.CalcBlockSubsidy(h.subsidyCache,
+			h.cfg.ActiveNet, h.activeSubsidySplit(), height)
+	}
+
+	return stats
+}
+
+// APINetwork returns the network difficulty and hash rate, the share of the
+// network hash rate mined by the pool and the subsidy split of the block
+// being mined.
+func (h *Hub) APINetwork(w http.ResponseWriter, r *http.Request) {
+	RespondWithJSON(w, http.StatusOK, h.networkStats())
+}
+
+// indexPageData is the data rendered by the front page.
+type indexPageData struct {
+	Network     string
+	SoloPool    bool
+	Connections int
+	TimeToBlock time.Duration
+	PoolShare   float64
+	Regions     []*regionInfo
+	Stats       *networkStats
+	Events      []indexEvent
+}
+
+// indexEvent is a recent pool event listed on the front page.
+type indexEvent struct {
+	Time   time.Time
+	Type   string
+	Detail string
+}
+
+// IndexPage renders the front page, an overview of the pool and the
+// network.
+func (h *Hub) IndexPage(w http.ResponseWriter, r *http.Request) {
+	connections := 0
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		connections += len(endpoint.clients)
+		endpoint.clientsMtx.Unlock()
+	}
+
+	stats := h.networkStats()
+	data := indexPageData{
+		Network:     h.cfg.ActiveNet.Name,
+		SoloPool:    h.cfg.SoloPool,
+		Connections: connections,
+		TimeToBlock: h.timeToBlockDuration(),
+		PoolShare:   stats.PoolShare * 100,
+		Regions:     h.regionInfo(),
+		Stats:       stats,
+	}
+
+	events, _, err := dividend.ListPoolEvents(h.db,
+		poolEventFilter(r, false), &dividend.PageQuery{Limit: indexEvents})
+	if err != nil {
+		chainLog.Errorf("Failed to list pool events: %v", err)
+	}
+
+	for _, event := range events {
+		data.Events = append(data.Events, indexEvent{
+			Time:   time.Unix(0, event.CreatedOn).UTC(),
+			Type:   event.Type,
+			Detail: event.Detail,
+		})
+	}
+
+	err = h.renderPage(w, r, indexPage, data)
+	if err != nil {
+		chainLog.Errorf("Failed to render front page: %v", err)
+	}
+}
@@ -16,12 +16,9 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/decred/dcrd/chaincfg"
-
-	"github.com/decred/dcrd/wire"
-
 	"github.com/decred/dcrd/blockchain"
 
 	"github.com/davecgh/go-spew/spew"
@@ -39,6 +36,9 @@ const (
 
 // Client represents a client connection.
 type Client struct {
+	accepted uint32 // update atomically
+	rejected uint32 // update atomically
+
 	conn               net.Conn
 	endpoint           *Endpoint
 	encoder            *json.Encoder
@@ -46,12 +46,16 @@ type Client struct {
 	ctx                context.Context
 	cancel             context.CancelFunc
 	ip                 string
+	connectedOn        int64
 	extraNonce1        string
+	userAgent          string
 	ch                 chan Message
 	readCh             chan []byte
 	req                map[uint64]string
 	reqMtx             sync.RWMutex
 	account            string
+	worker             string
+	diffData           *DifficultyData
 	authorized         bool
 	subscribed         bool
 	lastSubmissionTime *big.Int
@@ -73,6 +77,8 @@ func NewClient(conn net.Conn, endpoint *Endpoint, ip string) *Client {
 		encoder:            json.NewEncoder(conn),
 		reader:             bufio.NewReaderSize(conn, MaxMessageSize),
 		ip:                 ip,
+		connectedOn:        time.Now().Unix(),
+		diffData:           endpoint.difficultyData(),
 		lastSubmissionTime: zeroInt,
 		hashRate:           zeroRat,
 	}
@@ -88,10 +94,15 @@ func (c *Client) generateID() string {
 }
 
 // GenerateExtraNonce1 generates a random 4-byte extraNonce1 for the
-// client.
+// client. The first byte is the extranonce1 prefix of the hub when it serves
+// clients alongside stratum frontends, keeping the search spaces of clients
+// of different frontends apart.
 func (c *Client) GenerateExtraNonce1() {
 	id := make([]byte, 4)
 	rand.Read(id)
+	if prefix := c.endpoint.hub.extraNonce1Prefix(); prefix >= 0 {
+		id[0] = byte(prefix)
+	}
 	c.extraNonce1 = hex.EncodeToString(id)
 }
 
@@ -110,7 +121,11 @@ func (c *Client) shutdown() {
 	close(c.readCh)
 	c.endpoint.hub.limiter.RemoveLimiter(c.ip)
 	c.endpoint.RemoveClient(c)
-	log.Tracef("Connection to (%v) terminated.", c.generateID())
+	c.endpoint.hub.conns.release(hostIP(c.ip))
+	if c.worker != "" {
+		c.endpoint.hub.backend.disconnectWorker(c.worker, c.ip)
+	}
+	stratumLog.Tracef("Connection to (%v) terminated.", c.generateID())
 }
 
 // calculateHashRate generates the client hash rate based on work submissions
@@ -123,7 +138,7 @@ func (c *Client) calculateHashRate() error {
 	}
 
 	expectedShareTime := new(big.Int).Add(c.lastSubmissionTime,
-		c.endpoint.hub.cfg.MaxGenTime)
+		c.endpoint.hub.maxGenTime())
 	diff := new(big.Rat).SetFrac(now, expectedShareTime)
 	hash := dividend.MinerHashes[c.endpoint.miner]
 	if hash == nil {
@@ -138,40 +153,28 @@ func (c *Client) calculateHashRate() error {
 		Mul(diff, new(big.Rat).SetInt(hash)),
 		new(big.Rat).SetInt(teraHash))
 
-	log.Tracef("hash rate of (%v) is %v", c.generateID(),
+	stratumLog.Tracef("hash rate of (%v) is %v", c.generateID(),
 		c.hashRate.FloatString(12))
 	c.hashRateMtx.Unlock()
 
 	return nil
 }
 
-// claimWeightedShare records a weighted share for the pool client. This serves
-// as proof of verifiable work contributed to the mining pool. This also
-// updates the hash rate of the miner based on the last submitted share time.
-func (c *Client) claimWeightedShare() error {
-	if c.endpoint.hub.cfg.ActiveNet.Name == chaincfg.MainNetParams.Name &&
-		c.endpoint.miner == dividend.CPU {
-		log.Error("CPU miners are reserved for only simnet testing purposes")
-		return nil
-	}
-
-	weight := dividend.ShareWeights[c.endpoint.miner]
-	share := dividend.NewShare(c.account, weight)
-	err := share.Create(c.endpoint.hub.db)
-	if err != nil {
-		return err
-	}
-
-	log.Tracef("Weighted share of (%v) for pool client (%v) claimed",
-		weight, c.generateID())
-	return nil
-}
-
 // handleAuthorizeRequest processes authorize request messages received.
 func (c *Client) handleAuthorizeRequest(req *Request, allowed bool) {
 	if !allowed {
-		log.Errorf("unable to process authorize request, limit reached")
+		stratumLog.Errorf("unable to process authorize request, limit reached")
+		err := NewStratumError(Unknown, nil)
+		resp := AuthorizeResponse(*req.ID, false, err)
+		c.ch <- resp
+		return
+	}
+
+	if c.endpoint.hub.dcrdSyncing() {
+		stratumLog.Debugf("Refusing authorization of (%v), dcrd is syncing",
+			c.generateID())
 		err := NewStratumError(Unknown, nil)
+		err.Message = dcrdSyncingMessage
 		resp := AuthorizeResponse(*req.ID, false, err)
 		c.ch <- resp
 		return
@@ -183,7 +186,7 @@ func (c *Client) handleAuthorizeRequest(req *Request, allowed bool) {
 	if !c.endpoint.hub.cfg.SoloPool {
 		username, err := ParseAuthorizeRequest(req)
 		if err != nil {
-			log.Errorf("unable to parse authorize request: %v", err)
+			stratumLog.Errorf("unable to parse authorize request: %v", err)
 			err := NewStratumError(Unknown, nil)
 			resp := AuthorizeResponse(*req.ID, false, err)
 			c.ch <- resp
@@ -191,9 +194,9 @@ func (c *Client) handleAuthorizeRequest(req *Request, allowed bool) {
 		}
 
 		parts := strings.Split(username, ".")
-		if len(parts) != 2 {
-			log.Errorf("Invalid username format, expected `address.id`,got %v",
-				username)
+		if len(parts) != 2 && len(parts) != 3 {
+			stratumLog.Errorf("Invalid username format, expected `address.id` or "+
+				"`address.id.worker`, got %v", username)
 			err := NewStratumError(Unknown, nil)
 			resp := AuthorizeResponse(*req.ID, false, err)
 			c.ch <- resp
@@ -202,12 +205,16 @@ func (c *Client) handleAuthorizeRequest(req *Request, allowed bool) {
 
 		name := strings.TrimSpace(parts[1])
 		address := strings.TrimSpace(parts[0])
+		workerName := dividend.DefaultWorkerName
+		if len(parts) == 3 && strings.TrimSpace(parts[2]) != "" {
+			workerName = strings.TrimSpace(parts[2])
+		}
 
 		// Ensure the provided address is valid and associated with the active
 		// network.
 		addr, err := dcrutil.DecodeAddress(address)
 		if err != nil {
-			log.Errorf("unable to decode address: %v", err)
+			stratumLog.Errorf("unable to decode address: %v", err)
 			err := NewStratumError(Unknown, nil)
 			resp := AuthorizeResponse(*req.ID, false, err)
 			c.ch <- resp
@@ -215,7 +222,7 @@ func (c *Client) handleAuthorizeRequest(req *Request, allowed bool) {
 		}
 
 		if !addr.IsForNet(c.endpoint.hub.cfg.ActiveNet) {
-			log.Errorf("Address (%v) is not associated with the active network"+
+			stratumLog.Errorf("Address (%v) is not associated with the active network"+
 				" (%v)", address, c.endpoint.hub.cfg.ActiveNet.Name)
 			err := NewStratumError(Unknown, nil)
 			resp := AuthorizeResponse(*req.ID, false, err)
@@ -223,37 +230,28 @@ func (c *Client) handleAuthorizeRequest(req *Request, allowed bool) {
 			return
 		}
 
-		id := dividend.AccountID(name, address)
-		_, err = dividend.FetchAccount(c.endpoint.hub.db, []byte(*id))
-		if err != nil && err.Error() !=
-			database.ErrValueNotFound([]byte(*id)).Error() {
-			log.Errorf("unable to fetch account: %v", err)
-			err := NewStratumError(Unknown, nil)
-			resp := AuthorizeResponse(*req.ID, false, err)
+		auth, serr := c.endpoint.hub.backend.authorizeWorker(name, address,
+			workerName, c.ip, c.userAgent, c.endpoint.region)
+		if serr != nil {
+			stratumLog.Debugf("Authorization of (%v) refused: %v",
+				c.generateID(), serr.Message)
+			resp := AuthorizeResponse(*req.ID, false, serr)
 			c.ch <- resp
 			return
 		}
 
-		// Create the account if it does not already exist.
-		account, err := dividend.NewAccount(name, address)
-		if err != nil {
-			log.Errorf("unable to create account: %v", err)
-			err := NewStratumError(Unknown, nil)
-			resp := AuthorizeResponse(*req.ID, false, err)
-			c.ch <- resp
-			return
-		}
-
-		err = account.Create(c.endpoint.hub.db)
-		if err != nil {
-			log.Errorf("unable to persist account: %v", err)
-			err := NewStratumError(Unknown, nil)
-			resp := AuthorizeResponse(*req.ID, false, err)
-			c.ch <- resp
-			return
+		// Apply the preferred difficulty of the account if set.
+		if auth.difficulty != nil {
+			diffData, err := c.endpoint.hub.accountDifficulty(auth.difficulty)
+			if err != nil {
+				stratumLog.Errorf("unable to apply account difficulty: %v", err)
+			} else {
+				c.diffData = diffData
+			}
 		}
 
-		c.account = *id
+		c.account = auth.account
+		c.worker = auth.worker
 	}
 
 	c.authorized = true
@@ -264,16 +262,16 @@ func (c *Client) handleAuthorizeRequest(req *Request, allowed bool) {
 // handleSubscribeRequest processes subscription request messages received.
 func (c *Client) handleSubscribeRequest(req *Request, allowed bool) {
 	if !allowed {
-		log.Errorf("unable to process subscribe request, limit reached")
+		stratumLog.Errorf("unable to process subscribe request, limit reached")
 		err := NewStratumError(Unknown, nil)
 		resp := SubscribeResponse(*req.ID, "", "", err)
 		c.ch <- resp
 		return
 	}
 
-	_, nid, err := ParseSubscribeRequest(req)
+	userAgent, nid, err := ParseSubscribeRequest(req)
 	if err != nil {
-		log.Errorf("unable to parse subscribe request: %v", err)
+		stratumLog.Errorf("unable to parse subscribe request: %v", err)
 		err := NewStratumError(Unknown, nil)
 		resp := SubscribeResponse(*req.ID, "", "", err)
 		c.ch <- resp
@@ -284,8 +282,10 @@ func (c *Client) handleSubscribeRequest(req *Request, allowed bool) {
 		nid = fmt.Sprintf("mn%v", c.extraNonce1)
 	}
 
+	c.userAgent = userAgent
+
 	resp := SubscribeResponse(*req.ID, nid, c.extraNonce1, nil)
-	log.Tracef("Subscribe response is: %v", spew.Sdump(resp))
+	stratumLog.Tracef("Subscribe response is: %v", spew.Sdump(resp))
 
 	c.ch <- resp
 	c.subscribed = true
@@ -293,37 +293,76 @@ func (c *Client) handleSubscribeRequest(req *Request, allowed bool) {
 
 // setDifficulty sends the pool client's difficulty ratio.
 func (c *Client) setDifficulty() {
-	log.Tracef("Difficulty is %v", c.endpoint.diffData.difficulty)
-	diffNotif := SetDifficultyNotification(c.endpoint.diffData.difficulty)
+	stratumLog.Tracef("Difficulty is %v", c.diffData.difficulty)
+	diffNotif := SetDifficultyNotification(c.diffData.difficulty)
 	c.ch <- diffNotif
 }
 
+// recordShare counts a share of the client of the provided status. Rejected
+// shares are recorded with the backend, accepted shares are recorded with
+// the backend as they are accepted.
+func (c *Client) recordShare(status shareStatus) {
+	if status == shareAccepted {
+		atomic.AddUint32(&c.accepted, 1)
+		return
+	}
+
+	atomic.AddUint32(&c.rejected, 1)
+	err := c.endpoint.hub.backend.recordShare(&clientShare{
+		account: c.account,
+		worker:  c.worker,
+		miner:   c.endpoint.miner,
+		status:  status,
+	})
+	if err != nil {
+		stratumLog.Errorf("failed to record rejected share of (%v): %v",
+			c.generateID(), err)
+	}
+}
+
 // handleSubmitWorkRequest processes work submission request messages received.
 func (c *Client) handleSubmitWorkRequest(req *Request, allowed bool) {
+	status := shareRejected
+	defer func() { c.recordShare(status) }()
+
 	if !allowed {
-		log.Errorf("unable to process submit work request, limit reached")
+		stratumLog.Errorf("unable to process submit work request, limit reached")
+		err := NewStratumError(Unknown, nil)
+		resp := SubmitWorkResponse(*req.ID, false, err)
+		c.ch <- resp
+		return
+	}
+
+	if c.endpoint.hub.dcrdSyncing() {
+		stratumLog.Debugf("Rejecting work submission of (%v), dcrd is syncing",
+			c.generateID())
 		err := NewStratumError(Unknown, nil)
+		err.Message = dcrdSyncingMessage
 		resp := SubmitWorkResponse(*req.ID, false, err)
 		c.ch <- resp
 		return
 	}
 
-	log.Tracef("Received work submission from (%v) is %v",
+	stratumLog.Tracef("Received work submission from (%v) is %v",
 		c.generateID(), spew.Sdump(req))
 
 	_, jobID, extraNonce2E, nTimeE, nonceE, err := ParseSubmitWorkRequest(req,
 		c.endpoint.miner)
 	if err != nil {
-		log.Errorf("unable to parse submit work request: %v", err)
+		stratumLog.Errorf("unable to parse submit work request: %v", err)
+		status = shareInvalid
 		err := NewStratumError(Unknown, nil)
 		resp := SubmitWorkResponse(*req.ID, false, err)
 		c.ch <- resp
 		return
 	}
 
-	job, err := FetchJob(c.endpoint.hub.db, []byte(jobID))
+	job, err := c.endpoint.hub.backend.fetchJob(jobID)
 	if err != nil {
-		log.Errorf("unable to fetch job: %v", err)
+		stratumLog.Errorf("unable to fetch job: %v", err)
+		if err.Error() == database.ErrValueNotFound([]byte(jobID)).Error() {
+			status = shareStale
+		}
 		err := NewStratumError(Unknown, nil)
 		resp := SubmitWorkResponse(*req.ID, false, err)
 		c.ch <- resp
@@ -333,31 +372,33 @@ func (c *Client) handleSubmitWorkRequest(req *Request, allowed bool) {
 	header, err := GenerateSolvedBlockHeader(job.Header,
 		c.extraNonce1, extraNonce2E, nTimeE, nonceE, c.endpoint.miner)
 	if err != nil {
-		log.Errorf("unable to generate solved block header: %v", err)
+		stratumLog.Errorf("unable to generate solved block header: %v", err)
+		status = shareInvalid
 		err := NewStratumError(Unknown, nil)
 		resp := SubmitWorkResponse(*req.ID, false, err)
 		c.ch <- resp
 		return
 	}
 
-	log.Tracef("Submitted work from (%v) is %v", c.generateID(),
+	stratumLog.Tracef("Submitted work from (%v) is %v", c.generateID(),
 		spew.Sdump(header))
-	log.Infof("Submited work hash at height (%v) is (%v)", header.Height,
+	stratumLog.Infof("Submited work hash at height (%v) is (%v)", header.Height,
 		header.BlockHash().String())
 
-	poolTarget := c.endpoint.diffData.target
+	poolTarget := c.diffData.target
 	target := blockchain.CompactToBig(header.Bits)
 	hash := header.BlockHash()
 	hashNum := blockchain.HashToBig(&hash)
 
-	log.Tracef("pool target is: %v", poolTarget)
-	log.Tracef("hash target is: %v", hashNum)
+	stratumLog.Tracef("pool target is: %v", poolTarget)
+	stratumLog.Tracef("hash target is: %v", hashNum)
 
 	// Only submit work to the network if the submitted blockhash is
 	// below the pool target for the client.
 	if hashNum.Cmp(poolTarget) > 0 {
-		log.Errorf("submitted work from (%v) is not less than its"+
+		stratumLog.Errorf("submitted work from (%v) is not less than its"+
 			" corresponding pool target", c.generateID())
+		status = shareInvalid
 		err := NewStratumError(LowDifficultyShare, nil)
 		resp := SubmitWorkResponse(*req.ID, false, err)
 		c.ch <- resp
@@ -367,91 +408,68 @@ func (c *Client) handleSubmitWorkRequest(req *Request, allowed bool) {
 	// Calculate the hash rate of the client.
 	err = c.calculateHashRate()
 	if err != nil {
-		log.Errorf("unable to calculate hash rate of (%v): %v",
+		stratumLog.Errorf("unable to calculate hash rate of (%v): %v",
 			c.generateID(), err)
 	}
 
-	// Claim a weighted share for work contributed to the pool if not mining
-	// in solo mining mode.
-	if !c.endpoint.hub.cfg.SoloPool {
-		err := c.claimWeightedShare()
-		if err != nil {
-			log.Errorf("failed to persist weighted share for (%v): %v",
-				c.generateID(), err)
-			err := NewStratumError(Unknown, nil)
-			resp := SubmitWorkResponse(*req.ID, false, err)
-			c.ch <- resp
-			return
-		}
+	c.hashRateMtx.RLock()
+	hashRate := c.hashRate
+	c.hashRateMtx.RUnlock()
+
+	// Record the share with the backend, a weighted share is claimed for
+	// work contributed to the pool if not mining in solo mining mode.
+	solvesBlock := hashNum.Cmp(target) < 0
+	err = c.endpoint.hub.backend.recordShare(&clientShare{
+		account:    c.account,
+		worker:     c.worker,
+		miner:      c.endpoint.miner,
+		status:     shareAccepted,
+		hashRate:   hashRate,
+		difficulty: c.diffData.difficulty,
+		height:     header.Height,
+		block:      solvesBlock,
+	})
+	if err != nil {
+		stratumLog.Errorf("failed to record share of (%v): %v",
+			c.generateID(), err)
+		err := NewStratumError(Unknown, nil)
+		resp := SubmitWorkResponse(*req.ID, false, err)
+		c.ch <- resp
+		return
 	}
 
+	status = shareAccepted
+
 	// Only submit work to the network if the submitted blockhash is
 	// below the network target difficulty.
-	if hashNum.Cmp(target) > 0 {
-		log.Tracef("submitted work from (%v) is not less than the"+
+	if !solvesBlock {
+		stratumLog.Tracef("submitted work from (%v) is not less than the"+
 			" network target difficulty", c.generateID())
 		resp := SubmitWorkResponse(*req.ID, true, nil)
 		c.ch <- resp
 		return
 	}
 
-	if hashNum.Cmp(target) < 0 {
-		// Persist the accepted work before submiting to the network. This is
-		// a workaround in order to have an accepted work record available
-		// when a block connected notification is received.
-
-		work := NewAcceptedWork(hash.String(), header.PrevBlock.String(),
-			header.Height, c.account, c.endpoint.miner)
-		err := work.Create(c.endpoint.hub.db)
-		if err != nil {
-			// If the submitted accetped work already exists, ignore the submission.
-			if err.Error() == ErrWorkAlreadyExists([]byte(work.UUID)).Error() {
-				log.Tracef("Work already exists, ignoring.")
-				err := NewStratumError(DuplicateShare, nil)
-				resp := SubmitWorkResponse(*req.ID, false, err)
-				c.ch <- resp
-				return
-			}
-
-			log.Errorf("unable to persist accepted work: %v", err)
-			err := NewStratumError(Unknown, nil)
-			resp := SubmitWorkResponse(*req.ID, false, err)
-			c.ch <- resp
-			return
-		}
-
-		// Generate and send the work submission.
-		headerB, err := header.Bytes()
-		if err != nil {
-			log.Errorf("unable to fetch block header bytes: %v", err)
-			err := NewStratumError(Unknown, nil)
-			resp := SubmitWorkResponse(*req.ID, false, err)
-			c.ch <- resp
-			return
-		}
-
-		submissionB := make([]byte, getworkDataLen)
-		copy(submissionB[:wire.MaxBlockHeaderPayload], headerB)
-		copy(submissionB[wire.MaxBlockHeaderPayload:],
-			c.endpoint.hub.blake256Pad)
-		submission := hex.EncodeToString(submissionB)
-		accepted, err := c.endpoint.hub.SubmitWork(&submission)
-		if err != nil {
-			log.Errorf("unable to submit work request: %v", err)
-			err := NewStratumError(Unknown, nil)
+	accepted, err := c.endpoint.hub.backend.submitBlock(header, c.account,
+		c.endpoint.miner)
+	if err != nil {
+		if err == errDuplicateWork {
+			stratumLog.Tracef("Work already exists, ignoring.")
+			err := NewStratumError(DuplicateShare, nil)
 			resp := SubmitWorkResponse(*req.ID, false, err)
 			c.ch <- resp
 			return
 		}
 
-		log.Tracef("Work accepted status is: %v", accepted)
-		c.ch <- SubmitWorkResponse(*req.ID, accepted, nil)
-
-		// Remove the work record if it is not accepted by the network.
-		if !accepted {
-			work.Delete(c.endpoint.hub.db)
-		}
+		stratumLog.Errorf("unable to submit work of (%v): %v",
+			c.generateID(), err)
+		err := NewStratumError(Unknown, nil)
+		resp := SubmitWorkResponse(*req.ID, false, err)
+		c.ch <- resp
+		return
 	}
+
+	c.ch <- SubmitWorkResponse(*req.ID, accepted, nil)
 }
 
 // read receives incoming data and passes the message received for
@@ -474,12 +492,12 @@ func (c *Client) read() {
 				}
 			}
 
-			log.Errorf("failed to read bytes: %v %T", err, err)
+			stratumLog.Errorf("failed to read bytes: %v %T", err, err)
 			c.cancel()
 			return
 		}
 
-		log.Tracef("Message received from (%v) is %v", c.generateID(),
+		stratumLog.Tracef("Message received from (%v) is %v", c.generateID(),
 			spew.Sdump(data))
 
 		c.readCh <- data
@@ -489,19 +507,19 @@ func (c *Client) read() {
 // process  handles incoming messages from the connected pool client.
 // It must be run as a goroutine.
 func (c *Client) process(ctx context.Context) {
-	log.Tracef("Listener for (%v) started.", c.generateID())
+	stratumLog.Tracef("Listener for (%v) started.", c.generateID())
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Tracef("Listener for (%v) done.", c.generateID())
+			stratumLog.Tracef("Listener for (%v) done.", c.generateID())
 			c.wg.Done()
 			return
 
 		case data := <-c.readCh:
 			msg, reqType, err := IdentifyMessage(data)
 			if err != nil {
-				log.Errorf("unable to identify message: %v", err)
+				stratumLog.Errorf("unable to identify message: %v", err)
 				c.cancel()
 				continue
 			}
@@ -524,23 +542,23 @@ func (c *Client) process(ctx context.Context) {
 					c.handleSubmitWorkRequest(req, allowed)
 
 				default:
-					log.Errorf("unknown request method for request: %s", req.Method)
+					stratumLog.Errorf("unknown request method for request: %s", req.Method)
 				}
 
 			case ResponseType:
 				resp := msg.(*Response)
 				method := c.fetchRequest(resp.ID)
 				if method == "" {
-					log.Errorf("no request found for response with id: ", resp.ID,
+					stratumLog.Errorf("no request found for response with id: ", resp.ID,
 						spew.Sdump(resp))
 					c.cancel()
 					continue
 				}
 
-				log.Errorf("unknown request method for response: %s", method)
+				stratumLog.Errorf("unknown request method for response: %s", method)
 
 			default:
-				log.Errorf("unknown message type received: %s", reqType)
+				stratumLog.Errorf("unknown message type received: %s", reqType)
 			}
 		}
 	}
@@ -551,21 +569,21 @@ func (c *Client) handleAntminerDR3Work(req *Request) {
 	jobID, prevBlock, genTx1, genTx2, blockVersion, nBits, nTime,
 		cleanJob, err := ParseWorkNotification(req)
 	if err != nil {
-		log.Errorf("unable to parse work message: %v", err)
+		stratumLog.Errorf("unable to parse work message: %v", err)
 	}
 
 	// The DR3 requires the nBits and nTime fields of a mining.notify message
 	// as big endian.
 	nBits, err = util.HexReversed(nBits)
 	if err != nil {
-		log.Errorf("unable to hex reverse nBits: %v", err)
+		stratumLog.Errorf("unable to hex reverse nBits: %v", err)
 		c.cancel()
 		return
 	}
 
 	nTime, err = util.HexReversed(nTime)
 	if err != nil {
-		log.Errorf("unable to hex reverse nTime: %v", err)
+		stratumLog.Errorf("unable to hex reverse nTime: %v", err)
 		c.cancel()
 		return
 	}
@@ -574,11 +592,11 @@ func (c *Client) handleAntminerDR3Work(req *Request) {
 	workNotif := WorkNotification(jobID, prevBlockRev,
 		genTx1, genTx2, blockVersion, nBits, nTime, cleanJob)
 
-	log.Tracef("DR3/DR5 work notification is: %v", spew.Sdump(workNotif))
+	stratumLog.Tracef("DR3/DR5 work notification is: %v", spew.Sdump(workNotif))
 
 	err = c.encoder.Encode(workNotif)
 	if err != nil {
-		log.Errorf("Message encoding error: %v", err)
+		stratumLog.Errorf("Message encoding error: %v", err)
 		c.cancel()
 		return
 	}
@@ -589,21 +607,21 @@ func (c *Client) handleInnosiliconD9Work(req *Request) {
 	jobID, prevBlock, genTx1, genTx2, blockVersion, nBits, nTime,
 		cleanJob, err := ParseWorkNotification(req)
 	if err != nil {
-		log.Errorf("unable to parse work message: %v", err)
+		stratumLog.Errorf("unable to parse work message: %v", err)
 	}
 
 	// The D9 requires the nBits and nTime fields of a mining.notify message
 	// as big endian.
 	nBits, err = util.HexReversed(nBits)
 	if err != nil {
-		log.Errorf("unable to hex reverse nBits: %v", err)
+		stratumLog.Errorf("unable to hex reverse nBits: %v", err)
 		c.cancel()
 		return
 	}
 
 	nTime, err = util.HexReversed(nTime)
 	if err != nil {
-		log.Errorf("unable to hex reverse nTime: %v", err)
+		stratumLog.Errorf("unable to hex reverse nTime: %v", err)
 		c.cancel()
 		return
 	}
@@ -612,11 +630,11 @@ func (c *Client) handleInnosiliconD9Work(req *Request) {
 	workNotif := WorkNotification(jobID, prevBlockRev,
 		genTx1, genTx2, blockVersion, nBits, nTime, cleanJob)
 
-	log.Tracef("D9 work notification is: %v", spew.Sdump(workNotif))
+	stratumLog.Tracef("D9 work notification is: %v", spew.Sdump(workNotif))
 
 	err = c.encoder.Encode(workNotif)
 	if err != nil {
-		log.Errorf("message encoding error: %v", err)
+		stratumLog.Errorf("message encoding error: %v", err)
 		c.cancel()
 		return
 	}
@@ -627,7 +645,7 @@ func (c *Client) handleWhatsminerD1Work(req *Request) {
 	jobID, prevBlock, genTx1, genTx2, blockVersion, nBits, nTime,
 		cleanJob, err := ParseWorkNotification(req)
 	if err != nil {
-		log.Errorf("unable to parse work message: %v", err)
+		stratumLog.Errorf("unable to parse work message: %v", err)
 	}
 
 	// The D1 requires the nBits and nTime fields of a mining.notify message
@@ -638,11 +656,11 @@ func (c *Client) handleWhatsminerD1Work(req *Request) {
 	workNotif := WorkNotification(jobID, prevBlockRev,
 		genTx1, genTx2, blockVersion, nBits, nTime, cleanJob)
 
-	log.Tracef("D1 work notification is: %v", spew.Sdump(workNotif))
+	stratumLog.Tracef("D1 work notification is: %v", spew.Sdump(workNotif))
 
 	err = c.encoder.Encode(workNotif)
 	if err != nil {
-		log.Errorf("message encoding error: %v", err)
+		stratumLog.Errorf("message encoding error: %v", err)
 		c.cancel()
 		return
 	}
@@ -650,12 +668,12 @@ func (c *Client) handleWhatsminerD1Work(req *Request) {
 
 // Send dispatches messages to a pool client. It must be run as a goroutine.
 func (c *Client) send(ctx context.Context) {
-	log.Tracef("Send handler for (%v) started.", c.generateID())
+	stratumLog.Tracef("Send handler for (%v) started.", c.generateID())
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Tracef("Send handler for (%v) done.", c.generateID())
+			stratumLog.Tracef("Send handler for (%v) done.", c.generateID())
 			c.wg.Done()
 			return
 
@@ -664,13 +682,13 @@ func (c *Client) send(ctx context.Context) {
 				continue
 			}
 
-			log.Tracef("Message sent to (%v) is %v", c.generateID(),
+			stratumLog.Tracef("Message sent to (%v) is %v", c.generateID(),
 				spew.Sdump(msg))
 
 			if msg.MessageType() == ResponseType {
 				err := c.encoder.Encode(msg)
 				if err != nil {
-					log.Errorf("Message encoding error: %v", err)
+					stratumLog.Errorf("Message encoding error: %v", err)
 					c.cancel()
 					continue
 				}
@@ -685,27 +703,27 @@ func (c *Client) send(ctx context.Context) {
 					case dividend.CPU:
 						err := c.encoder.Encode(msg)
 						if err != nil {
-							log.Errorf("Message encoding error: %v", err)
+							stratumLog.Errorf("Message encoding error: %v", err)
 							c.cancel()
 							continue
 						}
 
-						log.Tracef("Client (%v) notified of new work", id)
+						stratumLog.Tracef("Client (%v) notified of new work", id)
 
 					case dividend.AntminerDR3, dividend.AntminerDR5:
 						c.handleAntminerDR3Work(req)
-						log.Tracef("Client (%v) notified of new work", id)
+						stratumLog.Tracef("Client (%v) notified of new work", id)
 
 					case dividend.InnosiliconD9:
 						c.handleInnosiliconD9Work(req)
-						log.Tracef("Client (%v) notified of new work", id)
+						stratumLog.Tracef("Client (%v) notified of new work", id)
 
 					case dividend.WhatsminerD1:
 						c.handleWhatsminerD1Work(req)
-						log.Tracef("Client (%v) notified of new work", id)
+						stratumLog.Tracef("Client (%v) notified of new work", id)
 
 					default:
-						log.Errorf("unknown miner provided to receive work: %v",
+						stratumLog.Errorf("unknown miner provided to receive work: %v",
 							c.endpoint.miner)
 						c.cancel()
 						continue
@@ -715,7 +733,7 @@ func (c *Client) send(ctx context.Context) {
 				if req.Method != Notify {
 					err := c.encoder.Encode(msg)
 					if err != nil {
-						log.Errorf("message encoding error: %v", err)
+						stratumLog.Errorf("message encoding error: %v", err)
 						c.cancel()
 						continue
 					}
@@ -0,0 +1,327 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/gorilla/mux"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// The compatibility api serves pool and miner statistics in the format of the
+// Miningcore pool api, used by existing multi-pool monitoring apps. Hash
+// rates are in hashes per second and amounts in DCR.
+
+// compatPoolID is the pool id of the pool in the compatibility api.
+const compatPoolID = "dcr"
+
+// compatNetworkTypes are the network types of the compatibility api.
+var compatNetworkTypes = map[string]string{
+	chaincfg.MainNetParams.Name:  "Main",
+	chaincfg.TestNet3Params.Name: "Test",
+	chaincfg.SimNetParams.Name:   "Sim",
+}
+
+// compatCoin describes the mined coin.
+type compatCoin struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+}
+
+// compatPort describes a stratum port.
+type compatPort struct {
+	Name       string  `json:"name"`
+	Difficulty float64 `json:"difficulty"`
+}
+
+// compatPaymentProcessing describes the payment scheme of the pool.
+type compatPaymentProcessing struct {
+	Enabled        bool    `json:"enabled"`
+	MinimumPayment float64 `json:"minimumPayment"`
+	PayoutScheme   string  `json:"payoutScheme"`
+}
+
+// compatPoolStats are the statistics of the pool.
+type compatPoolStats struct {
+	ConnectedMiners int     `json:"connectedMiners"`
+	PoolHashrate    float64 `json:"poolHashrate"`
+	SharesPerSecond float64 `json:"sharesPerSecond"`
+}
+
+// compatNetworkStats are the statistics of the network.
+type compatNetworkStats struct {
+	NetworkType       string  `json:"networkType"`
+	NetworkHashrate   float64 `json:"networkHashrate"`
+	NetworkDifficulty float64 `json:"networkDifficulty"`
+	BlockHeight       uint32  `json:"blockHeight"`
+	RewardType        string  `json:"rewardType"`
+}
+
+// compatPool describes the pool.
+type compatPool struct {
+	ID                string                  `json:"id"`
+	Coin              compatCoin              `json:"coin"`
+	Ports             map[string]compatPort   `json:"ports"`
+	PaymentProcessing compatPaymentProcessing `json:"paymentProcessing"`
+	PoolFeePercent    float64                 `json:"poolFeePercent"`
+	PoolStats         compatPoolStats         `json:"poolStats"`
+	NetworkStats      compatNetworkStats      `json:"networkStats"`
+	TotalBlocks       int                     `json:"totalBlocks"`
+	LastPoolBlockTime string                  `json:"lastPoolBlockTime,omitempty"`
+}
+
+// compatWorker are the statistics of a worker of a miner.
+type compatWorker struct {
+	Hashrate        float64 `json:"hashrate"`
+	SharesPerSecond float64 `json:"sharesPerSecond"`
+}
+
+// compatPerformance is the performance of a miner's workers.
+type compatPerformance struct {
+	Created string                   `json:"created"`
+	Workers map[string]*compatWorker `json:"workers"`
+}
+
+// compatMiner are the statistics of a miner, the accounts paying out to an
+// address.
+type compatMiner struct {
+	PendingShares   float64            `json:"pendingShares"`
+	PendingBalance  float64            `json:"pendingBalance"`
+	TotalPaid       float64            `json:"totalPaid"`
+	LastPayment     string             `json:"lastPayment,omitempty"`
+	LastPaymentLink string             `json:"lastPaymentLink,omitempty"`
+	Performance     *compatPerformance `json:"performance"`
+}
+
+// hashesPerSecond converts a hash rate in TH/s to hashes per second.
+func hashesPerSecond(hashRate *big.Rat) float64 {
+	f, _ := new(big.Rat).Mul(hashRate, new(big.Rat).SetInt(teraHash)).
+		Float64()
+	return f
+}
+
+// compatPool returns the description of the pool in the compatibility api
+// format.
+func (h *Hub) compatPool() (*compatPool, error) {
+	work, err := ListMinedWork(h.db)
+	if err != nil {
+		return nil, err
+	}
+
+	bits := atomic.LoadUint32(&h.lastWorkBits)
+	netHashRate, _ := dividend.NetworkHashRate(h.cfg.ActiveNet, bits).
+		Float64()
+	networkType, ok := compatNetworkTypes[h.cfg.ActiveNet.Name]
+	if !ok {
+		networkType = h.cfg.ActiveNet.Name
+	}
+
+	pool := &compatPool{
+		ID:    compatPoolID,
+		Coin:  compatCoin{Type: "DCR", Name: "Decred", Symbol: "DCR"},
+		Ports: make(map[string]compatPort, len(h.endpoints)),
+		PaymentProcessing: compatPaymentProcessing{
+			Enabled:      !h.cfg.SoloPool,
+			PayoutScheme: "SOLO",
+		},
+		PoolStats: compatPoolStats{
+			PoolHashrate: hashesPerSecond(h.hashRate("")),
+		},
+		NetworkStats: compatNetworkStats{
+			NetworkType:     networkType,
+			NetworkHashrate: netHashRate,
+			NetworkDifficulty: dividend.NetworkDifficulty(h.cfg.ActiveNet,
+				bits),
+			BlockHeight: atomic.LoadUint32(&h.lastWorkHeight),
+			RewardType:  "POW",
+		},
+		TotalBlocks: len(work),
+	}
+
+	if !h.cfg.SoloPool {
+		pool.PaymentProcessing.MinimumPayment = h.minPayment().ToCoin()
+		pool.PaymentProcessing.PayoutScheme =
+			strings.ToUpper(h.cfg.PaymentMethod)
+		pool.PoolFeePercent = h.cfg.PoolFee * 100
+	}
+
+	if len(work) > 0 {
+		last := work[len(work)-1]
+		pool.LastPoolBlockTime = time.Unix(last.CreatedOn, 0).UTC().
+			Format(time.RFC3339)
+	}
+
+	for _, endpoint := range h.endpoints {
+		diff, _ := new(big.Float).SetInt(endpoint.difficultyData().difficulty).
+			Float64()
+		pool.Ports[strconv.FormatUint(uint64(endpoint.port), 10)] =
+			compatPort{Name: endpoint.miner, Difficulty: diff}
+	}
+
+	miners := make(map[string]struct{})
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			miners[client.account] = struct{}{}
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+	pool.PoolStats.ConnectedMiners = len(miners)
+
+	return pool, nil
+}
+
+// compatMiner returns the statistics of the accounts paying out to the
+// provided address in the compatibility api format.
+func (h *Hub) compatMiner(address string) (*compatMiner, error) {
+	accounts, err := dividend.FilterAccounts(h.db,
+		func(account *dividend.Account) bool {
+			return account.Address == address
+		})
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, dividend.ErrAccountNotFound(address)
+	}
+
+	ids := make(map[string]struct{}, len(accounts))
+	for _, account := range accounts {
+		ids[account.UUID] = struct{}{}
+	}
+
+	miner := &compatMiner{
+		Performance: &compatPerformance{
+			Created: time.Now().UTC().Format(time.RFC3339),
+			Workers: make(map[string]*compatWorker),
+		},
+	}
+
+	pending, err := dividend.FetchPendingPayments(h.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, pmt := range pending {
+		if _, ok := ids[pmt.Account]; ok {
+			miner.PendingBalance += pmt.Amount.ToCoin()
+		}
+	}
+
+	var lastPayment int64
+	for id := range ids {
+		paid, err := dividend.FetchArchivedPaymentsForAccount(h.db,
+			[]byte(id), make([]byte, 8))
+		if err != nil {
+			return nil, err
+		}
+		for _, pmt := range paid {
+			miner.TotalPaid += pmt.Amount.ToCoin()
+			if pmt.CreatedOn > lastPayment {
+				lastPayment = pmt.CreatedOn
+			}
+		}
+	}
+	if lastPayment > 0 {
+		miner.LastPayment = time.Unix(0, lastPayment).UTC().
+			Format(time.RFC3339)
+	}
+
+	// Clients of the same worker are reported as one worker.
+	workers := make(map[string]*big.Rat)
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			if _, ok := ids[client.account]; !ok {
+				continue
+			}
+
+			client.hashRateMtx.RLock()
+			hashRate := new(big.Rat).Set(client.hashRate)
+			client.hashRateMtx.RUnlock()
+
+			if total, ok := workers[client.worker]; ok {
+				total.Add(total, hashRate)
+				continue
+			}
+			workers[client.worker] = hashRate
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	for id, hashRate := range workers {
+		name := id
+		if id != "" {
+			worker, err := dividend.FetchWorker(h.db, []byte(id))
+			if err == nil {
+				name = worker.Name
+			}
+		}
+
+		miner.Performance.Workers[name] = &compatWorker{
+			Hashrate: hashesPerSecond(hashRate),
+		}
+	}
+
+	return miner, nil
+}
+
+// CompatPools lists the pool in the format of the Miningcore pools api.
+func (h *Hub) CompatPools(w http.ResponseWriter, r *http.Request) {
+	pool, err := h.compatPool()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string][]*compatPool{"pools": {pool}})
+}
+
+// CompatPool describes the pool in the format of the Miningcore pool api.
+func (h *Hub) CompatPool(w http.ResponseWriter, r *http.Request) {
+	if mux.Vars(r)["pool"] != compatPoolID {
+		RespondWithError(w, http.StatusNotFound, "pool not found")
+		return
+	}
+
+	pool, err := h.compatPool()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]*compatPool{"pool": pool})
+}
+
+// CompatMiner returns the statistics of the accounts paying out to the
+// requested address in the format of the Miningcore miner api.
+func (h *Hub) CompatMiner(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if vars["pool"] != compatPoolID {
+		RespondWithError(w, http.StatusNotFound, "pool not found")
+		return
+	}
+
+	miner, err := h.compatMiner(vars["address"])
+	if err != nil {
+		if err.Error() == dividend.ErrAccountNotFound(vars["address"]).Error() {
+			RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, miner)
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestHashesPerSecond(t *testing.T) {
+	if hps := hashesPerSecond(big.NewRat(3, 2)); hps != 1.5e12 {
+		t.Fatalf("expected 1.5e12 hashes per second, got %v", hps)
+	}
+}
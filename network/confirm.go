@@ -0,0 +1,321 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// Mined work statuses. Blocks found by the pool are pending until the next
+// block votes on them, their earnings are provisional meanwhile. They are
+// then confirmed when approved by the majority of its votes or disapproved,
+// forfeiting their reward. Confirmed blocks mature once their reward is
+// spendable.
+const (
+	WorkPending     = "pending"
+	WorkConfirmed   = "confirmed"
+	WorkDisapproved = "disapproved"
+	WorkMature      = "mature"
+)
+
+// blockApproved returns whether the votes of the provided block header
+// approve the regular transaction tree of its parent, which holds the
+// reward of the parent. Headers without votes approve their parent.
+func blockApproved(header *wire.BlockHeader) bool {
+	return header.VoteBits&dcrutil.BlockValid != 0
+}
+
+// creditWork records the reward of the provided block found by the pool
+// and credits its earnings as provisional payments, held until the votes of
+// the next block approve the block.
+func (h *Hub) creditWork(work *AcceptedWork) error {
+	if work.Reward == 0 {
+		hash, err := chainhash.NewHashFromStr(work.BlockHash)
+		if err != nil {
+			return err
+		}
+
+		h.rpccMtx.Lock()
+		block, err := h.rpcc.GetBlock(hash)
+		h.rpccMtx.Unlock()
+		if err != nil {
+			return fmt.Errorf("unable to fetch block: %v", err)
+		}
+
+		work.Reward = coinbaseReward(block.Transactions[0])
+		err = h.validateReward(block, work.Reward)
+		if err != nil {
+			chainLog.Warnf("Unexpected reward of block %v, crediting the reward "+
+				"paid: %v", work.BlockHash, err)
+		}
+
+		err = work.Update(h.db)
+		if err != nil {
+			return err
+		}
+
+		chainLog.Tracef("Accepted work (%v) at height %v has coinbase of %v",
+			work.BlockHash, work.Height, work.Reward)
+	}
+
+	// Only process shares and payments when not mining in solo pool mode.
+	if h.cfg.SoloPool {
+		return nil
+	}
+
+	return h.creditProvisionalPayments(work)
+}
+
+// creditProvisionalPayments generates the payments of the reward of the
+// provided found block per the configured payment scheme, as provisional
+// payments maturing with the reward.
+func (h *Hub) creditProvisionalPayments(work *AcceptedWork) error {
+	switch h.cfg.PaymentMethod {
+	case dividend.PPS:
+		err := dividend.PayPerShare(h.db, work.Reward, h.cfg.PoolFee,
+			work.Height, h.cfg.ActiveNet.CoinbaseMaturity)
+		if err != nil {
+			return fmt.Errorf("unable to generate PPS shares: %v", err)
+		}
+
+	case dividend.PPLNS:
+		err := dividend.PayPerLastNShares(h.db, work.Reward, h.cfg.PoolFee,
+			work.Height, h.cfg.ActiveNet.CoinbaseMaturity,
+			h.cfg.LastNPeriod)
+		if err != nil {
+			return fmt.Errorf("unable to generate PPLNS shares: %v", err)
+		}
+	}
+
+	_, err := dividend.SetProvisionalPayments(h.db, work.Height, true)
+	return err
+}
+
+// creditConnectedWork credits the earnings of the connected block as
+// provisional payments if it was found by the pool.
+func (h *Hub) creditConnectedWork(header *wire.BlockHeader) {
+	id := AcceptedWorkID(header.BlockHash().String(), header.Height)
+	work, err := FetchAcceptedWork(h.db, id)
+	if err != nil {
+		if err.Error() != database.ErrValueNotFound(id).Error() {
+			chainLog.Errorf("Failed to fetch accepted work: %v", err)
+		}
+		return
+	}
+
+	// Earnings of the block are only credited once.
+	if work.Reward > 0 {
+		return
+	}
+
+	err = h.creditWork(work)
+	if err != nil {
+		chainLog.Errorf("Failed to credit found block %v: %v", work.BlockHash,
+			err)
+		h.cancel()
+	}
+}
+
+// confirmParentWork confirms the parent of the connected block if it was
+// found by the pool and approved by the votes of the connected block,
+// crediting its provisional payments. Disapproved blocks have their reward
+// forfeited and their provisional payments voided.
+func (h *Hub) confirmParentWork(header *wire.BlockHeader) {
+	parentID := AcceptedWorkID(header.PrevBlock.String(), header.Height-1)
+	parent, err := FetchAcceptedWork(h.db, parentID)
+	if err != nil {
+		if err.Error() != database.ErrValueNotFound(parentID).Error() {
+			chainLog.Errorf("Failed to fetch accepted work: %v", err)
+		}
+		return
+	}
+
+	if parent.Confirmed || parent.Status == WorkDisapproved {
+		return
+	}
+
+	// Remove the competing blocks found by the pool at the parent height,
+	// they are orphaned.
+	child := &AcceptedWork{
+		Height:   header.Height,
+		PrevHash: header.PrevBlock.String(),
+	}
+	_, err = child.FilterParentAcceptedWork(h.db)
+	if err != nil {
+		chainLog.Errorf("Failed to filter parent accepted work: %v", err)
+		return
+	}
+
+	chainLog.Tracef("Found mined parent %v for block %v", parent.BlockHash,
+		header.BlockHash())
+
+	if !blockApproved(header) {
+		parent.Status = WorkDisapproved
+		err = parent.Update(h.db)
+		if err != nil {
+			chainLog.Errorf("Failed to update disapproved work: %v", err)
+			h.cancel()
+			return
+		}
+
+		if !h.cfg.SoloPool {
+			_, err = dividend.VoidProvisionalPayments(h.db, parent.Height,
+				true)
+			if err != nil {
+				chainLog.Errorf("Failed to void provisional payments: %v", err)
+				h.cancel()
+				return
+			}
+		}
+
+		chainLog.Warnf("Block %v at height %v was disapproved by the votes of "+
+			"block %v, its reward is forfeited", parent.BlockHash,
+			parent.Height, header.BlockHash())
+		h.logPoolEvent(dividend.PoolEventBlockDisapproved,
+			fmt.Sprintf("block %v at height %v disapproved by votes",
+				parent.BlockHash, parent.Height),
+			map[string]interface{}{
+				"height":    parent.Height,
+				"blockhash": parent.BlockHash,
+			}, "")
+		return
+	}
+
+	// Credit the block now if its connection was missed.
+	if parent.Reward == 0 {
+		err = h.creditWork(parent)
+		if err != nil {
+			chainLog.Errorf("Failed to credit found block %v: %v",
+				parent.BlockHash, err)
+			h.cancel()
+			return
+		}
+	}
+
+	// Update accepted work as confirmed mined.
+	parent.Confirmed = true
+	parent.Status = WorkConfirmed
+	parent.Confirmations = header.Height - parent.Height + 1
+	err = parent.Update(h.db)
+	if err != nil {
+		chainLog.Errorf("Failed to confirm accepted work: %v", err)
+		h.cancel()
+		return
+	}
+
+	h.publish("", EventBlockFound, parent)
+	h.logPoolEvent(dividend.PoolEventBlockFound,
+		fmt.Sprintf("block %v found at height %v", parent.BlockHash,
+			parent.Height),
+		map[string]interface{}{
+			"height":    parent.Height,
+			"blockhash": parent.BlockHash,
+			"reward":    parent.Reward,
+		}, "")
+
+	if h.cfg.SoloPool {
+		return
+	}
+
+	credited, err := dividend.SetProvisionalPayments(h.db, parent.Height,
+		false)
+	if err != nil {
+		chainLog.Errorf("Failed to credit provisional payments: %v", err)
+		h.cancel()
+		return
+	}
+
+	chainLog.Tracef("Credited %v provisional payments of block %v", credited,
+		parent.BlockHash)
+
+	go h.notify(parent.MinedBy, dividend.AlertBlockFound, "Block found",
+		fmt.Sprintf("Your account mined block %v at height %v.",
+			parent.BlockHash, parent.Height))
+}
+
+// revertParentWork reverts the confirmation, or disapproval, of the parent
+// of the disconnected block if it was found by the pool. Its earnings are
+// provisional again until a block building on it connects and votes on it,
+// voided payments of a disapproved parent are restored as they were.
+func (h *Hub) revertParentWork(header *wire.BlockHeader) {
+	parentID := AcceptedWorkID(header.PrevBlock.String(), header.Height-1)
+	parent, err := FetchAcceptedWork(h.db, parentID)
+	if err != nil {
+		if err.Error() != database.ErrValueNotFound(parentID).Error() {
+			chainLog.Errorf("Failed to fetch accepted work: %v", err)
+		}
+		return
+	}
+
+	if parent.Status == WorkPending {
+		return
+	}
+
+	disapproved := parent.Status == WorkDisapproved
+	parent.Confirmed = false
+	parent.Status = WorkPending
+	parent.Confirmations = 0
+	err = parent.Update(h.db)
+	if err != nil {
+		chainLog.Errorf("Failed to revert confirmed work: %v", err)
+		h.cancel()
+		return
+	}
+
+	if !h.cfg.SoloPool {
+		if disapproved {
+			_, err = dividend.VoidProvisionalPayments(h.db, parent.Height,
+				false)
+		} else {
+			_, err = dividend.SetProvisionalPayments(h.db, parent.Height,
+				true)
+		}
+		if err != nil {
+			chainLog.Errorf("Failed to revert payments of mined work: %v", err)
+			h.cancel()
+			return
+		}
+	}
+
+	chainLog.Infof("Confirmation of mined work %v at height %v reverted",
+		parent.BlockHash, parent.Height)
+}
+
+// trackMinedWork updates the confirmations of the confirmed blocks of the
+// pool as of the provided chain height, marking blocks mature once their
+// reward is spendable.
+func (h *Hub) trackMinedWork(height uint32) {
+	mined, err := ListMinedWork(h.db)
+	if err != nil {
+		chainLog.Errorf("Failed to list mined work: %v", err)
+		return
+	}
+
+	maturity := uint32(h.cfg.ActiveNet.CoinbaseMaturity)
+	for _, work := range mined {
+		if work.Status == WorkMature || height <= work.Height {
+			continue
+		}
+
+		work.Confirmations = height - work.Height + 1
+		if work.Confirmations > maturity {
+			work.Status = WorkMature
+			chainLog.Infof("Block %v at height %v matured", work.BlockHash,
+				work.Height)
+		}
+
+		err := work.Update(h.db)
+		if err != nil {
+			chainLog.Errorf("Failed to update mined work confirmations: %v", err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+func TestBlockApproved(t *testing.T) {
+	header := &wire.BlockHeader{VoteBits: dcrutil.BlockValid}
+	if !blockApproved(header) {
+		t.Fatal("expected the parent approved")
+	}
+
+	header.VoteBits = 0
+	if blockApproved(header) {
+		t.Fatal("expected the parent disapproved")
+	}
+}
+
+func TestRevertDisapprovedWork(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confirm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := database.OpenDB(filepath.Join(dir, "test.kv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = database.CreateBuckets(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No shares are recorded, recomputing the payments of the block under
+	// PPS would fail and cancel the hub.
+	h := &Hub{
+		db: db,
+		cfg: &HubConfig{
+			ActiveNet:     &chaincfg.SimNetParams,
+			PaymentMethod: dividend.PPS,
+		},
+		cancel: func() { t.Fatal("unexpected hub cancellation") },
+	}
+
+	parentHash := chainhash.HashH([]byte("parent"))
+	parent := NewAcceptedWork(parentHash.String(), "", 10, "x", "CPU")
+	parent.Reward = dcrutil.Amount(1e8)
+	err = parent.Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pmts := []*dividend.Payment{
+		dividend.NewPayment("x", dcrutil.Amount(6e7), 10, 26),
+		dividend.NewPayment("y", dcrutil.Amount(4e7), 10, 26),
+	}
+	for _, pmt := range pmts {
+		pmt.Provisional = true
+		err = pmt.Create(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	header := &wire.BlockHeader{
+		PrevBlock: parentHash,
+		Height:    11,
+	}
+
+	// Ensure the payments of a disapproved block are voided.
+	h.confirmParentWork(header)
+
+	pending, err := dividend.FetchPendingPayments(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != 0 {
+		t.Fatalf("expected no pending payments, got %v", len(pending))
+	}
+
+	// Ensure disconnecting the disapproving block restores the voided
+	// payments as they were.
+	h.revertParentWork(header)
+
+	parent, err = FetchAcceptedWork(db, []byte(parent.UUID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parent.Status != WorkPending {
+		t.Fatalf("expected pending work, got %v", parent.Status)
+	}
+
+	pending, err = dividend.FetchPendingPaymentsAtHeight(db, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != len(pmts) {
+		t.Fatalf("expected %v restored payments, got %v", len(pmts),
+			len(pending))
+	}
+
+	for _, pmt := range pending {
+		if !pmt.Provisional || pmt.Voided {
+			t.Fatalf("expected payment of %v to be provisional", pmt.Account)
+		}
+
+		var match bool
+		for _, orig := range pmts {
+			if orig.Account == pmt.Account && orig.Amount == pmt.Amount &&
+				orig.CreatedOn == pmt.CreatedOn {
+				match = true
+			}
+		}
+		if !match {
+			t.Fatalf("unexpected restored payment of %v to %v", pmt.Amount,
+				pmt.Account)
+		}
+	}
+
+	// Ensure voided payments are pruned past the reorg limit.
+	h.confirmParentWork(header)
+	err = dividend.PruneVoidedPayments(db, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := dividend.FilterPayments(db,
+		func(*dividend.Payment) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != 0 {
+		t.Fatalf("expected voided payments pruned, got %v", len(all))
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// connRejectTimeout is the time a refused connection has to send its
+	// first request, answered with the reason the connection is refused.
+	connRejectTimeout = time.Second * 2
+
+	// maxPendingRejects is the maximum number of refused connections being
+	// answered at once, further refused connections are closed without an
+	// answer so floods of connections cannot pile up.
+	maxPendingRejects = 64
+
+	// connLimitMessage is the error message of connections refused when
+	// the pool serves its maximum number of clients.
+	connLimitMessage = "Pool connection limit reached, retry later"
+
+	// ipConnLimitMessage is the error message of connections refused when
+	// their address has its maximum number of clients connected.
+	ipConnLimitMessage = "Connection limit of the address reached"
+)
+
+// connLimits tracks the clients connected to the endpoints of a hub, in
+// total and per source ip, capping them to the configured limits.
+type connLimits struct {
+	total     uint32
+	ips       map[string]uint32
+	mtx       sync.Mutex
+	rejecting int32
+}
+
+// acquire reserves a connection of the provided ip, the returned message is
+// the reason the connection is refused when a limit is reached. Zero limits
+// are not enforced.
+func (l *connLimits) acquire(ip string, maxClients uint32, maxIPClients uint32) (string, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if maxClients > 0 && l.total >= maxClients {
+		return connLimitMessage, false
+	}
+
+	if maxIPClients > 0 && l.ips[ip] >= maxIPClients {
+		return ipConnLimitMessage, false
+	}
+
+	if l.ips == nil {
+		l.ips = make(map[string]uint32)
+	}
+	l.total++
+	l.ips[ip]++
+
+	return "", true
+}
+
+// release releases a connection of the provided ip reserved by acquire.
+func (l *connLimits) release(ip string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.ips[ip] == 0 {
+		return
+	}
+
+	l.total--
+	l.ips[ip]--
+	if l.ips[ip] == 0 {
+		delete(l.ips, ip)
+	}
+}
+
+// reject answers the provided refused connection with the provided reason,
+// or closes it right away when the maximum number of refused connections are
+// already being answered.
+func (l *connLimits) reject(conn net.Conn, message string) {
+	if atomic.AddInt32(&l.rejecting, 1) > maxPendingRejects {
+		atomic.AddInt32(&l.rejecting, -1)
+		conn.Close()
+		return
+	}
+
+	go func() {
+		rejectConn(conn, message)
+		atomic.AddInt32(&l.rejecting, -1)
+	}()
+}
+
+// acquireConn reserves a client connection of the provided ip within the
+// configured connection limits, counting refused connections.
+func (h *Hub) acquireConn(ip string) (string, bool) {
+	message, ok := h.conns.acquire(ip, h.cfg.MaxClients, h.cfg.MaxIPClients)
+	if !ok {
+		if message == connLimitMessage {
+			atomic.AddUint64(&h.metrics.connsRefused, 1)
+		} else {
+			atomic.AddUint64(&h.metrics.ipConnsRefused, 1)
+		}
+	}
+
+	return message, ok
+}
+
+// rejectConn answers the first request of a refused connection with a
+// stratum error carrying the provided reason and closes the connection.
+// It must be run as a goroutine, bounded by connLimits.reject.
+func rejectConn(conn net.Conn, message string) {
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(connRejectTimeout))
+	var id uint64
+	line, err := bufio.NewReaderSize(conn, MaxMessageSize).ReadSlice('\n')
+	if err == nil {
+		var req Request
+		if json.Unmarshal(line, &req) == nil && req.ID != nil {
+			id = *req.ID
+		}
+	}
+
+	serr := NewStratumError(Unknown, nil)
+	serr.Message = message
+	json.NewEncoder(conn).Encode(NewResponse(id, nil, serr))
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnLimits(t *testing.T) {
+	var l connLimits
+	for i := 0; i < 2; i++ {
+		if _, ok := l.acquire("10.0.0.1", 3, 2); !ok {
+			t.Fatalf("expected connection %v of the address acquired", i)
+		}
+	}
+
+	message, ok := l.acquire("10.0.0.1", 3, 2)
+	if ok || message != ipConnLimitMessage {
+		t.Fatalf("expected the address limit reached, got %q", message)
+	}
+
+	if _, ok := l.acquire("10.0.0.2", 3, 2); !ok {
+		t.Fatal("expected a connection of another address acquired")
+	}
+
+	message, ok = l.acquire("10.0.0.3", 3, 2)
+	if ok || message != connLimitMessage {
+		t.Fatalf("expected the total limit reached, got %q", message)
+	}
+
+	l.release("10.0.0.1")
+	if _, ok := l.acquire("10.0.0.1", 3, 2); !ok {
+		t.Fatal("expected a released connection acquired again")
+	}
+
+	// Releasing an address without connections is a no-op.
+	l.release("10.0.0.4")
+	if l.total != 3 || len(l.ips) != 2 {
+		t.Fatalf("unexpected connections tracked: %v, %v", l.total, l.ips)
+	}
+
+	// Zero limits are not enforced.
+	var unlimited connLimits
+	for i := 0; i < 10; i++ {
+		if _, ok := unlimited.acquire("10.0.0.1", 0, 0); !ok {
+			t.Fatal("expected connections acquired without limits")
+		}
+	}
+}
+
+func TestRejectConn(t *testing.T) {
+	server, client := net.Pipe()
+	go rejectConn(server, connLimitMessage)
+
+	_, err := client.Write([]byte(`{"id":7,"method":"mining.subscribe",` +
+		`"params":[]}` + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := bufio.NewReader(client).ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	var resp Response
+	err = json.Unmarshal(line, &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.ID != 7 || resp.Error == nil ||
+		resp.Error.Message != connLimitMessage {
+		t.Fatalf("unexpected rejection response: %s", line)
+	}
+
+	// Ensure refused connections beyond the pending limit are closed
+	// without an answer.
+	var limits connLimits
+	clients := make([]net.Conn, 0, maxPendingRejects)
+	for i := 0; i < maxPendingRejects; i++ {
+		server, client := net.Pipe()
+		limits.reject(server, connLimitMessage)
+		clients = append(clients, client)
+	}
+
+	server, client = net.Pipe()
+	limits.reject(server, connLimitMessage)
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = client.Read(make([]byte, 1))
+	if err != io.EOF {
+		t.Fatalf("expected a refused connection beyond the limit to be "+
+			"closed, got %v", err)
+	}
+
+	for _, client := range clients {
+		client.Close()
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// dashboardClient summarizes a connected client on the operator dashboard.
+type dashboardClient struct {
+	ID          string
+	IP          string
+	Account     string
+	AccountID   string
+	Worker      string
+	Miner       string
+	Region      string
+	Difficulty  string
+	HashRate    string
+	Accepted    uint32
+	Rejected    uint32
+	ConnectedOn int64
+
+	// The share ratios of the client's worker over its lifetime, as
+	// percentages.
+	AcceptedRatio string
+	StaleRatio    string
+	InvalidRatio  string
+}
+
+// dashboardPayment summarizes the pending payments of an account on the
+// operator dashboard.
+type dashboardPayment struct {
+	Account           string
+	AccountID         string
+	Payments          int
+	Total             dcrutil.Amount
+	EstimatedMaturity uint32
+}
+
+// dashboardData is the data rendered by the operator dashboard.
+type dashboardData struct {
+	Operator          string
+	Token             string
+	Message           string
+	SoloPool          bool
+	DcrdConnected     bool
+	WalletState       string
+	LastWorkHeight    uint32
+	WorkAge           time.Duration
+	LastPaymentHeight uint32
+	TxFeeReserve      dcrutil.Amount
+	HashRate          string
+	TimeToBlock       time.Duration
+	Alerts            []operatorAlert
+	Clients           []dashboardClient
+	Payments          []dashboardPayment
+}
+
+// dashboardToken returns the token dashboard forms of the provided operator
+// submit, it protects dashboard controls from cross-site requests.
+func (h *Hub) dashboardToken(operator string) string {
+	mac := hmac.New(sha256.New, h.sessionKey)
+	mac.Write([]byte("dashboard:" + operator))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// dashboardClients summarizes the connected clients, ordered by account.
+func (h *Hub) dashboardClients() []dashboardClient {
+	clients := make([]dashboardClient, 0)
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			client.hashRateMtx.RLock()
+			hashRate := client.hashRate.FloatString(6)
+			client.hashRateMtx.RUnlock()
+
+			clients = append(clients, dashboardClient{
+				ID:          client.generateID(),
+				IP:          client.ip,
+				AccountID:   client.account,
+				Worker:      client.worker,
+				Miner:       endpoint.miner,
+				Region:      endpoint.region,
+				Difficulty:  client.diffData.difficulty.String(),
+				HashRate:    hashRate,
+				Accepted:    atomic.LoadUint32(&client.accepted),
+				Rejected:    atomic.LoadUint32(&client.rejected),
+				ConnectedOn: client.connectedOn,
+			})
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	// Resolve account and worker names.
+	names := make(map[string]string)
+	for i := range clients {
+		c := &clients[i]
+		if c.AccountID != "" {
+			name, ok := names[c.AccountID]
+			if !ok {
+				account, err := dividend.FetchAccount(h.db, []byte(c.AccountID))
+				if err == nil {
+					name = account.Name
+				}
+				names[c.AccountID] = name
+			}
+			c.Account = name
+		}
+
+		if c.Worker != "" {
+			worker, err := dividend.FetchWorker(h.db, []byte(c.Worker))
+			if err == nil {
+				c.Worker = worker.Name
+				ratios := worker.ShareRatios()
+				c.AcceptedRatio = fmt.Sprintf("%.2f", ratios.Accepted*100)
+				c.StaleRatio = fmt.Sprintf("%.2f", ratios.Stale*100)
+				c.InvalidRatio = fmt.Sprintf("%.2f", ratios.Invalid*100)
+			}
+		}
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].Account != clients[j].Account {
+			return clients[i].Account < clients[j].Account
+		}
+		return clients[i].ID < clients[j].ID
+	})
+
+	return clients
+}
+
+// dashboardPayments summarizes the pending payments per account.
+func (h *Hub) dashboardPayments() ([]dashboardPayment, error) {
+	pending, err := dividend.FetchPendingPayments(h.db)
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := dividend.GeneratePaymentBundles(pending)
+	payments := make([]dashboardPayment, 0, len(bundles))
+	for _, bundle := range bundles {
+		payment := dashboardPayment{
+			Account:   bundle.Account,
+			AccountID: bundle.Account,
+			Payments:  len(bundle.Payments),
+			Total:     bundle.Total(),
+		}
+
+		for _, pmt := range bundle.Payments {
+			if payment.EstimatedMaturity == 0 ||
+				pmt.EstimatedMaturity < payment.EstimatedMaturity {
+				payment.EstimatedMaturity = pmt.EstimatedMaturity
+			}
+		}
+
+		if bundle.Account != dividend.PoolFeesK {
+			account, err := dividend.FetchAccount(h.db,
+				[]byte(bundle.Account))
+			if err == nil {
+				payment.Account = account.Name
+			}
+		}
+
+		payments = append(payments, payment)
+	}
+
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].Total > payments[j].Total
+	})
+
+	return payments, nil
+}
+
+// Dashboard renders the operator dashboard.
+func (h *Hub) Dashboard(w http.ResponseWriter, r *http.Request) {
+	operator := requestOperator(r)
+	data := dashboardData{
+		Operator:          operator,
+		Token:             h.dashboardToken(operator),
+		Message:           r.URL.Query().Get("msg"),
+		SoloPool:          h.cfg.SoloPool,
+		LastWorkHeight:    atomic.LoadUint32(&h.lastWorkHeight),
+		WorkAge:           h.workAge(time.Now()).Round(time.Second),
+		LastPaymentHeight: atomic.LoadUint32(&h.lastPaymentHeight),
+		HashRate:          h.hashRate("").FloatString(6),
+		TimeToBlock:       h.timeToBlockDuration(),
+		Alerts:            h.activeAlerts(),
+		Clients:           h.dashboardClients(),
+	}
+
+	h.rpccMtx.Lock()
+	data.DcrdConnected = !h.rpcc.Disconnected()
+	h.rpccMtx.Unlock()
+
+	if !h.cfg.SoloPool {
+		h.grpcMtx.Lock()
+		data.WalletState = h.gConn.GetState().String()
+		h.grpcMtx.Unlock()
+
+		h.paymentMtx.Lock()
+		data.TxFeeReserve = h.txFeeReserve
+		h.paymentMtx.Unlock()
+
+		var err error
+		data.Payments, err = h.dashboardPayments()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err := h.renderPage(w, r, dashboardPage, data)
+	if err != nil {
+		log.Errorf("Failed to render dashboard: %v", err)
+	}
+}
+
+// dashboardAction validates a dashboard control request and redirects back
+// to the dashboard with the outcome of the provided action.
+func (h *Hub) dashboardAction(w http.ResponseWriter, r *http.Request, action func() (string, error)) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	expected := h.dashboardToken(requestOperator(r))
+	if !hmac.Equal([]byte(r.PostForm.Get("csrf")), []byte(expected)) {
+		http.Error(w, "invalid form token", http.StatusForbidden)
+		return
+	}
+
+	msg, err := action()
+	if err != nil {
+		msg = err.Error()
+	}
+
+	http.Redirect(w, r, "/admin/dashboard?msg="+url.QueryEscape(msg),
+		http.StatusSeeOther)
+}
+
+// DashboardSuspend handles dashboard requests to suspend or ban an account.
+func (h *Hub) DashboardSuspend(w http.ResponseWriter, r *http.Request) {
+	h.dashboardAction(w, r, func() (string, error) {
+		id := r.PostForm.Get("accountid")
+		account, _, err := h.suspendAccount(r, id,
+			r.PostForm.Get("reason"), r.PostForm.Get("ban") == "true")
+		if err != nil {
+			return "", err
+		}
+
+		tr := h.translator(w, r)
+		if account.Banned {
+			return tr.T("dashboard.banned", account.Name), nil
+		}
+		return tr.T("dashboard.suspended", account.Name), nil
+	})
+}
+
+// DashboardReinstate handles dashboard requests to reinstate an account.
+func (h *Hub) DashboardReinstate(w http.ResponseWriter, r *http.Request) {
+	h.dashboardAction(w, r, func() (string, error) {
+		account, _, err := h.reinstateAccount(r, r.PostForm.Get("accountid"))
+		if err != nil {
+			return "", err
+		}
+
+		return h.translator(w, r).T("dashboard.reinstated", account.Name), nil
+	})
+}
+
+// DashboardPayouts handles dashboard requests to pay out mature payments
+// without waiting for the next block.
+func (h *Hub) DashboardPayouts(w http.ResponseWriter, r *http.Request) {
+	h.dashboardAction(w, r, func() (string, error) {
+		height, err := h.triggerPayouts(requestOperator(r))
+		if err != nil {
+			return "", err
+		}
+
+		return h.translator(w, r).T("dashboard.payouts.processed", height),
+			nil
+	})
+}
+
+// DashboardRegenerateWork handles dashboard requests to broadcast fresh work
+// to all connected clients.
+func (h *Hub) DashboardRegenerateWork(w http.ResponseWriter, r *http.Request) {
+	h.dashboardAction(w, r, func() (string, error) {
+		height, err := h.regenerateWork(requestOperator(r))
+		if err != nil {
+			return "", err
+		}
+
+		return h.translator(w, r).T("dashboard.work.regenerated", height),
+			nil
+	})
+}
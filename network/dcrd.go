@@ -0,0 +1,430 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/rpcclient"
+	"github.com/decred/dcrd/wire"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+const (
+	// dcrdCheckInterval is the interval between checks of the dcrd RPC
+	// connections.
+	dcrdCheckInterval = time.Second
+
+	// dcrdMinBackoff is the delay before retrying a failed reconnection
+	// to dcrd, it doubles with every failed attempt.
+	dcrdMinBackoff = time.Second
+
+	// dcrdMaxBackoff is the maximum delay between reconnection attempts
+	// to dcrd.
+	dcrdMaxBackoff = time.Minute
+
+	// dcrdMaxSyncLag is the number of known headers dcrd may have yet to
+	// connect blocks for while considered synced.
+	dcrdMaxSyncLag = 6
+
+	// dcrdMaxTipAge is the age of the chain tip past which dcrd is
+	// considered syncing, mirroring dcrd's own notion of being current.
+	// The tip age is not checked on simnet where blocks are generated on
+	// demand.
+	dcrdMaxTipAge = time.Hour * 24
+
+	// dcrdSyncingMessage is the error message of miner requests refused
+	// while dcrd is syncing.
+	dcrdSyncingMessage = "Pool chain backend is syncing, retry later"
+)
+
+// errDcrdSyncing is returned when work is requested while dcrd is syncing.
+var errDcrdSyncing = errors.New("dcrd is syncing")
+
+// dcrdBackend is a dcrd node the pool can pull work from. The client and
+// height of a backend are only updated by the dcrd backends handler, the
+// client is updated with the rpc client mutex held.
+type dcrdBackend struct {
+	cfg     *rpcclient.ConnConfig
+	client  *rpcclient.Client
+	height  int64
+	healthy bool
+	synced  bool
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// connected returns whether the backend has a live RPC connection.
+func (b *dcrdBackend) connected() bool {
+	return b.client != nil && !b.client.Disconnected()
+}
+
+// dcrdNotificationHandlers returns the handlers of the chain notifications
+// subscribed for with the provided backend. Only notifications of the
+// active backend are processed.
+func (h *Hub) dcrdNotificationHandlers(backend int32) *rpcclient.NotificationHandlers {
+	return &rpcclient.NotificationHandlers{
+		OnBlockConnected: func(headerB []byte, transactions [][]byte) {
+			if atomic.LoadInt32(&h.activeDcrd) == backend {
+				atomic.AddInt64(&h.metrics.blocksPending, 1)
+				h.connCh <- headerB
+			}
+		},
+
+		OnBlockDisconnected: func(headerB []byte) {
+			if atomic.LoadInt32(&h.activeDcrd) == backend {
+				atomic.AddInt64(&h.metrics.blocksPending, 1)
+				h.discCh <- headerB
+			}
+		},
+
+		OnTxAcceptedVerbose: func(tx *dcrjson.TxRawResult) {
+			if atomic.LoadInt32(&h.activeDcrd) != backend ||
+				!isVoteResult(tx) {
+				return
+			}
+
+			hash, err := chainhash.NewHashFromStr(tx.Txid)
+			if err != nil {
+				chainLog.Errorf("Invalid vote hash %v: %v", tx.Txid, err)
+				return
+			}
+			h.queueVote(hash)
+		},
+
+		// TODO: Switch to OnWork notifications when dcrd PR #1410 is merged.
+		// OnWork: func(headerE string, target string) {
+		// 	h.processWork(headerE, target)
+		// },
+	}
+}
+
+// connectDcrd establishes an RPC connection with the provided backend and
+// subscribes for chain and mempool notifications.
+func (h *Hub) connectDcrd(backend int32) (*rpcclient.Client, error) {
+	cfg := h.backends[backend].cfg
+	rpcc, err := rpcclient.New(cfg, h.dcrdNotificationHandlers(backend))
+	if err != nil {
+		return nil, fmt.Errorf("rpc error (dcrd %v): %v", cfg.Host, err)
+	}
+
+	// TODO: Subscribe for OnWork notifications when dcrd PR #1410 is merged.
+	// if err := rpcc.NotifyWork(); err != nil {
+	// 	rpcc.Shutdown()
+	// 	return nil, fmt.Errorf("notify work rpc error (dcrd): %v", err)
+	// }
+
+	if err := rpcc.NotifyBlocks(); err != nil {
+		rpcc.Shutdown()
+		return nil, fmt.Errorf("notify blocks rpc error (dcrd %v): %v",
+			cfg.Host, err)
+	}
+
+	if err := rpcc.NotifyNewTransactions(true); err != nil {
+		rpcc.Shutdown()
+		return nil, fmt.Errorf("notify new transactions rpc error "+
+			"(dcrd %v): %v", cfg.Host, err)
+	}
+
+	return rpcc, nil
+}
+
+// nextBackoff returns the delay before the reconnection attempt following
+// one delayed by the provided backoff.
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > dcrdMaxBackoff {
+		return dcrdMaxBackoff
+	}
+	return backoff
+}
+
+// chainSynced returns whether a dcrd node with the provided chain state is
+// synced: it has connected blocks up to the best header known and, unless the
+// tip age is not checked, its tip is recent.
+func chainSynced(blocks, headers int64, tipTime, now time.Time, checkTipAge bool) bool {
+	if headers-blocks > dcrdMaxSyncLag {
+		return false
+	}
+
+	return !checkTipAge || now.Sub(tipTime) <= dcrdMaxTipAge
+}
+
+// dcrdChainState returns the chain height of the provided dcrd client and
+// whether it is synced.
+func (h *Hub) dcrdChainState(client *rpcclient.Client, now time.Time) (int64, bool, error) {
+	info, err := client.GetBlockChainInfo()
+	if err != nil {
+		return 0, false, err
+	}
+
+	checkTipAge := h.cfg.ActiveNet.Name != chaincfg.SimNetParams.Name
+	var tipTime time.Time
+	if checkTipAge {
+		hash, err := chainhash.NewHashFromStr(info.BestBlockHash)
+		if err != nil {
+			return 0, false, err
+		}
+
+		header, err := client.GetBlockHeader(hash)
+		if err != nil {
+			return 0, false, err
+		}
+		tipTime = header.Timestamp
+	}
+
+	synced := chainSynced(int64(info.Blocks), int64(info.Headers), tipTime,
+		now, checkTipAge)
+	return int64(info.Blocks), synced, nil
+}
+
+// dcrdSyncing returns whether the active dcrd backend is syncing, miners are
+// refused and no work is generated while it is.
+func (h *Hub) dcrdSyncing() bool {
+	return atomic.LoadInt32(&h.syncing) == 1
+}
+
+// setDcrdSyncing updates the sync state of the active dcrd backend, work is
+// fetched when it catches up.
+func (h *Hub) setDcrdSyncing(host string, syncing bool) {
+	var state int32
+	if syncing {
+		state = 1
+	}
+
+	if atomic.SwapInt32(&h.syncing, state) == state {
+		return
+	}
+
+	if syncing {
+		chainLog.Warnf("dcrd %v is syncing, refusing miners until it catches up.",
+			host)
+		return
+	}
+
+	chainLog.Infof("dcrd %v is synced, resuming work.", host)
+	h.signalWork()
+}
+
+// checkDcrdBackend reconnects the provided backend if its connection was
+// lost and the reconnection backoff elapsed, and updates its chain height
+// and sync state.
+func (h *Hub) checkDcrdBackend(idx int32, now time.Time) {
+	b := h.backends[idx]
+	if !b.connected() {
+		b.healthy = false
+		if now.Before(b.retryAt) {
+			return
+		}
+
+		client, err := h.connectDcrd(idx)
+		if err != nil {
+			chainLog.Errorf("Failed to reconnect to dcrd %v, retrying in %v: %v",
+				b.cfg.Host, b.backoff, err)
+			b.retryAt = now.Add(b.backoff)
+			b.backoff = nextBackoff(b.backoff)
+			return
+		}
+
+		h.rpccMtx.Lock()
+		old := b.client
+		b.client = client
+		h.rpccMtx.Unlock()
+		if old != nil {
+			old.Shutdown()
+		}
+
+		b.backoff = dcrdMinBackoff
+		chainLog.Infof("RPC connection re-established with dcrd %v.", b.cfg.Host)
+	}
+
+	height, synced, err := h.dcrdChainState(b.client, now)
+	if err != nil {
+		chainLog.Errorf("Failed to fetch the chain state of dcrd %v: %v",
+			b.cfg.Host, err)
+		b.healthy = false
+		return
+	}
+
+	b.height = height
+	b.synced = synced
+	b.healthy = true
+}
+
+// selectDcrdBackend returns the index of the healthy backend with the
+// highest chain, the active backend is kept unless it lags behind. It
+// returns the active backend if no backend is healthy.
+func selectDcrdBackend(backends []*dcrdBackend, active int32) int32 {
+	best := int32(-1)
+	for idx, b := range backends {
+		if !b.healthy {
+			continue
+		}
+
+		if best < 0 || b.height > backends[best].height {
+			best = int32(idx)
+		}
+	}
+
+	if best < 0 {
+		return active
+	}
+
+	if backends[active].healthy &&
+		backends[active].height >= backends[best].height {
+		return active
+	}
+
+	return best
+}
+
+// updateActiveDcrd switches work generation to the selected backend when it
+// is not the current one, or when the current one reconnected, and sends
+// fresh work to all clients unless the backend is syncing.
+func (h *Hub) updateActiveDcrd() {
+	active := atomic.LoadInt32(&h.activeDcrd)
+	selected := selectDcrdBackend(h.backends, active)
+	backend := h.backends[selected]
+	if backend.healthy {
+		h.setDcrdSyncing(backend.cfg.Host, !backend.synced)
+	}
+
+	h.rpccMtx.Lock()
+	if h.rpcc == backend.client {
+		h.rpccMtx.Unlock()
+		return
+	}
+	h.rpcc = backend.client
+	atomic.StoreInt32(&h.activeDcrd, selected)
+	h.rpccMtx.Unlock()
+
+	if selected != active {
+		chainLog.Warnf("Failing over from dcrd %v to dcrd %v.",
+			h.backends[active].cfg.Host, backend.cfg.Host)
+		h.logPoolEvent(dividend.PoolEventBackendFailover,
+			"dcrd failed over to "+backend.cfg.Host,
+			map[string]string{
+				"from": h.backends[active].cfg.Host,
+				"to":   backend.cfg.Host,
+			}, "")
+	}
+
+	if h.dcrdSyncing() {
+		return
+	}
+
+	headerE, target, err := h.GetWork()
+	if err != nil {
+		chainLog.Errorf("Failed to fetch work: %v", err)
+		return
+	}
+
+	h.processWork(headerE, target)
+}
+
+// relayBlock submits the block of the provided work submission, accepted by
+// the active backend, to all other reachable backends in parallel so the
+// block propagates from every node at once rather than racing competing
+// blocks from the active node alone.
+func (h *Hub) relayBlock(submission string) {
+	if len(h.backends) < 2 {
+		return
+	}
+
+	headerB, err := hex.DecodeString(submission[:wire.MaxBlockHeaderPayload*2])
+	if err != nil {
+		chainLog.Errorf("Failed to decode submitted header: %v", err)
+		return
+	}
+
+	var header wire.BlockHeader
+	err = header.FromBytes(headerB)
+	if err != nil {
+		chainLog.Errorf("Failed to deserialize submitted header: %v", err)
+		return
+	}
+
+	hash := header.BlockHash()
+	h.rpccMtx.Lock()
+	active := h.rpcc
+	hosts := make([]string, 0, len(h.backends))
+	clients := make([]*rpcclient.Client, 0, len(h.backends))
+	for _, b := range h.backends {
+		if b.client != nil && b.client != active &&
+			!b.client.Disconnected() {
+			hosts = append(hosts, b.cfg.Host)
+			clients = append(clients, b.client)
+		}
+	}
+	h.rpccMtx.Unlock()
+
+	if len(clients) == 0 {
+		return
+	}
+
+	start := time.Now()
+	msgBlock, err := active.GetBlock(&hash)
+	if err != nil {
+		chainLog.Errorf("Failed to fetch block %v: %v", hash, err)
+		return
+	}
+
+	// Submit the block to all backends before waiting on any of them.
+	block := dcrutil.NewBlock(msgBlock)
+	results := make([]rpcclient.FutureSubmitBlockResult, 0, len(clients))
+	for _, client := range clients {
+		results = append(results, client.SubmitBlockAsync(block, nil))
+	}
+
+	var relayed int
+	for idx, result := range results {
+		err := result.Receive()
+		if err != nil {
+			chainLog.Debugf("Failed to relay block %v to %v: %v", hash,
+				hosts[idx], err)
+			continue
+		}
+		relayed++
+	}
+
+	chainLog.Infof("Relayed block %v to %v of %v backends in %v", hash, relayed,
+		len(clients), time.Since(start))
+}
+
+// handleDcrdBackends monitors the dcrd backends, re-establishing dropped
+// connections with exponential backoff and failing over to the backend with
+// the highest chain when the active one disconnects or lags behind. It must
+// be run as a goroutine.
+func (h *Hub) handleDcrdBackends(ctx context.Context) {
+	ticker := time.NewTicker(dcrdCheckInterval)
+	defer ticker.Stop()
+	h.wg.Add(1)
+	chainLog.Trace("Started dcrd backends handler.")
+
+	for {
+		select {
+		case <-ctx.Done():
+			chainLog.Trace("Dcrd backends handler done.")
+			h.wg.Done()
+			return
+		case now := <-ticker.C:
+			for idx := range h.backends {
+				h.checkDcrdBackend(int32(idx), now)
+			}
+
+			h.updateActiveDcrd()
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextBackoff(t *testing.T) {
+	backoff := dcrdMinBackoff
+	expected := []time.Duration{2, 4, 8, 16, 32, 60, 60}
+	for _, secs := range expected {
+		backoff = nextBackoff(backoff)
+		if backoff != secs*time.Second {
+			t.Fatalf("expected a backoff of %vs, got %v", secs, backoff)
+		}
+	}
+}
+
+func TestSelectDcrdBackend(t *testing.T) {
+	backends := []*dcrdBackend{
+		{healthy: true, height: 100},
+		{healthy: true, height: 100},
+		{healthy: false, height: 0},
+	}
+
+	if selected := selectDcrdBackend(backends, 0); selected != 0 {
+		t.Fatalf("expected the active backend to be kept, got %v", selected)
+	}
+
+	backends[1].height = 101
+	if selected := selectDcrdBackend(backends, 0); selected != 1 {
+		t.Fatalf("expected to fail over from a lagging backend, got %v",
+			selected)
+	}
+
+	backends[0].healthy = false
+	backends[1].healthy = false
+	if selected := selectDcrdBackend(backends, 1); selected != 1 {
+		t.Fatalf("expected the active backend without healthy backends, "+
+			"got %v", selected)
+	}
+
+	backends[2].healthy = true
+	if selected := selectDcrdBackend(backends, 1); selected != 2 {
+		t.Fatalf("expected to fail over from a disconnected backend, "+
+			"got %v", selected)
+	}
+}
+
+func TestChainSynced(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		blocks      int64
+		headers     int64
+		tipTime     time.Time
+		checkTipAge bool
+		synced      bool
+	}{
+		{"current", 100, 100, now.Add(-time.Minute), true, true},
+		{"within lag", 100, 100 + dcrdMaxSyncLag, now, true, true},
+		{"behind headers", 100, 101 + dcrdMaxSyncLag, now, true, false},
+		{"stale tip", 100, 100, now.Add(-dcrdMaxTipAge - time.Second),
+			true, false},
+		{"stale tip unchecked", 100, 100, time.Time{}, false, true},
+	}
+
+	for _, test := range tests {
+		synced := chainSynced(test.blocks, test.headers, test.tipTime, now,
+			test.checkTipAge)
+		if synced != test.synced {
+			t.Fatalf("%v: expected synced %v, got %v", test.name,
+				test.synced, synced)
+		}
+	}
+}
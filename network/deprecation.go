@@ -0,0 +1,49 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// legacyAPIDeprecated is when the unversioned api routes superseded by the
+// versioned api were deprecated.
+var legacyAPIDeprecated = time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC)
+
+// deprecationHeaders sets the headers announcing the deprecation of a route:
+// the Deprecation header with the date of deprecation, the Sunset header
+// with the date the route is removed when known and a Link header referring
+// to the route superseding it.
+func deprecationHeaders(header http.Header, deprecated time.Time, sunset time.Time, successor string) {
+	header.Set("Deprecation", fmt.Sprintf("@%d", deprecated.Unix()))
+	if !sunset.IsZero() {
+		header.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+	if successor != "" {
+		header.Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`,
+			successor))
+	}
+}
+
+// Deprecated wraps the handler of an unversioned api route superseded by the
+// provided versioned route. Responses announce the deprecation with
+// Deprecation, Sunset and Link headers, and requests after the configured
+// sunset are answered with 410 Gone.
+func (h *Hub) Deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sunset := h.cfg.LegacyAPISunset
+		deprecationHeaders(w.Header(), legacyAPIDeprecated, sunset, successor)
+
+		if !sunset.IsZero() && !time.Now().Before(sunset) {
+			RespondWithError(w, http.StatusGone,
+				fmt.Sprintf("route removed, use %v", successor))
+			return
+		}
+
+		next(w, r)
+	}
+}
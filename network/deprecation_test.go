@@ -0,0 +1,54 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeprecated(t *testing.T) {
+	served := false
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	}
+
+	h := &Hub{cfg: &HubConfig{}}
+	w := httptest.NewRecorder()
+	h.Deprecated("/api/v1/blocks", handler)(w,
+		httptest.NewRequest("GET", "/mined", nil))
+	if !served || w.Code != http.StatusOK {
+		t.Fatalf("expected the route to be served, got %v", w.Code)
+	}
+	if w.Header().Get("Deprecation") == "" ||
+		w.Header().Get("Sunset") != "" ||
+		w.Header().Get("Link") != `</api/v1/blocks>; rel="successor-version"` {
+		t.Fatalf("unexpected headers %v", w.Header())
+	}
+
+	// Routes are announced to be removed at the sunset.
+	served = false
+	h.cfg.LegacyAPISunset = time.Now().Add(time.Hour)
+	w = httptest.NewRecorder()
+	h.Deprecated("/api/v1/blocks", handler)(w,
+		httptest.NewRequest("GET", "/mined", nil))
+	sunset, err := http.ParseTime(w.Header().Get("Sunset"))
+	if err != nil || !served ||
+		sunset.Unix() != h.cfg.LegacyAPISunset.Unix() {
+		t.Fatalf("unexpected sunset %v (%v)", sunset, err)
+	}
+
+	// Routes are gone after the sunset.
+	served = false
+	h.cfg.LegacyAPISunset = time.Now().Add(-time.Hour)
+	w = httptest.NewRecorder()
+	h.Deprecated("/api/v1/blocks", handler)(w,
+		httptest.NewRequest("GET", "/mined", nil))
+	if served || w.Code != http.StatusGone {
+		t.Fatalf("expected the route to be gone, got %v", w.Code)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+const (
+	// ReportPayments lists every payment made to an account.
+	ReportPayments = "payments"
+
+	// ReportDaily summarizes the payments made to an account per day.
+	ReportDaily = "daily"
+)
+
+// dailyEarnings is the summary of the payments of an account created on a
+// day.
+type dailyEarnings struct {
+	day      string
+	payments int
+	total    dcrutil.Amount
+}
+
+// forEachAccountPayment calls the provided function with the payments made
+// to the provided account selected by the query, oldest first. Payments are
+// fetched a page at a time so large histories are never held in memory.
+func (h *Hub) forEachAccountPayment(account string, query *dividend.PageQuery, fn func([]*dividend.Payment) error) error {
+	query.Ascending = true
+	query.Limit = maxPageLimit
+	query.Cursor = ""
+	for {
+		payments, next, err := dividend.FetchAccountPayments(h.db, account,
+			query)
+		if err != nil {
+			return err
+		}
+
+		err = fn(payments)
+		if err != nil {
+			return err
+		}
+
+		if next == "" {
+			return nil
+		}
+		query.Cursor = next
+	}
+}
+
+// DownloadAccountPayments streams the payment history of the authenticated
+// account as csv. The `report` parameter selects either every payment
+// (payments, the default) or daily earnings summaries (daily), the `from`
+// and `to` parameters bound the payments by the unix time they were made.
+func (h *Hub) DownloadAccountPayments(w http.ResponseWriter, r *http.Request) {
+	report := r.URL.Query().Get("report")
+	if report == "" {
+		report = ReportPayments
+	}
+
+	if report != ReportPayments && report != ReportDaily {
+		RespondWithError(w, http.StatusBadRequest,
+			fmt.Sprintf("provided 'report' parameter is neither '%v' nor '%v'",
+				ReportPayments, ReportDaily))
+		return
+	}
+
+	query, err := parsePageQuery(r, true)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id := requestAccountID(r)
+	filename := fmt.Sprintf("%v-%v.csv", report, id)
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", filename))
+
+	flusher, _ := w.(http.Flusher)
+	cw := csv.NewWriter(w)
+	flush := func() error {
+		cw.Flush()
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return cw.Error()
+	}
+
+	// Errors after the first write cannot change the response status, the
+	// download is cut short instead.
+	if report == ReportPayments {
+		cw.Write([]string{"created", "height", "amount",
+			"estimatedmaturity", "paidonheight"})
+		err = h.forEachAccountPayment(id, query,
+			func(payments []*dividend.Payment) error {
+				for _, pmt := range payments {
+					cw.Write([]string{
+						time.Unix(0, pmt.CreatedOn).UTC().Format(time.RFC3339),
+						strconv.FormatUint(uint64(pmt.Height), 10),
+						strconv.FormatFloat(pmt.Amount.ToCoin(), 'f', -1, 64),
+						strconv.FormatUint(uint64(pmt.EstimatedMaturity), 10),
+						strconv.FormatUint(uint64(pmt.PaidOnHeight), 10),
+					})
+				}
+				return flush()
+			})
+		if err != nil {
+			log.Errorf("Failed to stream payments of (%v): %v", id, err)
+		}
+		return
+	}
+
+	days := make(map[string]*dailyEarnings)
+	err = h.forEachAccountPayment(id, query,
+		func(payments []*dividend.Payment) error {
+			for _, pmt := range payments {
+				day := time.Unix(0, pmt.CreatedOn).UTC().Format("2006-01-02")
+				earnings, ok := days[day]
+				if !ok {
+					earnings = &dailyEarnings{day: day}
+					days[day] = earnings
+				}
+				earnings.payments++
+				earnings.total += pmt.Amount
+			}
+			return nil
+		})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	summaries := make([]*dailyEarnings, 0, len(days))
+	for _, earnings := range days {
+		summaries = append(summaries, earnings)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].day < summaries[j].day
+	})
+
+	cw.Write([]string{"day", "payments", "amount"})
+	for _, earnings := range summaries {
+		cw.Write([]string{
+			earnings.day,
+			strconv.Itoa(earnings.payments),
+			strconv.FormatFloat(earnings.total.ToCoin(), 'f', -1, 64),
+		})
+	}
+
+	err = flush()
+	if err != nil {
+		log.Errorf("Failed to write daily earnings of (%v): %v", id, err)
+	}
+}
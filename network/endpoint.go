@@ -8,15 +8,21 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
+
+	"github.com/dnldd/dcrpool/dividend"
 )
 
 // Endpoint represents a stratum endpoint.
 type Endpoint struct {
+	listening  uint32 // update atomically
 	port       uint32
 	diffData   *DifficultyData
 	miner      string
+	region     string
 	listener   net.Listener
 	hub        *Hub
 	clients    map[string]*Client
@@ -25,12 +31,65 @@ type Endpoint struct {
 	wg         sync.WaitGroup
 }
 
+// ParseStratumPorts parses the stratum ports of miners of the form
+// <miner>=<port>, overriding the default ports of the miners. Miners must be
+// known and ports distinct from the ports of other miners.
+func ParseStratumPorts(entries []string) (map[string]uint32, error) {
+	ports := make(map[string]uint32, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid stratum port %q, expected "+
+				"<miner>=<port>", entry)
+		}
+
+		miner := strings.TrimSpace(parts[0])
+		if _, ok := dividend.MinerPorts[miner]; !ok {
+			return nil, fmt.Errorf("unknown miner %q", miner)
+		}
+
+		port, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 16)
+		if err != nil || port == 0 {
+			return nil, fmt.Errorf("invalid stratum port %q of miner %v",
+				parts[1], miner)
+		}
+
+		ports[miner] = uint32(port)
+	}
+
+	used := make(map[uint32]string)
+	for miner, port := range stratumPorts(ports) {
+		if other, ok := used[port]; ok {
+			return nil, fmt.Errorf("miners %v and %v share stratum port %v",
+				other, miner, port)
+		}
+		used[port] = miner
+	}
+
+	return ports, nil
+}
+
+// stratumPorts returns the stratum ports of all known miners, the provided
+// ports override the default ports of their miners.
+func stratumPorts(overrides map[string]uint32) map[string]uint32 {
+	ports := make(map[string]uint32, len(dividend.MinerPorts))
+	for miner, port := range dividend.MinerPorts {
+		ports[miner] = port
+		if override, ok := overrides[miner]; ok {
+			ports[miner] = override
+		}
+	}
+
+	return ports
+}
+
 // NewEndpoint creates an endpoint instance.
 func NewEndpoint(hub *Hub, port uint32, miner string) (*Endpoint, error) {
 	endpoint := &Endpoint{
 		port:    port,
 		hub:     hub,
 		miner:   miner,
+		region:  hub.cfg.Region,
 		clients: make(map[string]*Client),
 		connCh:  make(chan net.Conn),
 	}
@@ -48,23 +107,37 @@ func NewEndpoint(hub *Hub, port uint32, miner string) (*Endpoint, error) {
 	return endpoint, nil
 }
 
+// difficultyData returns the pool difficulty data of the endpoint, assigned
+// to clients when they connect.
+func (e *Endpoint) difficultyData() *DifficultyData {
+	e.hub.poolDiffMtx.RLock()
+	defer e.hub.poolDiffMtx.RUnlock()
+	return e.diffData
+}
+
 // listen sets up a listener for incoming client connections on the endpoint.
 // It must be run as a goroutine.
 func (e *Endpoint) listen() {
-	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", "0.0.0.0", e.port))
+	addr := net.JoinHostPort(e.hub.cfg.StratumListen,
+		strconv.FormatUint(uint64(e.port), 10))
+	listener, err := net.Listen("tcp", addr)
 	if err != nil {
-		log.Errorf("unable to listen on tcp address: %v", err)
+		stratumLog.Errorf("unable to listen on tcp address: %v", err)
 		return
 	}
 
 	e.listener = listener
-	defer e.listener.Close()
-	log.Infof("Listening on %v for %v", e.port, e.miner)
+	atomic.StoreUint32(&e.listening, 1)
+	defer func() {
+		atomic.StoreUint32(&e.listening, 0)
+		e.listener.Close()
+	}()
+	stratumLog.Infof("Listening on %v for %v", addr, e.miner)
 
 	for {
 		conn, err := e.listener.Accept()
 		if err != nil {
-			log.Tracef("unable to accept connection: %v for endpoint %v",
+			stratumLog.Tracef("unable to accept connection: %v for endpoint %v",
 				err, e.port)
 			return
 		}
@@ -76,25 +149,42 @@ func (e *Endpoint) listen() {
 // connect creates new pool clients from established connections.
 // It must be run as a goroutine.
 func (e *Endpoint) connect(ctx context.Context) {
-	log.Tracef("Started connection handler for %v clients.", e.miner)
+	stratumLog.Tracef("Started connection handler for %v clients.", e.miner)
 
 	for {
 		select {
 		case <-ctx.Done():
 			e.clientsMtx.Lock()
 			for _, client := range e.clients {
-				log.Tracef("Terminating (%v) client.", client.generateID())
+				stratumLog.Tracef("Terminating (%v) client.", client.generateID())
 				client.cancel()
 			}
 			e.clientsMtx.Unlock()
 			e.wg.Wait()
 
-			log.Tracef("Connection handler for %v clients done.", e.miner)
+			stratumLog.Tracef("Connection handler for %v clients done.", e.miner)
 			e.hub.wg.Done()
 			return
 
 		case conn := <-e.connCh:
-			client := NewClient(conn, e, conn.RemoteAddr().String())
+			addr := conn.RemoteAddr().String()
+			ip := hostIP(addr)
+			if e.hub.backend.bannedIP(ip) {
+				stratumLog.Tracef("Rejected connection from banned address (%v).",
+					addr)
+				conn.Close()
+				continue
+			}
+
+			message, ok := e.hub.acquireConn(ip)
+			if !ok {
+				stratumLog.Debugf("Refused connection from (%v): %v",
+					addr, message)
+				e.hub.conns.reject(conn, message)
+				continue
+			}
+
+			client := NewClient(conn, e, addr)
 			e.clientsMtx.Lock()
 			e.clients[client.generateID()] = client
 			e.clientsMtx.Unlock()
@@ -113,6 +203,6 @@ func (e *Endpoint) RemoveClient(c *Client) {
 	e.clientsMtx.Lock()
 	id := c.generateID()
 	delete(e.clients, id)
-	log.Tracef("Client (%s) removed.", id)
+	stratumLog.Tracef("Client (%s) removed.", id)
 	e.clientsMtx.Unlock()
 }
@@ -0,0 +1,37 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+func TestParseStratumPorts(t *testing.T) {
+	ports, err := ParseStratumPorts([]string{"cpu=6550"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := stratumPorts(ports)
+	if merged[dividend.CPU] != 6550 ||
+		merged[dividend.AntminerDR3] != dividend.MinerPorts[dividend.AntminerDR3] {
+		t.Fatalf("unexpected stratum ports: %v", merged)
+	}
+
+	for _, entries := range [][]string{
+		{"cpu"},
+		{"unknown=6550"},
+		{"cpu=0"},
+		{"cpu=70000"},
+		{"cpu=5553"},
+	} {
+		_, err := ParseStratumPorts(entries)
+		if err == nil {
+			t.Errorf("expected an error parsing %v", entries)
+		}
+	}
+}
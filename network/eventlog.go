@@ -0,0 +1,140 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+const (
+	// backendCheckInterval is the interval between checks of the backend
+	// connections recorded in the pool event log.
+	backendCheckInterval = time.Second * 10
+
+	// indexEvents is the number of recent pool events listed on the front
+	// page.
+	indexEvents = 10
+)
+
+// logPoolEvent records an event to the pool event log.
+func (h *Hub) logPoolEvent(eventType string, detail string, data interface{}, actor string) {
+	err := dividend.NewPoolEvent(eventType, detail, data, actor).Create(h.db)
+	if err != nil {
+		log.Errorf("Failed to record %v pool event: %v", eventType, err)
+	}
+}
+
+// handleBackendEvents periodically checks the dcrd and wallet connections
+// and records their disconnections and reconnections to the pool event log.
+// It must be run as a goroutine.
+func (h *Hub) handleBackendEvents(ctx context.Context) {
+	ticker := time.NewTicker(backendCheckInterval)
+	defer ticker.Stop()
+	h.wg.Add(1)
+	log.Trace("Started backend events handler.")
+
+	connected := map[string]bool{"dcrd": true, "wallet": true}
+	for {
+		select {
+		case <-ctx.Done():
+			log.Trace("Backend events handler done.")
+			h.wg.Done()
+			return
+		case <-ticker.C:
+			state := make(map[string]bool, 2)
+			h.rpccMtx.Lock()
+			state["dcrd"] = !h.rpcc.Disconnected()
+			h.rpccMtx.Unlock()
+
+			if !h.cfg.SoloPool {
+				h.grpcMtx.Lock()
+				state["wallet"] = h.gConn.GetState() == connectivity.Ready
+				h.grpcMtx.Unlock()
+			}
+
+			for backend, up := range state {
+				if up == connected[backend] {
+					continue
+				}
+
+				connected[backend] = up
+				if up {
+					h.logPoolEvent(dividend.PoolEventBackendReconnected,
+						backend+" reconnected",
+						map[string]string{"backend": backend}, "")
+					continue
+				}
+
+				h.logPoolEvent(dividend.PoolEventBackendDisconnected,
+					backend+" disconnected",
+					map[string]string{"backend": backend}, "")
+			}
+		}
+	}
+}
+
+// poolEventFilter returns the filter of the pool events listed for the
+// provided request, events can be filtered by type with the `type` query
+// parameter. Only public events are listed unless the listing is for
+// operators.
+func poolEventFilter(r *http.Request, operator bool) func(*dividend.PoolEvent) bool {
+	eventType := r.URL.Query().Get("type")
+	return func(event *dividend.PoolEvent) bool {
+		if !operator && !event.Public() {
+			return false
+		}
+		return eventType == "" || event.Type == eventType
+	}
+}
+
+// APIPoolEvents returns a page of the public pool event log, most recent
+// first by default: blocks found, reorgs, payouts and backend disconnects.
+func (h *Hub) APIPoolEvents(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r, true)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, next, err := dividend.ListPoolEvents(h.db,
+		poolEventFilter(r, false), query)
+	if err != nil {
+		respondWithListError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"results": events,
+		"next":    next,
+	})
+}
+
+// ListPoolEvents handles operator requests for a page of the pool event
+// log, including operator actions such as bans and suspensions.
+func (h *Hub) ListPoolEvents(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r, true)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, next, err := dividend.ListPoolEvents(h.db,
+		poolEventFilter(r, true), query)
+	if err != nil {
+		respondWithListError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"results": events,
+		"next":    next,
+	})
+}
@@ -0,0 +1,394 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Live feed event types. Hash rate, block found, payment and payout confirmed
+// events are pool wide, share and payment sent events are only sent to the
+// feed of the account they concern.
+const (
+	EventHashRate        = "hashrate"
+	EventShare           = "share"
+	EventBlockFound      = "blockfound"
+	EventPayment         = "payment"
+	EventPaymentSent     = "paymentsent"
+	EventPayoutConfirmed = "payoutconfirmed"
+)
+
+const (
+	// feedInterval is the interval between hash rate events.
+	feedInterval = time.Second * 10
+
+	// feedPingInterval is the interval between pings sent to feed
+	// subscribers, subscribers not responding within feedPongWait are
+	// disconnected.
+	feedPingInterval = time.Second * 30
+	feedPongWait     = time.Second * 60
+
+	// feedWriteWait is the time allowed to write an event to a subscriber.
+	feedWriteWait = time.Second * 10
+
+	// feedBufferSize is the number of events buffered per subscriber,
+	// events are dropped for subscribers which fall further behind.
+	feedBufferSize = 64
+)
+
+// feedUpgrader upgrades feed requests to websocket connections. The feed is
+// read-only so connections from all origins are accepted, account feeds
+// authenticated by a web session cookie check their origin beforehand.
+var feedUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Event represents a live feed event.
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+	Time int64       `json:"time"`
+}
+
+// feedSubscriber represents a connected live feed, account feeds also
+// receive the events of their account. Private account feeds only receive
+// the events of their account.
+type feedSubscriber struct {
+	account string
+	private bool
+	events  chan *Event
+}
+
+// subscribe registers a live feed subscriber.
+func (h *Hub) subscribe(account string, private bool) *feedSubscriber {
+	sub := &feedSubscriber{
+		account: account,
+		private: account != "" && private,
+		events:  make(chan *Event, feedBufferSize),
+	}
+
+	h.feedMtx.Lock()
+	h.feedSubs[sub] = struct{}{}
+	h.feedMtx.Unlock()
+
+	return sub
+}
+
+// unsubscribe removes a live feed subscriber.
+func (h *Hub) unsubscribe(sub *feedSubscriber) {
+	h.feedMtx.Lock()
+	delete(h.feedSubs, sub)
+	h.feedMtx.Unlock()
+}
+
+// publish sends an event to live feed subscribers. Events of an account are
+// only sent to the feeds of that account, pool wide events are sent to all
+// feeds but private ones.
+func (h *Hub) publish(account string, eventType string, data interface{}) {
+	event := &Event{
+		Type: eventType,
+		Data: data,
+		Time: time.Now().Unix(),
+	}
+
+	h.feedMtx.Lock()
+	for sub := range h.feedSubs {
+		if account != "" && sub.account != account {
+			continue
+		}
+		if account == "" && sub.private {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			atomic.AddUint64(&h.metrics.eventsDropped, 1)
+			log.Tracef("Dropped %v event for a slow feed subscriber",
+				eventType)
+		}
+	}
+	h.feedMtx.Unlock()
+}
+
+// hashRateEvent returns the data of a hash rate event for the provided
+// subscriber, private feeds only receive the hash rate and connections of
+// their account.
+func (h *Hub) hashRateEvent(sub *feedSubscriber) map[string]interface{} {
+	account := sub.account
+	connections := 0
+	accountConnections := 0
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		connections += len(endpoint.clients)
+		for _, client := range endpoint.clients {
+			if account != "" && client.account == account {
+				accountConnections++
+			}
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	if sub.private {
+		return map[string]interface{}{
+			"accounthashrate":    h.hashRate(account).FloatString(12),
+			"accountconnections": accountConnections,
+			"hashrateunit":       hashRateUnit,
+		}
+	}
+
+	data := map[string]interface{}{
+		"hashrate":     h.hashRate("").FloatString(12),
+		"hashrateunit": hashRateUnit,
+		"connections":  connections,
+		"timetoblock":  h.timeToBlock(),
+	}
+
+	if account != "" {
+		data["accounthashrate"] = h.hashRate(account).FloatString(12)
+		data["accountconnections"] = accountConnections
+	}
+
+	return data
+}
+
+// serveFeed upgrades the request to a websocket connection and streams live
+// feed events to it until either end disconnects.
+func (h *Hub) serveFeed(w http.ResponseWriter, r *http.Request, account string, private bool) {
+	conn, err := feedUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Errorf("Failed to upgrade feed connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	sub := h.subscribe(account, private)
+	defer h.unsubscribe(sub)
+
+	// Subscribers do not send messages, reading is only required to process
+	// pongs and to detect disconnections.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(feedPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(feedPongWait))
+		})
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	hashRateTicker := time.NewTicker(feedInterval)
+	defer hashRateTicker.Stop()
+	pingTicker := time.NewTicker(feedPingInterval)
+	defer pingTicker.Stop()
+
+	write := func(event *Event) error {
+		conn.SetWriteDeadline(time.Now().Add(feedWriteWait))
+		return conn.WriteJSON(event)
+	}
+
+	err = write(&Event{Type: EventHashRate, Data: h.hashRateEvent(sub),
+		Time: time.Now().Unix()})
+	if err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+				time.Now().Add(feedWriteWait))
+			return
+		case <-done:
+			return
+		case event := <-sub.events:
+			if write(event) != nil {
+				return
+			}
+		case <-hashRateTicker.C:
+			err := write(&Event{Type: EventHashRate,
+				Data: h.hashRateEvent(sub), Time: time.Now().Unix()})
+			if err != nil {
+				return
+			}
+		case <-pingTicker.C:
+			err := conn.WriteControl(websocket.PingMessage, nil,
+				time.Now().Add(feedWriteWait))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// serveEvents streams live feed events to the request as server-sent events
+// until either end disconnects. Events are sent as unnamed messages whose data
+// is the json encoded event, as sent over websocket connections.
+func (h *Hub) serveEvents(w http.ResponseWriter, r *http.Request, account string, private bool) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Disable response buffering by nginx reverse proxies.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sub := h.subscribe(account, private)
+	defer h.unsubscribe(sub)
+
+	hashRateTicker := time.NewTicker(feedInterval)
+	defer hashRateTicker.Stop()
+	pingTicker := time.NewTicker(feedPingInterval)
+	defer pingTicker.Stop()
+
+	// Streams outlive the write timeout of the api server, the deadline is
+	// extended for every write instead.
+	send := func(msg string) error {
+		rc.SetWriteDeadline(time.Now().Add(feedWriteWait))
+		_, err := fmt.Fprint(w, msg)
+		if err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	write := func(event *Event) error {
+		b, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		return send(fmt.Sprintf("data: %s\n\n", b))
+	}
+
+	err := write(&Event{Type: EventHashRate, Data: h.hashRateEvent(sub),
+		Time: time.Now().Unix()})
+	if err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-r.Context().Done():
+			return
+		case event := <-sub.events:
+			if write(event) != nil {
+				return
+			}
+		case <-hashRateTicker.C:
+			err := write(&Event{Type: EventHashRate,
+				Data: h.hashRateEvent(sub), Time: time.Now().Unix()})
+			if err != nil {
+				return
+			}
+		case <-pingTicker.C:
+			// Comments keep idle connections open through proxies.
+			if send(": ping\n\n") != nil {
+				return
+			}
+		}
+	}
+}
+
+// privateFeed returns whether the `private` parameter of the provided account
+// feed request asks for the events of the account only.
+func privateFeed(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("private")
+	if v == "" {
+		return false, nil
+	}
+
+	private, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("provided 'private' parameter is not a " +
+			"boolean")
+	}
+
+	return private, nil
+}
+
+// Events streams pool wide live events as server-sent events, for clients
+// unable to hold websocket connections.
+func (h *Hub) Events(w http.ResponseWriter, r *http.Request) {
+	h.serveEvents(w, r, "", false)
+}
+
+// AccountEvents streams pool wide live events and the events of the
+// authenticated account as server-sent events, or only the events of the
+// account if the stream is private.
+func (h *Hub) AccountEvents(w http.ResponseWriter, r *http.Request) {
+	private, err := privateFeed(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.serveEvents(w, r, requestAccountID(r), private)
+}
+
+// Feed streams pool wide live events over a websocket connection.
+func (h *Hub) Feed(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, "", false)
+}
+
+// AccountFeed streams pool wide live events and the events of the
+// authenticated account over a websocket connection, or only the events of
+// the account if the feed is private.
+func (h *Hub) AccountFeed(w http.ResponseWriter, r *http.Request) {
+	// Browsers attach the session cookie to websocket upgrades from any
+	// origin, and these are not subject to cors.
+	if webSessionRequest(r) && !h.sessionOrigin(r.Header.Get("Origin")) {
+		RespondWithError(w, http.StatusForbidden,
+			"origin not allowed for web session feeds")
+		return
+	}
+
+	private, err := privateFeed(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.serveFeed(w, r, requestAccountID(r), private)
+}
+
+// sessionOrigin asserts the provided origin is the origin of the configured
+// public url or an origin explicitly allowed to call the api.
+func (h *Hub) sessionOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	if h.cfg.PublicURL != "" {
+		u, err := url.Parse(h.cfg.PublicURL)
+		if err == nil && strings.EqualFold(u.Scheme+"://"+u.Host, origin) {
+			return true
+		}
+	}
+
+	for _, allowed := range h.cfg.CORSOrigins {
+		if allowed != "*" && strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := &Hub{
+		ctx:      ctx,
+		feedSubs: make(map[*feedSubscriber]struct{}),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(h.Events))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %v", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	next := func() *Event {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+
+			var event Event
+			err = json.Unmarshal([]byte(line[len("data: "):]), &event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return &event
+		}
+	}
+
+	// Streams start with a hash rate event.
+	event := next()
+	if event.Type != EventHashRate {
+		t.Fatalf("expected %v event, got %v", EventHashRate, event.Type)
+	}
+
+	// Subscribers are registered before the first event is sent, events
+	// published afterwards are streamed.
+	h.publish("", EventBlockFound, map[string]uint32{"height": 10})
+	event = next()
+	if event.Type != EventBlockFound {
+		t.Fatalf("expected %v event, got %v", EventBlockFound, event.Type)
+	}
+}
+
+func TestPublishPrivate(t *testing.T) {
+	h := &Hub{feedSubs: make(map[*feedSubscriber]struct{})}
+	pool := h.subscribe("", true)
+	account := h.subscribe("a", false)
+	private := h.subscribe("a", true)
+	other := h.subscribe("b", true)
+
+	h.publish("", EventBlockFound, nil)
+	h.publish("a", EventShare, nil)
+
+	for _, tc := range []struct {
+		name   string
+		sub    *feedSubscriber
+		events []string
+	}{
+		{"pool", pool, []string{EventBlockFound}},
+		{"account", account, []string{EventBlockFound, EventShare}},
+		{"private", private, []string{EventShare}},
+		{"other", other, nil},
+	} {
+		if len(tc.sub.events) != len(tc.events) {
+			t.Fatalf("%v: expected %d events, got %d", tc.name,
+				len(tc.events), len(tc.sub.events))
+		}
+		for _, eventType := range tc.events {
+			if event := <-tc.sub.events; event.Type != eventType {
+				t.Fatalf("%v: expected %v event, got %v", tc.name,
+					eventType, event.Type)
+			}
+		}
+	}
+
+	if data := h.hashRateEvent(private); data["hashrate"] != nil ||
+		data["accounthashrate"] == nil {
+		t.Fatalf("unexpected private hash rate event %v", data)
+	}
+}
+
+func TestAccountFeedOrigin(t *testing.T) {
+	h := &Hub{
+		cfg: &HubConfig{
+			PublicURL:   "https://pool.example/",
+			CORSOrigins: []string{"*", "https://stats.example"},
+		},
+	}
+
+	// Ensure web session feeds are refused from other origins, including
+	// when all origins are allowed to call the api.
+	req := httptest.NewRequest(http.MethodGet, "/api/account/feed", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rec := httptest.NewRecorder()
+	h.AccountFeed(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a forbidden origin, got %v", rec.Code)
+	}
+
+	origins := map[string]bool{
+		"https://pool.example":  true,
+		"https://stats.example": true,
+		"https://evil.example":  false,
+		"":                      false,
+	}
+	for origin, allowed := range origins {
+		if h.sessionOrigin(origin) != allowed {
+			t.Errorf("expected origin %q allowed to be %v", origin, allowed)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrutil"
+)
+
+// feeEstimateTarget is the number of blocks payout transactions are
+// estimated to confirm within.
+const feeEstimateTarget = 2
+
+// parseFeeEstimate parses the fee rate estimated by dcrd, in DCR/kB. Older
+// dcrd versions return the rate as a number, newer versions as an object.
+func parseFeeEstimate(raw json.RawMessage) (dcrutil.Amount, error) {
+	var rate float64
+	err := json.Unmarshal(raw, &rate)
+	if err != nil {
+		var result struct {
+			FeeRate float64 `json:"feerate"`
+		}
+		err = json.Unmarshal(raw, &result)
+		if err != nil {
+			return 0, fmt.Errorf("malformed fee estimate: %v", err)
+		}
+		rate = result.FeeRate
+	}
+
+	if rate <= 0 {
+		return 0, fmt.Errorf("invalid fee estimate: %v", rate)
+	}
+
+	return dcrutil.NewAmount(rate)
+}
+
+// boundFeeRate returns the provided fee rate within the configured bounds.
+func boundFeeRate(rate, min, max dcrutil.Amount) dcrutil.Amount {
+	if rate < min {
+		return min
+	}
+	if rate > max {
+		return max
+	}
+	return rate
+}
+
+// estimateFeeRate returns the fee rate of a payout transaction, per kB, as
+// estimated by dcrd from its mempool within the configured bounds. The
+// minimum fee rate is used when dcrd cannot estimate the fee rate.
+func (h *Hub) estimateFeeRate() dcrutil.Amount {
+	target, err := json.Marshal(feeEstimateTarget)
+	if err != nil {
+		paymentLog.Errorf("Failed to encode fee estimate target: %v", err)
+		return h.cfg.MinFeeRate
+	}
+
+	h.rpccMtx.Lock()
+	raw, err := h.rpcc.RawRequest("estimatesmartfee",
+		[]json.RawMessage{target})
+	h.rpccMtx.Unlock()
+	if err != nil {
+		paymentLog.Warnf("Unable to estimate payout fee rate, using the minimum "+
+			"fee rate of %v/kB: %v", h.cfg.MinFeeRate, err)
+		return h.cfg.MinFeeRate
+	}
+
+	rate, err := parseFeeEstimate(raw)
+	if err != nil {
+		paymentLog.Warnf("Unable to estimate payout fee rate, using the minimum "+
+			"fee rate of %v/kB: %v", h.cfg.MinFeeRate, err)
+		return h.cfg.MinFeeRate
+	}
+
+	bounded := boundFeeRate(rate, h.cfg.MinFeeRate, h.cfg.MaxFeeRate)
+	paymentLog.Tracef("Estimated payout fee rate is %v/kB, using %v/kB", rate,
+		bounded)
+
+	return bounded
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil"
+)
+
+func TestParseFeeEstimate(t *testing.T) {
+	tests := []struct {
+		raw     string
+		rate    dcrutil.Amount
+		wantErr bool
+	}{
+		{raw: `0.0002`, rate: 20000},
+		{raw: `{"feerate":0.0003,"blocks":2}`, rate: 30000},
+		{raw: `0`, wantErr: true},
+		{raw: `-1`, wantErr: true},
+		{raw: `"fast"`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		rate, err := parseFeeEstimate(json.RawMessage(test.raw))
+		if test.wantErr {
+			if err == nil {
+				t.Fatalf("expected an error for %v", test.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error for %v: %v", test.raw, err)
+		}
+		if rate != test.rate {
+			t.Fatalf("expected rate %v for %v, got %v", test.rate,
+				test.raw, rate)
+		}
+	}
+}
+
+func TestBoundFeeRate(t *testing.T) {
+	min := dcrutil.Amount(1e4)
+	max := dcrutil.Amount(1e5)
+	tests := []struct {
+		rate dcrutil.Amount
+		want dcrutil.Amount
+	}{
+		{rate: 5e3, want: min},
+		{rate: 5e4, want: 5e4},
+		{rate: 5e5, want: max},
+	}
+
+	for _, test := range tests {
+		got := boundFeeRate(test.rate, min, max)
+		if got != test.want {
+			t.Fatalf("expected %v for %v, got %v", test.want, test.rate, got)
+		}
+	}
+}
@@ -0,0 +1,511 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/decred/dcrd/wire"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/frontendrpc"
+)
+
+const (
+	// backendCallTimeout is the timeout of calls of stratum frontends to the
+	// pool backend.
+	backendCallTimeout = time.Second * 10
+
+	// backendRetryInterval is the interval stratum frontends retry streaming
+	// the work of the pool backend at.
+	backendRetryInterval = time.Second * 5
+)
+
+// upstream is the pool backend of a stratum frontend. It relays the work of
+// the clients of the frontend to the backend over the frontend RPC.
+type upstream struct {
+	hub     *Hub
+	conn    *grpc.ClientConn
+	client  frontendrpc.FrontendServiceClient
+	name    string
+	secret  string
+	id      uint32
+	jobs    map[string]*Job
+	jobsMtx sync.Mutex
+}
+
+// NewFrontendHub initializes the hub of a stateless stratum frontend of the
+// pool backend of the provided connection. The frontend registers with the
+// backend as the provided name, the share difficulties of its clients are
+// derived from the settings of the backend.
+func NewFrontendHub(ctx context.Context, cancel context.CancelFunc, conn *grpc.ClientConn, name string, secret string, hcfg *HubConfig, limiter *RateLimiter) (*Hub, error) {
+	h := &Hub{
+		limiter:  limiter,
+		cfg:      hcfg,
+		poolDiff: make(map[string]*DifficultyData),
+		metrics:  newMetrics(int(hcfg.AccountMetrics)),
+		workCh:   make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	u := &upstream{
+		hub:    h,
+		conn:   conn,
+		client: frontendrpc.NewFrontendServiceClient(conn),
+		name:   name,
+		secret: secret,
+		jobs:   make(map[string]*Job),
+	}
+	h.backend = u
+	h.upstream = u
+
+	err := u.register()
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.GenerateDifficultyData()
+	if err != nil {
+		log.Error("Failed to generate difficulty data: %v", err)
+		return nil, err
+	}
+
+	// Setup listeners for all known pool clients.
+	for miner, port := range stratumPorts(h.cfg.StratumPorts) {
+		endpoint, err := NewEndpoint(h, port, miner)
+		if err != nil {
+			log.Error("Failed to create listeners: %v", err)
+			return nil, err
+		}
+
+		h.endpoints = append(h.endpoints, endpoint)
+	}
+
+	return h, nil
+}
+
+// extraNonce1Prefix returns the extranonce1 prefix of the clients of the hub,
+// negative when the extranonce1 of clients is not prefixed.
+func (h *Hub) extraNonce1Prefix() int32 {
+	return atomic.LoadInt32(&h.noncePrefix)
+}
+
+// callContext returns the context of a call to the backend.
+func (u *upstream) callContext() (context.Context, context.CancelFunc) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		frontendrpc.SecretMetadataKey, u.secret)
+	return context.WithTimeout(ctx, backendCallTimeout)
+}
+
+// register registers the frontend with the backend, adopting the settings
+// of the backend. Clients are disconnected when the backend assigns the
+// frontend a different extranonce1 prefix, such as after a restart of the
+// backend, so they do not share the search space of clients of another
+// frontend.
+func (u *upstream) register() error {
+	ctx, cancel := u.callContext()
+	defer cancel()
+
+	resp, err := u.client.Register(ctx, &frontendrpc.RegisterRequest{
+		Name:   u.name,
+		Region: u.hub.cfg.Region,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to register with the pool backend: %v", err)
+	}
+
+	h := u.hub
+	if resp.Network != h.cfg.ActiveNet.Name {
+		return fmt.Errorf("pool backend is on %v, the frontend on %v",
+			resp.Network, h.cfg.ActiveNet.Name)
+	}
+
+	if resp.Id == 0 || resp.Id > maxFrontends {
+		return fmt.Errorf("invalid extranonce1 prefix %v assigned", resp.Id)
+	}
+
+	h.cfgMtx.Lock()
+	h.cfg.SoloPool = resp.SoloPool
+	h.cfg.MaxGenTime = new(big.Int).SetUint64(resp.MaxGenTime)
+	h.cfgMtx.Unlock()
+
+	atomic.StoreUint32(&u.id, resp.Id)
+	prev := atomic.SwapInt32(&h.noncePrefix, int32(resp.Id))
+	if prev > 0 && prev != int32(resp.Id) {
+		stratumLog.Warnf("Pool backend assigned extranonce1 prefix %02x, "+
+			"was %02x, disconnecting clients", resp.Id, prev)
+		for _, endpoint := range h.endpoints {
+			endpoint.clientsMtx.Lock()
+			for _, client := range endpoint.clients {
+				client.cancel()
+			}
+			endpoint.clientsMtx.Unlock()
+		}
+	}
+
+	stratumLog.Infof("Registered with the pool backend as %v, extranonce1 "+
+		"prefix %02x", u.name, resp.Id)
+
+	return nil
+}
+
+// cacheJob caches the provided job, pruning jobs of earlier heights which
+// can no longer be mined.
+func (u *upstream) cacheJob(job *Job) {
+	u.jobsMtx.Lock()
+	for id, j := range u.jobs {
+		if j.Height+1 < job.Height {
+			delete(u.jobs, id)
+		}
+	}
+	u.jobs[job.UUID] = job
+	u.jobsMtx.Unlock()
+}
+
+// applyWork applies the provided work update of the backend, dispatching
+// its job to the clients of the frontend.
+func (u *upstream) applyWork(update *frontendrpc.WorkUpdate) {
+	h := u.hub
+	atomic.StoreInt64(&h.heartbeat, time.Now().UnixNano())
+
+	var syncing int32
+	if update.Syncing {
+		syncing = 1
+	}
+	if atomic.SwapInt32(&h.syncing, syncing) != syncing {
+		stratumLog.Infof("Pool backend syncing: %v", update.Syncing)
+	}
+
+	if update.MaxGenTime != 0 &&
+		h.maxGenTime().Uint64() != update.MaxGenTime {
+		h.cfgMtx.Lock()
+		h.cfg.MaxGenTime = new(big.Int).SetUint64(update.MaxGenTime)
+		h.cfgMtx.Unlock()
+
+		err := h.updateDifficultyData()
+		if err != nil {
+			stratumLog.Errorf("Failed to update difficulty data: %v", err)
+		}
+	}
+
+	if update.JobId == "" {
+		return
+	}
+
+	job := &Job{
+		UUID:   update.JobId,
+		Height: update.Height,
+		Header: update.Header,
+	}
+	if len(job.Header) < 360 {
+		stratumLog.Errorf("Pool backend sent malformed job %v", job.UUID)
+		return
+	}
+
+	u.cacheJob(job)
+	atomic.StoreUint32(&h.lastWorkHeight, job.Height)
+	atomic.StoreInt64(&h.lastWorkTime, time.Now().UnixNano())
+	h.broadcastWork(job)
+}
+
+// streamWork streams the work of the backend until the stream fails.
+func (u *upstream) streamWork(ctx context.Context) error {
+	ctx = metadata.AppendToOutgoingContext(ctx,
+		frontendrpc.SecretMetadataKey, u.secret)
+	stream, err := u.client.Work(ctx,
+		&frontendrpc.WorkRequest{Id: atomic.LoadUint32(&u.id)})
+	if err != nil {
+		return err
+	}
+
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		u.applyWork(update)
+	}
+}
+
+// handleWork streams the work of the backend, registering again and
+// resuming the stream when it fails. It must be run as a goroutine.
+func (u *upstream) handleWork(ctx context.Context) {
+	h := u.hub
+	h.wg.Add(1)
+	stratumLog.Trace("Started backend work handler.")
+
+	for {
+		err := u.streamWork(ctx)
+		if ctx.Err() == nil {
+			stratumLog.Errorf("Work stream of the pool backend failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			stratumLog.Trace("Backend work handler done.")
+			h.wg.Done()
+			return
+		case <-time.After(backendRetryInterval):
+		}
+
+		err = u.register()
+		if err != nil {
+			stratumLog.Error(err)
+		}
+	}
+}
+
+// report reports the clients of the frontend to the backend, disconnecting
+// the clients the backend responds with.
+func (u *upstream) report() error {
+	h := u.hub
+	req := &frontendrpc.ReportRequest{Id: atomic.LoadUint32(&u.id)}
+	for _, endpoint := range h.endpoints {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			client.hashRateMtx.RLock()
+			hashRate := client.hashRate.FloatString(12)
+			client.hashRateMtx.RUnlock()
+
+			req.Clients = append(req.Clients, &frontendrpc.Client{
+				Id:          client.generateID(),
+				Ip:          client.ip,
+				Miner:       endpoint.miner,
+				Account:     client.account,
+				Worker:      client.worker,
+				UserAgent:   client.userAgent,
+				ConnectedOn: client.connectedOn,
+				Difficulty:  client.diffData.difficulty.String(),
+				Hashrate:    hashRate,
+				Accepted:    atomic.LoadUint32(&client.accepted),
+				Rejected:    atomic.LoadUint32(&client.rejected),
+			})
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	ctx, cancel := u.callContext()
+	defer cancel()
+	resp, err := u.client.Report(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range resp.Disconnect {
+		for _, endpoint := range h.endpoints {
+			endpoint.clientsMtx.Lock()
+			if client, ok := endpoint.clients[id]; ok {
+				stratumLog.Debugf("Disconnecting (%v) as requested by the "+
+					"pool backend", id)
+				client.cancel()
+			}
+			endpoint.clientsMtx.Unlock()
+		}
+	}
+
+	return nil
+}
+
+// handleReports periodically reports the clients of the frontend to the
+// backend. It must be run as a goroutine.
+func (u *upstream) handleReports(ctx context.Context) {
+	h := u.hub
+	ticker := time.NewTicker(frontendReportInterval)
+	defer ticker.Stop()
+	h.wg.Add(1)
+
+	for {
+		select {
+		case <-ctx.Done():
+			stratumLog.Trace("Backend report handler done.")
+			h.wg.Done()
+			return
+
+		case <-ticker.C:
+			err := u.report()
+			if err != nil {
+				stratumLog.Errorf("Failed to report clients to the pool "+
+					"backend: %v", err)
+			}
+		}
+	}
+}
+
+// runFrontend handles the process lifecycles of the hub of a stratum
+// frontend.
+func (h *Hub) runFrontend() {
+	h.wg.Add(len(h.endpoints))
+	for _, e := range h.endpoints {
+		go e.listen()
+		go e.connect(h.ctx)
+	}
+
+	go h.upstream.handleWork(h.ctx)
+	go h.upstream.handleReports(h.ctx)
+	h.wg.Wait()
+
+	h.upstream.conn.Close()
+}
+
+// bannedIP returns whether the provided ip address is banned by the
+// configuration of the frontend or by the backend. Connections are allowed
+// when the backend cannot be reached.
+func (u *upstream) bannedIP(ip string) bool {
+	if u.hub.configBanned(ip) {
+		return true
+	}
+
+	ctx, cancel := u.callContext()
+	defer cancel()
+	resp, err := u.client.Banned(ctx, &frontendrpc.BannedRequest{Ip: ip})
+	if err != nil {
+		stratumLog.Errorf("Failed to check ban of %v: %v", ip, err)
+		return false
+	}
+
+	return resp.Banned
+}
+
+// authorizeWorker authorizes the named worker of the account of the provided
+// name and address with the backend.
+func (u *upstream) authorizeWorker(name, address, workerName, ip, userAgent, region string) (*workerAuth, *StratumError) {
+	ctx, cancel := u.callContext()
+	defer cancel()
+	resp, err := u.client.Authorize(ctx, &frontendrpc.AuthorizeRequest{
+		Name:      name,
+		Address:   address,
+		Worker:    workerName,
+		Ip:        ip,
+		UserAgent: userAgent,
+		Region:    region,
+	})
+	if err != nil {
+		stratumLog.Errorf("unable to authorize worker: %v", err)
+		return nil, NewStratumError(Unknown, nil)
+	}
+
+	if resp.ErrorCode != 0 {
+		serr := NewStratumError(resp.ErrorCode, nil)
+		serr.Message = resp.ErrorMessage
+		return nil, serr
+	}
+
+	auth := &workerAuth{
+		account: resp.Account,
+		worker:  resp.Worker,
+	}
+	if resp.Difficulty != "" {
+		difficulty, ok := new(big.Int).SetString(resp.Difficulty, 10)
+		if ok {
+			auth.difficulty = difficulty
+		}
+	}
+
+	return auth, nil
+}
+
+// disconnectWorker records the disconnection of the provided worker with the
+// backend.
+func (u *upstream) disconnectWorker(worker, ip string) {
+	ctx, cancel := u.callContext()
+	defer cancel()
+	_, err := u.client.Disconnect(ctx, &frontendrpc.DisconnectRequest{
+		Worker: worker,
+		Ip:     ip,
+	})
+	if err != nil {
+		stratumLog.Errorf("failed to record disconnection of worker (%v): %v",
+			worker, err)
+	}
+}
+
+// fetchJob fetches the job of the provided id from the job cache, or from
+// the backend when not cached.
+func (u *upstream) fetchJob(id string) (*Job, error) {
+	u.jobsMtx.Lock()
+	job, ok := u.jobs[id]
+	u.jobsMtx.Unlock()
+	if ok {
+		return job, nil
+	}
+
+	ctx, cancel := u.callContext()
+	defer cancel()
+	resp, err := u.client.FetchJob(ctx, &frontendrpc.FetchJobRequest{JobId: id})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, database.ErrValueNotFound([]byte(id))
+		}
+		return nil, err
+	}
+
+	return &Job{
+		UUID:   id,
+		Height: resp.Height,
+		Header: resp.Header,
+	}, nil
+}
+
+// recordShare records the provided share with the backend.
+func (u *upstream) recordShare(share *clientShare) error {
+	req := &frontendrpc.ShareRequest{
+		Account: share.account,
+		Worker:  share.worker,
+		Miner:   share.miner,
+		Status:  uint32(share.status),
+		Height:  share.height,
+		Block:   share.block,
+	}
+	if share.hashRate != nil {
+		req.Hashrate = share.hashRate.String()
+	}
+	if share.difficulty != nil {
+		req.Difficulty = share.difficulty.String()
+	}
+
+	ctx, cancel := u.callContext()
+	defer cancel()
+	_, err := u.client.RecordShare(ctx, req)
+	return err
+}
+
+// submitBlock submits the provided solved block header to the network
+// through the backend.
+func (u *upstream) submitBlock(header *wire.BlockHeader, account, miner string) (bool, error) {
+	headerB, err := header.Bytes()
+	if err != nil {
+		return false, fmt.Errorf("unable to fetch block header bytes: %v", err)
+	}
+
+	ctx, cancel := u.callContext()
+	defer cancel()
+	resp, err := u.client.SubmitBlock(ctx, &frontendrpc.SubmitBlockRequest{
+		Header:  hex.EncodeToString(headerB),
+		Account: account,
+		Miner:   miner,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if resp.Duplicate {
+		return false, errDuplicateWork
+	}
+
+	return resp.Accepted, nil
+}
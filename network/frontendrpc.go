@@ -0,0 +1,471 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"crypto/subtle"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/frontendrpc"
+)
+
+const (
+	// maxFrontends is the number of stratum frontends a pool serves, each is
+	// assigned one of the extranonce1 prefixes the pool does not use.
+	maxFrontends = 255
+
+	// frontendReportInterval is the interval stratum frontends report their
+	// clients at.
+	frontendReportInterval = time.Second * 5
+
+	// frontendTimeout is the period after which the clients of frontends
+	// which stopped reporting are no longer accounted for.
+	frontendTimeout = frontendReportInterval * 3
+
+	// frontendStatusInterval is the interval the sync state of the pool is
+	// streamed to frontends at in the absence of work.
+	frontendStatusInterval = time.Second * 10
+)
+
+// frontend is a stratum frontend registered with the pool.
+type frontend struct {
+	id        uint32
+	name      string
+	region    string
+	endpoints map[string]*Endpoint
+	reported  time.Time
+}
+
+// FrontendRPCServer implements the gRPC frontend service over the hub.
+// Calls are authenticated by the frontend secret.
+type FrontendRPCServer struct {
+	hub    *Hub
+	secret string
+}
+
+// NewFrontendRPCServer creates a frontend service of the provided hub which
+// accepts the provided frontend secret.
+func NewFrontendRPCServer(hub *Hub, secret string) *FrontendRPCServer {
+	return &FrontendRPCServer{
+		hub:    hub,
+		secret: secret,
+	}
+}
+
+// authenticate asserts the frontend secret of the provided call context.
+func (s *FrontendRPCServer) authenticate(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "frontend secret required")
+	}
+
+	secrets := md.Get(frontendrpc.SecretMetadataKey)
+	if len(secrets) != 1 || subtle.ConstantTimeCompare([]byte(secrets[0]),
+		[]byte(s.secret)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid frontend secret")
+	}
+
+	return nil
+}
+
+// UnaryInterceptor authenticates unary frontend calls.
+func (s *FrontendRPCServer) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamInterceptor authenticates streaming frontend calls.
+func (s *FrontendRPCServer) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := s.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
+
+// frontendByID returns the registered frontend of the provided id, or nil if
+// no such frontend is registered. It must be called with the frontends mutex
+// held.
+func (h *Hub) frontendByID(id uint32) *frontend {
+	for _, f := range h.frontends {
+		if f.id == id {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// hasFrontends returns whether stratum frontends are streaming the work of
+// the pool.
+func (h *Hub) hasFrontends() bool {
+	h.frontendsMtx.Lock()
+	defer h.frontendsMtx.Unlock()
+	return len(h.workSubs) > 0
+}
+
+// notifyFrontends dispatches the provided job to the stratum frontends
+// streaming the work of the pool. Frontends yet to receive the previous job
+// are sent the provided job instead.
+func (h *Hub) notifyFrontends(job *Job) {
+	h.frontendsMtx.Lock()
+	for ch := range h.workSubs {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- job
+	}
+	h.frontendsMtx.Unlock()
+}
+
+// allEndpoints returns the endpoints of the pool and the endpoints mirroring
+// the clients of the stratum frontends which reported recently.
+func (h *Hub) allEndpoints() []*Endpoint {
+	h.frontendsMtx.Lock()
+	defer h.frontendsMtx.Unlock()
+
+	if len(h.frontends) == 0 {
+		return h.endpoints
+	}
+
+	endpoints := make([]*Endpoint, len(h.endpoints))
+	copy(endpoints, h.endpoints)
+	now := time.Now()
+	for _, f := range h.frontends {
+		if now.Sub(f.reported) > frontendTimeout {
+			continue
+		}
+		for _, endpoint := range f.endpoints {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	return endpoints
+}
+
+// mirrorClients updates the mirrored clients of the provided frontend with
+// the provided reported clients. It returns the ids of the mirrored clients
+// disconnected since the last report, such as clients of banned addresses,
+// for the frontend to disconnect. It must be called with the frontends mutex
+// held.
+func (h *Hub) mirrorClients(f *frontend, clients []*frontendrpc.Client) []string {
+	reported := make(map[string]struct{}, len(clients))
+	disconnect := make([]string, 0)
+	for _, rc := range clients {
+		endpoint, ok := f.endpoints[rc.Miner]
+		if !ok {
+			endpoint = &Endpoint{
+				hub:     h,
+				miner:   rc.Miner,
+				region:  f.region,
+				clients: make(map[string]*Client),
+			}
+			f.endpoints[rc.Miner] = endpoint
+		}
+
+		difficulty, ok := new(big.Int).SetString(rc.Difficulty, 10)
+		if !ok {
+			difficulty = new(big.Int)
+		}
+		hashRate, ok := new(big.Rat).SetString(rc.Hashrate)
+		if !ok {
+			hashRate = zeroRat
+		}
+
+		endpoint.clientsMtx.Lock()
+		client, ok := endpoint.clients[rc.Id]
+		if !ok {
+			ctx, cancel := context.WithCancel(context.Background())
+			client = &Client{
+				endpoint:           endpoint,
+				ctx:                ctx,
+				cancel:             cancel,
+				ip:                 rc.Ip,
+				connectedOn:        rc.ConnectedOn,
+				extraNonce1:        strings.TrimSuffix(rc.Id, "/"+rc.Miner),
+				userAgent:          rc.UserAgent,
+				lastSubmissionTime: zeroInt,
+				hashRate:           zeroRat,
+			}
+			endpoint.clients[rc.Id] = client
+		}
+
+		client.account = rc.Account
+		client.worker = rc.Worker
+		client.diffData = &DifficultyData{difficulty: difficulty}
+		client.hashRateMtx.Lock()
+		client.hashRate = hashRate
+		client.hashRateMtx.Unlock()
+		atomic.StoreUint32(&client.accepted, rc.Accepted)
+		atomic.StoreUint32(&client.rejected, rc.Rejected)
+		if client.ctx.Err() != nil {
+			disconnect = append(disconnect, rc.Id)
+		}
+		endpoint.clientsMtx.Unlock()
+
+		reported[rc.Id] = struct{}{}
+	}
+
+	// Mirrored clients no longer reported have disconnected.
+	for _, endpoint := range f.endpoints {
+		endpoint.clientsMtx.Lock()
+		for id := range endpoint.clients {
+			if _, ok := reported[id]; !ok {
+				delete(endpoint.clients, id)
+			}
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	f.reported = time.Now()
+
+	return disconnect
+}
+
+// Register registers a frontend, assigning it the extranonce1 prefix of its
+// clients. Frontends are identified by name, a frontend registering again
+// keeps its prefix.
+func (s *FrontendRPCServer) Register(ctx context.Context, req *frontendrpc.RegisterRequest) (*frontendrpc.RegisterResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument,
+			"a frontend name is required")
+	}
+
+	h := s.hub
+	h.frontendsMtx.Lock()
+	f, ok := h.frontends[name]
+	if !ok {
+		if len(h.frontends) >= maxFrontends {
+			h.frontendsMtx.Unlock()
+			return nil, status.Errorf(codes.ResourceExhausted,
+				"no more than %v frontends are supported", maxFrontends)
+		}
+
+		f = &frontend{
+			id:        uint32(len(h.frontends) + 1),
+			name:      name,
+			region:    req.Region,
+			endpoints: make(map[string]*Endpoint),
+		}
+		h.frontends[name] = f
+	}
+
+	// The clients of a frontend serving another region are mirrored anew
+	// as it reports them.
+	if f.region != req.Region {
+		f.region = req.Region
+		f.endpoints = make(map[string]*Endpoint)
+	}
+	h.frontendsMtx.Unlock()
+
+	if req.Region != "" && !h.advertisedRegion(req.Region) {
+		stratumLog.Warnf("Stratum frontend %v serves region %v, which is "+
+			"not advertised", name, req.Region)
+	}
+
+	stratumLog.Infof("Stratum frontend %v registered with extranonce1 "+
+		"prefix %02x", name, f.id)
+
+	return &frontendrpc.RegisterResponse{
+		Id:         f.id,
+		Network:    h.cfg.ActiveNet.Name,
+		SoloPool:   h.cfg.SoloPool,
+		MaxGenTime: h.maxGenTime().Uint64(),
+	}, nil
+}
+
+// Work streams the work of the pool and the sync state of dcrd to a
+// registered frontend.
+func (s *FrontendRPCServer) Work(req *frontendrpc.WorkRequest, stream frontendrpc.FrontendService_WorkServer) error {
+	h := s.hub
+	h.frontendsMtx.Lock()
+	f := h.frontendByID(req.Id)
+	if f == nil {
+		h.frontendsMtx.Unlock()
+		return status.Errorf(codes.NotFound, "frontend %v not registered",
+			req.Id)
+	}
+	ch := make(chan *Job, 1)
+	h.workSubs[ch] = struct{}{}
+	h.frontendsMtx.Unlock()
+
+	defer func() {
+		h.frontendsMtx.Lock()
+		delete(h.workSubs, ch)
+		h.frontendsMtx.Unlock()
+	}()
+
+	stratumLog.Debugf("Streaming work to stratum frontend %v", f.name)
+
+	// Fresh work is fetched for the subscribing frontend.
+	h.signalWork()
+
+	ticker := time.NewTicker(frontendStatusInterval)
+	defer ticker.Stop()
+
+	for {
+		update := &frontendrpc.WorkUpdate{
+			Syncing:    h.dcrdSyncing(),
+			MaxGenTime: h.maxGenTime().Uint64(),
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-h.ctx.Done():
+			return status.Error(codes.Unavailable, "pool shutting down")
+		case job := <-ch:
+			update.JobId = job.UUID
+			update.Header = job.Header
+			update.Height = job.Height
+		case <-ticker.C:
+		}
+
+		err := stream.Send(update)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Authorize authorizes a worker of an account, creating both if needed.
+func (s *FrontendRPCServer) Authorize(ctx context.Context, req *frontendrpc.AuthorizeRequest) (*frontendrpc.AuthorizeResponse, error) {
+	auth, serr := s.hub.authorizeWorker(req.Name, req.Address, req.Worker,
+		req.Ip, req.UserAgent, req.Region)
+	if serr != nil {
+		return &frontendrpc.AuthorizeResponse{
+			ErrorCode:    serr.Code,
+			ErrorMessage: serr.Message,
+		}, nil
+	}
+
+	resp := &frontendrpc.AuthorizeResponse{
+		Account: auth.account,
+		Worker:  auth.worker,
+	}
+	if auth.difficulty != nil {
+		resp.Difficulty = auth.difficulty.String()
+	}
+
+	return resp, nil
+}
+
+// Disconnect records the disconnection of a worker.
+func (s *FrontendRPCServer) Disconnect(ctx context.Context, req *frontendrpc.DisconnectRequest) (*frontendrpc.DisconnectResponse, error) {
+	s.hub.disconnectWorker(req.Worker, req.Ip)
+	return &frontendrpc.DisconnectResponse{}, nil
+}
+
+// Banned returns whether an ip address is banned by an operator or by the
+// configuration of the pool.
+func (s *FrontendRPCServer) Banned(ctx context.Context, req *frontendrpc.BannedRequest) (*frontendrpc.BannedResponse, error) {
+	return &frontendrpc.BannedResponse{
+		Banned: s.hub.bannedIP(req.Ip),
+	}, nil
+}
+
+// FetchJob fetches a job of the pool.
+func (s *FrontendRPCServer) FetchJob(ctx context.Context, req *frontendrpc.FetchJobRequest) (*frontendrpc.FetchJobResponse, error) {
+	job, err := s.hub.fetchJob(req.JobId)
+	if err != nil {
+		if err.Error() == database.ErrValueNotFound([]byte(req.JobId)).Error() {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &frontendrpc.FetchJobResponse{
+		Header: job.Header,
+		Height: job.Height,
+	}, nil
+}
+
+// RecordShare records an accepted or rejected share of a client.
+func (s *FrontendRPCServer) RecordShare(ctx context.Context, req *frontendrpc.ShareRequest) (*frontendrpc.ShareResponse, error) {
+	share := &clientShare{
+		account: req.Account,
+		worker:  req.Worker,
+		miner:   req.Miner,
+		status:  shareStatus(req.Status),
+		height:  req.Height,
+		block:   req.Block,
+	}
+
+	if share.status == shareAccepted {
+		var ok bool
+		share.difficulty, ok = new(big.Int).SetString(req.Difficulty, 10)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"invalid share difficulty '%v'", req.Difficulty)
+		}
+
+		share.hashRate, ok = new(big.Rat).SetString(req.Hashrate)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"invalid hash rate '%v'", req.Hashrate)
+		}
+	}
+
+	err := s.hub.recordShare(share)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &frontendrpc.ShareResponse{}, nil
+}
+
+// SubmitBlock submits a solved block header to the network.
+func (s *FrontendRPCServer) SubmitBlock(ctx context.Context, req *frontendrpc.SubmitBlockRequest) (*frontendrpc.SubmitBlockResponse, error) {
+	header, err := decodeWorkHeader(req.Header)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	accepted, err := s.hub.submitBlock(header, req.Account, req.Miner)
+	if err != nil {
+		if err == errDuplicateWork {
+			return &frontendrpc.SubmitBlockResponse{Duplicate: true}, nil
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &frontendrpc.SubmitBlockResponse{Accepted: accepted}, nil
+}
+
+// Report mirrors the reported clients of a frontend, so they are accounted
+// for by the pool and can be disconnected by operators. The response lists
+// the clients the frontend must disconnect.
+func (s *FrontendRPCServer) Report(ctx context.Context, req *frontendrpc.ReportRequest) (*frontendrpc.ReportResponse, error) {
+	h := s.hub
+	h.frontendsMtx.Lock()
+	defer h.frontendsMtx.Unlock()
+
+	f := h.frontendByID(req.Id)
+	if f == nil {
+		return nil, status.Errorf(codes.NotFound, "frontend %v not registered",
+			req.Id)
+	}
+
+	return &frontendrpc.ReportResponse{
+		Disconnect: h.mirrorClients(f, req.Clients),
+	}, nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dnldd/dcrpool/dividend"
+	"github.com/dnldd/dcrpool/frontendrpc"
+)
+
+func TestMirrorClients(t *testing.T) {
+	h := &Hub{frontends: make(map[string]*frontend)}
+	f := &frontend{
+		id:        1,
+		name:      "fe1",
+		endpoints: make(map[string]*Endpoint),
+	}
+	h.frontends[f.name] = f
+
+	// Frontends which have not reported are not accounted for.
+	if len(h.allEndpoints()) != 0 {
+		t.Fatal("expected no endpoints before the frontend reported")
+	}
+
+	clients := []*frontendrpc.Client{{
+		Id:         "01a1b2c3/" + dividend.CPU,
+		Ip:         "127.0.0.1:5550",
+		Miner:      dividend.CPU,
+		Account:    "a1",
+		Difficulty: "256",
+		Hashrate:   "1.5",
+		Accepted:   3,
+	}, {
+		Id:         "01d4e5f6/" + dividend.CPU,
+		Ip:         "127.0.0.2:5550",
+		Miner:      dividend.CPU,
+		Account:    "a2",
+		Difficulty: "256",
+		Hashrate:   "0.5",
+	}}
+	if disc := h.mirrorClients(f, clients); len(disc) != 0 {
+		t.Fatalf("expected no disconnections, got %v", disc)
+	}
+
+	endpoints := h.allEndpoints()
+	if len(endpoints) != 1 || len(endpoints[0].clients) != 2 {
+		t.Fatalf("expected 2 mirrored clients, got %v endpoints",
+			len(endpoints))
+	}
+
+	rates := h.sampleHashRates()
+	if rates[dividend.PoolSeries] != 2 {
+		t.Fatalf("expected a pool hash rate of 2, got %v",
+			rates[dividend.PoolSeries])
+	}
+
+	// Mirrored clients disconnected by the pool are reported back to the
+	// frontend, clients no longer reported are removed.
+	if n := h.disconnectIP("127.0.0.1"); n != 1 {
+		t.Fatalf("expected 1 client disconnected, got %v", n)
+	}
+	disc := h.mirrorClients(f, clients[:1])
+	if len(disc) != 1 || disc[0] != clients[0].Id {
+		t.Fatalf("expected %v to be disconnected, got %v", clients[0].Id,
+			disc)
+	}
+	if len(h.allEndpoints()[0].clients) != 1 {
+		t.Fatal("expected the unreported client to be removed")
+	}
+
+	// Frontends which stopped reporting are no longer accounted for.
+	f.reported = time.Now().Add(-frontendTimeout * 2)
+	if len(h.allEndpoints()) != 0 {
+		t.Fatal("expected no endpoints of a stale frontend")
+	}
+}
@@ -0,0 +1,590 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gqlField is a field selected by a graphql query.
+type gqlField struct {
+	alias     string
+	name      string
+	args      map[string]interface{}
+	selection []*gqlField
+}
+
+// gqlParser parses graphql query documents. Only single query operations
+// with fields, aliases, arguments and variables are supported.
+type gqlParser struct {
+	src  string
+	pos  int
+	vars map[string]interface{}
+}
+
+// parseGraphQL parses the selection set of the query operation of the
+// provided document, resolving variables against the provided values.
+func parseGraphQL(query string, vars map[string]interface{}) ([]*gqlField, error) {
+	if vars == nil {
+		vars = make(map[string]interface{})
+	}
+
+	p := &gqlParser{src: query, vars: vars}
+	selection, err := p.parseOperation()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skip()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("only a single operation is supported")
+	}
+
+	return selection, nil
+}
+
+// errorf returns a parse error at the current position.
+func (p *gqlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at %v: %v", p.pos,
+		fmt.Sprintf(format, args...))
+}
+
+// skip advances past ignored tokens: whitespace, commas and comments.
+func (p *gqlParser) skip() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next significant character, or zero at the end of the
+// document.
+func (p *gqlParser) peek() byte {
+	p.skip()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// expect consumes the provided punctuator.
+func (p *gqlParser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+	return nil
+}
+
+// name consumes a name.
+func (p *gqlParser) name() (string, error) {
+	p.skip()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(p.pos > start && c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+
+	if p.pos == start {
+		return "", p.errorf("expected a name")
+	}
+
+	return p.src[start:p.pos], nil
+}
+
+// parseOperation parses the query operation of the document and returns its
+// selection set.
+func (p *gqlParser) parseOperation() ([]*gqlField, error) {
+	if p.peek() == '{' {
+		return p.parseSelectionSet()
+	}
+
+	op, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	if op != "query" {
+		return nil, p.errorf("unsupported operation '%v'", op)
+	}
+
+	// Skip the operation name.
+	if c := p.peek(); c != '(' && c != '{' {
+		_, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek() == '(' {
+		err := p.parseVariableDefinitions()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p.parseSelectionSet()
+}
+
+// parseVariableDefinitions parses the variable definitions of the operation,
+// applying default values of variables not provided.
+func (p *gqlParser) parseVariableDefinitions() error {
+	err := p.expect('(')
+	if err != nil {
+		return err
+	}
+
+	for p.peek() != ')' {
+		err := p.expect('$')
+		if err != nil {
+			return err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+
+		err = p.expect(':')
+		if err != nil {
+			return err
+		}
+
+		err = p.parseType()
+		if err != nil {
+			return err
+		}
+
+		if p.peek() == '=' {
+			p.pos++
+			def, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+
+			if _, ok := p.vars[name]; !ok {
+				p.vars[name] = def
+			}
+		}
+	}
+
+	p.pos++
+	return nil
+}
+
+// parseType parses and discards a variable type, variables are checked by
+// the fields using them.
+func (p *gqlParser) parseType() error {
+	if p.peek() == '[' {
+		p.pos++
+		err := p.parseType()
+		if err != nil {
+			return err
+		}
+
+		err = p.expect(']')
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := p.name()
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.peek() == '!' {
+		p.pos++
+	}
+
+	return nil
+}
+
+// parseSelectionSet parses a selection set.
+func (p *gqlParser) parseSelectionSet() ([]*gqlField, error) {
+	err := p.expect('{')
+	if err != nil {
+		return nil, err
+	}
+
+	selection := make([]*gqlField, 0)
+	for p.peek() != '}' {
+		switch p.peek() {
+		case 0:
+			return nil, p.errorf("unterminated selection set")
+		case '.':
+			return nil, p.errorf("fragments are not supported")
+		}
+
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+
+		selection = append(selection, field)
+	}
+
+	p.pos++
+	if len(selection) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+
+	return selection, nil
+}
+
+// parseField parses a selected field.
+func (p *gqlParser) parseField() (*gqlField, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	field := &gqlField{alias: name, name: name}
+	if p.peek() == ':' {
+		p.pos++
+		field.name, err = p.name()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek() == '(' {
+		p.pos++
+		field.args = make(map[string]interface{})
+		for p.peek() != ')' {
+			arg, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+
+			err = p.expect(':')
+			if err != nil {
+				return nil, err
+			}
+
+			field.args[arg], err = p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+		}
+		p.pos++
+	}
+
+	if p.peek() == '@' {
+		return nil, p.errorf("directives are not supported")
+	}
+
+	if p.peek() == '{' {
+		field.selection, err = p.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return field, nil
+}
+
+// parseValue parses an argument value. Integers are returned as int64,
+// enum values as strings.
+func (p *gqlParser) parseValue() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '$':
+		p.pos++
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return p.vars[name], nil
+
+	case c == '"':
+		return p.parseString()
+
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+
+	case c == '[':
+		p.pos++
+		list := make([]interface{}, 0)
+		for p.peek() != ']' {
+			if p.peek() == 0 {
+				return nil, p.errorf("unterminated list")
+			}
+
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		p.pos++
+		return list, nil
+
+	case c == '{':
+		p.pos++
+		obj := make(map[string]interface{})
+		for p.peek() != '}' {
+			key, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+
+			err = p.expect(':')
+			if err != nil {
+				return nil, err
+			}
+
+			obj[key], err = p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+		}
+		p.pos++
+		return obj, nil
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, p.errorf("expected a value")
+	}
+
+	switch name {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	return name, nil
+}
+
+// parseString parses a string value, escapes match json escapes.
+func (p *gqlParser) parseString() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] != '"' {
+		if p.src[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+
+	if p.pos >= len(p.src) {
+		return "", p.errorf("unterminated string")
+	}
+	p.pos++
+
+	var s string
+	err := json.Unmarshal([]byte(p.src[start:p.pos]), &s)
+	if err != nil {
+		return "", p.errorf("invalid string")
+	}
+
+	return s, nil
+}
+
+// parseNumber parses an integer or float value.
+func (p *gqlParser) parseNumber() (interface{}, error) {
+	start := p.pos
+	float := false
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '.' || c == 'e' || c == 'E' {
+			float = true
+		} else if !(c >= '0' && c <= '9') && c != '-' && c != '+' {
+			break
+		}
+		p.pos++
+	}
+
+	lit := p.src[start:p.pos]
+	if float {
+		v, err := strconv.ParseFloat(lit, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number '%v'", lit)
+		}
+		return v, nil
+	}
+
+	v, err := strconv.ParseInt(lit, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number '%v'", lit)
+	}
+	return v, nil
+}
+
+// gqlResolver resolves the value of a field for the provided arguments.
+// Values are either scalars, objects or lists of objects.
+type gqlResolver func(args map[string]interface{}) (interface{}, error)
+
+// gqlObject is a graphql object, its fields are resolved on selection.
+type gqlObject struct {
+	typeName string
+	fields   map[string]gqlResolver
+}
+
+// gqlError is a graphql error, the path leads to the field which failed to
+// resolve.
+type gqlError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// gqlResult is a resolved object, fields are encoded in selection order.
+type gqlResult struct {
+	keys   []string
+	values []interface{}
+}
+
+// MarshalJSON encodes the resolved fields in selection order.
+func (res *gqlResult) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range res.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := json.Marshal(res.values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// gqlExecutor resolves selections, collecting field errors.
+type gqlExecutor struct {
+	errors []gqlError
+}
+
+// fail records a field error.
+func (e *gqlExecutor) fail(path []interface{}, format string, args ...interface{}) {
+	p := make([]interface{}, len(path))
+	copy(p, path)
+	e.errors = append(e.errors, gqlError{
+		Message: fmt.Sprintf(format, args...),
+		Path:    p,
+	})
+}
+
+// resolveObject resolves the provided selection of an object.
+func (e *gqlExecutor) resolveObject(obj *gqlObject, selection []*gqlField, path []interface{}) *gqlResult {
+	res := &gqlResult{}
+	for _, field := range selection {
+		fieldPath := append(path, field.alias)
+		res.keys = append(res.keys, field.alias)
+
+		if field.name == "__typename" {
+			res.values = append(res.values, obj.typeName)
+			continue
+		}
+
+		resolver, ok := obj.fields[field.name]
+		if !ok {
+			e.fail(fieldPath, "cannot query field '%v' on type '%v'",
+				field.name, obj.typeName)
+			res.values = append(res.values, nil)
+			continue
+		}
+
+		v, err := resolver(field.args)
+		if err != nil {
+			e.fail(fieldPath, "%v", err)
+			res.values = append(res.values, nil)
+			continue
+		}
+
+		res.values = append(res.values, e.resolveValue(field, v, fieldPath))
+	}
+
+	return res
+}
+
+// resolveValue resolves the selection of a field value.
+func (e *gqlExecutor) resolveValue(field *gqlField, v interface{}, path []interface{}) interface{} {
+	switch value := v.(type) {
+	case *gqlObject:
+		if value == nil {
+			return nil
+		}
+
+		if len(field.selection) == 0 {
+			e.fail(path, "field '%v' of type '%v' must have a selection "+
+				"of subfields", field.name, value.typeName)
+			return nil
+		}
+
+		return e.resolveObject(value, field.selection, path)
+
+	case []*gqlObject:
+		list := make([]interface{}, 0, len(value))
+		for i, obj := range value {
+			list = append(list, e.resolveValue(field, obj, append(path, i)))
+		}
+		return list
+	}
+
+	if len(field.selection) > 0 {
+		e.fail(path, "field '%v' is a scalar and must not have a selection",
+			field.name)
+		return nil
+	}
+
+	return v
+}
+
+// intArg returns the integer argument of the provided name, or the provided
+// default if it is not set.
+func intArg(args map[string]interface{}, name string, def int64) (int64, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return def, nil
+	}
+
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case float64:
+		if n == float64(int64(n)) {
+			return int64(n), nil
+		}
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+		if err == nil {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("argument '%v' is not an integer", name)
+}
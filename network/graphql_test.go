@@ -0,0 +1,103 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGraphQL(t *testing.T) {
+	item := func(name string) *gqlObject {
+		return &gqlObject{
+			typeName: "Item",
+			fields:   map[string]gqlResolver{"name": gqlValue(name)},
+		}
+	}
+
+	query := &gqlObject{
+		typeName: "Query",
+		fields: map[string]gqlResolver{
+			"count": gqlValue(2),
+			"items": func(args map[string]interface{}) (interface{}, error) {
+				limit, err := intArg(args, "limit", 0)
+				if err != nil {
+					return nil, err
+				}
+
+				items := []*gqlObject{item("a"), item("b")}
+				if limit > 0 && limit < int64(len(items)) {
+					items = items[:limit]
+				}
+				return items, nil
+			},
+		},
+	}
+
+	tests := []struct {
+		query    string
+		vars     map[string]interface{}
+		expected string
+		errors   int
+	}{
+		{
+			query:    `{ count items { name } }`,
+			expected: `{"count":2,"items":[{"name":"a"},{"name":"b"}]}`,
+		},
+		{
+			query: `query Items($limit: Int = 2) {
+				first: items(limit: $limit) { name __typename }
+			}`,
+			vars:     map[string]interface{}{"limit": float64(1)},
+			expected: `{"first":[{"name":"a","__typename":"Item"}]}`,
+		},
+		{
+			query:    `{ items(limit: "x") { name } missing }`,
+			expected: `{"items":null,"missing":null}`,
+			errors:   2,
+		},
+		{
+			query:    `{ count { name } items }`,
+			expected: `{"count":null,"items":[null,null]}`,
+			errors:   3,
+		},
+	}
+
+	for _, test := range tests {
+		selection, err := parseGraphQL(test.query, test.vars)
+		if err != nil {
+			t.Fatalf("%v: %v", test.query, err)
+		}
+
+		exec := &gqlExecutor{}
+		res, err := json.Marshal(exec.resolveObject(query, selection, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(res) != test.expected {
+			t.Fatalf("%v: expected %v, got %v", test.query, test.expected,
+				string(res))
+		}
+
+		if len(exec.errors) != test.errors {
+			t.Fatalf("%v: expected %v errors, got %v", test.query,
+				test.errors, exec.errors)
+		}
+	}
+
+	invalid := []string{
+		`mutation { count }`,
+		`{ count `,
+		`{ ...fields }`,
+		`{ count } { count }`,
+	}
+	for _, query := range invalid {
+		_, err := parseGraphQL(query, nil)
+		if err == nil {
+			t.Fatalf("%v: expected a syntax error", query)
+		}
+	}
+}
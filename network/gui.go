@@ -0,0 +1,155 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"sort"
+)
+
+const (
+	// blocksPage is the template of the blocks found page.
+	blocksPage = "blocks"
+
+	// leaderboardPage is the template of the leaderboard page.
+	leaderboardPage = "leaderboard"
+
+	// dashboardPage is the template of the operator dashboard.
+	dashboardPage = "dashboard"
+
+	// apiDocsPage is the template of the api documentation page.
+	apiDocsPage = "apidocs"
+
+	// indexPage is the template of the front page.
+	indexPage = "index"
+
+	// paymentsPage is the template of the payments page.
+	paymentsPage = "payments"
+)
+
+// pages are the web interface pages, each rendered by the template of the
+// same name.
+var pages = []string{indexPage, blocksPage, leaderboardPage, dashboardPage,
+	apiDocsPage, paymentsPage}
+
+// embeddedGUI holds the templates and static assets of the web interface.
+//
+//go:embed gui
+var embeddedGUI embed.FS
+
+// overlayFS serves the files of an override directory, falling back to the
+// files of a base file system.
+type overlayFS struct {
+	override fs.FS
+	base     fs.FS
+}
+
+// Open opens the named file of the override directory if it exists and the
+// file of the base file system otherwise.
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.override.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return o.base.Open(name)
+}
+
+// ReadDir lists the named directory of both file systems, entries of the
+// override directory replace those of the base file system.
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	found := false
+	merged := make(map[string]fs.DirEntry)
+	for _, fsys := range []fs.FS{o.base, o.override} {
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		found = true
+		for _, entry := range entries {
+			merged[entry.Name()] = entry
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name,
+			Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(merged))
+	for _, entry := range merged {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+// guiAssets returns the web interface assets, the templates and static
+// assets of the provided directory override the embedded ones.
+func guiAssets(dir string) fs.FS {
+	base, err := fs.Sub(embeddedGUI, "gui")
+	if err != nil {
+		panic(err)
+	}
+
+	if dir == "" {
+		return base
+	}
+
+	return &overlayFS{override: os.DirFS(dir), base: base}
+}
+
+// loadPages parses the page templates of the provided web interface assets.
+// Pages translate messages with `T` and list languages with `languages`,
+// both are bound to the request when rendered. Templates under
+// `templates/partials` are shared by all pages.
+func loadPages(assets fs.FS) (map[string]*template.Template, error) {
+	partials, err := fs.Glob(assets, "templates/partials/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	tmpls := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		file := "templates/" + page + ".html"
+		tmpl, err := template.New(page+".html").Funcs(template.FuncMap{
+			"T":         func(string, ...interface{}) string { return "" },
+			"languages": func() []language { return nil },
+		}).ParseFS(assets, append([]string{file}, partials...)...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page template %v: %v", file, err)
+		}
+		tmpls[page] = tmpl
+	}
+
+	return tmpls, nil
+}
+
+// Static serves the static assets of the web interface.
+func (h *Hub) Static() http.Handler {
+	static, err := fs.Sub(h.gui, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	return http.StripPrefix("/static/", http.FileServer(http.FS(static)))
+}
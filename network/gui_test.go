@@ -0,0 +1,87 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGUIOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(filepath.Join(dir, "templates"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(dir, "static"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "templates", "leaderboard.html"),
+		[]byte(`{{template "language"}}<h1>Custom {{.Network}}</h1>`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "static", "logo.svg"),
+		[]byte("<svg></svg>"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assets := guiAssets(dir)
+	pages, err := loadPages(assets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Hub{catalogs: map[string]Catalog{defaultLanguage: englishCatalog},
+		gui: assets, pages: pages}
+
+	// Overridden templates replace the embedded ones.
+	w := httptest.NewRecorder()
+	err = h.renderPage(w, httptest.NewRequest("GET", "/leaderboard", nil),
+		leaderboardPage, leaderboardPageData{Network: "mainnet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "Custom mainnet") ||
+		!strings.Contains(body, "English") {
+		t.Fatalf("unexpected page %v", body)
+	}
+
+	// Templates missing from the directory are embedded.
+	w = httptest.NewRecorder()
+	err = h.renderPage(w, httptest.NewRequest("GET", "/blocks", nil),
+		blocksPage, blocksPageData{Network: "mainnet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(w.Body.String(), englishCatalog["blocks.none"]) {
+		t.Fatalf("unexpected page %v", w.Body.String())
+	}
+
+	// Static assets are served from the directory, then the embedded ones.
+	for path, want := range map[string]string{
+		"/static/logo.svg":  "<svg></svg>",
+		"/static/style.css": "font-family",
+	} {
+		w = httptest.NewRecorder()
+		h.Static().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != 200 || !strings.Contains(w.Body.String(), want) {
+			t.Fatalf("unexpected response for %v: %v %v", path, w.Code,
+				w.Body.String())
+		}
+	}
+}
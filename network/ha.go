@@ -0,0 +1,260 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// SnapshotIDHeader is the header of replication responses identifying the
+// database snapshot they carry. Standbys acknowledge the snapshots they
+// stored with their next replication request.
+const SnapshotIDHeader = "X-Snapshot-Id"
+
+var (
+	// errLeaseExpired is returned when payouts are held since the standby
+	// has not replicated the primary within the lease period.
+	errLeaseExpired = errors.New("standby replication lease expired")
+
+	// errUnreplicated is returned when payouts are held since the standby
+	// has yet to acknowledge a snapshot including them.
+	errUnreplicated = errors.New("payouts not yet replicated by the standby")
+)
+
+// ReplicationRequest is a request of a standby replicating the database of
+// its primary.
+type ReplicationRequest struct {
+	Pass  string `json:"pass"`
+	Acked int64  `json:"acked"`
+}
+
+// renewLease records a replication request of the standby, renewing the
+// lease of the primary and the snapshot last acknowledged by the standby.
+// It returns whether a newer snapshot was acknowledged.
+func (h *Hub) renewLease(acked int64) bool {
+	atomic.StoreInt64(&h.leaseRenewed, time.Now().UnixNano())
+	for {
+		curr := atomic.LoadInt64(&h.snapshotAcked)
+		if acked <= curr {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&h.snapshotAcked, curr, acked) {
+			return true
+		}
+	}
+}
+
+// leaseValid returns whether the standby replicated the primary within the
+// lease period as of the provided time. A standby only takes over after the
+// lease of the primary expired.
+func (h *Hub) leaseValid(now time.Time) bool {
+	renewed := atomic.LoadInt64(&h.leaseRenewed)
+	return renewed != 0 && now.Sub(time.Unix(0, renewed)) < h.cfg.HALease
+}
+
+// payoutFence returns why recorded payouts may not be published, nil when
+// they may. Primaries only publish payouts while holding the lease, once the
+// standby acknowledged a snapshot including them, so a standby taking over
+// never pays out the payments of a payout it did not replicate.
+func (h *Hub) payoutFence(now time.Time) error {
+	if h.cfg.HALease == 0 {
+		return nil
+	}
+
+	if !h.leaseValid(now) {
+		return errLeaseExpired
+	}
+
+	if atomic.LoadInt64(&h.snapshotAcked) <= atomic.LoadInt64(&h.payoutsRecorded) {
+		return errUnreplicated
+	}
+
+	return nil
+}
+
+// signPayout signs the transaction of the provided payout and records the
+// payout unpublished, it is published once the standby replicated it.
+func (h *Hub) signPayout(pmts map[dcrutil.Address]dcrutil.Amount, height uint32, accounts int, amount dcrutil.Amount, feeRate dcrutil.Amount) (*dividend.Payout, error) {
+	if !h.leaseValid(time.Now()) {
+		return nil, errLeaseExpired
+	}
+
+	signedTx, err := h.signTransaction(pmts, feeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx wire.MsgTx
+	err = tx.Deserialize(bytes.NewReader(signedTx))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode signed transaction: %v", err)
+	}
+
+	payout := dividend.NewPayout(tx.TxHash().String(), height, accounts,
+		amount, feeRate)
+	payout.Unpublished = true
+	payout.RawTx = hex.EncodeToString(signedTx)
+	err = payout.Create(h.db)
+	if err != nil {
+		return nil, fmt.Errorf("unable to record payout %v: %v",
+			payout.TxHash, err)
+	}
+
+	paymentLog.Infof("Signed payout %v, held until replicated by the standby",
+		payout.TxHash)
+
+	return payout, nil
+}
+
+// payoutPublished records the publication of the provided payout.
+func (h *Hub) payoutPublished(payout *dividend.Payout) {
+	h.metrics.recordPayout(payout.Amount)
+
+	h.logPoolEvent(dividend.PoolEventPayout,
+		fmt.Sprintf("paid %v to %v accounts at height %v", payout.Amount,
+			payout.Accounts, payout.Height),
+		payout, "")
+
+	h.publish("", EventPayment, map[string]interface{}{
+		"height":   payout.Height,
+		"accounts": payout.Accounts,
+		"total":    payout.Amount.ToCoin(),
+		"txhash":   payout.TxHash,
+	})
+}
+
+// publishedTransaction returns whether dcrd knows the provided transaction.
+func (h *Hub) publishedTransaction(txHash string) bool {
+	hash, err := chainhash.NewHashFromStr(txHash)
+	if err != nil {
+		return false
+	}
+
+	h.rpccMtx.Lock()
+	_, err = h.rpcc.GetRawTransaction(hash)
+	h.rpccMtx.Unlock()
+	return err == nil
+}
+
+// publishUnpublishedPayouts publishes the recorded payouts not yet
+// published, once fencing allows. Payouts published by a primary before a
+// standby took over are recognized as such. It must be called with the
+// payment mutex held.
+func (h *Hub) publishUnpublishedPayouts() {
+	payouts, err := dividend.FetchUnpublishedPayouts(h.db)
+	if err != nil {
+		paymentLog.Errorf("Failed to fetch unpublished payouts: %v", err)
+		return
+	}
+
+	if len(payouts) == 0 {
+		return
+	}
+
+	err = h.payoutFence(time.Now())
+	if err != nil {
+		paymentLog.Debugf("Payouts held: %v", err)
+		return
+	}
+
+	for _, payout := range payouts {
+		signedTx, err := hex.DecodeString(payout.RawTx)
+		if err != nil {
+			paymentLog.Errorf("Invalid signed transaction of payout %v: %v",
+				payout.TxHash, err)
+			continue
+		}
+
+		_, err = h.publishSignedTransaction(signedTx)
+		if err != nil {
+			if !h.publishedTransaction(payout.TxHash) {
+				paymentLog.Errorf("Failed to publish payout %v: %v",
+					payout.TxHash, err)
+				continue
+			}
+			paymentLog.Infof("Payout %v was already published", payout.TxHash)
+		}
+
+		payout.Unpublished = false
+		payout.RawTx = ""
+		err = payout.Update(h.db)
+		if err != nil {
+			paymentLog.Errorf("Failed to update payout %v: %v", payout.TxHash,
+				err)
+			continue
+		}
+
+		h.payoutPublished(payout)
+	}
+}
+
+// releasePayouts publishes the recorded payouts not yet published, once
+// fencing allows.
+func (h *Hub) releasePayouts() {
+	h.paymentMtx.Lock()
+	defer h.paymentMtx.Unlock()
+	h.publishUnpublishedPayouts()
+}
+
+// Replicate handles replication requests of the standby of the pool,
+// streaming a snapshot of the database. Requests renew the lease of the
+// primary and acknowledge the snapshot last stored by the standby, releasing
+// the payouts it includes. Snapshots hold account credentials, replication
+// is refused unless the backup password is set.
+func (h *Hub) Replicate(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.HALease == 0 || h.cfg.BackupPass == "" {
+		RespondWithError(w, http.StatusNotFound,
+			"standby replication is not enabled")
+		return
+	}
+
+	var req ReplicationRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(req.Pass),
+		[]byte(h.cfg.BackupPass)) != 1 {
+		RespondWithError(w, http.StatusUnauthorized, "unauthorized access")
+		return
+	}
+
+	if h.renewLease(req.Acked) {
+		go h.releasePayouts()
+	}
+
+	// The snapshot is identified by the time before its transaction begins,
+	// it includes all payouts recorded earlier.
+	id := time.Now().UnixNano()
+	err = h.db.View(func(tx *bolt.Tx) error {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(int(tx.Size())))
+		w.Header().Set(SnapshotIDHeader, strconv.FormatInt(id, 10))
+		_, err := tx.WriteTo(w)
+		return err
+	})
+	if err != nil {
+		log.Errorf("Failed to stream database snapshot: %v", err)
+	}
+}
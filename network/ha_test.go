@@ -0,0 +1,77 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPayoutFence(t *testing.T) {
+	h := &Hub{cfg: &HubConfig{}}
+	now := time.Now()
+
+	// Payouts are not fenced without a standby.
+	if err := h.payoutFence(now); err != nil {
+		t.Fatalf("unexpected fence without a standby: %v", err)
+	}
+
+	h.cfg.HALease = 30 * time.Second
+	h.payoutsRecorded = now.UnixNano()
+	if err := h.payoutFence(now); err != errLeaseExpired {
+		t.Fatalf("expected %v before replication, got %v", errLeaseExpired,
+			err)
+	}
+
+	// Snapshots taken before the payouts were recorded do not release
+	// them.
+	if !h.renewLease(now.Add(-time.Second).UnixNano()) {
+		t.Fatal("expected the snapshot to be acknowledged")
+	}
+	if err := h.payoutFence(now); err != errUnreplicated {
+		t.Fatalf("expected %v, got %v", errUnreplicated, err)
+	}
+
+	if !h.renewLease(now.Add(time.Second).UnixNano()) {
+		t.Fatal("expected the snapshot to be acknowledged")
+	}
+	if h.renewLease(now.UnixNano()) {
+		t.Fatal("expected an older snapshot not to be acknowledged")
+	}
+	if err := h.payoutFence(now); err != nil {
+		t.Fatalf("unexpected fence after replication: %v", err)
+	}
+
+	// Payouts are fenced once the lease expires.
+	if err := h.payoutFence(now.Add(time.Minute)); err != errLeaseExpired {
+		t.Fatalf("expected %v after the lease, got %v", errLeaseExpired, err)
+	}
+}
+
+func TestReplicateAuth(t *testing.T) {
+	h := &Hub{cfg: &HubConfig{HALease: 30 * time.Second}}
+	replicate := func(pass string) int {
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"pass":"` + pass + `"}`)
+		h.Replicate(rec, httptest.NewRequest(http.MethodPost,
+			"/ha/replicate", body))
+		return rec.Code
+	}
+
+	// Ensure replication is refused without a backup password.
+	if code := replicate(""); code != http.StatusNotFound {
+		t.Fatalf("expected replication without a backup password to be "+
+			"refused, got %v", code)
+	}
+
+	h.cfg.BackupPass = "pass"
+	if code := replicate("wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("expected an invalid backup password to be "+
+			"unauthorized, got %v", code)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// maxGenerateBlocks is the maximum number of blocks generated per simnet
+// harness request.
+const maxGenerateBlocks = 256
+
+// validateGenerateCount asserts the number of blocks requested generated is
+// within bounds.
+func validateGenerateCount(blocks uint32) error {
+	if blocks == 0 || blocks > maxGenerateBlocks {
+		return fmt.Errorf("blocks must be between 1 and %v",
+			maxGenerateBlocks)
+	}
+
+	return nil
+}
+
+// GenerateBlocks handles simnet harness requests generating blocks on the
+// connected dcrd, used to mature and confirm the blocks and payouts of the
+// pool without waiting on the miners.
+func (h *Hub) GenerateBlocks(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Blocks uint32 `json:"blocks"`
+	}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	err = validateGenerateCount(params.Blocks)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.rpccMtx.Lock()
+	hashes, err := h.rpcc.Generate(params.Blocks)
+	if err != nil {
+		h.rpccMtx.Unlock()
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	height, err := h.rpcc.GetBlockCount()
+	h.rpccMtx.Unlock()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	generated := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		generated = append(generated, hash.String())
+	}
+
+	log.Infof("Simnet harness generated %v blocks by request of %v",
+		len(generated), requestOperator(r))
+
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"hashes": generated,
+		"height": height,
+	})
+}
+
+// HarnessStatus handles simnet harness requests for the progress of the
+// share, block and payout cycle of the pool: the shares accepted, the blocks
+// mined and confirmed, the pending payments and the payouts made and
+// confirmed.
+func (h *Hub) HarnessStatus(w http.ResponseWriter, r *http.Request) {
+	h.rpccMtx.Lock()
+	height, err := h.rpcc.GetBlockCount()
+	h.rpccMtx.Unlock()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	mined, err := ListMinedWork(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	pending, err := dividend.FetchPendingPayments(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var confirmed int
+	payouts, err := dividend.FilterPayouts(h.db,
+		func(payout *dividend.Payout) bool {
+			if payout.Confirmed() {
+				confirmed++
+			}
+			return true
+		})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"height":           height,
+		"sharesaccepted":   atomic.LoadUint64(&h.metrics.sharesAccepted),
+		"blocksmined":      len(mined),
+		"pendingpayments":  len(pending),
+		"payouts":          len(payouts),
+		"confirmedpayouts": confirmed,
+	})
+}
+
+// instantMineRequest is a request to generate a block crediting the account
+// of an accepted share in instant mining mode.
+type instantMineRequest struct {
+	account string
+	miner   string
+}
+
+// queueInstantMine queues the generation of a block crediting the provided
+// account. Requests made while a block is pending generation are dropped,
+// blocks are generated one at a time.
+func (h *Hub) queueInstantMine(account, miner string) {
+	select {
+	case h.mineCh <- &instantMineRequest{account: account, miner: miner}:
+	default:
+		log.Tracef("Instant mining pending, dropping request of %v", account)
+	}
+}
+
+// instantMine generates a block on dcrd and records it as work accepted
+// from the provided account, the block is then credited and confirmed like
+// blocks found by clients of the pool.
+func (h *Hub) instantMine(req *instantMineRequest) error {
+	h.rpccMtx.Lock()
+	hashes, err := h.rpcc.Generate(1)
+	if err != nil {
+		h.rpccMtx.Unlock()
+		return fmt.Errorf("unable to generate block: %v", err)
+	}
+	if len(hashes) == 0 {
+		h.rpccMtx.Unlock()
+		return fmt.Errorf("no block generated")
+	}
+	header, err := h.rpcc.GetBlockHeader(hashes[0])
+	h.rpccMtx.Unlock()
+	if err != nil {
+		return fmt.Errorf("unable to fetch block header: %v", err)
+	}
+
+	work := NewAcceptedWork(hashes[0].String(), header.PrevBlock.String(),
+		header.Height, req.account, req.miner)
+	err = work.Create(h.db)
+	if err != nil {
+		return fmt.Errorf("unable to persist accepted work: %v", err)
+	}
+
+	log.Infof("Instantly mined block %v at height %v for %v", work.BlockHash,
+		work.Height, req.account)
+
+	return nil
+}
+
+// handleInstantMining generates blocks crediting the accounts of accepted
+// shares in instant mining mode, only allowed on simnet. It must be run as a
+// goroutine.
+func (h *Hub) handleInstantMining(ctx context.Context) {
+	h.wg.Add(1)
+	log.Trace("Started instant mining handler.")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Trace("Instant mining handler done.")
+			h.wg.Done()
+			return
+
+		case req := <-h.mineCh:
+			err := h.instantMine(req)
+			if err != nil {
+				log.Errorf("Failed to instantly mine block: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+)
+
+func TestValidateGenerateCount(t *testing.T) {
+	tests := []struct {
+		blocks uint32
+		valid  bool
+	}{
+		{0, false},
+		{1, true},
+		{maxGenerateBlocks, true},
+		{maxGenerateBlocks + 1, false},
+	}
+
+	for _, test := range tests {
+		err := validateGenerateCount(test.blocks)
+		if (err == nil) != test.valid {
+			t.Fatalf("%v blocks: expected valid %v, got error %v",
+				test.blocks, test.valid, err)
+		}
+	}
+}
+
+func TestQueueInstantMine(t *testing.T) {
+	h := &Hub{mineCh: make(chan *instantMineRequest, 1)}
+	h.queueInstantMine("a", "cpu")
+	h.queueInstantMine("b", "cpu")
+
+	req := <-h.mineCh
+	if req.account != "a" {
+		t.Fatalf("expected the request of account a, got %v", req.account)
+	}
+
+	select {
+	case req := <-h.mineCh:
+		t.Fatalf("expected requests made while pending to be dropped, "+
+			"got %v", req.account)
+	default:
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+const (
+	// hashRateSampleInterval is the interval between hash rate samples.
+	hashRateSampleInterval = time.Minute * 5
+
+	// hashRatePruneInterval is the interval between prunes of expired hash
+	// rate samples.
+	hashRatePruneInterval = time.Hour
+)
+
+// sampleHashRates returns the hash rates of the pool and of the accounts and
+// workers of connected clients, keyed by series.
+func (h *Hub) sampleHashRates() map[string]float64 {
+	rates := map[string]float64{dividend.PoolSeries: 0}
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			client.hashRateMtx.RLock()
+			rate, _ := client.hashRate.Float64()
+			client.hashRateMtx.RUnlock()
+
+			rates[dividend.PoolSeries] += rate
+			if client.account != "" {
+				rates[dividend.AccountSeries(client.account)] += rate
+			}
+			if client.worker != "" {
+				rates[dividend.WorkerSeries(client.worker)] += rate
+			}
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	return rates
+}
+
+// handleHashRateSamples periodically records hash rate samples and prunes
+// expired ones.
+func (h *Hub) handleHashRateSamples(ctx context.Context) {
+	ticker := time.NewTicker(hashRateSampleInterval)
+	defer ticker.Stop()
+	h.wg.Add(1)
+	log.Trace("Started hash rate sampler.")
+
+	lastPrune := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Trace("Hash rate sampler done.")
+			h.wg.Done()
+			return
+		case now := <-ticker.C:
+			err := dividend.RecordHashRates(h.db, now, h.sampleHashRates(),
+				h.cfg.HashRatePolicy)
+			if err != nil {
+				log.Errorf("Failed to record hash rates: %v", err)
+			}
+
+			if now.Sub(lastPrune) >= hashRatePruneInterval {
+				err := dividend.PruneHashRates(h.db, now,
+					h.cfg.HashRatePolicy)
+				if err != nil {
+					log.Errorf("Failed to prune hash rates: %v", err)
+				}
+				lastPrune = now
+			}
+		}
+	}
+}
+
+// respondWithHashRates responds with the hash rate samples of the provided
+// series. The `resolution` parameter selects the sample resolution, 1h by
+// default, and the `from` and `to` parameters bound the samples in unix
+// time, the full retention of the resolution by default.
+func (h *Hub) respondWithHashRates(w http.ResponseWriter, r *http.Request, series string) {
+	res := r.URL.Query().Get("resolution")
+	if res == "" {
+		res = dividend.Resolution1h
+	}
+
+	policy := dividend.SeriesPolicy(series, h.cfg.HashRatePolicy)
+	retention, err := policy.Retention(res)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now()
+	from := now.Add(-retention)
+	to := now
+	for name, bound := range map[string]*time.Time{"from": &from, "to": &to} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+
+		secs, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || secs < 0 {
+			RespondWithError(w, http.StatusBadRequest,
+				"provided '"+name+"' parameter is not a positive number")
+			return
+		}
+		*bound = time.Unix(secs, 0)
+	}
+
+	samples, err := dividend.FetchHashRates(h.db, series, res, from, to)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := map[string]interface{}{
+		"resolution":   res,
+		"hashrateunit": hashRateUnit,
+		"results":      samples,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// FetchPoolHashRates returns the hash rate samples of the pool.
+func (h *Hub) FetchPoolHashRates(w http.ResponseWriter, r *http.Request) {
+	h.respondWithHashRates(w, r, dividend.PoolSeries)
+}
+
+// FetchAccountHashRates returns the hash rate samples of the authenticated
+// account.
+func (h *Hub) FetchAccountHashRates(w http.ResponseWriter, r *http.Request) {
+	h.respondWithHashRates(w, r,
+		dividend.AccountSeries(requestAccountID(r)))
+}
+
+// FetchWorkerHashRates returns the hash rate samples of a worker of the
+// authenticated account.
+func (h *Hub) FetchWorkerHashRates(w http.ResponseWriter, r *http.Request) {
+	worker, err := h.requestWorker(r, r.URL.Query().Get("id"))
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.respondWithHashRates(w, r, dividend.WorkerSeries(worker.UUID))
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/decred/dcrd/chaincfg"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/dnldd/dcrpool/database"
+)
+
+const (
+	// readyMinPeers is the number of peers dcrd must be connected to for
+	// the pool to be ready to serve miners, a node without peers mines on
+	// a chain the network may not see. Peers are not checked on simnet
+	// where a single node generates blocks.
+	readyMinPeers = 1
+
+	// readyMaxHeightLag is the number of blocks the active dcrd may lag
+	// behind the highest chain tip known to the backends while the pool
+	// is ready to serve miners.
+	readyMaxHeightLag = 1
+)
+
+// healthCheck is the outcome of a health check of a pool dependency.
+type healthCheck struct {
+	OK    bool        `json:"ok"`
+	Error string      `json:"error,omitempty"`
+	Info  interface{} `json:"info,omitempty"`
+}
+
+// dcrdState is the chain state of the active dcrd backend reported by the
+// health checks.
+type dcrdState struct {
+	Peers       int64  `json:"peers"`
+	Height      int64  `json:"height"`
+	Tip         int64  `json:"tip"`
+	WorkLatency string `json:"worklatency"`
+
+	workLatency time.Duration
+}
+
+// check asserts the active dcrd backend is connected to the required peers,
+// is at the known chain tip and provides work templates timely. Zero
+// latency thresholds disable the latency check.
+func (s *dcrdState) check(minPeers int64, maxLatency time.Duration) error {
+	if s.Peers < minPeers {
+		return fmt.Errorf("dcrd has %v peers, %v required", s.Peers,
+			minPeers)
+	}
+
+	if s.Tip-s.Height > readyMaxHeightLag {
+		return fmt.Errorf("dcrd is at height %v, %v blocks behind the "+
+			"known chain tip", s.Height, s.Tip-s.Height)
+	}
+
+	if maxLatency > 0 && s.workLatency > maxLatency {
+		return fmt.Errorf("dcrd work template latency of %v exceeds %v",
+			s.workLatency, maxLatency)
+	}
+
+	return nil
+}
+
+// fetchDcrdState returns the chain state of the active dcrd backend, the
+// known chain tip is the highest of its known headers and the chain tips of
+// all backends.
+func (h *Hub) fetchDcrdState() (*dcrdState, error) {
+	h.rpccMtx.Lock()
+	peers, err := h.rpcc.GetConnectionCount()
+	if err != nil {
+		h.rpccMtx.Unlock()
+		return nil, err
+	}
+	info, err := h.rpcc.GetBlockChainInfo()
+	h.rpccMtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	latency := time.Duration(atomic.LoadInt64(&h.workLatency))
+	state := &dcrdState{
+		Peers:       peers,
+		Height:      int64(info.Blocks),
+		Tip:         int64(info.Headers),
+		WorkLatency: latency.Round(time.Millisecond).String(),
+		workLatency: latency,
+	}
+
+	tip, err := h.chainTip()
+	if err == nil && int64(tip) > state.Tip {
+		state.Tip = int64(tip)
+	}
+
+	return state, nil
+}
+
+// Heartbeat returns the time the work handler of the pool last iterated, it
+// iterates at least every second while the pool runs. The zero time is
+// returned until the pool has caught up with the chain and started serving
+// miners.
+func (h *Hub) Heartbeat() time.Time {
+	heartbeat := atomic.LoadInt64(&h.heartbeat)
+	if heartbeat == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, heartbeat)
+}
+
+// checkDB asserts the database is writable by recording the time of the
+// check.
+func (h *Hub) checkDB() healthCheck {
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+
+		vbytes := make([]byte, 8)
+		binary.LittleEndian.PutUint64(vbytes, uint64(time.Now().Unix()))
+		return pbkt.Put(database.HealthCheck, vbytes)
+	})
+	if err != nil {
+		return healthCheck{Error: err.Error()}
+	}
+
+	return healthCheck{OK: true}
+}
+
+// checkDcrd asserts the dcrd rpc connection is up, dcrd is synced and
+// connected to peers and provides work templates timely.
+func (h *Hub) checkDcrd() healthCheck {
+	h.rpccMtx.Lock()
+	connected := !h.rpcc.Disconnected()
+	h.rpccMtx.Unlock()
+	if !connected {
+		return healthCheck{Error: "dcrd rpc connection is down"}
+	}
+
+	if h.dcrdSyncing() {
+		return healthCheck{Error: "dcrd is syncing"}
+	}
+
+	state, err := h.fetchDcrdState()
+	if err != nil {
+		return healthCheck{Error: fmt.Sprintf("unable to fetch dcrd "+
+			"chain state: %v", err)}
+	}
+
+	var minPeers int64 = readyMinPeers
+	if h.cfg.ActiveNet.Name == chaincfg.SimNetParams.Name {
+		minPeers = 0
+	}
+
+	err = state.check(minPeers, h.cfg.ReadyLatency)
+	if err != nil {
+		return healthCheck{Error: err.Error(), Info: state}
+	}
+
+	return healthCheck{OK: true, Info: state}
+}
+
+// checkWallet asserts the wallet grpc connection is up and the wallet
+// answers its periodic health checks.
+func (h *Hub) checkWallet() healthCheck {
+	h.grpcMtx.Lock()
+	state := h.gConn.GetState()
+	h.grpcMtx.Unlock()
+	if state != connectivity.Ready {
+		return healthCheck{Error: fmt.Sprintf("wallet grpc connection "+
+			"is %v", state)}
+	}
+
+	if h.walletUnreachable() {
+		return healthCheck{Error: "wallet is failing health checks"}
+	}
+
+	return healthCheck{OK: true}
+}
+
+// checkEndpoints asserts all stratum endpoints are listening for clients.
+func (h *Hub) checkEndpoints() healthCheck {
+	for _, endpoint := range h.endpoints {
+		if atomic.LoadUint32(&endpoint.listening) == 0 {
+			return healthCheck{Error: fmt.Sprintf("%v endpoint on port %v "+
+				"is not listening", endpoint.miner, endpoint.port)}
+		}
+	}
+
+	return healthCheck{OK: true}
+}
+
+// healthChecks runs the health checks of the pool's dependencies, the wallet
+// is only checked when payments are processed.
+func (h *Hub) healthChecks() map[string]healthCheck {
+	checks := map[string]healthCheck{
+		"db":      h.checkDB(),
+		"dcrd":    h.checkDcrd(),
+		"stratum": h.checkEndpoints(),
+	}
+
+	if !h.cfg.SoloPool {
+		checks["wallet"] = h.checkWallet()
+	}
+
+	return checks
+}
+
+// respondWithHealth responds with the provided health checks, the status is
+// ok when all required checks passed.
+func respondWithHealth(w http.ResponseWriter, checks map[string]healthCheck, required ...string) {
+	status, code := "ok", http.StatusOK
+	for _, name := range required {
+		if check, ok := checks[name]; ok && !check.OK {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	RespondWithJSON(w, code, map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// Healthz is the liveness probe of the pool. The pool is live as long as its
+// database is writable, the state of its other dependencies is reported.
+func (h *Hub) Healthz(w http.ResponseWriter, r *http.Request) {
+	respondWithHealth(w, h.healthChecks(), "db")
+}
+
+// Readyz is the readiness probe of the pool. The pool is ready to serve
+// miners when its database is writable, its dcrd and wallet connections are
+// up and its stratum endpoints are listening.
+func (h *Hub) Readyz(w http.ResponseWriter, r *http.Request) {
+	respondWithHealth(w, h.healthChecks(), "db", "dcrd", "wallet",
+		"stratum")
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRespondWithHealth(t *testing.T) {
+	checks := map[string]healthCheck{
+		"db":   {OK: true},
+		"dcrd": {Error: "dcrd rpc connection is down"},
+	}
+
+	// Checks that are not required do not fail the probe.
+	w := httptest.NewRecorder()
+	respondWithHealth(w, checks, "db")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	respondWithHealth(w, checks, "db", "dcrd", "wallet")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %v, got %v",
+			http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestDcrdStateCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		state dcrdState
+		valid bool
+	}{
+		{"ready", dcrdState{Peers: 8, Height: 100, Tip: 101,
+			workLatency: time.Millisecond * 50}, true},
+		{"no peers", dcrdState{Peers: 0, Height: 100, Tip: 100}, false},
+		{"behind tip", dcrdState{Peers: 8, Height: 100, Tip: 102}, false},
+		{"slow templates", dcrdState{Peers: 8, Height: 100, Tip: 100,
+			workLatency: time.Second * 3}, false},
+	}
+
+	for _, test := range tests {
+		err := test.state.check(readyMinPeers, time.Second*2)
+		if (err == nil) != test.valid {
+			t.Fatalf("%v: expected valid %v, got error %v", test.name,
+				test.valid, err)
+		}
+	}
+
+	// Latency is not checked without a threshold.
+	state := dcrdState{Peers: 8, workLatency: time.Minute}
+	err := state.check(readyMinPeers, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -6,11 +6,14 @@ package network
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/binary"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"html/template"
+	"io/fs"
 	"math/big"
+	"net"
 	"net/http"
 	"strconv"
 	"sync"
@@ -21,13 +24,13 @@ import (
 	"github.com/decred/dcrd/chaincfg/chainhash"
 
 	bolt "github.com/coreos/bbolt"
+	"github.com/decred/dcrd/blockchain"
 	"github.com/decred/dcrd/chaincfg"
 	"github.com/decred/dcrd/dcrutil"
 	"github.com/decred/dcrd/rpcclient"
 	"github.com/decred/dcrd/wire"
 	"github.com/decred/dcrwallet/rpc/walletrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
 
 	"github.com/dnldd/dcrpool/database"
 	"github.com/dnldd/dcrpool/dividend"
@@ -76,18 +79,63 @@ var (
 type HubConfig struct {
 	ActiveNet         *chaincfg.Params
 	DcrdRPCCfg        *rpcclient.ConnConfig
+	DcrdBackupCfgs    []*rpcclient.ConnConfig
 	PoolFee           float64
 	MaxTxFeeReserve   dcrutil.Amount
+	MinFeeRate        dcrutil.Amount
+	MaxFeeRate        dcrutil.Amount
 	MaxGenTime        *big.Int
 	WalletRPCCertFile string
 	WalletGRPCHost    string
+	WalletClientCert  string
+	WalletClientKey   string
+	WalletKeyPEM      []byte
 	PaymentMethod     string
 	LastNPeriod       uint32
 	WalletPass        string
+	WalletPassFile    string
+	PayoutAccount     string
 	MinPayment        dcrutil.Amount
 	SoloPool          bool
 	PoolFeeAddrs      []dcrutil.Address
 	BackupPass        string
+	AdminPass         string
+	SMTPHost          string
+	SMTPUser          string
+	SMTPPass          string
+	SMTPFrom          string
+	PublicURL         string
+	AddrChangeDelay   uint32
+	CaseInsensitive   bool
+	WorkerOffline     uint32
+	CaptchaURL        string
+	CaptchaSecret     string
+	ExplorerURL       string
+	CORSOrigins       []string
+	CORSMethods       []string
+	CORSHeaders       []string
+	LangDir           string
+	GUIDir            string
+	HashRatePolicy    dividend.HashRatePolicy
+	LegacyAPISunset   time.Time
+	TrustedProxies    []*net.IPNet
+	StratumListen     string
+	AccountMetrics    uint32
+	SessionLifetime   uint32
+	ReadyLatency      time.Duration
+	NoPreflight       bool
+	MaxClients        uint32
+	MaxIPClients      uint32
+	SubmitRetries     uint32
+	BannedNets        []*net.IPNet
+	ReadConfig        func() (*ReloadableConfig, error)
+	InstantMine       bool
+	HALease           time.Duration
+	Frontends         bool
+	Regions           []*Region
+	Region            string
+	StratumPorts      map[string]uint32
+	Alerts            AlertThresholds
 }
 
 // DifficultyData captures the pool target difficulty and pool difficulty
@@ -102,25 +150,68 @@ type DifficultyData struct {
 type Hub struct {
 	lastWorkHeight    uint32 // update atomically
 	lastPaymentHeight uint32 // update atomically
+	lastWorkBits      uint32 // update atomically
+	lastWorkTime      int64  // update atomically
+	workLatency       int64  // update atomically
+	heartbeat         int64  // update atomically
+	leaseRenewed      int64  // update atomically
+	snapshotAcked     int64  // update atomically
+	payoutsRecorded   int64  // update atomically
 	clients           uint32 // update atomically
+	activeDcrd        int32  // update atomically
+	syncing           int32  // update atomically
+	walletDown        int32  // update atomically
+	blockVersion      int32  // update atomically
+	stakeVersion      uint32 // update atomically
+	noncePrefix       int32  // update atomically
 
 	db           *bolt.DB
 	httpc        *http.Client
 	cfg          *HubConfig
+	cfgMtx       sync.RWMutex
 	limiter      *RateLimiter
+	mailer       *Mailer
 	rpcc         *rpcclient.Client
 	rpccMtx      sync.Mutex
+	backends     []*dcrdBackend
 	gConn        *grpc.ClientConn
 	grpc         walletrpc.WalletServiceClient
 	grpcMtx      sync.Mutex
 	poolDiff     map[string]*DifficultyData
 	poolDiffMtx  sync.RWMutex
 	connCh       chan []byte
+	workCh       chan struct{}
+	txCh         chan *chainhash.Hash
+	mineCh       chan *instantMineRequest
 	discCh       chan []byte
 	ctx          context.Context
 	cancel       context.CancelFunc
 	txFeeReserve dcrutil.Amount
+	payoutAcct   uint32
+	sessionKey   []byte
+	feedSubs     map[*feedSubscriber]struct{}
+	catalogs     map[string]Catalog
+	subsidyCache *blockchain.SubsidyCache
+	subsidySplit *dividend.SubsidySplit
+	subsidyMtx   sync.Mutex
+	gui          fs.FS
+	pages        map[string]*template.Template
+	respCache    map[string]*cachedResponse
+	respCacheMtx sync.Mutex
+	conns        connLimits
+	metrics      *metrics
+	alerts       map[string]*operatorAlert
+	alertsMtx    sync.Mutex
+	reconciled   *reconciliation
+	reconcileMtx sync.Mutex
+	paymentMtx   sync.Mutex
+	feedMtx      sync.Mutex
 	endpoints    []*Endpoint
+	backend      stratumBackend
+	upstream     *upstream
+	frontends    map[string]*frontend
+	workSubs     map[chan *Job]struct{}
+	frontendsMtx sync.Mutex
 	blake256Pad  []byte
 	wg           sync.WaitGroup
 }
@@ -137,7 +228,7 @@ func (h *Hub) GenerateBlake256Pad() {
 
 // GenerateDifficultyData generates difficulty data for all known miners.
 func (h *Hub) GenerateDifficultyData() error {
-	maxGenTime := h.cfg.MaxGenTime
+	maxGenTime := h.maxGenTime()
 	if h.cfg.SoloPool {
 		maxGenTime = soloMaxGenTime
 	}
@@ -165,45 +256,107 @@ func (h *Hub) GenerateDifficultyData() error {
 	return nil
 }
 
+// difficultyLimits returns the lowest and highest pool difficulty of the
+// known miners, preferred account difficulties are kept within these.
+func (h *Hub) difficultyLimits() (*big.Int, *big.Int) {
+	var min, max *big.Int
+	h.poolDiffMtx.RLock()
+	for _, diffData := range h.poolDiff {
+		if min == nil || diffData.difficulty.Cmp(min) < 0 {
+			min = diffData.difficulty
+		}
+		if max == nil || diffData.difficulty.Cmp(max) > 0 {
+			max = diffData.difficulty
+		}
+	}
+	h.poolDiffMtx.RUnlock()
+	return min, max
+}
+
+// accountDifficulty generates difficulty data for the provided preferred
+// account difficulty, adjusted to be within the pool difficulty limits.
+func (h *Hub) accountDifficulty(preferred *big.Int) (*DifficultyData, error) {
+	difficulty := new(big.Int).Set(preferred)
+	min, max := h.difficultyLimits()
+	if min != nil && difficulty.Cmp(min) < 0 {
+		difficulty.Set(min)
+	}
+	if max != nil && difficulty.Cmp(max) > 0 {
+		difficulty.Set(max)
+	}
+
+	target, err := dividend.DifficultyToTarget(h.cfg.ActiveNet, difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DifficultyData{
+		target:     target,
+		difficulty: difficulty,
+	}, nil
+}
+
 // processWork parses work received and dispatches a work notification to all
 // connected pool clients.
 func (h *Hub) processWork(headerE string, target string) {
-	heightD, err := hex.DecodeString(headerE[256:264])
+	start := time.Now()
+	header, err := decodeWorkHeader(headerE)
+	if err != nil {
+		chainLog.Errorf("Failed to decode work: %v", err)
+		return
+	}
+
+	err = validateWorkTemplate(h.cfg.ActiveNet, header)
 	if err != nil {
-		log.Errorf("Failed to decode block height: %v", err)
+		chainLog.Errorf("Invalid work: %v", err)
 		return
 	}
 
-	height := binary.LittleEndian.Uint32(heightD)
+	height := header.Height
 	atomic.StoreUint32(&h.lastWorkHeight, height)
+	atomic.StoreUint32(&h.lastWorkBits, header.Bits)
+	atomic.StoreInt64(&h.lastWorkTime, start.UnixNano())
+	h.trackWorkVersions(header)
 
-	log.Tracef("New work at height (%v) received (%v)", height, headerE)
+	chainLog.Tracef("New work at height (%v) received (%v)", height, headerE)
 
 	// Do not process work data id there are no connected  pool clients.
 	if !h.HasClients() {
 		return
 	}
 
-	blockVersion := headerE[:8]
-	prevBlock := headerE[8:72]
-	genTx1 := headerE[72:288]
-	nBits := headerE[232:240]
-	nTime := headerE[272:280]
-	genTx2 := headerE[352:360]
-
 	// Create a job for the received work.
 	job, err := NewJob(headerE, height)
 	if err != nil {
-		log.Errorf("Failed to create job: %v", err)
+		chainLog.Errorf("Failed to create job: %v", err)
 		return
 	}
 
 	err = job.Create(h.db)
 	if err != nil {
-		log.Errorf("Failed to persist job: %v", err)
+		chainLog.Errorf("Failed to persist job: %v", err)
 		return
 	}
 
+	h.broadcastWork(job)
+	h.notifyFrontends(job)
+
+	h.metrics.recordJob(time.Since(start))
+}
+
+// broadcastWork dispatches a work notification of the provided job to all
+// connected pool clients.
+func (h *Hub) broadcastWork(job *Job) {
+	// The work notification is assembled from the header of the template
+	// as provided by dcrd, its block and stake versions included.
+	headerE := job.Header
+	blockVersion := headerE[:8]
+	prevBlock := headerE[8:72]
+	genTx1 := headerE[72:288]
+	nBits := headerE[232:240]
+	nTime := headerE[272:280]
+	genTx2 := headerE[352:360]
+
 	workNotif := WorkNotification(job.UUID, prevBlock, genTx1, genTx2,
 		blockVersion, nBits, nTime, true)
 
@@ -223,19 +376,57 @@ func (h *Hub) processWork(headerE string, target string) {
 // NewHub initializes a websocket hub.
 func NewHub(ctx context.Context, cancel context.CancelFunc, db *bolt.DB, httpc *http.Client, hcfg *HubConfig, limiter *RateLimiter) (*Hub, error) {
 	h := &Hub{
-		db:       db,
-		httpc:    httpc,
-		limiter:  limiter,
-		cfg:      hcfg,
-		poolDiff: make(map[string]*DifficultyData),
-		clients:  0,
-		connCh:   make(chan []byte),
-		discCh:   make(chan []byte),
-		ctx:      ctx,
-		cancel:   cancel,
+		db:        db,
+		httpc:     httpc,
+		limiter:   limiter,
+		cfg:       hcfg,
+		poolDiff:  make(map[string]*DifficultyData),
+		feedSubs:  make(map[*feedSubscriber]struct{}),
+		respCache: make(map[string]*cachedResponse),
+		metrics:   newMetrics(int(hcfg.AccountMetrics)),
+		alerts:    make(map[string]*operatorAlert),
+		clients:   0,
+		connCh:    make(chan []byte),
+		workCh:    make(chan struct{}, 1),
+		txCh:      make(chan *chainhash.Hash, txQueueSize),
+		mineCh:    make(chan *instantMineRequest, 1),
+		discCh:    make(chan []byte),
+		frontends: make(map[string]*frontend),
+		workSubs:  make(map[chan *Job]struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	h.backend = h
+
+	// The clients of pools serving stratum frontends are assigned the
+	// extranonce1 prefix no frontend is assigned.
+	h.noncePrefix = -1
+	if hcfg.Frontends {
+		h.noncePrefix = 0
 	}
 
+	// Payouts recorded before a restart are only published once replicated
+	// by a snapshot taken after the restart.
+	h.payoutsRecorded = time.Now().UnixNano()
+
 	h.GenerateBlake256Pad()
+	h.mailer = NewMailer(hcfg.SMTPHost, hcfg.SMTPUser, hcfg.SMTPPass,
+		hcfg.SMTPFrom)
+
+	catalogs, err := LoadCatalogs(hcfg.LangDir)
+	if err != nil {
+		return nil, err
+	}
+	h.catalogs = catalogs
+
+	h.subsidyCache = blockchain.NewSubsidyCache(0, hcfg.ActiveNet)
+	h.subsidySplit = dividend.ParamsSubsidySplit(hcfg.ActiveNet)
+
+	h.gui = guiAssets(hcfg.GUIDir)
+	h.pages, err = loadPages(h.gui)
+	if err != nil {
+		return nil, err
+	}
 
 	if !h.cfg.SoloPool {
 		log.Infof("Payment method is %v.", hcfg.PaymentMethod)
@@ -249,7 +440,7 @@ func NewHub(ctx context.Context, cancel context.CancelFunc, db *bolt.DB, httpc *
 		sp = 1
 	}
 
-	err := db.Update(func(tx *bolt.Tx) error {
+	err = db.Update(func(tx *bolt.Tx) error {
 		pbkt := tx.Bucket(database.PoolBkt)
 		vbytes := make([]byte, 4)
 		binary.LittleEndian.PutUint32(vbytes, sp)
@@ -265,7 +456,8 @@ func NewHub(ctx context.Context, cancel context.CancelFunc, db *bolt.DB, httpc *
 		return nil, err
 	}
 
-	// Load the tx fee reserve, last payment height and mined blocks count.
+	// Load the tx fee reserve, last payment height, mined blocks count and
+	// session key.
 	var lastPaymentHeight uint32
 	err = db.Update(func(tx *bolt.Tx) error {
 		pbkt := tx.Bucket(database.PoolBkt)
@@ -304,6 +496,24 @@ func NewHub(ctx context.Context, cancel context.CancelFunc, db *bolt.DB, httpc *
 			atomic.StoreUint32(&h.lastPaymentHeight, lastPaymentHeight)
 		}
 
+		sessionKeyB := pbkt.Get(database.SessionKey)
+		if sessionKeyB == nil {
+			log.Info("Session key not found in db, initializing.")
+			sessionKeyB = make([]byte, 32)
+			_, err := rand.Read(sessionKeyB)
+			if err != nil {
+				return err
+			}
+
+			err = pbkt.Put(database.SessionKey, sessionKeyB)
+			if err != nil {
+				return err
+			}
+		}
+
+		h.sessionKey = make([]byte, len(sessionKeyB))
+		copy(h.sessionKey, sessionKeyB)
+
 		return nil
 	})
 	if err != nil {
@@ -326,7 +536,7 @@ func NewHub(ctx context.Context, cancel context.CancelFunc, db *bolt.DB, httpc *
 	}
 
 	// Setup listeners for all known pool clients.
-	for miner, port := range dividend.MinerPorts {
+	for miner, port := range stratumPorts(h.cfg.StratumPorts) {
 		endpoint, err := NewEndpoint(h, port, miner)
 		if err != nil {
 			log.Error("Failed to create listeners: %v", err)
@@ -336,61 +546,44 @@ func NewHub(ctx context.Context, cancel context.CancelFunc, db *bolt.DB, httpc *
 		h.endpoints = append(h.endpoints, endpoint)
 	}
 
-	// Create handlers for chain notifications being subscribed for.
-	ntfnHandlers := &rpcclient.NotificationHandlers{
-		OnBlockConnected: func(headerB []byte, transactions [][]byte) {
-			h.connCh <- headerB
-		},
-
-		OnBlockDisconnected: func(headerB []byte) {
-			h.discCh <- headerB
-		},
-
-		// TODO: Switch to OnWork notifications when dcrd PR #1410 is merged.
-		// OnWork: func(headerE string, target string) {
-		// 	h.processWork(headerE, target)
-		// },
+	// Establish RPC connections with the dcrd backends and subscribe for
+	// chain notifications. Work is pulled from the primary backend until
+	// failed over, backups are reconnected later if unreachable.
+	cfgs := append([]*rpcclient.ConnConfig{hcfg.DcrdRPCCfg},
+		hcfg.DcrdBackupCfgs...)
+	for _, cfg := range cfgs {
+		h.backends = append(h.backends, &dcrdBackend{
+			cfg:     cfg,
+			backoff: dcrdMinBackoff,
+		})
 	}
 
-	// Establish RPC connection with dcrd.
-	rpcc, err := rpcclient.New(hcfg.DcrdRPCCfg, ntfnHandlers)
-	if err != nil {
-		return nil, fmt.Errorf("rpc error (dcrd): %v", err)
-	}
+	for idx, backend := range h.backends {
+		backend.client, err = h.connectDcrd(int32(idx))
+		if err != nil {
+			if idx == 0 {
+				return nil, err
+			}
 
-	h.rpcc = rpcc
+			log.Warnf("Failed to connect to backup dcrd: %v", err)
+		}
+	}
 
-	// Subscribe for chain notifications.
+	h.rpcc = h.backends[0].client
 
-	// TODO: Subscribe for OnWork notifications when dcrd PR #1410 is merged.
-	// if err := h.rpcc.NotifyWork(); err != nil {
-	// 	h.rpccMtx.Lock()
-	// 	h.rpcc.Shutdown()
-	// 	h.rpccMtx.Unlock()
-	// 	return nil, fmt.Errorf("notify work rpc error (dcrd): %v", err)
-	// }
+	log.Infof("RPC connection established with dcrd.")
 
-	if err := h.rpcc.NotifyBlocks(); err != nil {
-		h.rpccMtx.Lock()
-		h.rpcc.Shutdown()
-		h.rpccMtx.Unlock()
-		return nil, fmt.Errorf("notify blocks rpc error (dcrd): %v", err)
+	_, synced, err := h.dcrdChainState(h.rpcc, time.Now())
+	if err != nil {
+		return nil, err
 	}
-
-	log.Infof("RPC connection established with dcrd.")
+	h.setDcrdSyncing(h.backends[0].cfg.Host, !synced)
 
 	// Establish GRPC connection with the wallet if not in solo pool mode.
 	if !h.cfg.SoloPool {
-		creds, err := credentials.NewClientTLSFromFile(hcfg.WalletRPCCertFile,
-			"localhost")
+		h.gConn, err = h.dialWallet()
 		if err != nil {
-			return nil, fmt.Errorf("grpc tls error (dcrwallet): %v", err)
-		}
-
-		h.gConn, err = grpc.Dial(hcfg.WalletGRPCHost,
-			grpc.WithTransportCredentials(creds))
-		if err != nil {
-			return nil, fmt.Errorf("grpc dial error (dcrwallet): %v", err)
+			return nil, err
 		}
 
 		if h.gConn == nil {
@@ -411,33 +604,78 @@ func NewHub(ctx context.Context, cancel context.CancelFunc, db *bolt.DB, httpc *
 		}
 
 		log.Infof("GPRC connection established with wallet.")
+
+		h.payoutAcct, err = h.fetchPayoutAccount(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+
+		log.Infof("Paying out from wallet account %v (%v).",
+			hcfg.PayoutAccount, h.payoutAcct)
+	}
+
+	// Fail fast when a dependency of the pool is unusable, before miners
+	// are accepted.
+	if !h.cfg.NoPreflight {
+		err = h.preflight()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return h, nil
 }
 
-// HasClients asserts the mining pool has clients.
+// HasClients asserts the mining pool has clients, or stratum frontends
+// streaming its work.
 func (h *Hub) HasClients() bool {
-	return atomic.LoadUint32(&h.clients) > 0
+	return atomic.LoadUint32(&h.clients) > 0 || h.hasFrontends()
 }
 
-// SubmitWork sends solved block data to the consensus daemon for evaluation.
+// SubmitWork sends solved block data to the consensus daemon for evaluation,
+// accepted blocks are relayed to the other dcrd backends. Submissions failing
+// with an rpc error are retried with backoff up to the configured number of
+// retries against the active backend, blocks rejected by dcrd are not.
 func (h *Hub) SubmitWork(data *string) (bool, error) {
-	h.rpccMtx.Lock()
-	status, err := h.rpcc.GetWorkSubmit(*data)
-	h.rpccMtx.Unlock()
+	status, attempts, err := retrySubmit(h.cfg.SubmitRetries,
+		func() (bool, error) {
+			h.rpccMtx.Lock()
+			defer h.rpccMtx.Unlock()
+			return h.rpcc.GetWorkSubmit(*data)
+		},
+		func(backoff time.Duration) bool {
+			select {
+			case <-h.ctx.Done():
+				return false
+			case <-time.After(backoff):
+				return true
+			}
+		})
+	if attempts > 1 || err != nil {
+		h.recordSubmission(*data, status, attempts, err)
+	}
 	if err != nil {
 		return false, err
 	}
 
-	return status, err
+	if status {
+		go h.relayBlock(*data)
+	}
+
+	return status, nil
 }
 
 // GetWork fetches available work from the consensus daemon.
 func (h *Hub) GetWork() (string, string, error) {
+	if h.dcrdSyncing() {
+		return "", "", errDcrdSyncing
+	}
+
+	start := time.Now()
 	h.rpccMtx.Lock()
 	work, err := h.rpcc.GetWork()
 	h.rpccMtx.Unlock()
+	atomic.StoreInt64(&h.workLatency, int64(time.Since(start)))
 	if err != nil {
 		return "", "", err
 	}
@@ -445,8 +683,20 @@ func (h *Hub) GetWork() (string, string, error) {
 	return work.Data, work.Target, err
 }
 
-// PublishTransaction creates a transaction paying pool accounts for work done.
-func (h *Hub) PublishTransaction(payouts map[dcrutil.Address]dcrutil.Amount, targetAmt dcrutil.Amount) error {
+// PublishTransaction creates a transaction paying pool accounts for work done
+// at the provided fee rate per kB and returns its hash.
+func (h *Hub) PublishTransaction(payouts map[dcrutil.Address]dcrutil.Amount, targetAmt dcrutil.Amount, feeRate dcrutil.Amount) (string, error) {
+	signedTx, err := h.signTransaction(payouts, feeRate)
+	if err != nil {
+		return "", err
+	}
+
+	return h.publishSignedTransaction(signedTx)
+}
+
+// signTransaction constructs and signs a transaction paying the provided
+// payouts at the provided fee rate from the payout account.
+func (h *Hub) signTransaction(payouts map[dcrutil.Address]dcrutil.Amount, feeRate dcrutil.Amount) ([]byte, error) {
 	outs := make([]*walletrpc.ConstructTransactionRequest_Output, 0, len(payouts))
 	for addr, amt := range payouts {
 		out := &walletrpc.ConstructTransactionRequest_Output{
@@ -459,171 +709,182 @@ func (h *Hub) PublishTransaction(payouts map[dcrutil.Address]dcrutil.Amount, tar
 		outs = append(outs, out)
 	}
 
-	// Construct the transaction.
+	// Read the wallet passphrase for the payout, it only unlocks the wallet
+	// for signing the transaction.
+	passphrase, err := h.walletPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(passphrase)
+
+	changeAddr, err := h.payoutChangeAddress(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	// Construct the transaction. All confirmed outputs of the payout
+	// account are spent, consolidating its funds into a single change
+	// output returned to the account on every payout rather than
+	// fragmenting them.
 	constructTxReq := &walletrpc.ConstructTransactionRequest{
-		SourceAccount:            0,
+		SourceAccount:            h.payoutAcct,
 		RequiredConfirmations:    1,
+		FeePerKb:                 int32(feeRate),
 		OutputSelectionAlgorithm: walletrpc.ConstructTransactionRequest_ALL,
 		NonChangeOutputs:         outs,
+		ChangeDestination: &walletrpc.ConstructTransactionRequest_OutputDestination{
+			Address: changeAddr,
+		},
 	}
 
 	h.grpcMtx.Lock()
 	constructTxResp, err := h.grpc.ConstructTransaction(context.TODO(), constructTxReq)
 	h.grpcMtx.Unlock()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Sign the transaction.
 	signTxReq := &walletrpc.SignTransactionRequest{
 		SerializedTransaction: constructTxResp.UnsignedTransaction,
-		Passphrase:            []byte(h.cfg.WalletPass),
+		Passphrase:            passphrase,
 	}
 
 	h.grpcMtx.Lock()
 	signedTxResp, err := h.grpc.SignTransaction(context.TODO(), signTxReq)
 	h.grpcMtx.Unlock()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Publish the transaction.
+	return signedTxResp.Transaction, nil
+}
+
+// publishSignedTransaction publishes the provided signed transaction through
+// the wallet, returning its hash.
+func (h *Hub) publishSignedTransaction(signedTx []byte) (string, error) {
 	pubTxReq := &walletrpc.PublishTransactionRequest{
-		SignedTransaction: signedTxResp.Transaction,
+		SignedTransaction: signedTx,
 	}
 
 	h.grpcMtx.Lock()
 	pubTxResp, err := h.grpc.PublishTransaction(context.TODO(), pubTxReq)
 	h.grpcMtx.Unlock()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	log.Infof("Published tx hash is: %x", pubTxResp.TransactionHash)
+	txHash, err := chainhash.NewHash(pubTxResp.TransactionHash)
+	if err != nil {
+		return "", err
+	}
 
-	return nil
+	paymentLog.Infof("Published tx hash is: %v", txHash)
+
+	return txHash.String(), nil
 }
 
-// handleGetWork periodically fetches available work from the consensus daemon.
+// signalWork signals the work handler to fetch work, for when the chain tip
+// changes or votes arrive.
+func (h *Hub) signalWork() {
+	select {
+	case h.workCh <- struct{}{}:
+	default:
+	}
+}
+
+// handleGetWork fetches available work from the consensus daemon when the
+// chain tip changes, as notified by block connected and disconnected
+// notifications, when votes arrive and periodically for updated timestamps.
 // It must be run as a goroutine.
 func (h *Hub) handleGetWork(ctx context.Context) {
 	var currHeaderE string
+	var currHeader *wire.BlockHeader
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 	h.wg.Add(1)
-	log.Trace("Started work handler.")
+	chainLog.Trace("Started work handler.")
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Trace("Work handler done.")
+			chainLog.Trace("Work handler done.")
 			h.wg.Done()
 			return
 		case <-ticker.C:
-			headerE, target, err := h.GetWork()
-			if err != nil {
-				log.Errorf("Failed to fetch work: %v", err)
-				continue
-			}
-
-			// Process incoming work if there is no current work.
-			if currHeaderE == "" {
-				log.Tracef("updated work based on no current work being" +
-					" available")
-				currHeaderE = headerE
-				h.processWork(currHeaderE, target)
-				continue
-			}
-
-			// Process incoming work if it has a higher height than the
-			// current work.
-			currHeaderHeightD, err := hex.DecodeString(currHeaderE[256:264])
-			if err != nil {
-				log.Errorf("Failed to decode current header block height: %v",
-					err)
-				h.cancel()
-				continue
-			}
-
-			currHeaderHeight := binary.LittleEndian.Uint32(currHeaderHeightD)
-
-			newHeaderHeightD, err := hex.DecodeString(headerE[256:264])
-			if err != nil {
-				log.Errorf("Failed to decode new header block height: %v",
-					err)
-				h.cancel()
-				continue
-			}
+		case <-h.workCh:
+			chainLog.Tracef("fetching work on chain update")
+		}
 
-			newHeaderHeight := binary.LittleEndian.Uint32(newHeaderHeightD)
-			if newHeaderHeight > currHeaderHeight {
-				log.Tracef("updated work based on new work having a higher"+
-					" height than the current work: %v > %v", newHeaderHeight,
-					currHeaderHeight)
-				currHeaderE = headerE
-				h.processWork(currHeaderE, target)
-				continue
-			}
+		atomic.StoreInt64(&h.heartbeat, time.Now().UnixNano())
 
-			// Process incoming work if it has more votes than the current work.
-			currHeaderVotersD, err := hex.DecodeString(currHeaderE[216:220])
-			if err != nil {
-				log.Errorf("Failed to decode current header block voters: %v",
-					err)
-				h.cancel()
-				continue
-			}
+		// Work is not generated from the stale templates of a syncing
+		// dcrd.
+		if h.dcrdSyncing() {
+			continue
+		}
 
-			currHeaderVoters := binary.LittleEndian.Uint16(currHeaderVotersD)
+		headerE, target, err := h.GetWork()
+		if err != nil {
+			chainLog.Errorf("Failed to fetch work: %v", err)
+			continue
+		}
 
-			newHeaderVotersD, err := hex.DecodeString(headerE[216:220])
-			if err != nil {
-				log.Errorf("Failed to decode new header blockvoters: %v",
-					err)
-				h.cancel()
-				continue
-			}
+		header, err := decodeWorkHeader(headerE)
+		if err != nil {
+			chainLog.Errorf("Failed to decode work: %v", err)
+			continue
+		}
 
-			newHeaderVoters :=
-				binary.LittleEndian.Uint16(newHeaderVotersD)
-			if newHeaderVoters > currHeaderVoters {
-				log.Tracef("updated work based on new work having more voters"+
-					" than the current work, %v > %v", newHeaderVoters,
-					currHeaderVoters)
-				currHeaderE = headerE
-				h.processWork(currHeaderE, target)
-				continue
-			}
+		// Process incoming work if there is no current work.
+		if currHeader == nil {
+			chainLog.Tracef("updated work based on no current work being" +
+				" available")
+			currHeaderE, currHeader = headerE, header
+			h.processWork(currHeaderE, target)
+			continue
+		}
 
-			// Process incoming work if it is at least 30 seconds older than
-			// the current work.
-			currNTimeD, err := hex.DecodeString(currHeaderE[272:280])
-			if err != nil {
-				log.Errorf("Failed to decode current header block time: %v",
-					err)
-				return
-			}
+		// Process incoming work if it builds on a different block than the
+		// current work, the chain tip changed.
+		if header.PrevBlock != currHeader.PrevBlock {
+			chainLog.Tracef("updated work based on new work building on a " +
+				"different block than the current work")
+			currHeaderE, currHeader = headerE, header
+			h.processWork(currHeaderE, target)
+			continue
+		}
 
-			currNTime :=
-				time.Unix(int64(binary.LittleEndian.Uint32(currNTimeD)), 0)
+		// Process incoming work if it has a higher height than the
+		// current work.
+		if header.Height > currHeader.Height {
+			chainLog.Tracef("updated work based on new work having a higher"+
+				" height than the current work: %v > %v", header.Height,
+				currHeader.Height)
+			currHeaderE, currHeader = headerE, header
+			h.processWork(currHeaderE, target)
+			continue
+		}
 
-			newNTimeD, err := hex.DecodeString(headerE[272:280])
-			if err != nil {
-				log.Errorf("Failed to decode new header block time: %v",
-					err)
-				return
-			}
+		// Process incoming work if it has more votes than the current work.
+		if header.Voters > currHeader.Voters {
+			chainLog.Tracef("updated work based on new work having more voters"+
+				" than the current work, %v > %v", header.Voters,
+				currHeader.Voters)
+			currHeaderE, currHeader = headerE, header
+			h.processWork(currHeaderE, target)
+			continue
+		}
 
-			newNTime :=
-				time.Unix(int64(binary.LittleEndian.Uint32(newNTimeD)), 0)
-			timeDiff := newNTime.Sub(currNTime)
-			if timeDiff >= time.Second*30 {
-				log.Tracef("updated work based on new work being 30 or more"+
-					" seconds (%v) younger than the current work", timeDiff)
-				currHeaderE = headerE
-				h.processWork(currHeaderE, target)
-				continue
-			}
+		// Process incoming work if it is at least 30 seconds older than
+		// the current work.
+		timeDiff := header.Timestamp.Sub(currHeader.Timestamp)
+		if timeDiff >= time.Second*30 {
+			chainLog.Tracef("updated work based on new work being 30 or more"+
+				" seconds (%v) younger than the current work", timeDiff)
+			currHeaderE, currHeader = headerE, header
+			h.processWork(currHeaderE, target)
+			continue
 		}
 	}
 }
@@ -632,173 +893,190 @@ func (h *Hub) handleGetWork(ctx context.Context) {
 // from the consensus daemon.
 func (h *Hub) handleChainUpdates(ctx context.Context) {
 	h.wg.Add(1)
-	log.Trace("Started chain updates handler.")
+	chainLog.Trace("Started chain updates handler.")
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Trace("Chain updates handler done.")
+			chainLog.Trace("Chain updates handler done.")
 			h.wg.Done()
 			return
 
 		case headerB := <-h.connCh:
+			atomic.AddInt64(&h.metrics.blocksPending, -1)
 			var header wire.BlockHeader
 			err := header.FromBytes(headerB)
 			if err != nil {
-				log.Errorf("Failed to create header from bytes: %v", err)
+				chainLog.Errorf("Failed to create header from bytes: %v", err)
 				h.cancel()
 				continue
 			}
-			log.Tracef("Block connected at height %v", header.Height)
+			chainLog.Tracef("Block connected at height %v", header.Height)
 
+			// Fetch work building on the connected block.
+			h.signalWork()
+
+			// Prune jobs and accepted work below the estimated reorg limit.
 			if header.Height > MaxReorgLimit {
 				pruneLimit := header.Height - MaxReorgLimit
 				err := PruneJobs(h.db, pruneLimit)
 				if err != nil {
-					log.Errorf("Failed to prune jobs to height %d: %v",
+					chainLog.Errorf("Failed to prune jobs to height %d: %v",
 						pruneLimit, err)
 					h.cancel()
 					continue
 				}
 
-				log.Tracef("Pruned jobs below height: %v", pruneLimit)
-			}
-
-			blockHash := header.BlockHash()
-			id := AcceptedWorkID(blockHash.String(), header.Height)
-			work, err := FetchAcceptedWork(h.db, id)
-			if err != nil {
-				log.Errorf("Failed to fetch accepted work: %v", err)
-				continue
-			}
-
-			prevWork, err := work.FilterParentAcceptedWork(h.db)
-			if err != nil {
-				log.Errorf("Failed to filter parent accepted work: %v", err)
-				continue
-			}
+				chainLog.Tracef("Pruned jobs below height: %v", pruneLimit)
 
-			// Prune accepted work that is below the estimated reorg limit.
-			if header.Height > MaxReorgLimit {
-				pruneLimit := header.Height - MaxReorgLimit
 				err = PruneAcceptedWork(h.db, pruneLimit)
 				if err != nil {
-					log.Errorf("Failed to prune accepted work below height (%v)"+
+					chainLog.Errorf("Failed to prune accepted work below height (%v)"+
 						": %v", pruneLimit, err)
 					h.cancel()
 					continue
 				}
 
-				log.Tracef("Pruned accepted work below height: %v", pruneLimit)
-			}
+				chainLog.Tracef("Pruned accepted work below height: %v", pruneLimit)
 
-			if prevWork == nil {
-				log.Tracef("No mined work found")
-				continue
+				// Voided payments can no longer be restored once their block
+				// is past the reorg limit.
+				if !h.cfg.SoloPool {
+					err = dividend.PruneVoidedPayments(h.db, pruneLimit)
+					if err != nil {
+						chainLog.Errorf("Failed to prune voided payments below "+
+							"height (%v): %v", pruneLimit, err)
+						h.cancel()
+						continue
+					}
+				}
 			}
 
-			log.Tracef("Found mined parent %v for work %v",
-				prevWork.BlockHash, header.BlockHash().String())
+			// Prune expired account tokens.
+			err = dividend.PruneTokens(h.db)
+			if err != nil {
+				chainLog.Errorf("Failed to prune expired tokens: %v", err)
+			}
 
-			// Update accepted work as confirmed mined.
-			prevWork.Confirmed = true
-			err = prevWork.Update(h.db)
+			// Apply the payout address changes which have taken effect.
+			_, err = dividend.ApplyAddressChanges(h.db, time.Now())
 			if err != nil {
-				log.Errorf("Failed to confirm accepted work: %v", err)
-				h.cancel()
-				continue
+				chainLog.Errorf("Failed to apply address changes: %v", err)
 			}
 
-			// Only process shares and payments when not mining in solo
-			// pool mode.
+			// Update the confirmations of unconfirmed payouts and process
+			// payments maturing at the connected block.
 			if !h.cfg.SoloPool {
-				h.rpccMtx.Lock()
-				block, err := h.rpcc.GetBlock(&blockHash)
-				h.rpccMtx.Unlock()
+				payouts, err := dividend.FetchUnconfirmedPayouts(h.db)
 				if err != nil {
-					log.Errorf("Failed to fetch block: %v", err)
-					h.cancel()
-					continue
-				}
-
-				coinbase :=
-					dcrutil.Amount(block.Transactions[0].TxOut[2].Value)
-
-				log.Tracef("Accepted work (%v) at height %v has coinbase"+
-					" of %v", header.BlockHash(), header.Height, coinbase)
-
-				// Pay dividends per the configured payment scheme and process
-				// mature payments.
-				switch h.cfg.PaymentMethod {
-				case dividend.PPS:
-					err := dividend.PayPerShare(h.db, coinbase, h.cfg.PoolFee,
-						header.Height, h.cfg.ActiveNet.CoinbaseMaturity)
-					if err != nil {
-						log.Error("Failed to process generate PPS shares: %v", err)
-						h.cancel()
-						continue
-					}
-
-				case dividend.PPLNS:
-					err := dividend.PayPerLastNShares(h.db, coinbase,
-						h.cfg.PoolFee, header.Height,
-						h.cfg.ActiveNet.CoinbaseMaturity, h.cfg.LastNPeriod)
-					if err != nil {
-						log.Errorf("Failed to generate PPLNS shares: %v", err)
-						h.cancel()
-						continue
-					}
+					chainLog.Errorf("Failed to fetch unconfirmed payouts: %v", err)
 				}
+				h.trackPayouts(payouts)
 
-				// Process mature payments.
 				err = h.ProcessPayments(header.Height)
 				if err != nil {
-					log.Errorf("Failed to process payments: %v", err)
+					chainLog.Errorf("Failed to process payments: %v", err)
 				}
 			}
 
+			// Credit the earnings of the block if found by the pool, confirm
+			// its parent if found by the pool and track the confirmations of
+			// found blocks until their rewards mature.
+			h.creditConnectedWork(&header)
+			h.confirmParentWork(&header)
+			h.trackMinedWork(header.Height)
+			h.updateSubsidySplit(&header)
+
+			err = h.storeProcessedHeight(header.Height)
+			if err != nil {
+				chainLog.Errorf("Failed to persist processed height: %v", err)
+			}
+
 		case headerB := <-h.discCh:
+			atomic.AddInt64(&h.metrics.blocksPending, -1)
 			var header wire.BlockHeader
 			err := header.FromBytes(headerB)
 			if err != nil {
-				log.Errorf("Failed to create header from bytes: %v", err)
+				chainLog.Errorf("Failed to create header from bytes: %v", err)
 				h.cancel()
 				continue
 			}
-			log.Tracef("Block disconnected at height %v", header.Height)
+			chainLog.Tracef("Block disconnected at height %v", header.Height)
+
+			// Fetch work building on the new chain tip.
+			h.signalWork()
+
+			// Update the confirmations of payouts possibly mined in the
+			// disconnected block, reverting them if so.
+			if !h.cfg.SoloPool {
+				payouts, err := dividend.FilterPayouts(h.db,
+					func(payout *dividend.Payout) bool {
+						return !payout.Confirmed() ||
+							payout.Height+MaxReorgLimit >= header.Height
+					})
+				if err != nil {
+					chainLog.Errorf("Failed to fetch recent payouts: %v", err)
+				}
+				h.trackPayouts(payouts)
+			}
 
 			// Delete mined work if it is disconnected from the chain.
 			id := AcceptedWorkID(header.BlockHash().String(), header.Height)
 			work, err := FetchAcceptedWork(h.db, id)
-			if err != nil {
-				log.Errorf("Failed to fetch mined work: %v", err)
+			if err != nil &&
+				err.Error() != database.ErrValueNotFound(id).Error() {
+				chainLog.Errorf("Failed to fetch mined work: %v", err)
 				continue
 			}
 
-			err = work.Delete(h.db)
-			if err != nil {
-				log.Errorf("Failed to delete mined work: %v", err)
-				h.cancel()
-				continue
+			if work != nil {
+				err = work.Delete(h.db)
+				if err != nil {
+					chainLog.Errorf("Failed to delete mined work: %v", err)
+					h.cancel()
+					continue
+				}
+
+				h.logPoolEvent(dividend.PoolEventReorg,
+					fmt.Sprintf("block %v at height %v disconnected by a "+
+						"reorg", work.BlockHash, work.Height),
+					map[string]interface{}{
+						"height":    work.Height,
+						"blockhash": work.BlockHash,
+					}, "")
 			}
 
+			// Revert the confirmation, or disapproval, of the parent work
+			// voted on by the disconnected block.
+			h.revertParentWork(&header)
+
 			// Only remove invalidated payments if not mining in solo pool mode.
 			if !h.cfg.SoloPool {
-				// If the disconnected block is an accepted work from the pool,
-				// delete all associated payments.
-				payments, err := dividend.FetchPendingPaymentsAtHeight(h.db,
-					header.Height)
+				// Void the payments generated when the disconnected block
+				// connected. Payments already paid can not be voided.
+				payments, err := dividend.FilterPayments(h.db,
+					func(payment *dividend.Payment) bool {
+						return payment.Height == header.Height
+					})
 				if err != nil {
-					log.Errorf("Failed to fetch pending payments"+
+					chainLog.Errorf("Failed to fetch payments"+
 						" at height (%v): %v", header.Height, err)
 					h.cancel()
 					continue
 				}
 
 				for _, pmt := range payments {
+					if pmt.PaidOnHeight != 0 {
+						chainLog.Warnf("Payment of %v to account %v at height %v "+
+							"invalidated by a reorg was already paid at "+
+							"height %v", pmt.Amount, pmt.Account, pmt.Height,
+							pmt.PaidOnHeight)
+						continue
+					}
+
 					err = pmt.Delete(h.db)
 					if err != nil {
-						log.Errorf("Failed to delete payment", err)
+						chainLog.Errorf("Failed to delete payment: %v", err)
 						h.cancel()
 						break
 					}
@@ -815,9 +1093,13 @@ func (h *Hub) shutdown() {
 		h.gConn.Close()
 	}
 
-	// Shutdown the daemon rpc connection.
+	// Shutdown the daemon rpc connections.
 	h.rpccMtx.Lock()
-	h.rpcc.Shutdown()
+	for _, backend := range h.backends {
+		if backend.client != nil {
+			backend.client.Shutdown()
+		}
+	}
 	h.rpccMtx.Unlock()
 
 	// Close the connection and disconnection channels.
@@ -829,6 +1111,16 @@ func (h *Hub) shutdown() {
 
 // run handles the process lifecycles of the pool hub.
 func (h *Hub) Run(ctx context.Context) {
+	// Stratum frontends only serve clients, the backend does the rest.
+	if h.upstream != nil {
+		h.runFrontend()
+		return
+	}
+
+	// Reconcile the blocks connected while the pool was down before
+	// processing chain updates.
+	h.catchUp()
+
 	h.wg.Add(len(h.endpoints))
 	for _, e := range h.endpoints {
 		go e.listen()
@@ -837,11 +1129,80 @@ func (h *Hub) Run(ctx context.Context) {
 
 	go h.handleGetWork(h.ctx)
 	go h.handleChainUpdates(h.ctx)
+	go h.handleHashRateSamples(h.ctx)
+	go h.handleBackendEvents(h.ctx)
+	go h.handleDcrdBackends(h.ctx)
+	go h.handleVoteNotifications(h.ctx)
+
+	if !h.cfg.SoloPool {
+		go h.handleWallet(h.ctx)
+		go h.handleReconciliation(h.ctx)
+	}
+
+	if !h.cfg.SoloPool && h.cfg.WorkerOffline > 0 {
+		go h.handleWorkerAlerts(h.ctx)
+	}
+
+	if h.cfg.Alerts.Enabled() {
+		go h.handleOperatorAlerts(h.ctx)
+	}
+
+	if h.cfg.InstantMine {
+		go h.handleInstantMining(h.ctx)
+	}
 	h.wg.Wait()
 
 	h.shutdown()
 }
 
+// trackPayouts updates the confirmations of the provided payout
+// transactions. Confirmations reverted by a reorg are logged.
+func (h *Hub) trackPayouts(payouts []*dividend.Payout) {
+	for _, payout := range payouts {
+		if payout.Unpublished {
+			continue
+		}
+
+		txHash, err := chainhash.NewHashFromStr(payout.TxHash)
+		if err != nil {
+			paymentLog.Errorf("Invalid payout tx hash %v: %v", payout.TxHash, err)
+			continue
+		}
+
+		h.rpccMtx.Lock()
+		tx, err := h.rpcc.GetRawTransactionVerbose(txHash)
+		h.rpccMtx.Unlock()
+		if err != nil {
+			paymentLog.Errorf("Failed to fetch payout tx %v: %v", payout.TxHash, err)
+			continue
+		}
+
+		if tx.Confirmations == payout.Confirmations {
+			continue
+		}
+
+		confirmed := payout.Confirmed()
+		payout.Confirmations = tx.Confirmations
+		err = payout.Update(h.db)
+		if err != nil {
+			paymentLog.Errorf("Failed to update payout %v: %v", payout.TxHash, err)
+			continue
+		}
+
+		if confirmed && !payout.Confirmed() {
+			paymentLog.Warnf("Payout %v confirmations reverted to %v by a reorg",
+				payout.TxHash, payout.Confirmations)
+			continue
+		}
+
+		if !confirmed && payout.Confirmed() {
+			h.publish("", EventPayoutConfirmed, payout)
+			h.logPoolEvent(dividend.PoolEventPayoutConfirmed,
+				fmt.Sprintf("payout %v confirmed", payout.TxHash), payout, "")
+		}
+	}
+}
+
 // ProcessPayments fetches all eligible payments and publishes a
 // transaction to the network paying dividends to participating accounts.
 func (h *Hub) ProcessPayments(height uint32) error {
@@ -852,6 +1213,29 @@ func (h *Hub) ProcessPayments(height uint32) error {
 	// maximize the transaction fee usage by processing mature payments
 	// after the transaction fees reserve has matured and ready for another
 	// transaction.
+	h.paymentMtx.Lock()
+	defer h.paymentMtx.Unlock()
+
+	// Payments are deferred while the wallet is unreachable, eligible
+	// payments are processed once it is reachable again.
+	if h.walletUnreachable() {
+		return errWalletUnreachable
+	}
+
+	// Recorded payouts are published first, including those of a primary
+	// the pool took over from. New payouts are held while any remains
+	// unpublished since their transactions spend the same outputs.
+	h.publishUnpublishedPayouts()
+	unpublished, err := dividend.FetchUnpublishedPayouts(h.db)
+	if err != nil {
+		return err
+	}
+	if len(unpublished) > 0 {
+		paymentLog.Debugf("Holding payments, %v payouts are unpublished",
+			len(unpublished))
+		return nil
+	}
+
 	lastPaymentHeight := atomic.LoadUint32(&h.lastPaymentHeight)
 	if lastPaymentHeight != 0 && (height-lastPaymentHeight) < 3 {
 		return nil
@@ -859,17 +1243,17 @@ func (h *Hub) ProcessPayments(height uint32) error {
 
 	// Fetch all eligible payments.
 	eligiblePmts, err := dividend.FetchEligiblePaymentBundles(h.db, height,
-		h.cfg.MinPayment)
+		h.minPayment())
 	if err != nil {
 		return err
 	}
 
 	if len(eligiblePmts) == 0 {
-		log.Infof("no eligible payments to process")
+		paymentLog.Tracef("no eligible payments to process")
 		return nil
 	}
 
-	log.Tracef("eligible payments are: %v", spew.Sdump(eligiblePmts))
+	paymentLog.Tracef("eligible payments are: %v", spew.Sdump(eligiblePmts))
 
 	// Generate the payment details from the eligible payments fetched.
 	details, targetAmt, err := dividend.GeneratePaymentDetails(h.db,
@@ -878,7 +1262,7 @@ func (h *Hub) ProcessPayments(height uint32) error {
 		return err
 	}
 
-	log.Tracef("mature rewards at height (%v) is: %v", height, targetAmt)
+	paymentLog.Tracef("mature rewards at height (%v) is: %v", height, targetAmt)
 
 	// Create address-amount kv pairs for the transaction, using the payment
 	// details.
@@ -889,22 +1273,61 @@ func (h *Hub) ProcessPayments(height uint32) error {
 			return err
 		}
 
+		if !addr.IsForNet(h.cfg.ActiveNet) {
+			return fmt.Errorf("payment address (%v) is not for the active "+
+				"network (%v)", addrStr, h.cfg.ActiveNet.Name)
+		}
+
 		pmts[addr] = amt
 	}
 
-	// Publish the transaction.
-	err = h.PublishTransaction(pmts, *targetAmt)
-	if err != nil {
-		return err
+	// Publish the transaction at the fee rate estimated by dcrd. High
+	// availability primaries record the signed transaction instead, it is
+	// published once the standby replicated the payout.
+	feeRate := h.estimateFeeRate()
+	var payout *dividend.Payout
+	if h.cfg.HALease > 0 {
+		payout, err = h.signPayout(pmts, height, len(eligiblePmts),
+			*targetAmt, feeRate)
+		if err != nil {
+			return err
+		}
+	} else {
+		txHash, err := h.PublishTransaction(pmts, *targetAmt, feeRate)
+		if err != nil {
+			return err
+		}
+
+		// Record the payout to track the confirmations of its transaction.
+		payout = dividend.NewPayout(txHash, height, len(eligiblePmts),
+			*targetAmt, feeRate)
+		err = payout.Create(h.db)
+		if err != nil {
+			paymentLog.Errorf("Failed to record payout %v: %v", txHash, err)
+		}
+
+		h.payoutPublished(payout)
 	}
 
-	// Update all payments published by the tx as paid and archive them.
+	// Update all payments paid by the tx as paid and archive them.
 	for _, bundle := range eligiblePmts {
-		bundle.UpdateAsPaid(h.db, height)
+		bundle.UpdateAsPaid(h.db, height, feeRate)
 		err = bundle.ArchivePayments(h.db)
 		if err != nil {
 			return err
 		}
+
+		if bundle.Account != dividend.PoolFeesK {
+			h.publish(bundle.Account, EventPaymentSent,
+				map[string]interface{}{
+					"height": height,
+					"amount": bundle.Total().ToCoin(),
+				})
+			go h.notify(bundle.Account, dividend.AlertPaymentSent,
+				"Payment sent",
+				fmt.Sprintf("A payment of %v was sent to your payout address.",
+					bundle.Total()))
+		}
 	}
 
 	// Update the last payment paid on time, the last payment height and the
@@ -936,7 +1359,16 @@ func (h *Hub) ProcessPayments(height uint32) error {
 		binary.LittleEndian.PutUint32(tbytes, uint32(h.txFeeReserve))
 		return pbkt.Put(database.TxFeeReserve, tbytes)
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	if payout.Unpublished {
+		atomic.StoreInt64(&h.payoutsRecorded, time.Now().UnixNano())
+		h.publishUnpublishedPayouts()
+	}
+
+	return nil
 }
 
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -953,19 +1385,8 @@ func RespondWithError(w http.ResponseWriter, code int, message string) {
 
 // FetchHash handles requests on the hash rate of the pool.
 func (h *Hub) FetchHash(w http.ResponseWriter, r *http.Request) {
-	// Iterate through all connected miners and add hash rates.
-	total := new(big.Rat).SetInt64(0)
-	for _, endpoint := range h.endpoints {
-		endpoint.clientsMtx.Lock()
-		for _, client := range endpoint.clients {
-			client.hashRateMtx.RLock()
-			total = total.Add(total, client.hashRate)
-			client.hashRateMtx.RUnlock()
-		}
-		endpoint.clientsMtx.Unlock()
-	}
-
-	hash := fmt.Sprintf("%v TH/s", total.FloatString(12))
+	hash := fmt.Sprintf("%v %v", h.hashRate("").FloatString(12),
+		hashRateUnit)
 	RespondWithJSON(w, http.StatusOK, map[string]string{"hash": hash})
 }
 
@@ -973,7 +1394,7 @@ func (h *Hub) FetchHash(w http.ResponseWriter, r *http.Request) {
 func (h *Hub) FetchConnections(w http.ResponseWriter, r *http.Request) {
 	connInfo := make(map[string]uint32)
 	total := 0
-	for _, endpoint := range h.endpoints {
+	for _, endpoint := range h.allEndpoints() {
 		endpoint.clientsMtx.Lock()
 		for _, client := range endpoint.clients {
 			connInfo[client.endpoint.miner]++
@@ -1071,15 +1492,23 @@ func (h *Hub) FetchMinedWorkByAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id := dividend.AccountID(params["name"], params["address"])
-	work, err := ListMinedWorkByAccount(h.db, *id)
+	account, err := dividend.FetchAccountByName(h.db, params["name"],
+		params["address"], h.cfg.CaseInsensitive)
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound,
+			dividend.ErrAccountNameNotFound(params["name"],
+				params["address"]).Error())
+		return
+	}
+
+	work, err := ListMinedWorkByAccount(h.db, account.UUID)
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	resp := map[string]interface{}{
-		"accountid": id,
+		"accountid": account.UUID,
 		"results":   work,
 	}
 
@@ -1119,17 +1548,24 @@ func (h *Hub) FetchProcessedPaymentsForAccount(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	id := dividend.AccountID(name, address)
+	account, err := dividend.FetchAccountByName(h.db, name, address,
+		h.cfg.CaseInsensitive)
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound,
+			dividend.ErrAccountNameNotFound(name, address).Error())
+		return
+	}
+
 	minNano := util.NanoToBigEndianBytes(time.Unix(int64(min), 0).UnixNano())
 	payments, err := dividend.FetchArchivedPaymentsForAccount(h.db,
-		[]byte(*id), minNano)
+		[]byte(account.UUID), minNano)
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	resp := map[string]interface{}{
-		"accountid": id,
+		"accountid": account.UUID,
 		"results":   payments,
 	}
 
@@ -0,0 +1,378 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultLanguage is the language of the web interface when no
+	// requested language has a catalog.
+	defaultLanguage = "en"
+
+	// languageCookie is the cookie persisting the selected language.
+	languageCookie = "lang"
+
+	// languageNameKey is the catalog message naming the language of the
+	// catalog in the language selector.
+	languageNameKey = "language.name"
+)
+
+// Catalog maps the message keys of the web interface to their translation,
+// messages may contain fmt verbs for their arguments.
+type Catalog map[string]string
+
+// englishCatalog is the built-in catalog of the web interface, messages
+// missing from other catalogs fall back to it.
+var englishCatalog = Catalog{
+	languageNameKey: "English",
+
+	"index.title":            "dcrpool",
+	"index.heading":          "dcrpool on %v",
+	"index.blocks":           "Blocks found",
+	"index.payments":         "Payments",
+	"index.leaderboard":      "Leaderboard",
+	"index.apidocs":          "Api",
+	"index.pool":             "Pool",
+	"index.hashrate":         "hash rate",
+	"index.connections":      "connected clients",
+	"index.poolshare":        "share of network hash rate",
+	"index.timetoblock":      "estimated time to block",
+	"index.regions":          "Stratum hosts",
+	"index.region":           "region",
+	"index.host":             "host",
+	"index.unknown":          "unknown",
+	"index.network":          "Network",
+	"index.height":           "height",
+	"index.difficulty":       "difficulty",
+	"index.networkhashrate":  "estimated hash rate",
+	"index.subsidy":          "Subsidy of the next block",
+	"index.subsidy.work":     "proof of work",
+	"index.subsidy.stake":    "votes",
+	"index.subsidy.treasury": "treasury",
+	"index.subsidy.total":    "total",
+	"index.events":           "Recent events",
+	"index.events.none":      "No events yet.",
+
+	"blocks.title":         "dcrpool blocks found",
+	"blocks.heading":       "Blocks found on %v",
+	"blocks.height":        "height",
+	"blocks.hash":          "hash",
+	"blocks.reward":        "reward",
+	"blocks.finder":        "finder",
+	"blocks.confirmations": "confirmations",
+	"blocks.status":        "status",
+	"blocks.mature":        "mature",
+	"blocks.confirmed":     "confirmed",
+	"blocks.pending":       "pending",
+	"blocks.none":          "No blocks found yet.",
+	"blocks.older":         "Older blocks",
+
+	"payments.title":         "dcrpool payments",
+	"payments.heading":       "Payments on %v",
+	"payments.height":        "height",
+	"payments.txhash":        "transaction",
+	"payments.amount":        "amount",
+	"payments.accounts":      "accounts",
+	"payments.confirmations": "confirmations",
+	"payments.status":        "status",
+	"payments.pending":       "pending",
+	"payments.broadcast":     "broadcast",
+	"payments.confirmed":     "confirmed",
+	"payments.none":          "No payments yet.",
+	"payments.older":         "Older payments",
+
+	"leaderboard.title":    "dcrpool leaderboard",
+	"leaderboard.heading":  "Top miners on %v",
+	"leaderboard.rank":     "rank",
+	"leaderboard.miner":    "miner",
+	"leaderboard.hashrate": "hash rate (TH/s)",
+	"leaderboard.workers":  "workers",
+	"leaderboard.none":     "No miners connected.",
+	"leaderboard.about":    "Miners are listed anonymously unless they opt in to being named.",
+
+	"apidocs.title": "dcrpool api",
+	"apidocs.about": "The OpenAPI document of the api:",
+
+	"dashboard.title":             "dcrpool operator dashboard",
+	"dashboard.heading":           "Operator dashboard",
+	"dashboard.signedin":          "Signed in as %v.",
+	"dashboard.health":            "Backend health",
+	"dashboard.connected":         "connected",
+	"dashboard.disconnected":      "disconnected",
+	"dashboard.wallet":            "wallet",
+	"dashboard.lastworkheight":    "last work height",
+	"dashboard.workage":           "work age",
+	"dashboard.lastpaymentheight": "last payment height",
+	"dashboard.txfeereserve":      "tx fee reserve",
+	"dashboard.hashrate":          "hash rate",
+	"dashboard.timetoblock":       "estimated time to block",
+	"dashboard.unknown":           "unknown",
+	"dashboard.clients":           "Connected clients (%d)",
+	"dashboard.client":            "client",
+	"dashboard.ip":                "ip",
+	"dashboard.account":           "account",
+	"dashboard.worker":            "worker",
+	"dashboard.region":            "region",
+	"dashboard.difficulty":        "difficulty",
+	"dashboard.clienthashrate":    "hash rate (TH/s)",
+	"dashboard.accepted":          "accepted",
+	"dashboard.rejected":          "rejected",
+	"dashboard.acceptedratio":     "accepted %",
+	"dashboard.staleratio":        "stale %",
+	"dashboard.invalidratio":      "invalid %",
+	"dashboard.payments":          "Pending payments (%d)",
+	"dashboard.paymentcount":      "payments",
+	"dashboard.total":             "total",
+	"dashboard.maturity":          "estimated maturity",
+	"dashboard.controls":          "Controls",
+	"dashboard.accountid":         "account id",
+	"dashboard.reason":            "reason",
+	"dashboard.ban":               "ban",
+	"dashboard.suspend":           "Suspend account",
+	"dashboard.suspend.submit":    "Suspend",
+	"dashboard.reinstate":         "Reinstate account",
+	"dashboard.reinstate.submit":  "Reinstate",
+	"dashboard.payouts":           "Payouts",
+	"dashboard.payouts.about":     "Pay out mature payments now.",
+	"dashboard.payouts.submit":    "Process payouts",
+	"dashboard.work":              "Work",
+	"dashboard.work.about":        "Fetch a fresh block template and send its work to all clients.",
+	"dashboard.work.submit":       "Regenerate work",
+	"dashboard.banned":            "Account %v banned.",
+	"dashboard.suspended":         "Account %v suspended.",
+	"dashboard.reinstated":        "Account %v reinstated.",
+	"dashboard.payouts.processed": "Payouts processed at height %v.",
+	"dashboard.work.regenerated":  "Work at height %v sent to all clients.",
+	"dashboard.alerts":            "Active alerts (%d)",
+	"dashboard.alerts.since":      "since %v",
+
+	"alert.hashratedrop":  "Pool hash rate dropped by %v, over the %v threshold.",
+	"alert.rejectrate":    "Rejected share rate of %v, over the %v threshold.",
+	"alert.dbsize":        "Database size of %v, over the %v threshold.",
+	"alert.dcrdlatency":   "dcrd response time of %v, over the %v threshold.",
+	"alert.walletlatency": "Wallet response time of %v, over the %v threshold.",
+	"alert.stalework":     "Work served to clients is %v old, over the %v threshold.",
+}
+
+// LoadCatalogs returns the built-in catalog along with the catalogs of the
+// provided directory. Catalogs are json objects of message keys to
+// translations named after their language code, `de.json` for instance.
+// Catalogs of the directory override the built-in catalog of their language.
+func LoadCatalogs(dir string) (map[string]Catalog, error) {
+	catalogs := map[string]Catalog{defaultLanguage: englishCatalog}
+	if dir == "" {
+		return catalogs, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var catalog Catalog
+		err = json.Unmarshal(b, &catalog)
+		if err != nil {
+			return nil, fmt.Errorf("invalid catalog %v: %v", file, err)
+		}
+
+		lang := strings.ToLower(strings.TrimSuffix(filepath.Base(file),
+			".json"))
+		catalogs[lang] = catalog
+	}
+
+	return catalogs, nil
+}
+
+// parseAcceptLanguage returns the lowercased language tags of the provided
+// Accept-Language header, most preferred first.
+func parseAcceptLanguage(header string) []string {
+	type tag struct {
+		lang string
+		q    float64
+	}
+
+	tags := make([]tag, 0)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if lang == "" || lang == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				v, err := strconv.ParseFloat(param[2:], 64)
+				if err == nil {
+					q = v
+				}
+			}
+		}
+
+		if q > 0 {
+			tags = append(tags, tag{lang: lang, q: q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	langs := make([]string, 0, len(tags))
+	for _, t := range tags {
+		langs = append(langs, t.lang)
+	}
+
+	return langs
+}
+
+// matchLanguage returns the first of the provided language tags with a
+// catalog, tags with a region match the catalog of their base language.
+func matchLanguage(catalogs map[string]Catalog, langs []string) (string, bool) {
+	for _, lang := range langs {
+		lang = strings.ToLower(lang)
+		if _, ok := catalogs[lang]; ok {
+			return lang, true
+		}
+
+		if i := strings.IndexAny(lang, "-_"); i > 0 {
+			if _, ok := catalogs[lang[:i]]; ok {
+				return lang[:i], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// language is an entry of the language selector.
+type language struct {
+	Code     string
+	Name     string
+	Selected bool
+}
+
+// translator translates the messages of the web interface to the language
+// of a request.
+type translator struct {
+	lang     string
+	catalogs map[string]Catalog
+}
+
+// T returns the translation of the provided message key formatted with the
+// provided arguments. Messages missing a translation fall back to english,
+// unknown keys are returned as is.
+func (t *translator) T(key string, args ...interface{}) string {
+	msg, ok := t.catalogs[t.lang][key]
+	if !ok {
+		msg, ok = englishCatalog[key]
+		if !ok {
+			msg = key
+		}
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// languages returns the languages of the selector, ordered by code.
+func (t *translator) languages() []language {
+	langs := make([]language, 0, len(t.catalogs))
+	for code, catalog := range t.catalogs {
+		name, ok := catalog[languageNameKey]
+		if !ok {
+			name = code
+		}
+		langs = append(langs, language{
+			Code:     code,
+			Name:     name,
+			Selected: code == t.lang,
+		})
+	}
+
+	sort.Slice(langs, func(i, j int) bool {
+		return langs[i].Code < langs[j].Code
+	})
+
+	return langs
+}
+
+// translator returns the translator of the provided request. The language is
+// selected with the `lang` query parameter, which is persisted as a cookie,
+// and otherwise negotiated from the Accept-Language header.
+func (h *Hub) translator(w http.ResponseWriter, r *http.Request) *translator {
+	if lang, ok := matchLanguage(h.catalogs,
+		[]string{r.URL.Query().Get("lang")}); ok {
+		http.SetCookie(w, &http.Cookie{
+			Name:     languageCookie,
+			Value:    lang,
+			Path:     "/",
+			Expires:  time.Now().AddDate(1, 0, 0),
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return &translator{lang: lang, catalogs: h.catalogs}
+	}
+
+	if cookie, err := r.Cookie(languageCookie); err == nil {
+		if lang, ok := matchLanguage(h.catalogs,
+			[]string{cookie.Value}); ok {
+			return &translator{lang: lang, catalogs: h.catalogs}
+		}
+	}
+
+	lang, ok := matchLanguage(h.catalogs,
+		parseAcceptLanguage(r.Header.Get("Accept-Language")))
+	if !ok {
+		lang = defaultLanguage
+	}
+
+	return &translator{lang: lang, catalogs: h.catalogs}
+}
+
+// renderPage renders the provided page in the language of the provided
+// request.
+func (h *Hub) renderPage(w http.ResponseWriter, r *http.Request, name string, data interface{}) error {
+	tmpl, ok := h.pages[name]
+	if !ok {
+		return fmt.Errorf("unknown page %v", name)
+	}
+
+	tr := h.translator(w, r)
+	page, err := tmpl.Clone()
+	if err != nil {
+		return err
+	}
+
+	page.Funcs(template.FuncMap{
+		"T":         tr.T,
+		"languages": tr.languages,
+	})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Language", tr.lang)
+	return page.Execute(w, data)
+}
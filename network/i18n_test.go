@@ -0,0 +1,125 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected []string
+	}{
+		{"", []string{}},
+		{"de", []string{"de"}},
+		{"fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5",
+			[]string{"fr-ch", "fr", "en", "de"}},
+		{"en;q=0.5, pt-BR", []string{"pt-br", "en"}},
+		{"es;q=0, it", []string{"it"}},
+	}
+
+	for i, test := range tests {
+		langs := parseAcceptLanguage(test.header)
+		if !reflect.DeepEqual(langs, test.expected) {
+			t.Fatalf("test %d: expected %v, got %v", i, test.expected,
+				langs)
+		}
+	}
+}
+
+func TestCatalogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalogs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	catalog := `{"language.name": "Deutsch", "blocks.heading": "Gefundene Blöcke auf %v"}`
+	err = ioutil.WriteFile(filepath.Join(dir, "DE.json"), []byte(catalog),
+		0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catalogs, err := LoadCatalogs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lang, ok := matchLanguage(catalogs, []string{"fr", "de-AT", "en"})
+	if !ok || lang != "de" {
+		t.Fatalf("expected language de, got %v", lang)
+	}
+
+	_, ok = matchLanguage(catalogs, []string{"fr"})
+	if ok {
+		t.Fatal("expected no language match")
+	}
+
+	tr := &translator{lang: lang, catalogs: catalogs}
+	msg := tr.T("blocks.heading", "mainnet")
+	if msg != "Gefundene Blöcke auf mainnet" {
+		t.Fatalf("unexpected translation %q", msg)
+	}
+
+	// Messages missing from a catalog fall back to english.
+	msg = tr.T("blocks.none")
+	if msg != englishCatalog["blocks.none"] {
+		t.Fatalf("unexpected fallback %q", msg)
+	}
+
+	langs := tr.languages()
+	if len(langs) != 2 || langs[0].Code != "de" || !langs[0].Selected ||
+		langs[0].Name != "Deutsch" || langs[1].Code != "en" {
+		t.Fatalf("unexpected languages %+v", langs)
+	}
+}
+
+func TestRenderPage(t *testing.T) {
+	pages, err := loadPages(guiAssets(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Hub{catalogs: map[string]Catalog{
+		defaultLanguage: englishCatalog,
+		"de":            {"blocks.heading": "Gefundene Blöcke auf %v"},
+	}, pages: pages}
+
+	r := httptest.NewRequest("GET", "/blocks?lang=de", nil)
+	w := httptest.NewRecorder()
+	err = h.renderPage(w, r, blocksPage,
+		blocksPageData{Network: "mainnet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Gefundene Blöcke auf mainnet") ||
+		!strings.Contains(body, englishCatalog["blocks.none"]) {
+		t.Fatalf("unexpected page %v", body)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != languageCookie ||
+		cookies[0].Value != "de" {
+		t.Fatalf("expected language cookie, got %v", cookies)
+	}
+
+	// The language cookie selects the language of later requests.
+	r = httptest.NewRequest("GET", "/blocks", nil)
+	r.Header.Set("Accept-Language", "en")
+	r.AddCookie(cookies[0])
+	if lang := h.translator(httptest.NewRecorder(), r).lang; lang != "de" {
+		t.Fatalf("expected language de, got %v", lang)
+	}
+}
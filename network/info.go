@@ -0,0 +1,95 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// endpointInfo describes a stratum endpoint of the pool.
+type endpointInfo struct {
+	Miner      string `json:"miner"`
+	Port       uint32 `json:"port"`
+	Difficulty string `json:"difficulty"`
+}
+
+// poolInfo describes the pool for pool directories.
+type poolInfo struct {
+	Version            string          `json:"version"`
+	Network            string          `json:"network"`
+	SoloPool           bool            `json:"solopool"`
+	PaymentMethod      string          `json:"paymentmethod,omitempty"`
+	PoolFee            float64         `json:"poolfee"`
+	MinPayment         float64         `json:"minpayment"`
+	LastNPeriod        uint32          `json:"lastnperiod,omitempty"`
+	CoinbaseMaturity   uint16          `json:"coinbasematurity"`
+	AddressChangeDelay uint32          `json:"addresschangedelay"`
+	MinDifficulty      string          `json:"mindifficulty"`
+	MaxDifficulty      string          `json:"maxdifficulty"`
+	Registration       bool            `json:"registration"`
+	Miners             []string        `json:"miners"`
+	Endpoints          []*endpointInfo `json:"endpoints"`
+	Regions            []*regionInfo   `json:"regions"`
+}
+
+// poolInfo returns the description of the pool.
+func (h *Hub) poolInfo() *poolInfo {
+	info := &poolInfo{
+		Version:            APIVersion,
+		Network:            h.cfg.ActiveNet.Name,
+		SoloPool:           h.cfg.SoloPool,
+		CoinbaseMaturity:   h.cfg.ActiveNet.CoinbaseMaturity,
+		AddressChangeDelay: h.cfg.AddrChangeDelay,
+		Registration:       !h.cfg.SoloPool,
+		Miners:             make([]string, 0, len(h.endpoints)),
+		Endpoints:          make([]*endpointInfo, 0, len(h.endpoints)),
+		Regions:            h.regionInfo(),
+	}
+
+	if !h.cfg.SoloPool {
+		info.PaymentMethod = h.cfg.PaymentMethod
+		info.PoolFee = h.cfg.PoolFee
+		info.MinPayment = h.minPayment().ToCoin()
+		if h.cfg.PaymentMethod == dividend.PPLNS {
+			info.LastNPeriod = h.cfg.LastNPeriod
+		}
+	}
+
+	min, max := h.difficultyLimits()
+	if min != nil {
+		info.MinDifficulty = min.String()
+	}
+	if max != nil {
+		info.MaxDifficulty = max.String()
+	}
+
+	for _, endpoint := range h.endpoints {
+		info.Endpoints = append(info.Endpoints, &endpointInfo{
+			Miner:      endpoint.miner,
+			Port:       endpoint.port,
+			Difficulty: endpoint.difficultyData().difficulty.String(),
+		})
+	}
+
+	sort.Slice(info.Endpoints, func(i, j int) bool {
+		return info.Endpoints[i].Port < info.Endpoints[j].Port
+	})
+
+	for _, endpoint := range info.Endpoints {
+		info.Miners = append(info.Miners, endpoint.Miner)
+	}
+
+	return info
+}
+
+// APIPoolInfo describes the pool's payment scheme, fee, minimum payment,
+// difficulty limits, stratum endpoints with their supported miners and
+// regional stratum hosts, for pool directories to index.
+func (h *Hub) APIPoolInfo(w http.ResponseWriter, r *http.Request) {
+	RespondWithJSON(w, http.StatusOK, h.poolInfo())
+}
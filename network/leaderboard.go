@@ -0,0 +1,151 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// defaultLeaderboardSize is the number of accounts listed on the leaderboard
+// when no limit is requested.
+const defaultLeaderboardSize = 10
+
+// leaderboardEntry is an account listed on the leaderboard. The miner is
+// anonymized unless the account opted in to being named.
+type leaderboardEntry struct {
+	Rank     int    `json:"rank"`
+	Miner    string `json:"miner"`
+	HashRate string `json:"hashrate"`
+	Workers  int    `json:"workers"`
+}
+
+// leaderboard returns the provided number of accounts with the highest hash
+// rate of their connected clients, highest first.
+func (h *Hub) leaderboard(limit int) []*leaderboardEntry {
+	type accountRate struct {
+		id       string
+		hashRate *big.Rat
+		workers  int
+	}
+
+	rates := make(map[string]*accountRate)
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			if client.account == "" {
+				continue
+			}
+
+			rate, ok := rates[client.account]
+			if !ok {
+				rate = &accountRate{id: client.account, hashRate: new(big.Rat)}
+				rates[client.account] = rate
+			}
+
+			client.hashRateMtx.RLock()
+			rate.hashRate.Add(rate.hashRate, client.hashRate)
+			client.hashRateMtx.RUnlock()
+			rate.workers++
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	ranked := make([]*accountRate, 0, len(rates))
+	for _, rate := range rates {
+		ranked = append(ranked, rate)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if c := ranked[i].hashRate.Cmp(ranked[j].hashRate); c != 0 {
+			return c > 0
+		}
+		return ranked[i].id < ranked[j].id
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	entries := make([]*leaderboardEntry, 0, len(ranked))
+	for i, rate := range ranked {
+		miner := anonymizedFinder(rate.id)
+		account, err := dividend.FetchAccount(h.db, []byte(rate.id))
+		if err == nil && account.PublicLeaderboard && account.ErasedOn == 0 {
+			miner = account.Name
+		}
+
+		entries = append(entries, &leaderboardEntry{
+			Rank:     i + 1,
+			Miner:    miner,
+			HashRate: rate.hashRate.FloatString(12),
+			Workers:  rate.workers,
+		})
+	}
+
+	return entries
+}
+
+// leaderboardLimit parses the `limit` parameter of a leaderboard request.
+func leaderboardLimit(r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultLeaderboardSize, true
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 || n > maxPageLimit {
+		return 0, false
+	}
+	return n, true
+}
+
+// APILeaderboard returns the accounts with the highest hash rates, the
+// number of accounts listed is set by the `limit` parameter.
+func (h *Hub) APILeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit, ok := leaderboardLimit(r)
+	if !ok {
+		RespondWithError(w, http.StatusBadRequest,
+			"provided 'limit' parameter is not between 1 and "+
+				strconv.Itoa(maxPageLimit))
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"hashrateunit": hashRateUnit,
+		"results":      h.leaderboard(limit),
+	})
+}
+
+// leaderboardPageData is the data rendered by the leaderboard page.
+type leaderboardPageData struct {
+	Network string
+	Entries []*leaderboardEntry
+}
+
+// LeaderboardPage renders the accounts with the highest hash rates,
+// accepting the `limit` parameter of the leaderboard api.
+func (h *Hub) LeaderboardPage(w http.ResponseWriter, r *http.Request) {
+	limit, ok := leaderboardLimit(r)
+	if !ok {
+		http.Error(w, "provided 'limit' parameter is not between 1 and "+
+			strconv.Itoa(maxPageLimit), http.StatusBadRequest)
+		return
+	}
+
+	data := leaderboardPageData{
+		Network: h.cfg.ActiveNet.Name,
+		Entries: h.leaderboard(limit),
+	}
+
+	err := h.renderPage(w, r, leaderboardPage, data)
+	if err != nil {
+		log.Errorf("Failed to render leaderboard page: %v", err)
+	}
+}
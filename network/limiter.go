@@ -5,11 +5,15 @@
 package network
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
+
+	"github.com/dnldd/dcrpool/dividend"
 )
 
 const (
@@ -23,17 +27,23 @@ const (
 	// for pool clients.
 	clientBurst = 5
 
-	// apiTokenRate is the token refill rate for the api request bucket,
-	// per second.
-	apiTokenRate = 1
+	// registrationInterval is the token refill interval for the account
+	// registration bucket.
+	registrationInterval = time.Minute * 20
 
-	// apiBurst is the maximum token usage allowed per second,
-	// for api clients.
-	apiBurst = 1
+	// registrationBurst is the maximum number of account registration
+	// attempts allowed at once.
+	registrationBurst = 3
 
 	// apiClient represents an api client.
 	APIClient = "api"
 
+	// APIKeyClient represents an api client authenticated by an api key.
+	APIKeyClient = "apikey"
+
+	// RegistrationClient represents a client registering accounts.
+	RegistrationClient = "registration"
+
 	// poolClient represents a pool client.
 	PoolClient = "pool"
 )
@@ -52,12 +62,31 @@ type RequestLimiter struct {
 type RateLimiter struct {
 	mutex    sync.RWMutex
 	limiters map[string]*RequestLimiter
+	apiRate  rate.Limit
+	apiBurst int
+	keyRate  rate.Limit
+	keyBurst int
+}
+
+// requestRate returns the limit of the provided requests per second, a rate
+// of zero or less is unlimited.
+func requestRate(perSecond float64) rate.Limit {
+	if perSecond <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(perSecond)
 }
 
-// NewRateLimiter initializes a rate limiter.
-func NewRateLimiter() *RateLimiter {
+// NewRateLimiter initializes a rate limiter. Api clients are allowed the
+// provided requests per second and bursts per ip address, or per api key
+// when authenticated by one.
+func NewRateLimiter(apiRate float64, apiBurst int, keyRate float64, keyBurst int) *RateLimiter {
 	RateLimiter := &RateLimiter{
 		limiters: make(map[string]*RequestLimiter),
+		apiRate:  requestRate(apiRate),
+		apiBurst: apiBurst,
+		keyRate:  requestRate(keyRate),
+		keyBurst: keyBurst,
 	}
 	return RateLimiter
 }
@@ -68,7 +97,15 @@ func (r *RateLimiter) AddRequestLimiter(ip string, clientType string) *RequestLi
 	if clientType == APIClient {
 		limiter = &RequestLimiter{
 			ip:                 ip,
-			limiter:            rate.NewLimiter(apiTokenRate, apiBurst),
+			limiter:            rate.NewLimiter(r.apiRate, r.apiBurst),
+			lastAllowedRequest: 0,
+		}
+	}
+
+	if clientType == APIKeyClient {
+		limiter = &RequestLimiter{
+			ip:                 ip,
+			limiter:            rate.NewLimiter(r.keyRate, r.keyBurst),
 			lastAllowedRequest: 0,
 		}
 	}
@@ -81,6 +118,15 @@ func (r *RateLimiter) AddRequestLimiter(ip string, clientType string) *RequestLi
 		}
 	}
 
+	if clientType == RegistrationClient {
+		limiter = &RequestLimiter{
+			ip: ip,
+			limiter: rate.NewLimiter(rate.Every(registrationInterval),
+				registrationBurst),
+			lastAllowedRequest: 0,
+		}
+	}
+
 	r.mutex.Lock()
 	r.limiters[ip] = limiter
 	r.mutex.Unlock()
@@ -124,12 +170,81 @@ func (r *RateLimiter) WithinLimit(ip string, clientType string) bool {
 	return allow
 }
 
-// LimiterMiddleware wraps the the request limit logic as request middleware.
-func (r *RateLimiter) LimiterMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		accepted := r.WithinLimit(req.RemoteAddr, APIClient)
-		if accepted {
-			next.ServeHTTP(w, req)
+// Reserve asserts that the client referenced by the provided key is within
+// the limits of the rate limiter like WithinLimit, and otherwise returns how
+// long the client has to wait before its next request is allowed.
+func (r *RateLimiter) Reserve(key string, clientType string) (bool, time.Duration) {
+	reqLimiter := r.GetLimiter(key)
+	if reqLimiter == nil {
+		reqLimiter = r.AddRequestLimiter(key, clientType)
+	}
+
+	now := time.Now()
+	reservation := reqLimiter.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	reqLimiter.lastAllowedRequest = uint32(now.Unix())
+	return true, 0
+}
+
+// apiKeyLimiterKey returns the key the requests authenticated by the
+// provided api key are limited by.
+func apiKeyLimiterKey(id string) string {
+	return APIKeyClient + ":" + id
+}
+
+// registrationKey returns the key the registration attempts of the provided
+// ip address are limited by, kept apart from its request limiter.
+func registrationKey(ip string) string {
+	return RegistrationClient + ":" + ip
+}
+
+// unlimitedPaths are the paths of health probes, they are not rate limited.
+var unlimitedPaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// RateLimit wraps api request rate limiting as request middleware. Requests
+// are limited per ip address, or per api key when authenticated by one.
+// Requests over quota are refused with a 429 status and the number of
+// seconds to wait before retrying in the Retry-After header.
+func (h *Hub) RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unlimitedPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
 		}
+
+		key, clientType := remoteIP(r), APIClient
+		if full := r.Header.Get("X-API-Key"); full != "" {
+			apiKey, err := dividend.AuthenticateAPIKey(h.db, full)
+			if err == nil {
+				key, clientType = apiKeyLimiterKey(apiKey.UUID), APIKeyClient
+			}
+		}
+
+		allowed, wait := h.limiter.Reserve(key, clientType)
+		if !allowed {
+			retry := int(math.Ceil(wait.Seconds()))
+			if retry < 1 {
+				retry = 1
+			}
+
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			RespondWithError(w, http.StatusTooManyRequests,
+				"rate limit exceeded, try again later")
+			return
+		}
+
+		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,40 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+)
+
+func TestReserve(t *testing.T) {
+	limiter := NewRateLimiter(1, 2, 0, 1)
+
+	// Ip addresses are allowed their burst, then have to wait.
+	for i := 0; i < 2; i++ {
+		allowed, _ := limiter.Reserve("127.0.0.1", APIClient)
+		if !allowed {
+			t.Fatalf("request %d: expected request to be allowed", i)
+		}
+	}
+
+	allowed, wait := limiter.Reserve("127.0.0.1", APIClient)
+	if allowed || wait <= 0 {
+		t.Fatalf("expected request to be limited, got wait %v", wait)
+	}
+
+	// Limits are kept per client.
+	allowed, _ = limiter.Reserve("127.0.0.2", APIClient)
+	if !allowed {
+		t.Fatal("expected request of another ip to be allowed")
+	}
+
+	// Api keys with a rate of zero are not limited.
+	for i := 0; i < 10; i++ {
+		allowed, _ := limiter.Reserve(apiKeyLimiterKey("k"), APIKeyClient)
+		if !allowed {
+			t.Fatalf("request %d: expected key request to be allowed", i)
+		}
+	}
+}
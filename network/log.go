@@ -9,10 +9,16 @@ import (
 	"github.com/decred/slog"
 )
 
-// log is a logger that is initialized with no output filters.  This
-// means the package will not perform any logging by default until the caller
-// requests it.
-var log slog.Logger
+// Loggers of the package, initialized with no output filters. This means the
+// package will not perform any logging by default until the caller requests
+// it. Stratum client, chain and payment logging have their own loggers so
+// their levels can be set independently, the remaining logging uses log.
+var (
+	log        slog.Logger
+	stratumLog slog.Logger
+	chainLog   slog.Logger
+	paymentLog slog.Logger
+)
 
 // The default amount of logging is none.
 func init() {
@@ -23,9 +29,30 @@ func init() {
 // by default until UseLogger is called.
 func DisableLog() {
 	log = slog.Disabled
+	stratumLog = slog.Disabled
+	chainLog = slog.Disabled
+	paymentLog = slog.Disabled
 }
 
 // UseLogger uses a specified Logger to output package logging info.
 func UseLogger(logger slog.Logger) {
 	log = logger
 }
+
+// UseStratumLogger uses a specified Logger to output the logging of stratum
+// clients and endpoints.
+func UseStratumLogger(logger slog.Logger) {
+	stratumLog = logger
+}
+
+// UseChainLogger uses a specified Logger to output the logging of work,
+// block submission and chain updates.
+func UseChainLogger(logger slog.Logger) {
+	chainLog = logger
+}
+
+// UsePaymentLogger uses a specified Logger to output the logging of
+// payments, payouts and the wallet.
+func UsePaymentLogger(logger slog.Logger) {
+	paymentLog = logger
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// luckWindows are the numbers of recent blocks luck is averaged over.
+var luckWindows = []int{10, 50, 100}
+
+// roundEffort returns the effort of the current round at the network
+// difficulty of the most recent work.
+func (h *Hub) roundEffort() (float64, error) {
+	work, err := dividend.FetchRoundWork(h.db)
+	if err != nil {
+		return 0, err
+	}
+
+	return dividend.RoundEffort(h.cfg.ActiveNet, work,
+		atomic.LoadUint32(&h.lastWorkBits)), nil
+}
+
+// timeToBlock returns the average time in seconds expected for the pool to
+// find a block at its current hash rate and the current network difficulty.
+func (h *Hub) timeToBlock() float64 {
+	hashRate := h.hashRate("")
+	hashRate.Mul(hashRate, new(big.Rat).SetInt(teraHash))
+	return dividend.TimeToBlock(atomic.LoadUint32(&h.lastWorkBits), hashRate)
+}
+
+// timeToBlockDuration returns the average time expected for the pool to find
+// a block, rounded to the second. Estimates too large for a duration are
+// zero, unknown.
+func (h *Hub) timeToBlockDuration() time.Duration {
+	ttb := h.timeToBlock()
+	if ttb >= float64(math.MaxInt64)/float64(time.Second) {
+		return 0
+	}
+
+	return time.Duration(ttb * float64(time.Second)).Round(time.Second)
+}
+
+// APILuck returns the effort of the current round, the luck of recent blocks
+// found by the pool and the average luck over windows of recent blocks. The
+// number of blocks listed is set by the `limit` parameter.
+func (h *Hub) APILuck(w http.ResponseWriter, r *http.Request) {
+	limit := defaultPageLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxPageLimit {
+			RespondWithError(w, http.StatusBadRequest,
+				"provided 'limit' parameter is not between 1 and "+
+					strconv.Itoa(maxPageLimit))
+			return
+		}
+		limit = n
+	}
+
+	effort, err := h.roundEffort()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	work, err := ListMinedWork(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Blocks found before efforts were recorded are excluded, the most
+	// recent blocks are listed first.
+	mined := make([]*AcceptedWork, 0, len(work))
+	for i := len(work) - 1; i >= 0; i-- {
+		if work[i].Effort > 0 {
+			mined = append(mined, work[i])
+		}
+	}
+
+	blocks := make([]map[string]interface{}, 0, limit)
+	for i := 0; i < len(mined) && i < limit; i++ {
+		blocks = append(blocks, map[string]interface{}{
+			"height":    mined[i].Height,
+			"blockhash": mined[i].BlockHash,
+			"createdon": mined[i].CreatedOn,
+			"effort":    mined[i].Effort,
+			"luck":      dividend.Luck(mined[i].Effort),
+		})
+	}
+
+	// The average luck of a window is the number of blocks found relative
+	// to the number expected for the total effort of the window.
+	windows := make([]map[string]interface{}, 0, len(luckWindows))
+	for _, size := range luckWindows {
+		if len(mined) < size {
+			break
+		}
+
+		var total float64
+		for _, block := range mined[:size] {
+			total += block.Effort
+		}
+
+		windows = append(windows, map[string]interface{}{
+			"blocks": size,
+			"effort": total / float64(size),
+			"luck":   float64(size) / total,
+		})
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"roundeffort": effort,
+		"windows":     windows,
+		"results":     blocks,
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+)
+
+// Mailer delivers emails to account holders over SMTP.
+type Mailer struct {
+	host string
+	user string
+	pass string
+	from string
+}
+
+// NewMailer creates a mailer instance. Mail delivery is disabled when no
+// SMTP host is provided, only the recipient and subject of messages are
+// logged instead.
+func NewMailer(host string, user string, pass string, from string) *Mailer {
+	return &Mailer{
+		host: host,
+		user: user,
+		pass: pass,
+		from: from,
+	}
+}
+
+// Enabled asserts the mailer is configured to deliver emails.
+func (m *Mailer) Enabled() bool {
+	return m.host != ""
+}
+
+// Send delivers an email with the provided subject and body to the
+// provided recipient.
+func (m *Mailer) Send(to string, subject string, body string) error {
+	if !m.Enabled() {
+		// Bodies carry account tokens and are never logged.
+		log.Debugf("Mail delivery disabled, dropped message to %v (%v)",
+			to, subject)
+		return nil
+	}
+
+	msg := new(bytes.Buffer)
+	fmt.Fprintf(msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(msg, "To: %s\r\n", to)
+	fmt.Fprintf(msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(msg, "\r\n%s\r\n", body)
+
+	var auth smtp.Auth
+	if m.user != "" {
+		host, _, err := net.SplitHostPort(m.host)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.user, m.pass, host)
+	}
+
+	return smtp.SendMail(m.host, auth, m.from, []string{to}, msg.Bytes())
+}
@@ -0,0 +1,408 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/decred/dcrd/dcrutil"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// accountCounters tracks the share counters of an account.
+type accountCounters struct {
+	sharesAccepted uint64
+	sharesRejected uint64
+}
+
+// metrics tracks the pool counters exposed for monitoring since the pool
+// was started.
+type metrics struct {
+	sharesAccepted uint64 // update atomically
+	sharesRejected uint64 // update atomically
+	jobs           uint64 // update atomically
+	jobLatency     int64  // update atomically
+	payouts        uint64 // update atomically
+	paidOut        int64  // update atomically
+	blocksPending  int64  // update atomically
+	txDropped      uint64 // update atomically
+	eventsDropped  uint64 // update atomically
+	connsRefused   uint64 // update atomically
+	ipConnsRefused uint64 // update atomically
+
+	// accounts tracks the share counters of up to accountLimit accounts,
+	// accounts beyond the limit are not tracked to cap the cardinality of
+	// account metrics.
+	accountLimit int
+	accounts     map[string]*accountCounters
+	accountsMtx  sync.Mutex
+}
+
+// newMetrics creates the pool metrics, tracking the counters of up to the
+// provided number of accounts.
+func newMetrics(accountLimit int) *metrics {
+	return &metrics{
+		accountLimit: accountLimit,
+		accounts:     make(map[string]*accountCounters),
+	}
+}
+
+// recordShare counts an accepted or rejected share of the provided account.
+func (m *metrics) recordShare(account string, accepted bool) {
+	if accepted {
+		atomic.AddUint64(&m.sharesAccepted, 1)
+	} else {
+		atomic.AddUint64(&m.sharesRejected, 1)
+	}
+
+	if account == "" || m.accountLimit == 0 {
+		return
+	}
+
+	m.accountsMtx.Lock()
+	defer m.accountsMtx.Unlock()
+
+	counters, ok := m.accounts[account]
+	if !ok {
+		if len(m.accounts) >= m.accountLimit {
+			return
+		}
+		counters = new(accountCounters)
+		m.accounts[account] = counters
+	}
+
+	if accepted {
+		counters.sharesAccepted++
+		return
+	}
+
+	counters.sharesRejected++
+}
+
+// trackedAccounts returns the ids of the accounts with tracked counters,
+// sorted, along with a copy of their counters.
+func (m *metrics) trackedAccounts() ([]string, map[string]accountCounters) {
+	m.accountsMtx.Lock()
+	defer m.accountsMtx.Unlock()
+
+	ids := make([]string, 0, len(m.accounts))
+	counters := make(map[string]accountCounters, len(m.accounts))
+	for id, c := range m.accounts {
+		ids = append(ids, id)
+		counters[id] = *c
+	}
+	sort.Strings(ids)
+
+	return ids, counters
+}
+
+// recordJob counts a job broadcast to clients and the time it took.
+func (m *metrics) recordJob(latency time.Duration) {
+	atomic.AddUint64(&m.jobs, 1)
+	atomic.AddInt64(&m.jobLatency, int64(latency))
+}
+
+// recordPayout counts a payout transaction and the amount paid.
+func (m *metrics) recordPayout(amount dcrutil.Amount) {
+	atomic.AddUint64(&m.payouts, 1)
+	atomic.AddInt64(&m.paidOut, int64(amount))
+}
+
+// writeMetric writes a metric in the prometheus text format.
+func writeMetric(buf *bytes.Buffer, name string, metricType string, help string, value interface{}) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help,
+		name, metricType, name, value)
+}
+
+// writeRuntimeMetrics writes the goroutine count, heap usage and garbage
+// collection statistics of the Go runtime.
+func writeRuntimeMetrics(buf *bytes.Buffer) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	writeMetric(buf, "go_goroutines", "gauge",
+		"Number of goroutines that currently exist.", runtime.NumGoroutine())
+	writeMetric(buf, "go_memstats_heap_alloc_bytes", "gauge",
+		"Number of heap bytes allocated and still in use.", stats.HeapAlloc)
+	writeMetric(buf, "go_memstats_heap_inuse_bytes", "gauge",
+		"Number of heap bytes in in-use spans.", stats.HeapInuse)
+	writeMetric(buf, "go_memstats_heap_objects", "gauge",
+		"Number of allocated heap objects.", stats.HeapObjects)
+	writeMetric(buf, "go_memstats_sys_bytes", "gauge",
+		"Number of bytes obtained from the system.", stats.Sys)
+	writeMetric(buf, "go_memstats_next_gc_bytes", "gauge",
+		"Heap size at which the next garbage collection takes place.",
+		stats.NextGC)
+	writeMetric(buf, "go_gc_cycles_total", "counter",
+		"Number of completed garbage collection cycles.", stats.NumGC)
+	writeMetric(buf, "go_gc_pause_seconds_total", "counter",
+		"Time spent in garbage collection stop-the-world pauses.",
+		time.Duration(stats.PauseTotalNs).Seconds())
+
+	// The most recent pause is at the last written entry of the circular
+	// pause buffer.
+	var lastPause time.Duration
+	if stats.NumGC > 0 {
+		lastPause = time.Duration(stats.PauseNs[(stats.NumGC+255)%256])
+	}
+	writeMetric(buf, "go_gc_last_pause_seconds", "gauge",
+		"Duration of the last garbage collection pause.", lastPause.Seconds())
+}
+
+// writeDBMetrics writes the transaction and freelist statistics of the
+// database.
+func writeDBMetrics(buf *bytes.Buffer, db *bolt.DB) {
+	stats := db.Stats()
+
+	writeMetric(buf, "dcrpool_db_read_tx_total", "counter",
+		"Number of read transactions started.", stats.TxN)
+	writeMetric(buf, "dcrpool_db_open_read_tx", "gauge",
+		"Number of open read transactions.", stats.OpenTxN)
+	writeMetric(buf, "dcrpool_db_writes_total", "counter",
+		"Number of page writes of write transactions.", stats.TxStats.Write)
+	writeMetric(buf, "dcrpool_db_write_seconds_total", "counter",
+		"Time spent writing pages to disk.", stats.TxStats.WriteTime.Seconds())
+	writeMetric(buf, "dcrpool_db_free_pages", "gauge",
+		"Number of free pages on the freelist.", stats.FreePageN)
+	writeMetric(buf, "dcrpool_db_pending_pages", "gauge",
+		"Number of pages pending release to the freelist.",
+		stats.PendingPageN)
+	writeMetric(buf, "dcrpool_db_freelist_bytes", "gauge",
+		"Number of bytes used by the freelist.", stats.FreelistInuse)
+}
+
+// queueDepths returns the number of items pending processing in each queue
+// of the pool, keyed by queue name. Growing queues indicate the subsystem
+// consuming them does not keep up.
+func (h *Hub) queueDepths() map[string]int {
+	depths := map[string]int{
+		"blocks":      int(atomic.LoadInt64(&h.metrics.blocksPending)),
+		"votes":       len(h.txCh),
+		"instantmine": len(h.mineCh),
+	}
+
+	h.feedMtx.Lock()
+	for sub := range h.feedSubs {
+		depths["feed"] += len(sub.events)
+	}
+	h.feedMtx.Unlock()
+
+	h.frontendsMtx.Lock()
+	for ch := range h.workSubs {
+		depths["frontends"] += len(ch)
+	}
+	h.frontendsMtx.Unlock()
+
+	return depths
+}
+
+// writeQueueMetrics writes the depth of the queues of the pool and the
+// items dropped by the queues that drop items when full.
+func (h *Hub) writeQueueMetrics(buf *bytes.Buffer) {
+	depths := h.queueDepths()
+	names := make([]string, 0, len(depths))
+	for name := range depths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf.WriteString("# HELP dcrpool_queue_depth Number of items pending " +
+		"processing in a queue.\n# TYPE dcrpool_queue_depth gauge\n")
+	for _, name := range names {
+		fmt.Fprintf(buf, "dcrpool_queue_depth{queue=%q} %v\n", name,
+			depths[name])
+	}
+
+	buf.WriteString("# HELP dcrpool_queue_dropped_total Number of items " +
+		"dropped by a full queue.\n" +
+		"# TYPE dcrpool_queue_dropped_total counter\n")
+	fmt.Fprintf(buf, "dcrpool_queue_dropped_total{queue=%q} %v\n", "feed",
+		atomic.LoadUint64(&h.metrics.eventsDropped))
+	fmt.Fprintf(buf, "dcrpool_queue_dropped_total{queue=%q} %v\n", "votes",
+		atomic.LoadUint64(&h.metrics.txDropped))
+}
+
+// boolGauge returns the gauge value of the provided condition.
+func boolGauge(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// writeAccountMetrics writes the hash rate and share counters of the tracked
+// accounts, labeled by account id and name.
+func (h *Hub) writeAccountMetrics(buf *bytes.Buffer) {
+	ids, counters := h.metrics.trackedAccounts()
+	labels := make(map[string]string, len(ids))
+	for _, id := range ids {
+		name := ""
+		account, err := dividend.FetchAccount(h.db, []byte(id))
+		if err == nil {
+			name = account.Name
+		}
+		labels[id] = fmt.Sprintf("account=%q,name=%q", id, name)
+	}
+
+	buf.WriteString("# HELP dcrpool_account_hashrate_terahashes Estimated " +
+		"hash rate of the connected clients of an account in TH/s.\n" +
+		"# TYPE dcrpool_account_hashrate_terahashes gauge\n")
+	for _, id := range ids {
+		hashRate, _ := h.hashRate(id).Float64()
+		fmt.Fprintf(buf, "dcrpool_account_hashrate_terahashes{%s} %v\n",
+			labels[id], hashRate)
+	}
+
+	buf.WriteString("# HELP dcrpool_account_shares_accepted_total Number " +
+		"of accepted shares of an account.\n" +
+		"# TYPE dcrpool_account_shares_accepted_total counter\n")
+	for _, id := range ids {
+		fmt.Fprintf(buf, "dcrpool_account_shares_accepted_total{%s} %v\n",
+			labels[id], counters[id].sharesAccepted)
+	}
+
+	buf.WriteString("# HELP dcrpool_account_shares_rejected_total Number " +
+		"of rejected shares of an account.\n" +
+		"# TYPE dcrpool_account_shares_rejected_total counter\n")
+	for _, id := range ids {
+		fmt.Fprintf(buf, "dcrpool_account_shares_rejected_total{%s} %v\n",
+			labels[id], counters[id].sharesRejected)
+	}
+}
+
+// Metrics returns pool metrics in the prometheus text format.
+func (h *Hub) Metrics(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+
+	hashRate, _ := h.hashRate("").Float64()
+	writeMetric(&buf, "dcrpool_hashrate_terahashes", "gauge",
+		"Estimated hash rate of connected clients in TH/s.", hashRate)
+
+	connections := 0
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		connections += len(endpoint.clients)
+		endpoint.clientsMtx.Unlock()
+	}
+	writeMetric(&buf, "dcrpool_clients", "gauge",
+		"Number of connected clients.", connections)
+
+	buf.WriteString("# HELP dcrpool_connections_refused_total Number of " +
+		"connections refused by a connection limit.\n" +
+		"# TYPE dcrpool_connections_refused_total counter\n")
+	fmt.Fprintf(&buf, "dcrpool_connections_refused_total{limit=%q} %v\n",
+		"total", atomic.LoadUint64(&h.metrics.connsRefused))
+	fmt.Fprintf(&buf, "dcrpool_connections_refused_total{limit=%q} %v\n",
+		"ip", atomic.LoadUint64(&h.metrics.ipConnsRefused))
+
+	writeMetric(&buf, "dcrpool_shares_accepted_total", "counter",
+		"Number of accepted shares.",
+		atomic.LoadUint64(&h.metrics.sharesAccepted))
+	writeMetric(&buf, "dcrpool_shares_rejected_total", "counter",
+		"Number of rejected shares.",
+		atomic.LoadUint64(&h.metrics.sharesRejected))
+
+	fmt.Fprintf(&buf, "# HELP dcrpool_job_latency_seconds Time taken to "+
+		"broadcast new work to clients.\n"+
+		"# TYPE dcrpool_job_latency_seconds summary\n"+
+		"dcrpool_job_latency_seconds_sum %v\n"+
+		"dcrpool_job_latency_seconds_count %v\n",
+		time.Duration(atomic.LoadInt64(&h.metrics.jobLatency)).Seconds(),
+		atomic.LoadUint64(&h.metrics.jobs))
+
+	writeMetric(&buf, "dcrpool_last_work_height", "gauge",
+		"Height of the last work received.",
+		atomic.LoadUint32(&h.lastWorkHeight))
+	writeMetric(&buf, "dcrpool_work_age_seconds", "gauge",
+		"Time since the work served to clients was received.",
+		h.workAge(time.Now()).Seconds())
+
+	if !h.cfg.SoloPool {
+		writeMetric(&buf, "dcrpool_last_payment_height", "gauge",
+			"Height of the last payment made.",
+			atomic.LoadUint32(&h.lastPaymentHeight))
+		writeMetric(&buf, "dcrpool_payouts_total", "counter",
+			"Number of payout transactions published.",
+			atomic.LoadUint64(&h.metrics.payouts))
+		writeMetric(&buf, "dcrpool_paid_coins_total", "counter",
+			"Amount paid out in DCR.",
+			dcrutil.Amount(atomic.LoadInt64(&h.metrics.paidOut)).ToCoin())
+	}
+
+	var size int64
+	buckets := make(map[string]int)
+	err := h.db.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		pbkt := tx.Bucket(database.PoolBkt)
+		if pbkt == nil {
+			return database.ErrBucketNotFound(database.PoolBkt)
+		}
+
+		return pbkt.ForEach(func(k, v []byte) error {
+			if v == nil {
+				buckets[string(k)] = pbkt.Bucket(k).Stats().KeyN
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeMetric(&buf, "dcrpool_db_size_bytes", "gauge",
+		"Size of the database in bytes.", size)
+	writeDBMetrics(&buf, h.db)
+
+	names := make([]string, 0, len(buckets))
+	for name := range buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf.WriteString("# HELP dcrpool_db_bucket_keys Number of keys in " +
+		"each database bucket.\n# TYPE dcrpool_db_bucket_keys gauge\n")
+	for _, name := range names {
+		fmt.Fprintf(&buf, "dcrpool_db_bucket_keys{bucket=%q} %v\n", name,
+			buckets[name])
+	}
+
+	h.rpccMtx.Lock()
+	dcrdConnected := !h.rpcc.Disconnected()
+	h.rpccMtx.Unlock()
+	writeMetric(&buf, "dcrpool_dcrd_connected", "gauge",
+		"Whether the dcrd rpc connection is up.", boolGauge(dcrdConnected))
+
+	if !h.cfg.SoloPool {
+		h.grpcMtx.Lock()
+		walletConnected := h.gConn.GetState() == connectivity.Ready
+		h.grpcMtx.Unlock()
+		writeMetric(&buf, "dcrpool_wallet_connected", "gauge",
+			"Whether the wallet grpc connection is up.",
+			boolGauge(walletConnected))
+	}
+
+	h.writeQueueMetrics(&buf)
+	writeRuntimeMetrics(&buf)
+
+	if h.metrics.accountLimit > 0 {
+		h.writeAccountMetrics(&buf)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+func TestAccountMetricsLimit(t *testing.T) {
+	m := newMetrics(2)
+	m.recordShare("x", true)
+	m.recordShare("y", false)
+	m.recordShare("z", true)
+	m.recordShare("x", false)
+	m.recordShare("", true)
+
+	ids, counters := m.trackedAccounts()
+	if len(ids) != 2 || ids[0] != "x" || ids[1] != "y" {
+		t.Fatalf("expected accounts x and y to be tracked, got %v", ids)
+	}
+
+	if counters["x"].sharesAccepted != 1 || counters["x"].sharesRejected != 1 {
+		t.Errorf("unexpected counters of x %+v", counters["x"])
+	}
+
+	if m.sharesAccepted != 3 || m.sharesRejected != 2 {
+		t.Errorf("expected pool counters to include untracked accounts, "+
+			"got %v accepted and %v rejected", m.sharesAccepted,
+			m.sharesRejected)
+	}
+
+	m = newMetrics(0)
+	m.recordShare("x", true)
+	if ids, _ := m.trackedAccounts(); len(ids) != 0 {
+		t.Errorf("expected no tracked accounts, got %v", ids)
+	}
+}
+
+func TestQueueMetrics(t *testing.T) {
+	h := &Hub{
+		metrics:  newMetrics(0),
+		txCh:     make(chan *chainhash.Hash, txQueueSize),
+		mineCh:   make(chan *instantMineRequest, 1),
+		feedSubs: make(map[*feedSubscriber]struct{}),
+		workSubs: make(map[chan *Job]struct{}),
+	}
+
+	h.queueVote(&chainhash.Hash{})
+	sub := h.subscribe("", false)
+	h.publish("", EventHashRate, nil)
+	h.publish("", EventHashRate, nil)
+	atomic.AddInt64(&h.metrics.blocksPending, 1)
+
+	depths := h.queueDepths()
+	if depths["votes"] != 1 || depths["feed"] != 2 ||
+		depths["blocks"] != 1 || depths["instantmine"] != 0 {
+		t.Fatalf("unexpected queue depths %v", depths)
+	}
+
+	// Events published to a full feed are dropped and counted.
+	for i := 0; i < feedBufferSize; i++ {
+		h.publish("", EventHashRate, nil)
+	}
+	if len(sub.events) != feedBufferSize || h.metrics.eventsDropped != 2 {
+		t.Fatalf("expected 2 dropped events, got %v",
+			h.metrics.eventsDropped)
+	}
+
+	var buf bytes.Buffer
+	h.writeQueueMetrics(&buf)
+	writeRuntimeMetrics(&buf)
+	for _, metric := range []string{
+		`dcrpool_queue_depth{queue="votes"} 1`,
+		`dcrpool_queue_dropped_total{queue="feed"} 2`,
+		"go_goroutines ",
+		"go_gc_pause_seconds_total ",
+	} {
+		if !strings.Contains(buf.String(), metric) {
+			t.Errorf("expected metric %v in %v", metric, buf.String())
+		}
+	}
+}
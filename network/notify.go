@@ -0,0 +1,136 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// notify delivers an alert to the provided account over the delivery
+// channels the account opted into. Delivery failures are logged.
+func (h *Hub) notify(accountID string, alert string, subject string, body string) {
+	account, err := dividend.FetchAccount(h.db, []byte(accountID))
+	if err != nil {
+		log.Errorf("Failed to fetch account (%v) to notify: %v", accountID, err)
+		return
+	}
+
+	if account.EmailVerified &&
+		account.WantsNotification(alert, dividend.ChannelEmail) {
+		err := h.mailer.Send(account.Email, subject, body)
+		if err != nil {
+			log.Errorf("Failed to email %v alert to account (%v): %v", alert,
+				accountID, err)
+		}
+	}
+}
+
+// notifyWorkerRecovered alerts the account of the referenced worker that the
+// worker is submitting shares again after being alerted as offline.
+func (h *Hub) notifyWorkerRecovered(workerID string) {
+	worker, err := dividend.FetchWorker(h.db, []byte(workerID))
+	if err != nil {
+		log.Errorf("Failed to fetch worker (%v) to notify: %v", workerID, err)
+		return
+	}
+
+	h.notify(worker.Account, dividend.AlertWorkerOffline, "Worker back online",
+		fmt.Sprintf("Worker %v is submitting shares again.", worker.Name))
+}
+
+// handleWorkerAlerts periodically alerts accounts of recently active
+// workers which stopped submitting shares for the configured period. It
+// must be run as a goroutine.
+func (h *Hub) handleWorkerAlerts(ctx context.Context) {
+	period := time.Second * time.Duration(h.cfg.WorkerOffline)
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	h.wg.Add(1)
+	log.Trace("Started worker alerts handler.")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Trace("Worker alerts handler done.")
+			h.wg.Done()
+			return
+		case <-ticker.C:
+			workers, err := dividend.MarkOfflineWorkers(h.db, period)
+			if err != nil {
+				log.Errorf("Failed to fetch offline workers: %v", err)
+				continue
+			}
+
+			for _, worker := range workers {
+				h.notify(worker.Account, dividend.AlertWorkerOffline,
+					"Worker offline",
+					fmt.Sprintf("Worker %v has not submitted shares since %v.",
+						worker.Name, time.Unix(worker.LastShareOn, 0).UTC()))
+			}
+		}
+	}
+}
+
+// FetchNotificationPreferences returns the notification preferences of the
+// authenticated account.
+func (h *Hub) FetchNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := map[string]interface{}{
+		"preferences": account.NotificationPreferences(),
+		"alerts":      dividend.Alerts,
+		"channels":    dividend.Channels,
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// UpdateNotificationPreferences updates the notification preferences of the
+// authenticated account. The request body maps alert types to the delivery
+// channels to use, alert types not provided are left unchanged.
+func (h *Hub) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	prefs := map[string][]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&prefs)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	account, err := h.requestAccount(r)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = account.SetNotificationPreferences(prefs)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = account.Update(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordActivity(r, account.UUID, dividend.ActivitySettingsChange,
+		"notification preferences updated")
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]interface{}{"preferences": account.NotificationPreferences()})
+}
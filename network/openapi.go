@@ -0,0 +1,297 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// openAPIVersion is the version of the OpenAPI specification describing the
+// api.
+const openAPIVersion = "3.0.3"
+
+// openAPIInfo is the metadata of an OpenAPI document.
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// openAPIServer is a server of an OpenAPI document.
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+// openAPISchema is the schema of an OpenAPI parameter.
+type openAPISchema struct {
+	Type string   `json:"type"`
+	Enum []string `json:"enum,omitempty"`
+}
+
+// openAPIParameter is a parameter of an OpenAPI operation.
+type openAPIParameter struct {
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required,omitempty"`
+	Schema      openAPISchema `json:"schema"`
+}
+
+// openAPIResponse is a response of an OpenAPI operation.
+type openAPIResponse struct {
+	Description string `json:"description"`
+}
+
+// openAPIOperation is an operation of an OpenAPI document.
+type openAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Parameters  []*openAPIParameter        `json:"parameters,omitempty"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+// openAPISecurityScheme is a security scheme of an OpenAPI document.
+type openAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// openAPIComponents are the reusable components of an OpenAPI document.
+type openAPIComponents struct {
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+// openAPIDocument is an OpenAPI document describing the api.
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Servers    []openAPIServer                         `json:"servers"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+// apiParameters are the query parameters accepted by api operations.
+var apiParameters = map[string]*openAPIParameter{
+	"limit": {Name: "limit", In: "query", Schema: openAPISchema{Type: "integer"},
+		Description: "The number of entries listed, at most 500."},
+	"cursor": {Name: "cursor", In: "query", Schema: openAPISchema{Type: "string"},
+		Description: "The cursor of the page, returned by the previous page."},
+	"order": {Name: "order", In: "query",
+		Schema:      openAPISchema{Type: "string", Enum: []string{"asc", "desc"}},
+		Description: "The order of entries, descending by default."},
+	"from": {Name: "from", In: "query", Schema: openAPISchema{Type: "integer"},
+		Description: "The inclusive lower bound of the listing."},
+	"to": {Name: "to", In: "query", Schema: openAPISchema{Type: "integer"},
+		Description: "The exclusive upper bound of the listing."},
+	"resolution": {Name: "resolution", In: "query",
+		Schema: openAPISchema{Type: "string", Enum: []string{
+			dividend.Resolution5m, dividend.Resolution1h,
+			dividend.Resolution1d}},
+		Description: "The resolution of hash rate samples, 1h by default."},
+	"id": {Name: "id", In: "query", Required: true,
+		Schema: openAPISchema{Type: "string"}, Description: "The worker id."},
+	"query": {Name: "query", In: "query", Schema: openAPISchema{Type: "string"},
+		Description: "The GraphQL query, for GET requests."},
+	"private": {Name: "private", In: "query",
+		Schema:      openAPISchema{Type: "boolean"},
+		Description: "Only stream the events of the account."},
+	"type": {Name: "type", In: "query", Schema: openAPISchema{Type: "string"},
+		Description: "Only list entries of the type."},
+	"variables": {Name: "variables", In: "query",
+		Schema:      openAPISchema{Type: "string"},
+		Description: "The json encoded GraphQL variables, for GET requests."},
+}
+
+// apiOperationDoc documents an api operation.
+type apiOperationDoc struct {
+	summary string
+	params  []string
+	scope   string
+}
+
+// apiOperationDocs documents the api operations, keyed by method and path
+// relative to the api prefix. Routes missing from it are listed without a
+// summary.
+var apiOperationDocs = map[string]apiOperationDoc{
+	"GET /pool": {summary: "Pool hash rate, clients, blocks found, " +
+		"round effort and estimated time to find a block."},
+	"GET /info": {summary: "Payment scheme, fees, minimum payment, " +
+		"difficulties, stratum endpoints and regional stratum hosts of " +
+		"the pool."},
+	"GET /network": {summary: "Network difficulty and hash rate, the " +
+		"pool's share of the network hash rate and the next block subsidy."},
+	"GET /blocks": {summary: "A page of the blocks found by the pool.",
+		params: []string{"limit", "cursor", "order", "from", "to"}},
+	"GET /payouts": {summary: "A page of the payouts of the pool and the " +
+		"pending payouts.",
+		params: []string{"limit", "cursor", "order", "from", "to"}},
+	"GET /eventlog": {summary: "A page of the pool event log: blocks " +
+		"found, reorgs, payouts and backend disconnects.",
+		params: []string{"limit", "cursor", "order", "from", "to", "type"}},
+	"GET /hashrate": {summary: "Hash rate samples of the pool.",
+		params: []string{"resolution", "from", "to"}},
+	"GET /luck": {summary: "Effort and luck of the recent blocks found.",
+		params: []string{"limit"}},
+	"GET /leaderboard": {summary: "Accounts with the highest hash rates.",
+		params: []string{"limit"}},
+	"GET /feed":   {summary: "Websocket feed of live pool events."},
+	"GET /events": {summary: "Server-sent events stream of live pool events."},
+	"GET /graphql": {summary: "GraphQL query of pool and account data.",
+		params: []string{"query", "variables"}},
+	"POST /graphql":     {summary: "GraphQL query of pool and account data."},
+	"GET /openapi.json": {summary: "The OpenAPI document of the api."},
+	"GET /account": {summary: "Hash rate, clients and blocks found of the " +
+		"account.", scope: dividend.ScopeReadStats},
+	"GET /account/feed": {summary: "Websocket feed of live pool events " +
+		"and the events of the account.", params: []string{"private"},
+		scope: dividend.ScopeReadStats},
+	"GET /account/events": {summary: "Server-sent events stream of live " +
+		"pool events and the events of the account.",
+		params: []string{"private"}, scope: dividend.ScopeReadStats},
+	"GET /account/workers": {summary: "The workers of the account.",
+		scope: dividend.ScopeReadStats},
+	"GET /account/workers/detail": {summary: "The details of a worker of " +
+		"the account.", params: []string{"id"}, scope: dividend.ScopeReadStats},
+	"GET /account/hashrate": {summary: "Hash rate samples of the account.",
+		params: []string{"resolution", "from", "to"},
+		scope:  dividend.ScopeReadStats},
+	"GET /account/workers/hashrate": {summary: "Hash rate samples of a " +
+		"worker of the account.",
+		params: []string{"id", "resolution", "from", "to"},
+		scope:  dividend.ScopeReadStats},
+	"GET /account/blocks": {summary: "A page of the blocks mined by the " +
+		"account.", params: []string{"limit", "cursor", "order", "from", "to"},
+		scope: dividend.ScopeReadStats},
+	"GET /account/payments": {summary: "A page of the payments made to " +
+		"the account.",
+		params: []string{"limit", "cursor", "order", "from", "to"},
+		scope:  dividend.ScopeReadPayments},
+}
+
+// pathVariable matches the variables of route path templates.
+var pathVariable = regexp.MustCompile(`{([^}:]+)(:[^}]+)?}`)
+
+// apiOperation returns the OpenAPI operation of the provided api route.
+// Account routes require a session token or an api key.
+func apiOperation(method string, path string) *openAPIOperation {
+	doc := apiOperationDocs[method+" "+path]
+	op := &openAPIOperation{
+		Summary:    doc.summary,
+		Parameters: make([]*openAPIParameter, 0, len(doc.params)),
+		Responses: map[string]openAPIResponse{
+			"200":     {Description: "Success."},
+			"default": {Description: "An error, described by the `error` field."},
+		},
+	}
+
+	for _, name := range doc.params {
+		op.Parameters = append(op.Parameters, apiParameters[name])
+	}
+
+	for _, match := range pathVariable.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, &openAPIParameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   openAPISchema{Type: "string"},
+		})
+	}
+
+	if path == "/account" || strings.HasPrefix(path, "/account/") {
+		op.Security = []map[string][]string{
+			{"session": {}}, {"apikey": {}},
+		}
+		if doc.scope != "" {
+			op.Description = "Api keys require the `" + doc.scope +
+				"` scope."
+		}
+	}
+
+	return op
+}
+
+// openAPISpec returns the OpenAPI document of the api routes of the provided
+// router, OPTIONS routes are omitted.
+func openAPISpec(router *mux.Router) *openAPIDocument {
+	prefix := "/api/" + APIVersion
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title: "dcrpool api",
+			Description: "Pool statistics for third-party apps and pool " +
+				"lists. Hash rates are reported in " + hashRateUnit + ".",
+			Version: APIVersion,
+		},
+		Servers: []openAPIServer{{URL: prefix}},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			SecuritySchemes: map[string]openAPISecurityScheme{
+				"session": {Type: "http", Scheme: "bearer"},
+				"apikey":  {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+	}
+
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tmpl, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(tmpl, prefix+"/") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		path := strings.TrimPrefix(tmpl, prefix)
+		for _, method := range methods {
+			if method == http.MethodOptions {
+				continue
+			}
+
+			ops, ok := doc.Paths[path]
+			if !ok {
+				ops = make(map[string]*openAPIOperation)
+				doc.Paths[path] = ops
+			}
+			ops[strings.ToLower(method)] = apiOperation(method, path)
+		}
+
+		return nil
+	})
+
+	return doc
+}
+
+// OpenAPI returns a handler responding with the OpenAPI document of the api
+// routes of the provided router, generated from the routes it serves.
+func (h *Hub) OpenAPI(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		RespondWithJSON(w, http.StatusOK, openAPISpec(router))
+	}
+}
+
+// apiDocsPageData is the data rendered by the api documentation page.
+type apiDocsPageData struct {
+	SpecURL string
+}
+
+// APIDocsPage renders an interactive browser of the api, backed by its
+// OpenAPI document.
+func (h *Hub) APIDocsPage(w http.ResponseWriter, r *http.Request) {
+	data := apiDocsPageData{SpecURL: "/api/" + APIVersion + "/openapi.json"}
+	err := h.renderPage(w, r, apiDocsPage, data)
+	if err != nil {
+		log.Errorf("Failed to render api docs page: %v", err)
+	}
+}
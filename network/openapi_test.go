@@ -0,0 +1,57 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	handler := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/blocks", handler).Methods("GET")
+
+	api := router.PathPrefix("/api/" + APIVersion).Subrouter()
+	api.HandleFunc("/blocks", handler).Methods("GET")
+	api.HandleFunc("/graphql", handler).Methods("GET", "POST")
+	api.HandleFunc("/undocumented/{name}", handler).Methods("GET")
+	apiAcc := api.NewRoute().Subrouter()
+	apiAcc.HandleFunc("/account/payments", handler).Methods("GET")
+	api.PathPrefix("/").HandlerFunc(handler).Methods("OPTIONS")
+
+	doc := openAPISpec(api)
+	if len(doc.Paths) != 4 {
+		t.Fatalf("expected 4 paths, got %v", doc.Paths)
+	}
+
+	blocks := doc.Paths["/blocks"]["get"]
+	if blocks == nil || blocks.Summary == "" ||
+		len(blocks.Parameters) != 5 || blocks.Security != nil {
+		t.Fatalf("unexpected blocks operation %+v", blocks)
+	}
+
+	if len(doc.Paths["/graphql"]) != 2 || doc.Paths["/graphql"]["post"] == nil {
+		t.Fatalf("unexpected graphql operations %v", doc.Paths["/graphql"])
+	}
+
+	undocumented := doc.Paths["/undocumented/{name}"]["get"]
+	if undocumented == nil || len(undocumented.Parameters) != 1 ||
+		undocumented.Parameters[0].Name != "name" ||
+		undocumented.Parameters[0].In != "path" {
+		t.Fatalf("unexpected undocumented operation %+v", undocumented)
+	}
+
+	payments := doc.Paths["/account/payments"]["get"]
+	if payments == nil || len(payments.Security) != 2 ||
+		payments.Description != "Api keys require the `"+
+			dividend.ScopeReadPayments+"` scope." {
+		t.Fatalf("unexpected payments operation %+v", payments)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// Payout statuses.
+const (
+	payoutProvisional = "provisional"
+	payoutPending     = "pending"
+	payoutSigned      = "signed"
+	payoutBroadcast   = "broadcast"
+	payoutConfirmed   = "confirmed"
+)
+
+// poolPayout is a payout of the pool as listed publicly. Pending payouts are
+// the payments expected to mature at a height and have no transaction yet.
+type poolPayout struct {
+	TxHash        string         `json:"txhash,omitempty"`
+	Height        uint32         `json:"height"`
+	Accounts      int            `json:"accounts"`
+	Amount        dcrutil.Amount `json:"amount"`
+	FeeRate       dcrutil.Amount `json:"feerate,omitempty"`
+	Confirmations int64          `json:"confirmations"`
+	Status        string         `json:"status"`
+	CreatedOn     int64          `json:"createdon,omitempty"`
+	Explorer      string         `json:"explorer,omitempty"`
+}
+
+// pendingPayouts returns the pending payments of the pool grouped by their
+// estimated maturity height, soonest first. Groups of payments of found
+// blocks yet to be approved by votes are provisional.
+func (h *Hub) pendingPayouts() ([]*poolPayout, error) {
+	pending, err := dividend.FetchPendingPayments(h.db)
+	if err != nil {
+		return nil, err
+	}
+
+	byHeight := make(map[uint32]*poolPayout)
+	accounts := make(map[uint32]map[string]struct{})
+	for _, pmt := range pending {
+		payout, ok := byHeight[pmt.EstimatedMaturity]
+		if !ok {
+			payout = &poolPayout{
+				Height: pmt.EstimatedMaturity,
+				Status: payoutPending,
+			}
+			byHeight[pmt.EstimatedMaturity] = payout
+			accounts[pmt.EstimatedMaturity] = make(map[string]struct{})
+		}
+
+		payout.Amount += pmt.Amount
+		if pmt.Provisional {
+			payout.Status = payoutProvisional
+		}
+		accounts[pmt.EstimatedMaturity][pmt.Account] = struct{}{}
+	}
+
+	payouts := make([]*poolPayout, 0, len(byHeight))
+	for height, payout := range byHeight {
+		payout.Accounts = len(accounts[height])
+		payouts = append(payouts, payout)
+	}
+
+	sort.Slice(payouts, func(i, j int) bool {
+		return payouts[i].Height < payouts[j].Height
+	})
+
+	return payouts, nil
+}
+
+// poolPayouts returns the public listing of the provided payouts.
+func (h *Hub) poolPayouts(payouts []*dividend.Payout) []*poolPayout {
+	listed := make([]*poolPayout, 0, len(payouts))
+	for _, p := range payouts {
+		payout := &poolPayout{
+			TxHash:        p.TxHash,
+			Height:        p.Height,
+			Accounts:      p.Accounts,
+			Amount:        p.Amount,
+			FeeRate:       p.FeeRate,
+			Confirmations: p.Confirmations,
+			Status:        payoutBroadcast,
+			CreatedOn:     p.CreatedOn,
+		}
+
+		switch {
+		case p.Unpublished:
+			payout.Status = payoutSigned
+		case p.Confirmed():
+			payout.Status = payoutConfirmed
+		}
+
+		if h.cfg.ExplorerURL != "" {
+			payout.Explorer = h.cfg.ExplorerURL + "/tx/" + p.TxHash
+		}
+
+		listed = append(listed, payout)
+	}
+
+	return listed
+}
+
+// APIPayouts returns a page of the payouts of the pool, most recent first by
+// default, with their amounts, transaction hashes, confirmations and
+// statuses. The first page also lists the pending payouts.
+func (h *Hub) APIPayouts(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r, true)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	payouts, next, err := dividend.ListPayouts(h.db, query)
+	if err != nil {
+		respondWithListError(w, err)
+		return
+	}
+
+	pending := make([]*poolPayout, 0)
+	if query.Cursor == "" {
+		pending, err = h.pendingPayouts()
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"pending": pending,
+		"results": h.poolPayouts(payouts),
+		"next":    next,
+	})
+}
+
+// paymentsPageData is the data rendered by the payments page.
+type paymentsPageData struct {
+	Network string
+	Pending []*poolPayout
+	Payouts []*poolPayout
+	Next    string
+}
+
+// PaymentsPage renders a page of the payouts of the pool, most recent first,
+// accepting the pagination parameters of the payouts api.
+func (h *Hub) PaymentsPage(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePageQuery(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	payouts, next, err := dividend.ListPayouts(h.db, query)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if err.Error() == database.ErrInvalidCursor().Error() {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	data := paymentsPageData{
+		Network: h.cfg.ActiveNet.Name,
+		Payouts: h.poolPayouts(payouts),
+	}
+
+	if query.Cursor == "" {
+		data.Pending, err = h.pendingPayouts()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if next != "" {
+		values := r.URL.Query()
+		values.Set("cursor", next)
+		data.Next = (&url.URL{Path: r.URL.Path,
+			RawQuery: values.Encode()}).String()
+	}
+
+	err = h.renderPage(w, r, paymentsPage, data)
+	if err != nil {
+		paymentLog.Errorf("Failed to render payments page: %v", err)
+	}
+}
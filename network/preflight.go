@@ -0,0 +1,203 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/rpcclient"
+	"github.com/decred/dcrd/wire"
+	"github.com/decred/dcrwallet/rpc/walletrpc"
+)
+
+const (
+	// preflightTimeout is the time the wallet has to answer the preflight
+	// checks.
+	preflightTimeout = time.Second * 30
+
+	// preflightMessage is the message the wallet signs to prove it can
+	// sign payouts with the configured passphrase.
+	preflightMessage = "dcrpool preflight"
+)
+
+// checkDcrdNetwork asserts the active dcrd backend is on the active network.
+func (h *Hub) checkDcrdNetwork() error {
+	h.rpccMtx.Lock()
+	net, err := h.rpcc.GetCurrentNet()
+	h.rpccMtx.Unlock()
+	if err != nil {
+		return fmt.Errorf("unable to fetch the dcrd network: %v", err)
+	}
+
+	if net != h.cfg.ActiveNet.Net {
+		return fmt.Errorf("dcrd is on %v, expected %v", net,
+			h.cfg.ActiveNet.Net)
+	}
+
+	return nil
+}
+
+// checkWalletNetwork asserts the wallet is on the active network.
+func (h *Hub) checkWalletNetwork(ctx context.Context) error {
+	h.grpcMtx.Lock()
+	resp, err := h.grpc.Network(ctx, &walletrpc.NetworkRequest{})
+	h.grpcMtx.Unlock()
+	if err != nil {
+		return fmt.Errorf("unable to fetch the wallet network: %v", err)
+	}
+
+	if net := wire.CurrencyNet(resp.ActiveNetwork); net != h.cfg.ActiveNet.Net {
+		return fmt.Errorf("wallet is on %v, expected %v", net,
+			h.cfg.ActiveNet.Net)
+	}
+
+	return nil
+}
+
+// checkWalletSigning asserts the wallet is on the active network and signs
+// with the configured passphrase, signing a message with an address of the
+// payout account.
+func (h *Hub) checkWalletSigning(ctx context.Context) error {
+	err := h.checkWalletNetwork(ctx)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := h.walletPassphrase()
+	if err != nil {
+		return err
+	}
+	defer zeroBytes(passphrase)
+
+	addr, err := h.payoutChangeAddress(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to fetch an address of the payout "+
+			"account: %v", err)
+	}
+
+	h.grpcMtx.Lock()
+	_, err = h.grpc.SignMessage(ctx, &walletrpc.SignMessageRequest{
+		Address:    addr,
+		Message:    preflightMessage,
+		Passphrase: passphrase,
+	})
+	h.grpcMtx.Unlock()
+	if err != nil {
+		return fmt.Errorf("wallet is unable to sign with the configured "+
+			"passphrase: %v", err)
+	}
+
+	return nil
+}
+
+// checkFeeAddrs asserts the provided pool fee addresses are valid for the
+// provided network.
+func checkFeeAddrs(addrs []dcrutil.Address, net *chaincfg.Params) error {
+	if len(addrs) == 0 {
+		return fmt.Errorf("no pool fee addresses set")
+	}
+
+	for _, addr := range addrs {
+		if !addr.IsForNet(net) {
+			return fmt.Errorf("pool fee address %v is not a %v address",
+				addr, net.Name)
+		}
+	}
+
+	return nil
+}
+
+// preflight verifies the pool's dependencies before miners are accepted: the
+// database is writable, the active dcrd backend is on the active network and
+// synced and, when payments are processed, the pool fee addresses are valid
+// and the wallet is on the active network and can sign payouts. All failing
+// checks are reported.
+func (h *Hub) preflight() error {
+	failures := make([]string, 0)
+	fail := func(name string, err error) {
+		failures = append(failures, fmt.Sprintf("%v: %v", name, err))
+	}
+
+	if check := h.checkDB(); !check.OK {
+		fail("db", fmt.Errorf("database is not writable: %v", check.Error))
+	}
+
+	host := h.backends[0].cfg.Host
+	if err := h.checkDcrdNetwork(); err != nil {
+		fail(fmt.Sprintf("dcrd (%v)", host), err)
+	} else if check := h.checkDcrd(); !check.OK {
+		fail(fmt.Sprintf("dcrd (%v)", host), fmt.Errorf("%v", check.Error))
+	}
+
+	if !h.cfg.SoloPool {
+		if err := checkFeeAddrs(h.cfg.PoolFeeAddrs, h.cfg.ActiveNet); err != nil {
+			fail("pool fee", err)
+		}
+
+		ctx, cancel := context.WithTimeout(h.ctx, preflightTimeout)
+		err := h.checkWalletSigning(ctx)
+		cancel()
+		if err != nil {
+			fail(fmt.Sprintf("wallet (%v)", h.cfg.WalletGRPCHost), err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("preflight checks failed: %v",
+			strings.Join(failures, "; "))
+	}
+
+	log.Infof("Preflight checks passed.")
+
+	return nil
+}
+
+// CheckBackends asserts the dcrd backends and, when payments are processed,
+// the wallet of the provided config are reachable and on the active network,
+// for validating configurations without starting the pool. All failing
+// backends are reported.
+func CheckBackends(ctx context.Context, cfg *HubConfig) error {
+	h := &Hub{cfg: cfg}
+	failures := make([]string, 0)
+	cfgs := append([]*rpcclient.ConnConfig{cfg.DcrdRPCCfg},
+		cfg.DcrdBackupCfgs...)
+	for _, dcrdCfg := range cfgs {
+		client, err := rpcclient.New(dcrdCfg, nil)
+		if err == nil {
+			h.rpcc = client
+			err = h.checkDcrdNetwork()
+			client.Shutdown()
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("dcrd (%v): %v",
+				dcrdCfg.Host, err))
+		}
+	}
+
+	if !cfg.SoloPool {
+		conn, err := h.dialWallet()
+		if err == nil {
+			h.grpc = walletrpc.NewWalletServiceClient(conn)
+			err = h.checkWalletNetwork(ctx)
+			conn.Close()
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("wallet (%v): %v",
+				cfg.WalletGRPCHost, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("backend checks failed: %v",
+			strings.Join(failures, "; "))
+	}
+
+	return nil
+}
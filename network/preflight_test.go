@@ -0,0 +1,34 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+)
+
+func TestCheckFeeAddrs(t *testing.T) {
+	addr, err := dcrutil.DecodeAddress("DsXete8zpkHZXGd4vcxhz4rPqmEqydC9u5E")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = checkFeeAddrs([]dcrutil.Address{addr}, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = checkFeeAddrs([]dcrutil.Address{addr}, &chaincfg.TestNet3Params)
+	if err == nil {
+		t.Fatal("expected an error checking a mainnet address on testnet")
+	}
+
+	err = checkFeeAddrs(nil, &chaincfg.MainNetParams)
+	if err == nil {
+		t.Fatal("expected an error checking no fee addresses")
+	}
+}
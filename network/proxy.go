@@ -0,0 +1,68 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/dnldd/dcrpool/util"
+)
+
+// clientIP returns the ip address of the client of the provided request.
+// Requests relayed by one of the provided trusted proxies are attributed to
+// the nearest untrusted address of their X-Forwarded-For header, or to their
+// X-Real-IP header without one. The forwarding headers of other requests are
+// ignored since clients can set them at will.
+func clientIP(proxies []*net.IPNet, r *http.Request) string {
+	ip := remoteIP(r)
+	if len(proxies) == 0 {
+		return ip
+	}
+
+	peer := net.ParseIP(ip)
+	if peer == nil || !util.ContainsIP(proxies, peer) {
+		return ip
+	}
+
+	// Each proxy appends the address it received the request from, the
+	// addresses are walked from the nearest until one is not a trusted
+	// proxy.
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		addrs := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(addrs) - 1; i >= 0; i-- {
+			addr := net.ParseIP(strings.TrimSpace(addrs[i]))
+			if addr == nil {
+				break
+			}
+
+			ip = addr.String()
+			if !util.ContainsIP(proxies, addr) {
+				break
+			}
+		}
+		return ip
+	}
+
+	if addr := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); addr != nil {
+		return addr.String()
+	}
+
+	return ip
+}
+
+// RealIP wraps attributing requests relayed by trusted reverse proxies to
+// their clients as request middleware. The remote address of such requests
+// is replaced by the address of their client, so rate limiting, bans and
+// logs apply to it.
+func (h *Hub) RealIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(h.cfg.TrustedProxies) > 0 {
+			r.RemoteAddr = clientIP(h.cfg.TrustedProxies, r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
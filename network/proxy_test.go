@@ -0,0 +1,51 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnldd/dcrpool/util"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := util.ParseCIDRs([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		realIP    string
+		want      string
+	}{
+		{"direct", "1.2.3.4:1234", "", "", "1.2.3.4"},
+		{"untrusted peer", "1.2.3.4:1234", "5.6.7.8", "5.6.7.8", "1.2.3.4"},
+		{"forwarded", "10.0.0.1:1234", "5.6.7.8", "", "5.6.7.8"},
+		{"proxy chain", "10.0.0.1:1234", "5.6.7.8, 9.9.9.9, 10.0.0.2", "",
+			"9.9.9.9"},
+		{"only proxies", "10.0.0.1:1234", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"},
+		{"real ip", "10.0.0.1:1234", "", "5.6.7.8", "5.6.7.8"},
+		{"invalid", "10.0.0.1:1234", "", "xxx", "10.0.0.1"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = test.remote
+		if test.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		if test.realIP != "" {
+			r.Header.Set("X-Real-IP", test.realIP)
+		}
+
+		if ip := clientIP(proxies, r); ip != test.want {
+			t.Fatalf("%v: expected %v, got %v", test.name, test.want, ip)
+		}
+	}
+}
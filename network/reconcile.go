@@ -0,0 +1,354 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrwallet/rpc/walletrpc"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+const (
+	// reconcileInterval is the interval between reconciliations of the
+	// payments of the pool against the chain.
+	reconcileInterval = time.Hour
+
+	// reconcileDepth is the number of recent blocks reconciled.
+	reconcileDepth = 4032
+
+	// payoutConfirmGrace is the number of blocks a payout transaction is
+	// given to confirm before it is reported.
+	payoutConfirmGrace = 12
+)
+
+// Payment reconciliation issue kinds.
+const (
+	// issueNoPayout reports payments marked paid at a height without a
+	// payout recorded at the height.
+	issueNoPayout = "nopayout"
+
+	// issueUnconfirmed reports payouts whose transaction is unknown to dcrd
+	// or did not confirm.
+	issueUnconfirmed = "unconfirmed"
+
+	// issueUnrecorded reports wallet transactions spending the funds of the
+	// payout account without a payout recorded for them.
+	issueUnrecorded = "unrecorded"
+)
+
+// reconcileIssue is a discrepancy between the payments recorded by the pool
+// and the chain.
+type reconcileIssue struct {
+	Kind   string         `json:"kind"`
+	TxHash string         `json:"txhash,omitempty"`
+	Height uint32         `json:"height"`
+	Amount dcrutil.Amount `json:"amount"`
+	Detail string         `json:"detail"`
+}
+
+// reconciliation is the outcome of reconciling the payments of the pool
+// against the chain, from the provided height to the chain tip.
+type reconciliation struct {
+	FromHeight uint32           `json:"fromheight"`
+	Height     uint32           `json:"height"`
+	Issues     []reconcileIssue `json:"issues"`
+	CreatedOn  int64            `json:"createdon"`
+}
+
+// reconcilePaidPayments reports the provided paid payments marked paid at a
+// height without a payout recorded at the height.
+func reconcilePaidPayments(paid []*dividend.Payment, payouts []*dividend.Payout) []reconcileIssue {
+	recorded := make(map[uint32]struct{}, len(payouts))
+	for _, payout := range payouts {
+		recorded[payout.Height] = struct{}{}
+	}
+
+	missing := make(map[uint32]*reconcileIssue)
+	heights := make([]uint32, 0)
+	for _, pmt := range paid {
+		if _, ok := recorded[pmt.PaidOnHeight]; ok {
+			continue
+		}
+
+		issue, ok := missing[pmt.PaidOnHeight]
+		if !ok {
+			issue = &reconcileIssue{
+				Kind:   issueNoPayout,
+				Height: pmt.PaidOnHeight,
+			}
+			missing[pmt.PaidOnHeight] = issue
+			heights = append(heights, pmt.PaidOnHeight)
+		}
+		issue.Amount += pmt.Amount
+	}
+
+	issues := make([]reconcileIssue, 0, len(heights))
+	for _, height := range heights {
+		issue := missing[height]
+		issue.Detail = fmt.Sprintf("payments of %v marked paid at height "+
+			"%v without a payout transaction", issue.Amount, height)
+		issues = append(issues, *issue)
+	}
+
+	return issues
+}
+
+// reconcilePayouts reports the provided payouts published before the grace
+// period as of the provided tip whose transaction is unknown to dcrd or
+// unconfirmed.
+func (h *Hub) reconcilePayouts(payouts []*dividend.Payout, tip uint32) []reconcileIssue {
+	issues := make([]reconcileIssue, 0)
+	for _, payout := range payouts {
+		if payout.Height+payoutConfirmGrace > tip {
+			continue
+		}
+
+		issue := reconcileIssue{
+			Kind:   issueUnconfirmed,
+			TxHash: payout.TxHash,
+			Height: payout.Height,
+			Amount: payout.Amount,
+		}
+
+		txHash, err := chainhash.NewHashFromStr(payout.TxHash)
+		if err != nil {
+			issue.Detail = fmt.Sprintf("invalid payout tx hash: %v", err)
+			issues = append(issues, issue)
+			continue
+		}
+
+		h.rpccMtx.Lock()
+		tx, err := h.rpcc.GetRawTransactionVerbose(txHash)
+		h.rpccMtx.Unlock()
+		if err != nil {
+			issue.Detail = fmt.Sprintf("payout tx not found: %v", err)
+			issues = append(issues, issue)
+			continue
+		}
+
+		if tx.Confirmations < 1 {
+			issue.Detail = fmt.Sprintf("payout tx unconfirmed %v blocks "+
+				"after it was published", tip-payout.Height)
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+// reconcileWalletTxs reports the wallet transactions mined from the
+// provided height that spend the funds of the payout account without a
+// recorded payout.
+func (h *Hub) reconcileWalletTxs(ctx context.Context, from uint32, payouts []*dividend.Payout) ([]reconcileIssue, error) {
+	recorded := make(map[string]struct{}, len(payouts))
+	for _, payout := range payouts {
+		recorded[payout.TxHash] = struct{}{}
+	}
+
+	h.grpcMtx.Lock()
+	defer h.grpcMtx.Unlock()
+	stream, err := h.grpc.GetTransactions(ctx, &walletrpc.GetTransactionsRequest{
+		StartingBlockHeight: int32(from),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]reconcileIssue, 0)
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		block := resp.MinedTransactions
+		if block == nil {
+			continue
+		}
+
+		for _, tx := range block.Transactions {
+			if tx.TransactionType != walletrpc.TransactionDetails_REGULAR {
+				continue
+			}
+
+			var spent int64
+			for _, debit := range tx.Debits {
+				if debit.PreviousAccount == h.payoutAcct {
+					spent += debit.PreviousAmount
+				}
+			}
+			if spent == 0 {
+				continue
+			}
+
+			for _, credit := range tx.Credits {
+				spent -= credit.Amount
+			}
+
+			hash, err := chainhash.NewHash(tx.Hash)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, ok := recorded[hash.String()]; ok {
+				continue
+			}
+
+			issues = append(issues, reconcileIssue{
+				Kind:   issueUnrecorded,
+				TxHash: hash.String(),
+				Height: uint32(block.Height),
+				Amount: dcrutil.Amount(spent),
+				Detail: fmt.Sprintf("tx spending %v of payout account funds "+
+					"without a payout record", dcrutil.Amount(spent)),
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// reconcilePayments cross-checks the payments and payouts of the recent
+// blocks recorded by the pool against the chain and the wallet.
+func (h *Hub) reconcilePayments(ctx context.Context) (*reconciliation, error) {
+	// Payouts are recorded once published, reconciling while a payout is
+	// in progress would report it.
+	h.paymentMtx.Lock()
+	defer h.paymentMtx.Unlock()
+
+	h.rpccMtx.Lock()
+	tipHeight, err := h.rpcc.GetBlockCount()
+	h.rpccMtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	tip := uint32(tipHeight)
+	var from uint32
+	if tip > reconcileDepth {
+		from = tip - reconcileDepth
+	}
+
+	payouts, err := dividend.FilterPayouts(h.db,
+		func(payout *dividend.Payout) bool {
+			return payout.Height >= from
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	paid, err := dividend.FilterArchivedPayments(h.db,
+		func(pmt *dividend.Payment) bool {
+			return pmt.PaidOnHeight >= from
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	issues := reconcilePaidPayments(paid, payouts)
+	issues = append(issues, h.reconcilePayouts(payouts, tip)...)
+
+	unrecorded, err := h.reconcileWalletTxs(ctx, from, payouts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch wallet transactions: %v", err)
+	}
+	issues = append(issues, unrecorded...)
+
+	return &reconciliation{
+		FromHeight: from,
+		Height:     tip,
+		Issues:     issues,
+		CreatedOn:  time.Now().Unix(),
+	}, nil
+}
+
+// runReconciliation reconciles the payments of the pool and keeps the
+// outcome for operators, issues found are logged to the pool event log.
+func (h *Hub) runReconciliation(ctx context.Context) (*reconciliation, error) {
+	rec, err := h.reconcilePayments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h.reconcileMtx.Lock()
+	h.reconciled = rec
+	h.reconcileMtx.Unlock()
+
+	if len(rec.Issues) == 0 {
+		paymentLog.Debugf("Reconciled payments of blocks %v to %v", rec.FromHeight,
+			rec.Height)
+		return rec, nil
+	}
+
+	for _, issue := range rec.Issues {
+		paymentLog.Warnf("Payment reconciliation (%v): %v", issue.Kind, issue.Detail)
+	}
+
+	h.logPoolEvent(dividend.PoolEventReconciliation,
+		fmt.Sprintf("%v payment reconciliation issues in blocks %v to %v",
+			len(rec.Issues), rec.FromHeight, rec.Height), rec, "")
+
+	return rec, nil
+}
+
+// handleReconciliation periodically reconciles the payments of the pool
+// against the chain. It must be run as a goroutine.
+func (h *Hub) handleReconciliation(ctx context.Context) {
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+	h.wg.Add(1)
+	paymentLog.Trace("Started reconciliation handler.")
+
+	for {
+		select {
+		case <-ctx.Done():
+			paymentLog.Trace("Reconciliation handler done.")
+			h.wg.Done()
+			return
+		case <-ticker.C:
+			if h.walletUnreachable() {
+				continue
+			}
+
+			_, err := h.runReconciliation(ctx)
+			if err != nil {
+				paymentLog.Errorf("Failed to reconcile payments: %v", err)
+			}
+		}
+	}
+}
+
+// Reconciliation handles operator requests reconciling the payments of the
+// pool against the chain: payments marked paid without a payout, payouts
+// whose transaction did not confirm and wallet transactions spending payout
+// account funds without a payout record.
+func (h *Hub) Reconciliation(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.SoloPool {
+		RespondWithError(w, http.StatusBadRequest,
+			"payment processing is disabled in solo pool mode")
+		return
+	}
+
+	rec, err := h.runReconciliation(r.Context())
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	paymentLog.Infof("Payments reconciled by request of %v", requestOperator(r))
+
+	RespondWithJSON(w, http.StatusOK, rec)
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+func TestReconcilePaidPayments(t *testing.T) {
+	payouts := []*dividend.Payout{
+		dividend.NewPayout("a", 100, 2, dcrutil.Amount(3e8), 1e4),
+	}
+
+	paid := make([]*dividend.Payment, 0)
+	for _, height := range []uint32{100, 100, 120, 120, 130} {
+		pmt := dividend.NewPayment("x", dcrutil.Amount(1e8), 90, 95)
+		pmt.PaidOnHeight = height
+		paid = append(paid, pmt)
+	}
+
+	issues := reconcilePaidPayments(paid, payouts)
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %v", len(issues))
+	}
+
+	if issues[0].Kind != issueNoPayout || issues[0].Height != 120 ||
+		issues[0].Amount != dcrutil.Amount(2e8) {
+		t.Fatalf("unexpected issue at height 120: %+v", issues[0])
+	}
+
+	if issues[1].Height != 130 || issues[1].Amount != dcrutil.Amount(1e8) {
+		t.Fatalf("unexpected issue at height 130: %+v", issues[1])
+	}
+}
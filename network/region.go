@@ -0,0 +1,87 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Region is a regional stratum host of the pool advertised to miners.
+type Region struct {
+	Name string
+	Host string
+}
+
+// ParseRegions parses regional stratum hosts of the form <name>=<host>.
+// Region names must be unique.
+func ParseRegions(entries []string) ([]*Region, error) {
+	regions := make([]*Region, 0, len(entries))
+	names := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid region %q, expected "+
+				"<name>=<host>", entry)
+		}
+
+		name := strings.TrimSpace(parts[0])
+		host := strings.TrimSpace(parts[1])
+		if name == "" || host == "" {
+			return nil, fmt.Errorf("invalid region %q, expected "+
+				"<name>=<host>", entry)
+		}
+
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("duplicate region %q", name)
+		}
+		names[name] = struct{}{}
+
+		regions = append(regions, &Region{Name: name, Host: host})
+	}
+
+	return regions, nil
+}
+
+// advertisedRegion returns whether the region of the provided name is
+// advertised by the pool.
+func (h *Hub) advertisedRegion(name string) bool {
+	for _, region := range h.cfg.Regions {
+		if region.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// regionInfo describes a regional stratum host of the pool.
+type regionInfo struct {
+	Name        string `json:"name"`
+	Host        string `json:"host"`
+	Connections int    `json:"connections"`
+}
+
+// regionInfo returns the advertised regional stratum hosts of the pool with
+// the number of clients connected through each, in configuration order.
+func (h *Hub) regionInfo() []*regionInfo {
+	connections := make(map[string]int)
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		connections[endpoint.region] += len(endpoint.clients)
+		endpoint.clientsMtx.Unlock()
+	}
+
+	regions := make([]*regionInfo, 0, len(h.cfg.Regions))
+	for _, region := range h.cfg.Regions {
+		regions = append(regions, &regionInfo{
+			Name:        region.Name,
+			Host:        region.Host,
+			Connections: connections[region.Name],
+		})
+	}
+
+	return regions
+}
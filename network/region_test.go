@@ -0,0 +1,67 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+func TestParseRegions(t *testing.T) {
+	regions, err := ParseRegions([]string{"eu=eu.pool.example.com",
+		" us = us.pool.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(regions) != 2 || regions[1].Name != "us" ||
+		regions[1].Host != "us.pool.example.com" {
+		t.Fatalf("unexpected regions parsed: %v", regions)
+	}
+
+	for _, entries := range [][]string{
+		{"eu"},
+		{"=eu.pool.example.com"},
+		{"eu="},
+		{"eu=eu.pool.example.com", "eu=eu2.pool.example.com"},
+	} {
+		_, err := ParseRegions(entries)
+		if err == nil {
+			t.Errorf("expected an error parsing %v", entries)
+		}
+	}
+}
+
+func TestRegionInfo(t *testing.T) {
+	h := &Hub{
+		cfg: &HubConfig{
+			Regions: []*Region{
+				{Name: "eu", Host: "eu.pool.example.com"},
+				{Name: "us", Host: "us.pool.example.com"},
+			},
+		},
+		frontends: make(map[string]*frontend),
+	}
+	h.endpoints = []*Endpoint{{
+		miner:  dividend.CPU,
+		region: "eu",
+		clients: map[string]*Client{
+			"a": {},
+			"b": {},
+		},
+	}}
+
+	regions := h.regionInfo()
+	if len(regions) != 2 || regions[0].Connections != 2 ||
+		regions[1].Connections != 0 {
+		t.Fatalf("unexpected region connections: %v, %v",
+			regions[0].Connections, regions[1].Connections)
+	}
+
+	if !h.advertisedRegion("us") || h.advertisedRegion("asia") {
+		t.Fatal("unexpected advertised regions")
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+
+	"github.com/decred/dcrd/dcrutil"
+
+	"github.com/dnldd/dcrpool/dividend"
+	"github.com/dnldd/dcrpool/util"
+)
+
+// ReloadableConfig is the configuration of the pool that can be reloaded
+// while it runs, without restarting the pool and disconnecting its clients.
+type ReloadableConfig struct {
+	MaxGenTime *big.Int
+	MinPayment dcrutil.Amount
+	BannedNets []*net.IPNet
+}
+
+// maxGenTime returns the configured share creation target time.
+func (h *Hub) maxGenTime() *big.Int {
+	h.cfgMtx.RLock()
+	defer h.cfgMtx.RUnlock()
+	return h.cfg.MaxGenTime
+}
+
+// minPayment returns the configured minimum payment.
+func (h *Hub) minPayment() dcrutil.Amount {
+	h.cfgMtx.RLock()
+	defer h.cfgMtx.RUnlock()
+	return h.cfg.MinPayment
+}
+
+// configBanned returns whether the provided ip address belongs to the
+// networks banned by configuration.
+func (h *Hub) configBanned(ip string) bool {
+	h.cfgMtx.RLock()
+	defer h.cfgMtx.RUnlock()
+	return util.ContainsIP(h.cfg.BannedNets, net.ParseIP(ip))
+}
+
+// disconnectBanned disconnects the clients of the addresses banned by
+// configuration, returning the number of clients disconnected.
+func (h *Hub) disconnectBanned() uint32 {
+	var disconnected uint32
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			if h.configBanned(hostIP(client.ip)) {
+				client.cancel()
+				disconnected++
+			}
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	return disconnected
+}
+
+// updateDifficultyData recalculates the pool difficulties of the known
+// miners from the share creation target time and assigns them to the
+// endpoints. Clients connecting from then on mine at the new difficulties,
+// connected clients keep theirs until they reconnect.
+func (h *Hub) updateDifficultyData() error {
+	err := h.GenerateDifficultyData()
+	if err != nil {
+		return err
+	}
+
+	h.poolDiffMtx.Lock()
+	for _, endpoint := range h.endpoints {
+		if diffData, ok := h.poolDiff[endpoint.miner]; ok {
+			endpoint.diffData = diffData
+		}
+	}
+	h.poolDiffMtx.Unlock()
+
+	return nil
+}
+
+// Reload re-reads the reloadable configuration and applies it: the share
+// creation target time the pool difficulties are derived from, the minimum
+// payment and the banned networks. Clients of newly banned addresses are
+// disconnected. The log level is applied when the configuration is read.
+func (h *Hub) Reload(actor string) error {
+	if h.cfg.ReadConfig == nil {
+		return fmt.Errorf("configuration reloading is not supported")
+	}
+
+	rc, err := h.cfg.ReadConfig()
+	if err != nil {
+		return err
+	}
+
+	h.cfgMtx.Lock()
+	genTimeChanged := h.cfg.MaxGenTime.Cmp(rc.MaxGenTime) != 0
+	h.cfg.MaxGenTime = rc.MaxGenTime
+	h.cfg.MinPayment = rc.MinPayment
+	h.cfg.BannedNets = rc.BannedNets
+	h.cfgMtx.Unlock()
+
+	if genTimeChanged && !h.cfg.SoloPool {
+		err := h.updateDifficultyData()
+		if err != nil {
+			return fmt.Errorf("unable to update pool difficulties: %v", err)
+		}
+	}
+
+	disconnected := h.disconnectBanned()
+
+	log.Infof("Configuration reloaded: share target time %vs, minimum "+
+		"payment %v, %v banned networks, %v clients disconnected",
+		rc.MaxGenTime, rc.MinPayment, len(rc.BannedNets), disconnected)
+
+	h.logPoolEvent(dividend.PoolEventConfigReload, "configuration reloaded",
+		map[string]interface{}{
+			"maxgentime":   rc.MaxGenTime.Uint64(),
+			"minpayment":   rc.MinPayment.ToCoin(),
+			"bannednets":   len(rc.BannedNets),
+			"disconnected": disconnected,
+		}, actor)
+
+	return nil
+}
+
+// ReloadConfig handles operator requests to reload the configuration of
+// the pool, as on SIGHUP.
+func (h *Hub) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	err := h.Reload(requestOperator(r))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.cfgMtx.RLock()
+	resp := map[string]interface{}{
+		"maxgentime": h.cfg.MaxGenTime.Uint64(),
+		"minpayment": h.cfg.MinPayment.ToCoin(),
+		"bannednets": len(h.cfg.BannedNets),
+	}
+	h.cfgMtx.RUnlock()
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dnldd/dcrpool/util"
+)
+
+func TestConfigBanned(t *testing.T) {
+	nets, err := util.ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	h := &Hub{cfg: &HubConfig{BannedNets: nets}}
+	for ip, banned := range map[string]bool{
+		"10.1.2.3":    true,
+		"192.168.1.5": true,
+		"192.168.1.6": false,
+		"invalid":     false,
+	} {
+		if h.configBanned(ip) != banned {
+			t.Fatalf("%v: expected banned %v", ip, banned)
+		}
+	}
+}
+
+func TestReloadInvalidConfig(t *testing.T) {
+	// Configuration that fails to load is not applied.
+	h := &Hub{cfg: &HubConfig{
+		ReadConfig: func() (*ReloadableConfig, error) {
+			return nil, errors.New("invalid banned address")
+		},
+	}}
+	err := h.Reload("")
+	if err == nil {
+		t.Fatal("expected an error reloading invalid configuration")
+	}
+}
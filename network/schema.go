@@ -0,0 +1,355 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/dnldd/dcrpool/dividend"
+	"github.com/dnldd/dcrpool/util"
+)
+
+const (
+	// maxGraphQLRequestSize is the maximum size of a graphql request body.
+	maxGraphQLRequestSize = 1 << 16
+)
+
+// gqlRequest is a graphql request.
+type gqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// gqlValue returns a resolver of a fixed value.
+func gqlValue(v interface{}) gqlResolver {
+	return func(map[string]interface{}) (interface{}, error) {
+		return v, nil
+	}
+}
+
+// gqlBlock returns the graphql object of a block found by the pool.
+func gqlBlock(work *AcceptedWork) *gqlObject {
+	return &gqlObject{
+		typeName: "Block",
+		fields: map[string]gqlResolver{
+			"height":    gqlValue(work.Height),
+			"blockHash": gqlValue(work.BlockHash),
+			"prevHash":  gqlValue(work.PrevHash),
+			"minedBy":   gqlValue(work.MinedBy),
+			"miner":     gqlValue(work.Miner),
+			"confirmed": gqlValue(work.Confirmed),
+		},
+	}
+}
+
+// gqlBlocks returns the graphql objects of the provided blocks, most recent
+// first. The number of blocks returned can be limited.
+func gqlBlocks(work []*AcceptedWork, args map[string]interface{}) ([]*gqlObject, error) {
+	limit, err := intArg(args, "limit", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit < 0 {
+		return nil, fmt.Errorf("argument 'limit' must not be negative")
+	}
+
+	blocks := make([]*gqlObject, 0, len(work))
+	for i := len(work) - 1; i >= 0; i-- {
+		if limit > 0 && int64(len(blocks)) == limit {
+			break
+		}
+		blocks = append(blocks, gqlBlock(work[i]))
+	}
+
+	return blocks, nil
+}
+
+// gqlPool returns the graphql object of the pool.
+func (h *Hub) gqlPool() *gqlObject {
+	minedWork := func() ([]*AcceptedWork, error) {
+		return ListMinedWork(h.db)
+	}
+
+	pool := &gqlObject{
+		typeName: "Pool",
+		fields: map[string]gqlResolver{
+			"network":      gqlValue(h.cfg.ActiveNet.Name),
+			"soloPool":     gqlValue(h.cfg.SoloPool),
+			"hashrateUnit": gqlValue(hashRateUnit),
+			"hashrate": func(map[string]interface{}) (interface{}, error) {
+				return h.hashRate("").FloatString(12), nil
+			},
+			"connections": func(map[string]interface{}) (interface{}, error) {
+				connections := 0
+				for _, endpoint := range h.allEndpoints() {
+					endpoint.clientsMtx.Lock()
+					connections += len(endpoint.clients)
+					endpoint.clientsMtx.Unlock()
+				}
+				return connections, nil
+			},
+			"lastWorkHeight": func(map[string]interface{}) (interface{}, error) {
+				return atomic.LoadUint32(&h.lastWorkHeight), nil
+			},
+			"blocksFound": func(map[string]interface{}) (interface{}, error) {
+				work, err := minedWork()
+				if err != nil {
+					return nil, err
+				}
+				return len(work), nil
+			},
+			"lastBlock": func(map[string]interface{}) (interface{}, error) {
+				work, err := minedWork()
+				if err != nil {
+					return nil, err
+				}
+				if len(work) == 0 {
+					return (*gqlObject)(nil), nil
+				}
+				return gqlBlock(work[len(work)-1]), nil
+			},
+		},
+	}
+
+	if !h.cfg.SoloPool {
+		pool.fields["paymentMethod"] = gqlValue(h.cfg.PaymentMethod)
+		pool.fields["poolFee"] = gqlValue(h.cfg.PoolFee)
+		pool.fields["lastPaymentHeight"] =
+			func(map[string]interface{}) (interface{}, error) {
+				return atomic.LoadUint32(&h.lastPaymentHeight), nil
+			}
+	}
+
+	return pool
+}
+
+// gqlWorker returns the graphql object of a worker.
+func gqlWorker(worker *dividend.Worker, connected bool) *gqlObject {
+	hashRate := "0"
+	if worker.HashRate != nil {
+		hashRate = worker.HashRate.FloatString(12)
+	}
+
+	difficulty := "0"
+	if worker.Difficulty != nil {
+		difficulty = worker.Difficulty.String()
+	}
+
+	ratios := worker.ShareRatios()
+	now := time.Now().Unix()
+	uptime := workerUptime(worker, now, connected)
+	return &gqlObject{
+		typeName: "Worker",
+		fields: map[string]gqlResolver{
+			"id":             gqlValue(worker.UUID),
+			"name":           gqlValue(worker.Name),
+			"createdOn":      gqlValue(worker.CreatedOn),
+			"lastShareOn":    gqlValue(worker.LastShareOn),
+			"hashrate":       gqlValue(hashRate),
+			"difficulty":     gqlValue(difficulty),
+			"connected":      gqlValue(connected),
+			"acceptedShares": gqlValue(worker.AcceptedShares),
+			"staleShares":    gqlValue(worker.StaleShares),
+			"invalidShares":  gqlValue(worker.InvalidShares),
+			"acceptedRatio":  gqlValue(ratios.Accepted),
+			"staleRatio":     gqlValue(ratios.Stale),
+			"invalidRatio":   gqlValue(ratios.Invalid),
+			"lastSeen":       gqlValue(worker.LastSeen(now, connected)),
+			"uptime24h":      gqlValue(uptime["24h"]),
+			"uptime7d":       gqlValue(uptime["7d"]),
+		},
+	}
+}
+
+// gqlPayment returns the graphql object of a payment.
+func gqlPayment(payment *dividend.Payment) *gqlObject {
+	return &gqlObject{
+		typeName: "Payment",
+		fields: map[string]gqlResolver{
+			"height":            gqlValue(payment.Height),
+			"amount":            gqlValue(payment.Amount.ToCoin()),
+			"createdOn":         gqlValue(payment.CreatedOn),
+			"estimatedMaturity": gqlValue(payment.EstimatedMaturity),
+			"paidOnHeight":      gqlValue(payment.PaidOnHeight),
+		},
+	}
+}
+
+// gqlAccount returns the graphql object of the provided account. Payments
+// are only resolved for api keys with the payments scope.
+func (h *Hub) gqlAccount(account *dividend.Account, key *dividend.APIKey) *gqlObject {
+	return &gqlObject{
+		typeName: "Account",
+		fields: map[string]gqlResolver{
+			"id":           gqlValue(account.UUID),
+			"name":         gqlValue(account.Name),
+			"address":      gqlValue(account.Address),
+			"createdOn":    gqlValue(account.CreatedOn),
+			"hashrateUnit": gqlValue(hashRateUnit),
+			"hashrate": func(map[string]interface{}) (interface{}, error) {
+				return h.hashRate(account.UUID).FloatString(12), nil
+			},
+			"workers": func(map[string]interface{}) (interface{}, error) {
+				workers, err := dividend.ListWorkers(h.db, account.UUID)
+				if err != nil {
+					return nil, err
+				}
+
+				connected := h.connectedWorkers()
+				objs := make([]*gqlObject, 0, len(workers))
+				for _, worker := range workers {
+					objs = append(objs, gqlWorker(worker,
+						connected[worker.UUID]))
+				}
+				return objs, nil
+			},
+			"blocks": func(args map[string]interface{}) (interface{}, error) {
+				work, err := ListMinedWorkByAccount(h.db, account.UUID)
+				if err != nil {
+					return nil, err
+				}
+				return gqlBlocks(work, args)
+			},
+			"payments": func(args map[string]interface{}) (interface{}, error) {
+				if key != nil && !key.HasScope(dividend.ScopeReadPayments) {
+					return nil, fmt.Errorf("api key lacks the '%v' scope",
+						dividend.ScopeReadPayments)
+				}
+
+				min, err := intArg(args, "min", 0)
+				if err != nil {
+					return nil, err
+				}
+
+				minNano := util.NanoToBigEndianBytes(
+					time.Unix(min, 0).UnixNano())
+				payments, err := dividend.FetchArchivedPaymentsForAccount(
+					h.db, []byte(account.UUID), minNano)
+				if err != nil {
+					return nil, err
+				}
+
+				objs := make([]*gqlObject, 0, len(payments))
+				for _, payment := range payments {
+					objs = append(objs, gqlPayment(payment))
+				}
+				return objs, nil
+			},
+		},
+	}
+}
+
+// graphQLAccount authenticates the account of a graphql request, either by
+// session or by api key. Unauthenticated requests can only query pool data.
+func (h *Hub) graphQLAccount(r *http.Request) (*dividend.Account, *dividend.APIKey, error) {
+	var accountID string
+	var key *dividend.APIKey
+	if full := r.Header.Get("X-API-Key"); full != "" {
+		var err error
+		key, err = dividend.AuthenticateAPIKey(h.db, full)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid api key")
+		}
+
+		if !key.HasScope(dividend.ScopeReadStats) {
+			return nil, nil, fmt.Errorf("api key lacks the '%v' scope",
+				dividend.ScopeReadStats)
+		}
+
+		accountID = key.Account
+	} else {
+		if bearerToken(r) == "" {
+			return nil, nil, fmt.Errorf("authentication required")
+		}
+
+		id, _, code, msg := h.authenticateSession(r)
+		if code != http.StatusOK {
+			return nil, nil, fmt.Errorf("%v", msg)
+		}
+
+		accountID = id
+	}
+
+	account, err := dividend.FetchAccount(h.db, []byte(accountID))
+	if err != nil {
+		return nil, nil, dividend.ErrAccountNotFound(accountID)
+	}
+
+	return account, key, nil
+}
+
+// GraphQL handles graphql queries of pool and account stats. Queries are
+// accepted as json posts or as the query parameter of get requests.
+func (h *Hub) GraphQL(w http.ResponseWriter, r *http.Request) {
+	var req gqlRequest
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			err := json.Unmarshal([]byte(v), &req.Variables)
+			if err != nil {
+				RespondWithJSON(w, http.StatusBadRequest,
+					map[string]interface{}{"errors": []gqlError{{
+						Message: "variables are invalid json"}}})
+				return
+			}
+		}
+	} else {
+		dc := json.NewDecoder(http.MaxBytesReader(w, r.Body,
+			maxGraphQLRequestSize))
+		err := dc.Decode(&req)
+		if err != nil {
+			RespondWithJSON(w, http.StatusBadRequest,
+				map[string]interface{}{"errors": []gqlError{{
+					Message: "request body is invalid json"}}})
+			return
+		}
+	}
+
+	selection, err := parseGraphQL(req.Query, req.Variables)
+	if err != nil {
+		RespondWithJSON(w, http.StatusBadRequest,
+			map[string]interface{}{"errors": []gqlError{{
+				Message: err.Error()}}})
+		return
+	}
+
+	query := &gqlObject{
+		typeName: "Query",
+		fields: map[string]gqlResolver{
+			"pool": func(map[string]interface{}) (interface{}, error) {
+				return h.gqlPool(), nil
+			},
+			"blocks": func(args map[string]interface{}) (interface{}, error) {
+				work, err := ListMinedWork(h.db)
+				if err != nil {
+					return nil, err
+				}
+				return gqlBlocks(work, args)
+			},
+			"account": func(map[string]interface{}) (interface{}, error) {
+				account, key, err := h.graphQLAccount(r)
+				if err != nil {
+					return nil, err
+				}
+				return h.gqlAccount(account, key), nil
+			},
+		},
+	}
+
+	exec := &gqlExecutor{}
+	resp := map[string]interface{}{
+		"data": exec.resolveObject(query, selection, nil),
+	}
+	if len(exec.errors) > 0 {
+		resp["errors"] = exec.errors
+	}
+
+	RespondWithJSON(w, http.StatusOK, resp)
+}
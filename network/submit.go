@@ -0,0 +1,79 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+const (
+	// submitMinBackoff is the delay before the first retry of a failed
+	// block submission, it doubles with every failed attempt.
+	submitMinBackoff = time.Millisecond * 250
+
+	// submitMaxBackoff is the maximum delay between retries of a failed
+	// block submission.
+	submitMaxBackoff = time.Second * 4
+)
+
+// retrySubmit calls submit until it completes without error or the provided
+// retries are exhausted, waiting with exponential backoff between attempts.
+// Retries stop early when wait returns false. It returns the outcome of the
+// last attempt and the number of attempts made.
+func retrySubmit(retries uint32, submit func() (bool, error), wait func(time.Duration) bool) (bool, int, error) {
+	backoff := submitMinBackoff
+	attempts := 0
+	for {
+		attempts++
+		status, err := submit()
+		if err == nil || attempts > int(retries) {
+			return status, attempts, err
+		}
+
+		chainLog.Warnf("Block submission failed (attempt %v), retrying in %v: %v",
+			attempts, backoff, err)
+		if !wait(backoff) {
+			return status, attempts, err
+		}
+
+		backoff *= 2
+		if backoff > submitMaxBackoff {
+			backoff = submitMaxBackoff
+		}
+	}
+}
+
+// recordSubmission records the outcome of a block submission that needed
+// retries or failed in the pool event log.
+func (h *Hub) recordSubmission(submission string, accepted bool, attempts int, err error) {
+	header, derr := decodeWorkHeader(submission)
+	if derr != nil {
+		chainLog.Errorf("Failed to decode submitted header: %v", derr)
+		return
+	}
+
+	hash := header.BlockHash().String()
+	data := map[string]interface{}{
+		"height":    header.Height,
+		"blockhash": hash,
+		"attempts":  attempts,
+		"accepted":  accepted,
+	}
+
+	detail := fmt.Sprintf("block %v at height %v submitted after %v "+
+		"attempts", hash, header.Height, attempts)
+	if err != nil {
+		data["error"] = err.Error()
+		detail = fmt.Sprintf("block %v at height %v failed submission "+
+			"after %v attempts: %v", hash, header.Height, attempts, err)
+		chainLog.Errorf("Failed to submit block %v at height %v after %v "+
+			"attempts: %v", hash, header.Height, attempts, err)
+	}
+
+	h.logPoolEvent(dividend.PoolEventBlockSubmission, detail, data, "")
+}
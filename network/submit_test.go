@@ -0,0 +1,71 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetrySubmit(t *testing.T) {
+	errTransient := errors.New("connection reset")
+
+	// Submissions are retried until they complete, with backoff doubling
+	// up to its bound.
+	var calls int
+	var waits []time.Duration
+	wait := func(backoff time.Duration) bool {
+		waits = append(waits, backoff)
+		return true
+	}
+	status, attempts, err := retrySubmit(6, func() (bool, error) {
+		calls++
+		if calls < 7 {
+			return false, errTransient
+		}
+		return true, nil
+	}, wait)
+	if err != nil || !status || attempts != 7 {
+		t.Fatalf("expected an accepted block after 7 attempts, got %v "+
+			"after %v (%v)", status, attempts, err)
+	}
+
+	expected := []time.Duration{submitMinBackoff, submitMinBackoff * 2,
+		submitMinBackoff * 4, submitMinBackoff * 8, submitMaxBackoff,
+		submitMaxBackoff}
+	for i, backoff := range expected {
+		if waits[i] != backoff {
+			t.Fatalf("expected backoff %v of attempt %v, got %v", backoff,
+				i+1, waits[i])
+		}
+	}
+
+	// Retries are bounded.
+	calls = 0
+	_, attempts, err = retrySubmit(2, func() (bool, error) {
+		calls++
+		return false, errTransient
+	}, wait)
+	if err != errTransient || attempts != 3 || calls != 3 {
+		t.Fatalf("expected 3 failed attempts, got %v (%v)", attempts, err)
+	}
+
+	// Rejected blocks are not retried.
+	_, attempts, err = retrySubmit(2, func() (bool, error) {
+		return false, nil
+	}, wait)
+	if err != nil || attempts != 1 {
+		t.Fatalf("expected a single attempt, got %v (%v)", attempts, err)
+	}
+
+	// Retries stop when waiting is interrupted.
+	_, attempts, _ = retrySubmit(2, func() (bool, error) {
+		return false, errTransient
+	}, func(time.Duration) bool { return false })
+	if attempts != 1 {
+		t.Fatalf("expected a single attempt, got %v", attempts)
+	}
+}
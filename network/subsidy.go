@@ -0,0 +1,146 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// blockSubsidySplit returns the subsidy split paid by the provided block.
+// The coinbase input holds the work and treasury subsidy, the first coinbase
+// output pays the treasury and the stakebase inputs of the votes hold the
+// stake subsidy. Blocks without votes do not reveal the split.
+func blockSubsidySplit(block *wire.MsgBlock) (*dividend.SubsidySplit, error) {
+	if len(block.Transactions) == 0 {
+		return nil, fmt.Errorf("block has no coinbase")
+	}
+
+	coinbase := block.Transactions[0]
+	if len(coinbase.TxIn) == 0 || len(coinbase.TxOut) == 0 {
+		return nil, fmt.Errorf("malformed coinbase")
+	}
+
+	treasury := coinbase.TxOut[0].Value
+	work := coinbase.TxIn[0].ValueIn - treasury
+
+	var stake int64
+	for _, tx := range block.STransactions {
+		if isVote(tx) {
+			stake += tx.TxIn[0].ValueIn
+		}
+	}
+
+	if stake == 0 {
+		return nil, fmt.Errorf("block has no votes")
+	}
+
+	if work <= 0 || treasury < 0 {
+		return nil, fmt.Errorf("unexpected coinbase subsidy (work %v, "+
+			"treasury %v)", work, treasury)
+	}
+
+	return dividend.NewSubsidySplit(work, stake, treasury), nil
+}
+
+// activeSubsidySplit returns the subsidy split of the last connected block.
+func (h *Hub) activeSubsidySplit() *dividend.SubsidySplit {
+	h.subsidyMtx.Lock()
+	defer h.subsidyMtx.Unlock()
+	return h.subsidySplit
+}
+
+// updateSubsidySplit records the subsidy split paid by the block of the
+// provided header, so consensus changes to the split are followed without
+// relying on the chain parameters of the pool. Operators are warned of
+// splits differing from the chain parameters.
+func (h *Hub) updateSubsidySplit(header *wire.BlockHeader) {
+	hash := header.BlockHash()
+	h.rpccMtx.Lock()
+	block, err := h.rpcc.GetBlock(&hash)
+	h.rpccMtx.Unlock()
+	if err != nil {
+		chainLog.Errorf("Failed to fetch block %v: %v", hash, err)
+		return
+	}
+
+	split, err := blockSubsidySplit(block)
+	if err != nil {
+		chainLog.Tracef("Subsidy split of block %v unavailable: %v", hash, err)
+		return
+	}
+
+	h.subsidyMtx.Lock()
+	prev := h.subsidySplit
+	h.subsidySplit = split
+	h.subsidyMtx.Unlock()
+
+	if *prev == *split {
+		return
+	}
+
+	chainLog.Infof("Subsidy split changed from %+v to %+v at height %v", *prev,
+		*split, header.Height)
+
+	params := dividend.ParamsSubsidySplit(h.cfg.ActiveNet)
+	if *split != *params {
+		chainLog.Warnf("Subsidy split %+v at height %v differs from the %v "+
+			"parameters (%+v)", *split, header.Height, h.cfg.ActiveNet.Name,
+			*params)
+	}
+}
+
+// coinbaseReward returns the reward paid by the provided coinbase to the
+// miner of its block, the work subsidy and transaction fees. The first
+// output of the coinbase pays the treasury, the remaining outputs pay the
+// miner.
+func coinbaseReward(coinbase *wire.MsgTx) dcrutil.Amount {
+	var reward int64
+	for i, out := range coinbase.TxOut {
+		if i == 0 {
+			continue
+		}
+		reward += out.Value
+	}
+
+	return dcrutil.Amount(reward)
+}
+
+// validateReward checks the reward of the provided block found by the pool
+// covers the work subsidy expected at its height per the subsidy schedule of
+// the chain parameters, given its votes.
+func (h *Hub) validateReward(block *wire.MsgBlock, reward dcrutil.Amount) error {
+	expected := dividend.CalcWorkSubsidy(h.subsidyCache, h.cfg.ActiveNet,
+		h.activeSubsidySplit(), block.Header.Height, block.Header.Voters)
+	if reward < expected {
+		return fmt.Errorf("block reward of %v is below the expected work "+
+			"subsidy of %v at height %v with %v votes", reward, expected,
+			block.Header.Height, block.Header.Voters)
+	}
+
+	return nil
+}
+
+// validateWorkTemplate checks the provided work header can be mined into a
+// block paying the expected work subsidy. Blocks from the stake validation
+// height must include the majority of their votes.
+func validateWorkTemplate(net *chaincfg.Params, header *wire.BlockHeader) error {
+	if int64(header.Height) < net.StakeValidationHeight {
+		return nil
+	}
+
+	required := net.TicketsPerBlock/2 + 1
+	if header.Voters < required {
+		return fmt.Errorf("work at height %v includes %v votes, %v are "+
+			"required", header.Height, header.Voters, required)
+	}
+
+	return nil
+}
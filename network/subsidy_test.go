@@ -0,0 +1,93 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+func TestBlockSubsidySplit(t *testing.T) {
+	commitment := make([]byte, voteCommitmentSize)
+	commitment[0] = 0x6a
+	commitment[1] = 0x24
+
+	coinbase := wire.NewMsgTx()
+	coinbase.AddTxIn(&wire.TxIn{ValueIn: 7e8})
+	coinbase.AddTxOut(wire.NewTxOut(1e8, []byte{0xa9}))
+	coinbase.AddTxOut(wire.NewTxOut(0, []byte{0x6a}))
+	coinbase.AddTxOut(wire.NewTxOut(6e8, []byte{0x76}))
+
+	block := &wire.MsgBlock{Transactions: []*wire.MsgTx{coinbase}}
+	_, err := blockSubsidySplit(block)
+	if err == nil {
+		t.Fatal("expected an error for a block without votes")
+	}
+
+	for i := 0; i < 5; i++ {
+		vote := wire.NewMsgTx()
+		vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex, wire.TxTreeRegular), 6e7, nil))
+		vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0,
+			wire.TxTreeStake), 0, nil))
+		vote.AddTxOut(wire.NewTxOut(0, commitment))
+		vote.AddTxOut(wire.NewTxOut(0, []byte{0x6a, 0x02, 0x01, 0x00}))
+		vote.AddTxOut(wire.NewTxOut(6e7, []byte{0xbb}))
+		block.STransactions = append(block.STransactions, vote)
+	}
+
+	split, err := blockSubsidySplit(block)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := dividend.SubsidySplit{Work: 600, Stake: 300, Treasury: 100}
+	if *split != expected {
+		t.Fatalf("expected split %+v, got %+v", expected, *split)
+	}
+}
+
+func TestCoinbaseReward(t *testing.T) {
+	coinbase := wire.NewMsgTx()
+	coinbase.AddTxIn(&wire.TxIn{ValueIn: 7e8})
+	coinbase.AddTxOut(wire.NewTxOut(1e8, []byte{0xa9}))
+	coinbase.AddTxOut(wire.NewTxOut(0, []byte{0x6a}))
+	coinbase.AddTxOut(wire.NewTxOut(5e8, []byte{0x76}))
+	coinbase.AddTxOut(wire.NewTxOut(1e8+2e4, []byte{0x76}))
+
+	reward := coinbaseReward(coinbase)
+	if reward != 6e8+2e4 {
+		t.Fatalf("expected a reward of %v, got %v", 6e8+2e4, reward)
+	}
+}
+
+func TestValidateWorkTemplate(t *testing.T) {
+	net := &chaincfg.MainNetParams
+	header := &wire.BlockHeader{
+		Height: uint32(net.StakeValidationHeight),
+		Voters: net.TicketsPerBlock / 2,
+	}
+	if validateWorkTemplate(net, header) == nil {
+		t.Fatal("expected work without the majority of votes to be invalid")
+	}
+
+	header.Voters++
+	err := validateWorkTemplate(net, header)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	header.Height--
+	header.Voters = 0
+	err = validateWorkTemplate(net, header)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/wire"
+)
+
+// decodeWorkHeader decodes the block header of the provided hex encoded
+// getwork data. The header is assembled by dcrd from its block template, the
+// block version, stake version and vote fields are mined as provided so
+// consensus upgrades apply without changes to the pool.
+func decodeWorkHeader(headerE string) (*wire.BlockHeader, error) {
+	if len(headerE) < wire.MaxBlockHeaderPayload*2 {
+		return nil, fmt.Errorf("work data too short to hold a block "+
+			"header: %v", len(headerE))
+	}
+
+	headerD, err := hex.DecodeString(headerE[:wire.MaxBlockHeaderPayload*2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode block header: %v", err)
+	}
+
+	var header wire.BlockHeader
+	err = header.FromBytes(headerD)
+	if err != nil {
+		return nil, err
+	}
+
+	return &header, nil
+}
+
+// latestVoteVersion returns the latest vote version with agendas defined by
+// the provided chain parameters.
+func latestVoteVersion(net *chaincfg.Params) uint32 {
+	var latest uint32
+	for version := range net.Deployments {
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// trackWorkVersions records the block and stake versions of the provided
+// work header, logging version upgrades. Operators are warned of stake
+// versions newer than the vote agendas known to the chain parameters of the
+// pool, work is still mined as provided by dcrd.
+func (h *Hub) trackWorkVersions(header *wire.BlockHeader) {
+	prevVersion := atomic.SwapInt32(&h.blockVersion, header.Version)
+	if prevVersion != 0 && prevVersion != header.Version {
+		chainLog.Infof("Block version changed from %v to %v at height %v",
+			prevVersion, header.Version, header.Height)
+	}
+
+	prevStake := atomic.SwapUint32(&h.stakeVersion, header.StakeVersion)
+	if prevStake == header.StakeVersion {
+		return
+	}
+
+	if prevStake != 0 {
+		chainLog.Infof("Stake version changed from %v to %v at height %v",
+			prevStake, header.StakeVersion, header.Height)
+	}
+
+	latest := latestVoteVersion(h.cfg.ActiveNet)
+	if header.StakeVersion > latest {
+		chainLog.Warnf("Stake version %v at height %v is newer than the latest "+
+			"vote version known to the %v parameters (%v), mining work "+
+			"as provided by dcrd", header.StakeVersion, header.Height,
+			h.cfg.ActiveNet.Name, latest)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/wire"
+)
+
+func TestDecodeWorkHeader(t *testing.T) {
+	header := wire.BlockHeader{
+		Version:      7,
+		Bits:         0x1d00ffff,
+		Voters:       5,
+		Height:       1024,
+		StakeVersion: 6,
+		Timestamp:    time.Unix(1540000000, 0),
+	}
+
+	var buf bytes.Buffer
+	err := header.Serialize(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Getwork data is the header followed by the blake256 padding.
+	headerE := hex.EncodeToString(buf.Bytes()) +
+		hex.EncodeToString(make([]byte, getworkDataLen-
+			wire.MaxBlockHeaderPayload))
+
+	decoded, err := decodeWorkHeader(headerE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.BlockHash() != header.BlockHash() {
+		t.Fatalf("expected header %v, got %v", header.BlockHash(),
+			decoded.BlockHash())
+	}
+
+	_, err = decodeWorkHeader(headerE[:100])
+	if err == nil {
+		t.Fatal("expected a short work data error")
+	}
+}
+
+func TestLatestVoteVersion(t *testing.T) {
+	net := &chaincfg.Params{
+		Deployments: map[uint32][]chaincfg.ConsensusDeployment{
+			4: nil,
+			6: nil,
+			5: nil,
+		},
+	}
+
+	if latest := latestVoteVersion(net); latest != 6 {
+		t.Fatalf("expected latest vote version 6, got %v", latest)
+	}
+}
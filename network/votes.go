@@ -0,0 +1,83 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// txQueueSize is the number of votes accepted to the mempool queued for
+	// the vote notifications handler, votes are dropped when full.
+	txQueueSize = 64
+
+	// voteCommitmentSize is the size of the script committing to the block
+	// voted on: OP_RETURN OP_DATA_36 <block hash> <block height>.
+	voteCommitmentSize = 38
+)
+
+// isVote returns whether the provided transaction is a vote. Votes spend a
+// stakebase and a ticket, and commit to the block voted on in their first
+// output.
+func isVote(tx *wire.MsgTx) bool {
+	if len(tx.TxIn) != 2 || len(tx.TxOut) < 3 {
+		return false
+	}
+
+	prevOut := tx.TxIn[0].PreviousOutPoint
+	if prevOut.Index != wire.MaxPrevOutIndex ||
+		!prevOut.Hash.IsEqual(&chainhash.Hash{}) {
+		return false
+	}
+
+	script := tx.TxOut[0].PkScript
+	return len(script) == voteCommitmentSize && script[0] == 0x6a &&
+		script[1] == 0x24
+}
+
+// isVoteResult returns whether the provided verbose transaction is a vote.
+// Votes are the only transactions spending a stakebase.
+func isVoteResult(tx *dcrjson.TxRawResult) bool {
+	return len(tx.Vin) > 0 && tx.Vin[0].Stakebase != ""
+}
+
+// queueVote queues the provided vote accepted to the mempool for the vote
+// notifications handler.
+func (h *Hub) queueVote(hash *chainhash.Hash) {
+	select {
+	case h.txCh <- hash:
+	default:
+		atomic.AddUint64(&h.metrics.txDropped, 1)
+		chainLog.Tracef("Vote queue full, dropping %v", hash)
+	}
+}
+
+// handleVoteNotifications signals the work handler to fetch work when a vote
+// is accepted to the mempool of the active dcrd backend, work including more
+// votes than the current work is sent to all clients. Votes are detected
+// from verbose transaction notifications, without fetching transactions. It
+// must be run as a goroutine.
+func (h *Hub) handleVoteNotifications(ctx context.Context) {
+	h.wg.Add(1)
+	chainLog.Trace("Started vote notifications handler.")
+
+	for {
+		select {
+		case <-ctx.Done():
+			chainLog.Trace("Vote notifications handler done.")
+			h.wg.Done()
+			return
+
+		case hash := <-h.txCh:
+			chainLog.Tracef("Vote %v received", hash)
+			h.signalWork()
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson"
+	"github.com/decred/dcrd/wire"
+)
+
+func TestIsVote(t *testing.T) {
+	commitment := make([]byte, voteCommitmentSize)
+	commitment[0] = 0x6a
+	commitment[1] = 0x24
+
+	vote := wire.NewMsgTx()
+	vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular), 0, nil))
+	vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0,
+		wire.TxTreeStake), 0, nil))
+	vote.AddTxOut(wire.NewTxOut(0, commitment))
+	vote.AddTxOut(wire.NewTxOut(0, []byte{0x6a, 0x02, 0x01, 0x00}))
+	vote.AddTxOut(wire.NewTxOut(1e8, []byte{0xbb}))
+
+	if !isVote(vote) {
+		t.Fatal("expected the transaction to be a vote")
+	}
+
+	vote.TxIn[0].PreviousOutPoint.Hash = chainhash.Hash{2}
+	if isVote(vote) {
+		t.Fatal("expected a transaction without a stakebase not to be a vote")
+	}
+}
+
+func TestIsVoteResult(t *testing.T) {
+	vote := &dcrjson.TxRawResult{
+		Vin: []dcrjson.Vin{
+			{Stakebase: "0000"},
+			{Txid: chainhash.Hash{1}.String(), Tree: wire.TxTreeStake},
+		},
+	}
+	if !isVoteResult(vote) {
+		t.Fatal("expected the transaction to be a vote")
+	}
+
+	tx := &dcrjson.TxRawResult{
+		Vin: []dcrjson.Vin{{Txid: chainhash.Hash{2}.String()}},
+	}
+	if isVoteResult(tx) {
+		t.Fatal("expected a transaction without a stakebase not to be a vote")
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync/atomic"
+	"time"
+
+	"github.com/decred/dcrwallet/rpc/walletrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// walletCheckInterval is the interval between health checks of the
+	// wallet connection.
+	walletCheckInterval = time.Second * 10
+
+	// walletPingTimeout is the time allowed for the wallet to answer a
+	// health check.
+	walletPingTimeout = time.Second * 5
+
+	// walletMaxFailures is the number of consecutive failed health checks
+	// after which the wallet is considered unreachable, payments are
+	// deferred and the connection is re-established.
+	walletMaxFailures = 3
+)
+
+// errWalletUnreachable is returned when payments are requested while the
+// wallet is unreachable.
+var errWalletUnreachable = errors.New("wallet is unreachable, payments " +
+	"are deferred until it is reachable")
+
+// walletTLSConfig returns the tls configuration of the wallet connection,
+// trusting the provided wallet certificate. The client certificate and key
+// are presented to the wallet when provided, the key is read from the key
+// file unless provided as PEM.
+func walletTLSConfig(walletCert, clientCert, clientKey string, clientKeyPEM []byte) (*tls.Config, error) {
+	pem, err := ioutil.ReadFile(walletCert)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %v", walletCert)
+	}
+
+	cfg := &tls.Config{
+		RootCAs:    pool,
+		ServerName: "localhost",
+	}
+
+	if clientCert != "" {
+		var cert tls.Certificate
+		if len(clientKeyPEM) > 0 {
+			certPEM, err := ioutil.ReadFile(clientCert)
+			if err != nil {
+				return nil, err
+			}
+			cert, err = tls.X509KeyPair(certPEM, clientKeyPEM)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			cert, err = tls.LoadX509KeyPair(clientCert, clientKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// fetchPayoutAccount returns the number of the configured payout account of
+// the wallet.
+func (h *Hub) fetchPayoutAccount(ctx context.Context) (uint32, error) {
+	h.grpcMtx.Lock()
+	resp, err := h.grpc.AccountNumber(ctx, &walletrpc.AccountNumberRequest{
+		AccountName: h.cfg.PayoutAccount,
+	})
+	h.grpcMtx.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("unable to fetch payout account (%v): %v",
+			h.cfg.PayoutAccount, err)
+	}
+
+	return resp.AccountNumber, nil
+}
+
+// payoutChangeAddress returns a new internal address of the payout account
+// for the change of a payout, keeping pool funds in the payout account.
+func (h *Hub) payoutChangeAddress(ctx context.Context) (string, error) {
+	h.grpcMtx.Lock()
+	resp, err := h.grpc.NextAddress(ctx, &walletrpc.NextAddressRequest{
+		Account:   h.payoutAcct,
+		Kind:      walletrpc.NextAddressRequest_BIP0044_INTERNAL,
+		GapPolicy: walletrpc.NextAddressRequest_GAP_POLICY_WRAP,
+	})
+	h.grpcMtx.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Address, nil
+}
+
+// walletPassphrase returns the wallet passphrase, read from the passphrase
+// file for every payout when configured so it is not held by the pool.
+func (h *Hub) walletPassphrase() ([]byte, error) {
+	if h.cfg.WalletPassFile == "" {
+		return []byte(h.cfg.WalletPass), nil
+	}
+
+	b, err := ioutil.ReadFile(h.cfg.WalletPassFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wallet passphrase: %v", err)
+	}
+
+	passphrase := bytes.TrimRight(b, "\r\n")
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("wallet passphrase file (%v) is empty",
+			h.cfg.WalletPassFile)
+	}
+
+	return passphrase, nil
+}
+
+// zeroBytes clears the provided secret.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// dialWallet establishes a grpc connection with the wallet.
+func (h *Hub) dialWallet() (*grpc.ClientConn, error) {
+	tlsCfg, err := walletTLSConfig(h.cfg.WalletRPCCertFile,
+		h.cfg.WalletClientCert, h.cfg.WalletClientKey, h.cfg.WalletKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("grpc tls error (dcrwallet): %v", err)
+	}
+
+	conn, err := grpc.Dial(h.cfg.WalletGRPCHost,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)))
+	if err != nil {
+		return nil, fmt.Errorf("grpc dial error (dcrwallet): %v", err)
+	}
+
+	return conn, nil
+}
+
+// walletUnreachable returns whether the wallet failed its recent health
+// checks, payments are deferred while it is.
+func (h *Hub) walletUnreachable() bool {
+	return atomic.LoadInt32(&h.walletDown) == 1
+}
+
+// pingWallet checks the wallet answers requests.
+func (h *Hub) pingWallet(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, walletPingTimeout)
+	defer cancel()
+	h.grpcMtx.Lock()
+	defer h.grpcMtx.Unlock()
+	_, err := h.grpc.Ping(ctx, &walletrpc.PingRequest{})
+	return err
+}
+
+// reconnectWallet replaces the wallet connection with a new one, closing
+// the previous connection.
+func (h *Hub) reconnectWallet() error {
+	conn, err := h.dialWallet()
+	if err != nil {
+		return err
+	}
+
+	h.grpcMtx.Lock()
+	old := h.gConn
+	h.gConn = conn
+	h.grpc = walletrpc.NewWalletServiceClient(conn)
+	h.grpcMtx.Unlock()
+	old.Close()
+
+	return nil
+}
+
+// handleWallet periodically checks the wallet connection. Payments are
+// deferred once the wallet fails consecutive health checks and resume when
+// it answers again, the connection is re-established with exponential
+// backoff while the wallet is unreachable. It must be run as a goroutine.
+func (h *Hub) handleWallet(ctx context.Context) {
+	ticker := time.NewTicker(walletCheckInterval)
+	defer ticker.Stop()
+	h.wg.Add(1)
+	paymentLog.Trace("Started wallet handler.")
+
+	var failures int
+	backoff := walletCheckInterval
+	var retryAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			paymentLog.Trace("Wallet handler done.")
+			h.wg.Done()
+			return
+		case now := <-ticker.C:
+			err := h.pingWallet(ctx)
+			if err == nil {
+				failures = 0
+				backoff = walletCheckInterval
+				if atomic.CompareAndSwapInt32(&h.walletDown, 1, 0) {
+					paymentLog.Info("Wallet is reachable, resuming payments.")
+				}
+				continue
+			}
+
+			failures++
+			paymentLog.Debugf("Wallet health check failed (%v): %v", failures, err)
+			if failures < walletMaxFailures {
+				continue
+			}
+
+			if atomic.CompareAndSwapInt32(&h.walletDown, 0, 1) {
+				paymentLog.Warnf("Wallet is unreachable, deferring payments: %v",
+					err)
+			}
+
+			if now.Before(retryAt) {
+				continue
+			}
+
+			err = h.reconnectWallet()
+			if err != nil {
+				paymentLog.Errorf("Failed to reconnect to the wallet: %v", err)
+			}
+			retryAt = now.Add(backoff)
+			backoff = nextBackoff(backoff)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"crypto/elliptic"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/certgen"
+)
+
+func TestWalletTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallettls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cert, key, err := certgen.NewTLSCertPair(elliptic.P256(), "test",
+		time.Now().Add(time.Hour), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "rpc.cert")
+	keyFile := filepath.Join(dir, "rpc.key")
+	err = ioutil.WriteFile(certFile, cert, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(keyFile, key, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := walletTLSConfig(certFile, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.RootCAs == nil || len(cfg.Certificates) != 0 {
+		t.Fatal("expected the wallet certificate trusted without a " +
+			"client certificate")
+	}
+
+	cfg, err = walletTLSConfig(certFile, certFile, keyFile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.Certificates) != 1 {
+		t.Fatalf("expected a client certificate, got %v",
+			len(cfg.Certificates))
+	}
+
+	cfg, err = walletTLSConfig(certFile, certFile, "", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.Certificates) != 1 {
+		t.Fatalf("expected a client certificate with a PEM key, got %v",
+			len(cfg.Certificates))
+	}
+
+	_, err = walletTLSConfig(keyFile, "", "", nil)
+	if err == nil {
+		t.Fatal("expected an error loading a file without certificates")
+	}
+}
+
+func TestWalletPassphrase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "walletpass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	h := &Hub{cfg: &HubConfig{WalletPass: "configured"}}
+	passphrase, err := h.walletPassphrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(passphrase) != "configured" {
+		t.Fatalf("expected the configured passphrase, got %q", passphrase)
+	}
+
+	passFile := filepath.Join(dir, "walletpass")
+	err = ioutil.WriteFile(passFile, []byte("secret\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.cfg = &HubConfig{WalletPassFile: passFile}
+	passphrase, err = h.walletPassphrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(passphrase) != "secret" {
+		t.Fatalf("expected the passphrase of the file, got %q", passphrase)
+	}
+
+	err = ioutil.WriteFile(passFile, []byte("\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = h.walletPassphrase()
+	if err == nil {
+		t.Fatal("expected an error reading an empty passphrase file")
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dnldd/dcrpool/dividend"
+)
+
+// validateWorkerName asserts the provided worker name can be used as part of
+// a stratum username, which is of the form `address.name.worker`.
+func validateWorkerName(name string) error {
+	if name == "" {
+		return fmt.Errorf("worker name cannot be empty")
+	}
+
+	if strings.ContainsAny(name, ". \t") {
+		return fmt.Errorf("worker name cannot contain periods or spaces")
+	}
+
+	return nil
+}
+
+// workerUptimeWindows are the windows the uptime of workers is reported
+// over, keyed by name.
+var workerUptimeWindows = map[string]time.Duration{
+	"24h": time.Hour * 24,
+	"7d":  time.Hour * 24 * 7,
+}
+
+// workerUptime returns the uptime of the provided worker over each reported
+// window.
+func workerUptime(worker *dividend.Worker, now int64, connected bool) map[string]float64 {
+	uptime := make(map[string]float64, len(workerUptimeWindows))
+	for name, window := range workerUptimeWindows {
+		uptime[name] = worker.Uptime(window, now, connected)
+	}
+
+	return uptime
+}
+
+// connectedWorkers returns the ids of workers with connected clients.
+func (h *Hub) connectedWorkers() map[string]bool {
+	connected := make(map[string]bool)
+	for _, endpoint := range h.allEndpoints() {
+		endpoint.clientsMtx.Lock()
+		for _, client := range endpoint.clients {
+			if client.worker != "" {
+				connected[client.worker] = true
+			}
+		}
+		endpoint.clientsMtx.Unlock()
+	}
+
+	return connected
+}
+
+// requestWorker fetches the worker referenced by the provided id if it
+// belongs to the account authenticated for the provided request.
+func (h *Hub) requestWorker(r *http.Request, id string) (*dividend.Worker, error) {
+	worker, err := dividend.FetchWorker(h.db, []byte(id))
+	if err != nil || worker.Account != requestAccountID(r) {
+		return nil, fmt.Errorf("worker not found")
+	}
+
+	return worker, nil
+}
+
+// ListWorkers lists the workers of the authenticated account.
+func (h *Hub) ListWorkers(w http.ResponseWriter, r *http.Request) {
+	workers, err := dividend.ListWorkers(h.db, requestAccountID(r))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	connected := h.connectedWorkers()
+	now := time.Now().Unix()
+	results := make([]map[string]interface{}, 0, len(workers))
+	for _, worker := range workers {
+		isConnected := connected[worker.UUID]
+		results = append(results, map[string]interface{}{
+			"id":             worker.UUID,
+			"name":           worker.Name,
+			"createdon":      worker.CreatedOn,
+			"lastshareon":    worker.LastShareOn,
+			"hashrate":       worker.HashRate,
+			"difficulty":     worker.Difficulty,
+			"connected":      isConnected,
+			"region":         worker.Region(),
+			"lastseen":       worker.LastSeen(now, isConnected),
+			"uptime":         workerUptime(worker, now, isConnected),
+			"acceptedshares": worker.AcceptedShares,
+			"staleshares":    worker.StaleShares,
+			"invalidshares":  worker.InvalidShares,
+			"shareratios":    worker.ShareRatios(),
+		})
+	}
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]interface{}{"results": results})
+}
+
+// FetchWorkerDetails returns the details of a worker of the authenticated
+// account: its share counts and ratios, last seen time, uptime, user agent,
+// region, difficulty history and connection history.
+func (h *Hub) FetchWorkerDetails(w http.ResponseWriter, r *http.Request) {
+	worker, err := h.requestWorker(r, r.URL.Query().Get("id"))
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	difficultyHistory := worker.DifficultyHistory
+	if difficultyHistory == nil {
+		difficultyHistory = make([]*dividend.DifficultyChange, 0)
+	}
+
+	connections := worker.Connections
+	if connections == nil {
+		connections = make([]*dividend.WorkerConnection, 0)
+	}
+
+	connected := h.connectedWorkers()[worker.UUID]
+	now := time.Now().Unix()
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"id":                worker.UUID,
+		"name":              worker.Name,
+		"createdon":         worker.CreatedOn,
+		"lastshareon":       worker.LastShareOn,
+		"hashrate":          worker.HashRate,
+		"difficulty":        worker.Difficulty,
+		"connected":         connected,
+		"lastseen":          worker.LastSeen(now, connected),
+		"uptime":            workerUptime(worker, now, connected),
+		"useragent":         worker.UserAgent,
+		"region":            worker.Region(),
+		"acceptedshares":    worker.AcceptedShares,
+		"staleshares":       worker.StaleShares,
+		"invalidshares":     worker.InvalidShares,
+		"shareratios":       worker.ShareRatios(),
+		"difficultyhistory": difficultyHistory,
+		"connections":       connections,
+	})
+}
+
+// RenameWorker renames a worker of the authenticated account. Clients
+// authorizing with the previous worker name afterwards create a new worker.
+func (h *Hub) RenameWorker(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	name := strings.TrimSpace(params["name"])
+	err = validateWorkerName(name)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	worker, err := h.requestWorker(r, params["id"])
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	prevName := worker.Name
+	err = worker.Rename(h.db, name)
+	if err != nil {
+		if err.Error() == dividend.ErrWorkerNameInUse(name).Error() {
+			RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordActivity(r, worker.Account, dividend.ActivitySettingsChange,
+		fmt.Sprintf("worker %v renamed to %v", prevName, name))
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]string{"id": worker.UUID, "name": worker.Name})
+}
+
+// DeleteWorker deletes a worker of the authenticated account. Workers with
+// connected clients cannot be deleted.
+func (h *Hub) DeleteWorker(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	dc := json.NewDecoder(r.Body)
+	err := dc.Decode(&params)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest,
+			"request body is invalid json")
+		return
+	}
+
+	worker, err := h.requestWorker(r, params["id"])
+	if err != nil {
+		RespondWithError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if h.connectedWorkers()[worker.UUID] {
+		RespondWithError(w, http.StatusConflict,
+			"worker has connected clients")
+		return
+	}
+
+	err = worker.Delete(h.db)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.recordActivity(r, worker.Account, dividend.ActivitySettingsChange,
+		fmt.Sprintf("worker %v deleted", worker.Name))
+
+	RespondWithJSON(w, http.StatusOK,
+		map[string]string{"response": "worker deleted"})
+}
@@ -6,21 +6,32 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/binary"
 	"fmt"
 	"math/big"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
+	"syscall"
 	"time"
 
 	bolt "github.com/coreos/bbolt"
 	"github.com/decred/dcrd/dcrutil"
 	"github.com/decred/dcrd/rpcclient"
 	"github.com/gorilla/mux"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
+	"github.com/dnldd/dcrpool/adminrpc"
 	"github.com/dnldd/dcrpool/database"
+	"github.com/dnldd/dcrpool/dividend"
+	"github.com/dnldd/dcrpool/frontendrpc"
 	"github.com/dnldd/dcrpool/network"
 )
 
@@ -34,7 +45,11 @@ type Pool struct {
 	hub     *network.Hub
 	limiter *network.RateLimiter
 	server  *http.Server
+	acmes   *http.Server
+	profs   *http.Server
 	router  *mux.Router
+	rpcs    *grpc.Server
+	frpcs   *grpc.Server
 }
 
 // initDB handles the creation, upgrading and backup of the database
@@ -104,75 +119,552 @@ func (p *Pool) initDB() error {
 	return nil
 }
 
+// apiPath returns the path of the provided route of the current api version.
+func apiPath(route string) string {
+	return "/api/" + network.APIVersion + route
+}
+
 // route configures the api routes of the pool.
 func (p *Pool) route() {
 	p.router = mux.NewRouter()
-	p.router.Use(p.limiter.LimiterMiddleware)
+	p.router.Use(p.hub.RealIP)
+	p.router.Use(p.hub.RateLimit)
 	p.router.HandleFunc("/hash", p.hub.FetchHash).Methods("GET")
-	p.router.HandleFunc("/connections", p.hub.FetchConnections).Methods("GET")
-	p.router.HandleFunc("/mined", p.hub.FetchMinedWork).Methods("GET")
+	p.router.HandleFunc("/connections", p.hub.Deprecated(apiPath("/pool"),
+		p.hub.FetchConnections)).Methods("GET")
+	p.router.HandleFunc("/metrics", p.hub.Metrics).Methods("GET")
+	p.router.HandleFunc("/healthz", p.hub.Healthz).Methods("GET")
+	p.router.HandleFunc("/readyz", p.hub.Readyz).Methods("GET")
+	p.router.HandleFunc("/mined", p.hub.Deprecated(apiPath("/blocks"),
+		p.hub.FetchMinedWork)).Methods("GET")
 	p.router.HandleFunc("/work/quotas", p.hub.FetchWorkQuotas).
 		Methods("GET")
 	p.router.HandleFunc("/work/height", p.hub.FetchLastWorkHeight).
 		Methods("GET")
+	p.router.PathPrefix("/static/").Handler(p.hub.Static()).Methods("GET")
+	p.router.HandleFunc("/", p.hub.IndexPage).Methods("GET")
+	p.router.HandleFunc("/blocks", p.hub.BlocksPage).Methods("GET")
+	p.router.HandleFunc("/payments", p.hub.PaymentsPage).Methods("GET")
+	p.router.HandleFunc("/apidocs", p.hub.APIDocsPage).Methods("GET")
+	p.router.HandleFunc("/leaderboard", p.hub.LeaderboardPage).
+		Methods("GET")
 	p.router.HandleFunc("/payment/height", p.hub.FetchLastPaymentHeight).
 		Methods("GET")
 	p.router.HandleFunc("/account/mined",
-		p.hub.FetchMinedWorkByAccount).Methods("POST")
+		p.hub.Deprecated(apiPath("/account/blocks"),
+			p.hub.FetchMinedWorkByAccount)).Methods("POST")
 	p.router.HandleFunc("/account/payments",
-		p.hub.FetchProcessedPaymentsForAccount).Methods("POST")
+		p.hub.Deprecated(apiPath("/account/payments"),
+			p.hub.FetchProcessedPaymentsForAccount)).Methods("POST")
 	p.router.HandleFunc("/backup", p.hub.BackupDB).Methods("POST")
+	if p.cfg.HALease > 0 && p.cfg.BackupPass != "" {
+		p.router.HandleFunc("/ha/replicate", p.hub.Replicate).
+			Methods("POST")
+	}
+	p.router.HandleFunc("/account/register", p.hub.RegisterAccount).
+		Methods("POST")
+	p.router.HandleFunc("/account/register/challenge",
+		p.hub.RegistrationChallenge).Methods("POST")
+	p.router.HandleFunc("/account/verify", p.hub.VerifyEmail).Methods("GET")
+	p.router.HandleFunc("/account/login", p.hub.Login).Methods("POST")
+	p.router.HandleFunc("/account/session/refresh", p.hub.RefreshSession).
+		Methods("POST")
+	p.router.HandleFunc("/account/login/challenge", p.hub.LoginChallenge).
+		Methods("POST")
+	p.router.HandleFunc("/account/login/signature", p.hub.SignatureLogin).
+		Methods("POST")
+	p.router.HandleFunc("/account/address/confirm",
+		p.hub.ConfirmAddressChange).Methods("GET")
+
+	// Account routes require an authenticated account session.
+	acc := p.router.NewRoute().Subrouter()
+	acc.Use(p.hub.AccountAuth)
+	acc.HandleFunc("/account/logout", p.hub.Logout).Methods("POST")
+	acc.HandleFunc("/account/sessions/revoke", p.hub.RevokeSessions).
+		Methods("POST")
+	acc.HandleFunc("/account/2fa/setup", p.hub.SetupTOTP).Methods("POST")
+	acc.HandleFunc("/account/2fa/enable", p.hub.EnableTOTP).Methods("POST")
+	acc.HandleFunc("/account/2fa/disable", p.hub.DisableTOTP).Methods("POST")
+	acc.HandleFunc("/account/keys", p.hub.ListAPIKeys).Methods("GET")
+	acc.HandleFunc("/account/keys", p.hub.CreateAPIKey).Methods("POST")
+	acc.HandleFunc("/account/keys/revoke", p.hub.RevokeAPIKey).Methods("POST")
+	acc.HandleFunc("/account/address", p.hub.RequestAddressChange).
+		Methods("POST")
+	acc.HandleFunc("/account/address/cancel", p.hub.CancelAddressChange).
+		Methods("POST")
+	acc.HandleFunc("/account/rename", p.hub.RenameAccount).Methods("POST")
+	acc.HandleFunc("/account/difficulty", p.hub.FetchDifficultyPreference).
+		Methods("GET")
+	acc.HandleFunc("/account/difficulty", p.hub.UpdateDifficultyPreference).
+		Methods("POST")
+	acc.HandleFunc("/account/activity", p.hub.FetchAccountActivity).
+		Methods("GET")
+	acc.HandleFunc("/account/finder", p.hub.FetchFinderPreference).
+		Methods("GET")
+	acc.HandleFunc("/account/finder", p.hub.UpdateFinderPreference).
+		Methods("POST")
+	acc.HandleFunc("/account/leaderboard", p.hub.FetchLeaderboardPreference).
+		Methods("GET")
+	acc.HandleFunc("/account/leaderboard",
+		p.hub.UpdateLeaderboardPreference).Methods("POST")
+	acc.HandleFunc("/account/export", p.hub.ExportAccount).Methods("GET")
+	acc.HandleFunc("/account/delete", p.hub.DeleteAccount).Methods("POST")
+	acc.HandleFunc("/account/notifications",
+		p.hub.FetchNotificationPreferences).Methods("GET")
+	acc.HandleFunc("/account/notifications",
+		p.hub.UpdateNotificationPreferences).Methods("POST")
+
+	// Account data routes accept either a session or a scoped api key.
+	keyed := p.router.NewRoute().Subrouter()
+	keyed.Use(p.hub.KeyAuth)
+	keyed.HandleFunc("/account/mined", p.hub.WithScope(dividend.ScopeReadStats,
+		p.hub.FetchAccountMinedWork)).Methods("GET")
+	keyed.HandleFunc("/account/payments",
+		p.hub.WithScope(dividend.ScopeReadPayments,
+			p.hub.FetchAccountPayments)).Methods("GET")
+	keyed.HandleFunc("/account/payments/csv",
+		p.hub.WithScope(dividend.ScopeReadPayments,
+			p.hub.DownloadAccountPayments)).Methods("GET")
+	keyed.HandleFunc("/account/workers", p.hub.WithScope(dividend.ScopeReadStats,
+		p.hub.ListWorkers)).Methods("GET")
+	keyed.HandleFunc("/account/hashrate",
+		p.hub.WithScope(dividend.ScopeReadStats,
+			p.hub.FetchAccountHashRates)).Methods("GET")
+	keyed.HandleFunc("/account/workers/detail",
+		p.hub.WithScope(dividend.ScopeReadStats,
+			p.hub.FetchWorkerDetails)).Methods("GET")
+	keyed.HandleFunc("/account/workers/hashrate",
+		p.hub.WithScope(dividend.ScopeReadStats,
+			p.hub.FetchWorkerHashRates)).Methods("GET")
+	keyed.HandleFunc("/account/workers/rename",
+		p.hub.WithScope(dividend.ScopeManageWorkers,
+			p.hub.RenameWorker)).Methods("POST")
+	keyed.HandleFunc("/account/workers/delete",
+		p.hub.WithScope(dividend.ScopeManageWorkers,
+			p.hub.DeleteWorker)).Methods("POST")
+
+	// Versioned stats api routes for third-party apps and pool lists.
+	api := p.router.PathPrefix(apiPath("")).Subrouter()
+	api.Use(p.hub.APIHeaders)
+	api.HandleFunc("/pool", p.hub.Cached(p.hub.APIPoolStats)).Methods("GET")
+	api.HandleFunc("/info", p.hub.Cached(p.hub.APIPoolInfo)).Methods("GET")
+	api.HandleFunc("/network", p.hub.Cached(p.hub.APINetwork)).
+		Methods("GET")
+	api.HandleFunc("/blocks", p.hub.Cached(p.hub.APIBlocks)).Methods("GET")
+	api.HandleFunc("/payouts", p.hub.Cached(p.hub.APIPayouts)).
+		Methods("GET")
+	api.HandleFunc("/eventlog", p.hub.Cached(p.hub.APIPoolEvents)).
+		Methods("GET")
+	api.HandleFunc("/hashrate", p.hub.Cached(p.hub.FetchPoolHashRates)).
+		Methods("GET")
+	api.HandleFunc("/luck", p.hub.Cached(p.hub.APILuck)).Methods("GET")
+	api.HandleFunc("/leaderboard", p.hub.Cached(p.hub.APILeaderboard)).
+		Methods("GET")
+	api.HandleFunc("/feed", p.hub.Feed).Methods("GET")
+	api.HandleFunc("/events", p.hub.Events).Methods("GET")
+	api.HandleFunc("/graphql", p.hub.GraphQL).Methods("GET", "POST")
+	api.HandleFunc("/openapi.json", p.hub.Cached(p.hub.OpenAPI(api))).
+		Methods("GET")
+
+	// Account stats api routes accept either a session or a scoped api key.
+	apiAcc := api.NewRoute().Subrouter()
+	apiAcc.Use(p.hub.KeyAuth)
+	apiAcc.HandleFunc("/account", p.hub.WithScope(dividend.ScopeReadStats,
+		p.hub.APIAccountStats)).Methods("GET")
+	apiAcc.HandleFunc("/account/feed", p.hub.WithScope(dividend.ScopeReadStats,
+		p.hub.AccountFeed)).Methods("GET")
+	apiAcc.HandleFunc("/account/events",
+		p.hub.WithScope(dividend.ScopeReadStats, p.hub.AccountEvents)).
+		Methods("GET")
+	apiAcc.HandleFunc("/account/workers",
+		p.hub.WithScope(dividend.ScopeReadStats, p.hub.ListWorkers)).
+		Methods("GET")
+	apiAcc.HandleFunc("/account/hashrate",
+		p.hub.WithScope(dividend.ScopeReadStats,
+			p.hub.FetchAccountHashRates)).Methods("GET")
+	apiAcc.HandleFunc("/account/workers/detail",
+		p.hub.WithScope(dividend.ScopeReadStats,
+			p.hub.FetchWorkerDetails)).Methods("GET")
+	apiAcc.HandleFunc("/account/workers/hashrate",
+		p.hub.WithScope(dividend.ScopeReadStats,
+			p.hub.FetchWorkerHashRates)).Methods("GET")
+	apiAcc.HandleFunc("/account/blocks",
+		p.hub.WithScope(dividend.ScopeReadStats,
+			p.hub.FetchAccountMinedWork)).Methods("GET")
+	apiAcc.HandleFunc("/account/payments",
+		p.hub.WithScope(dividend.ScopeReadPayments,
+			p.hub.FetchAccountPayments)).Methods("GET")
+
+	// Cross-origin preflight requests of all api routes.
+	api.PathPrefix("/").HandlerFunc(p.hub.APIPreflight).Methods("OPTIONS")
+
+	// Compatibility api routes serve monitoring apps of other pools.
+	if p.cfg.CompatAPI {
+		compat := p.router.PathPrefix("/api/pools").Subrouter()
+		compat.Use(p.hub.APIHeaders)
+		compat.HandleFunc("", p.hub.Cached(p.hub.CompatPools)).
+			Methods("GET")
+		compat.HandleFunc("/{pool}", p.hub.Cached(p.hub.CompatPool)).
+			Methods("GET")
+		compat.HandleFunc("/{pool}/miners/{address}",
+			p.hub.Cached(p.hub.CompatMiner)).Methods("GET")
+	}
+
+	// Admin routes require operator credentials.
+	admin := p.router.PathPrefix("/admin").Subrouter()
+	admin.Use(p.hub.AdminAuth)
+	admin.HandleFunc("/dashboard", p.hub.Dashboard).Methods("GET")
+	admin.HandleFunc("/dashboard/suspend", p.hub.DashboardSuspend).
+		Methods("POST")
+	admin.HandleFunc("/dashboard/reinstate", p.hub.DashboardReinstate).
+		Methods("POST")
+	admin.HandleFunc("/dashboard/payouts", p.hub.DashboardPayouts).
+		Methods("POST")
+	admin.HandleFunc("/dashboard/work", p.hub.DashboardRegenerateWork).
+		Methods("POST")
+	admin.HandleFunc("/work/regenerate", p.hub.RegenerateWork).
+		Methods("POST")
+	admin.HandleFunc("/account/2fa/reset", p.hub.ResetTOTP).Methods("POST")
+	admin.HandleFunc("/account/sessions/revoke",
+		p.hub.RevokeAccountSessions).Methods("POST")
+	admin.HandleFunc("/accounts", p.hub.ListAccounts).Methods("GET")
+	admin.HandleFunc("/clients", p.hub.ListClients).Methods("GET")
+	admin.HandleFunc("/eventlog", p.hub.ListPoolEvents).Methods("GET")
+	admin.HandleFunc("/reconciliation", p.hub.Reconciliation).Methods("GET")
+	admin.HandleFunc("/config/reload", p.hub.ReloadConfig).Methods("POST")
+	admin.HandleFunc("/clients/disconnect", p.hub.DisconnectClient).
+		Methods("POST")
+	admin.HandleFunc("/accounts/import", p.hub.ImportAccounts).
+		Methods("POST")
+	admin.HandleFunc("/account", p.hub.FetchAccountDetails).Methods("GET")
+	admin.HandleFunc("/account/notes", p.hub.AnnotateAccount).Methods("POST")
+	admin.HandleFunc("/account/suspend", p.hub.SuspendAccount).
+		Methods("POST")
+	admin.HandleFunc("/account/reinstate", p.hub.ReinstateAccount).
+		Methods("POST")
+
+	if p.cfg.SimnetHarness {
+		admin.HandleFunc("/harness/generate", p.hub.GenerateBlocks).
+			Methods("POST")
+		admin.HandleFunc("/harness/status", p.hub.HarnessStatus).
+			Methods("GET")
+	}
+
+	// Operator views serve account routes read-only as the account provided.
+	view := admin.PathPrefix("/view").Subrouter()
+	view.Use(p.hub.Impersonate)
+	view.HandleFunc("/account/mined", p.hub.FetchAccountMinedWork).
+		Methods("GET")
+	view.HandleFunc("/account/payments", p.hub.FetchAccountPayments).
+		Methods("GET")
+	view.HandleFunc("/account/payments/csv", p.hub.DownloadAccountPayments).
+		Methods("GET")
+	view.HandleFunc("/account/workers", p.hub.ListWorkers).Methods("GET")
+	view.HandleFunc("/account/workers/detail", p.hub.FetchWorkerDetails).
+		Methods("GET")
+	view.HandleFunc("/account/hashrate", p.hub.FetchAccountHashRates).
+		Methods("GET")
+	view.HandleFunc("/account/activity", p.hub.FetchAccountActivity).
+		Methods("GET")
+	view.HandleFunc("/account/notifications",
+		p.hub.FetchNotificationPreferences).Methods("GET")
 }
 
-// serveAPI starts the pool api server.
+// serveACME starts the server answering the Let's Encrypt http challenges of
+// the provided certificate manager, other requests are redirected to https.
+func (p *Pool) serveACME(m *autocert.Manager) {
+	p.acmes = &http.Server{
+		Addr:         fmt.Sprintf("0.0.0.0:%v", p.cfg.ACMEHTTPPort),
+		WriteTimeout: time.Second * 30,
+		ReadTimeout:  time.Second * 5,
+		IdleTimeout:  time.Second * 30,
+		Handler:      m.HTTPHandler(nil),
+	}
+
+	pLog.Infof("ACME challenge server listening on port %v.",
+		p.cfg.ACMEHTTPPort)
+
+	go func() {
+		if err := p.acmes.ListenAndServe(); err != nil &&
+			err != http.ErrServerClosed {
+			pLog.Error(err)
+		}
+	}()
+}
+
+// serveAPI starts the pool api server. Certificates of the configured
+// domains are obtained and renewed from Let's Encrypt, the self-signed pool
+// certificate is served when no domains are configured.
 func (p *Pool) serveAPI() {
+	if p.cfg.NoAPI {
+		pLog.Info("API server disabled.")
+		return
+	}
+
 	p.route()
+	addr := net.JoinHostPort(p.cfg.APIListen,
+		strconv.FormatUint(uint64(p.cfg.APIPort), 10))
 	p.server = &http.Server{
-		Addr:         fmt.Sprintf("0.0.0.0:%v", p.cfg.APIPort),
+		Addr:         addr,
 		WriteTimeout: time.Second * 30,
 		ReadTimeout:  time.Second * 5,
 		IdleTimeout:  time.Second * 30,
 		Handler:      p.router,
 	}
 
-	pLog.Infof("API server listening on port %v.", p.cfg.APIPort)
+	certFile, keyFile := defaultTLSCertFile, defaultTLSKeyFile
+	if len(p.cfg.Domains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(defaultCertsDir),
+			HostPolicy: autocert.HostWhitelist(p.cfg.Domains...),
+			Email:      p.cfg.ACMEEmail,
+		}
+		p.server.TLSConfig = &tls.Config{GetCertificate: m.GetCertificate}
+		certFile, keyFile = "", ""
+		p.serveACME(m)
+	}
+
+	pLog.Infof("API server listening on %v.", addr)
 
 	go func() {
-		if err := p.server.ListenAndServeTLS(defaultTLSCertFile, defaultTLSKeyFile); err != nil &&
+		if err := p.server.ListenAndServeTLS(certFile, keyFile); err != nil &&
 			err != http.ErrServerClosed {
 			pLog.Error(err)
 		}
 	}()
 }
 
-// shutdownAPI tears down the pool api server.
+// serveAdminRPC starts the gRPC admin service if configured.
+func (p *Pool) serveAdminRPC() error {
+	if p.cfg.AdminRPCPort == 0 {
+		return nil
+	}
+
+	creds, err := credentials.NewServerTLSFromFile(defaultTLSCertFile,
+		defaultTLSKeyFile)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp",
+		fmt.Sprintf("0.0.0.0:%v", p.cfg.AdminRPCPort))
+	if err != nil {
+		return err
+	}
+
+	srv := network.NewAdminRPCServer(p.hub, p.cfg.adminToken)
+	p.rpcs = grpc.NewServer(grpc.Creds(creds),
+		grpc.UnaryInterceptor(srv.UnaryInterceptor),
+		grpc.StreamInterceptor(srv.StreamInterceptor))
+	adminrpc.RegisterAdminServiceServer(p.rpcs, srv)
+
+	pLog.Infof("Admin RPC server listening on port %v.", p.cfg.AdminRPCPort)
+
+	go func() {
+		if err := p.rpcs.Serve(listener); err != nil {
+			pLog.Error(err)
+		}
+	}()
+
+	return nil
+}
+
+// shutdownAdminRPC tears down the gRPC admin service if running.
+func (p *Pool) shutdownAdminRPC() {
+	if p.rpcs != nil {
+		p.rpcs.GracefulStop()
+	}
+}
+
+// serveFrontendRPC starts the internal gRPC service of stratum frontends if
+// configured.
+func (p *Pool) serveFrontendRPC() error {
+	if p.cfg.FrontendRPCPort == 0 {
+		return nil
+	}
+
+	creds, err := credentials.NewServerTLSFromFile(defaultTLSCertFile,
+		defaultTLSKeyFile)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp",
+		fmt.Sprintf("0.0.0.0:%v", p.cfg.FrontendRPCPort))
+	if err != nil {
+		return err
+	}
+
+	srv := network.NewFrontendRPCServer(p.hub, p.cfg.FrontendSecret)
+	p.frpcs = grpc.NewServer(grpc.Creds(creds),
+		grpc.UnaryInterceptor(srv.UnaryInterceptor),
+		grpc.StreamInterceptor(srv.StreamInterceptor))
+	frontendrpc.RegisterFrontendServiceServer(p.frpcs, srv)
+
+	pLog.Infof("Frontend RPC server listening on port %v.",
+		p.cfg.FrontendRPCPort)
+
+	go func() {
+		if err := p.frpcs.Serve(listener); err != nil {
+			pLog.Error(err)
+		}
+	}()
+
+	return nil
+}
+
+// shutdownFrontendRPC tears down the gRPC frontend service if running.
+// Frontend work streams only end with the hub, so the service is stopped
+// without waiting for them.
+func (p *Pool) shutdownFrontendRPC() {
+	if p.frpcs != nil {
+		p.frpcs.Stop()
+	}
+}
+
+// shutdownAPI tears down the pool api server if running.
 func (p *Pool) shutdownAPI() {
+	if p.server == nil {
+		return
+	}
+
 	ctx, cl := context.WithTimeout(p.ctx, time.Second*5)
 	defer cl()
 	if err := p.server.Shutdown(ctx); err != nil {
 		pLog.Error(err)
 	}
+
+	if p.acmes != nil {
+		if err := p.acmes.Shutdown(ctx); err != nil {
+			pLog.Error(err)
+		}
+	}
 }
 
-// NewPool initializes the mining pool.
-func NewPool(cfg *config) (*Pool, error) {
-	p := new(Pool)
-	p.cfg = cfg
+// serveProfiler starts the net/http/pprof profiling server if configured.
+// Profiles served beyond localhost are served over TLS and require operator
+// authentication.
+func (p *Pool) serveProfiler() {
+	if p.cfg.ProfilePort == 0 {
+		return
+	}
 
-	err := p.initDB()
+	profiles := http.NewServeMux()
+	profiles.HandleFunc("/debug/pprof/", pprof.Index)
+	profiles.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	profiles.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	profiles.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	profiles.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	// Profiles and traces are written over the requested period, the
+	// server has no write timeout.
+	addr := net.JoinHostPort(p.cfg.ProfileListen,
+		strconv.FormatUint(uint64(p.cfg.ProfilePort), 10))
+	p.profs = &http.Server{
+		Addr:        addr,
+		ReadTimeout: time.Second * 5,
+		IdleTimeout: time.Second * 30,
+		Handler:     profiles,
+	}
+
+	loopback := isLoopback(p.cfg.ProfileListen)
+	if !loopback {
+		p.profs.Handler = p.hub.AdminAuth(profiles)
+	}
+
+	pLog.Infof("Profile server listening on %v.", addr)
+
+	go func() {
+		var err error
+		if loopback {
+			err = p.profs.ListenAndServe()
+		} else {
+			err = p.profs.ListenAndServeTLS(defaultTLSCertFile,
+				defaultTLSKeyFile)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			pLog.Error(err)
+		}
+	}()
+}
+
+// shutdownProfiler tears down the profiling server if running.
+func (p *Pool) shutdownProfiler() {
+	if p.profs == nil {
+		return
+	}
+
+	ctx, cl := context.WithTimeout(context.Background(), time.Second*5)
+	defer cl()
+	if err := p.profs.Shutdown(ctx); err != nil {
+		pLog.Error(err)
+	}
+}
+
+// readReloadableConfig re-reads the configuration reloadable while the pool
+// runs.
+func (p *Pool) readReloadableConfig() (*network.ReloadableConfig, error) {
+	cfg, err := reloadConfig(p.cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	p.limiter = network.NewRateLimiter()
+	minPmt, err := dcrutil.NewAmount(cfg.MinPayment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &network.ReloadableConfig{
+		MaxGenTime: new(big.Int).SetUint64(cfg.MaxGenTime),
+		MinPayment: minPmt,
+		BannedNets: cfg.bannedNets,
+	}, nil
+}
+
+// dcrdRPCConfigs returns the RPC connection configs of the primary and backup
+// dcrd backends.
+func dcrdRPCConfigs(cfg *config) (*rpcclient.ConnConfig, []*rpcclient.ConnConfig) {
 	dcrdRPCCfg := &rpcclient.ConnConfig{
 		Host:         cfg.DcrdRPCHost,
 		Endpoint:     "ws",
 		User:         cfg.RPCUser,
 		Pass:         cfg.RPCPass,
 		Certificates: cfg.dcrdRPCCerts,
+
+		// The hub reconnects with backoff and resumes work itself.
+		DisableAutoReconnect: true,
+	}
+
+	dcrdBackupCfgs := make([]*rpcclient.ConnConfig, 0, len(cfg.DcrdBackups))
+	for _, host := range cfg.DcrdBackups {
+		dcrdBackupCfgs = append(dcrdBackupCfgs, &rpcclient.ConnConfig{
+			Host:                 host,
+			Endpoint:             "ws",
+			User:                 cfg.RPCUser,
+			Pass:                 cfg.RPCPass,
+			Certificates:         cfg.dcrdBackupCerts,
+			DisableAutoReconnect: true,
+		})
+	}
+
+	return dcrdRPCCfg, dcrdBackupCfgs
+}
+
+// NewPool initializes the mining pool.
+func NewPool(cfg *config) (*Pool, error) {
+	p := new(Pool)
+	p.cfg = cfg
+
+	err := p.initDB()
+	if err != nil {
+		return nil, err
 	}
 
+	p.limiter = network.NewRateLimiter(cfg.APIRate, cfg.APIBurst,
+		cfg.APIKeyRate, cfg.APIKeyBurst)
+	dcrdRPCCfg, dcrdBackupCfgs := dcrdRPCConfigs(cfg)
+
 	minPmt, err := dcrutil.NewAmount(cfg.MinPayment)
 	if err != nil {
 		return nil, err
@@ -183,22 +675,91 @@ func NewPool(cfg *config) (*Pool, error) {
 		return nil, err
 	}
 
+	minFeeRate, err := dcrutil.NewAmount(cfg.MinFeeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	maxFeeRate, err := dcrutil.NewAmount(cfg.MaxFeeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pools taking over as standbys are no longer fenced by a standby of
+	// their own.
+	var haLease time.Duration
+	if cfg.Standby == "" {
+		haLease = time.Duration(cfg.HALease) * time.Second
+	}
+
 	p.ctx, p.cancel = context.WithCancel(context.Background())
 	hcfg := &network.HubConfig{
 		ActiveNet:         cfg.net,
 		WalletRPCCertFile: cfg.WalletRPCCert,
 		WalletGRPCHost:    cfg.WalletGRPCHost,
+		WalletClientCert:  cfg.WalletTLSCert,
+		WalletClientKey:   cfg.WalletTLSKey,
+		WalletKeyPEM:      cfg.walletTLSKey,
 		DcrdRPCCfg:        dcrdRPCCfg,
+		DcrdBackupCfgs:    dcrdBackupCfgs,
 		PoolFee:           cfg.PoolFee,
 		MaxTxFeeReserve:   maxTxFeeReserve,
+		MinFeeRate:        minFeeRate,
+		MaxFeeRate:        maxFeeRate,
 		MaxGenTime:        new(big.Int).SetUint64(cfg.MaxGenTime),
 		PaymentMethod:     cfg.PaymentMethod,
 		LastNPeriod:       cfg.LastNPeriod,
 		WalletPass:        cfg.WalletPass,
+		WalletPassFile:    cfg.WalletPassFile,
+		PayoutAccount:     cfg.PayoutAccount,
 		MinPayment:        minPmt,
 		PoolFeeAddrs:      cfg.poolFeeAddrs,
 		SoloPool:          cfg.SoloPool,
 		BackupPass:        cfg.BackupPass,
+		AdminPass:         cfg.AdminPass,
+		SMTPHost:          cfg.SMTPHost,
+		SMTPUser:          cfg.SMTPUser,
+		SMTPPass:          cfg.SMTPPass,
+		SMTPFrom:          cfg.SMTPFrom,
+		PublicURL:         cfg.PublicURL,
+		AddrChangeDelay:   cfg.AddrChangeDelay,
+		CaseInsensitive:   cfg.CaseInsensitive,
+		WorkerOffline:     cfg.WorkerOffline,
+		CaptchaURL:        cfg.CaptchaURL,
+		CaptchaSecret:     cfg.CaptchaSecret,
+		ExplorerURL:       cfg.ExplorerURL,
+		CORSOrigins:       cfg.CORSOrigins,
+		CORSMethods:       cfg.CORSMethods,
+		CORSHeaders:       cfg.CORSHeaders,
+		LangDir:           cfg.LangDir,
+		GUIDir:            cfg.GUIDir,
+		HashRatePolicy:    cfg.hashRatePolicy,
+		LegacyAPISunset:   cfg.legacySunset,
+		TrustedProxies:    cfg.trustedProxies,
+		StratumListen:     cfg.StratumListen,
+		AccountMetrics:    cfg.AccountMetrics,
+		SessionLifetime:   cfg.SessionLifetime,
+		ReadyLatency:      time.Duration(cfg.ReadyLatency) * time.Millisecond,
+		NoPreflight:       cfg.NoPreflight,
+		SubmitRetries:     cfg.SubmitRetries,
+		BannedNets:        cfg.bannedNets,
+		ReadConfig:        p.readReloadableConfig,
+		InstantMine:       cfg.InstantMine,
+		HALease:           haLease,
+		Frontends:         cfg.FrontendRPCPort != 0,
+		Regions:           cfg.regions,
+		Region:            cfg.StratumRegion,
+		StratumPorts:      cfg.stratumPorts,
+		MaxClients:        cfg.MaxClients,
+		MaxIPClients:      cfg.MaxIPClients,
+		Alerts: network.AlertThresholds{
+			HashRateDrop:   cfg.AlertHashDrop,
+			RejectRate:     cfg.AlertRejects,
+			DBSize:         int64(cfg.AlertDBSize) * 1e6,
+			BackendLatency: time.Duration(cfg.AlertLatency) * time.Millisecond,
+			WorkAge:        time.Duration(cfg.AlertWorkAge) * time.Second,
+			Email:          cfg.AlertEmail,
+		},
 	}
 
 	p.hub, err = network.NewHub(p.ctx, p.cancel, p.db, p.httpc, hcfg, p.limiter)
@@ -219,15 +780,61 @@ func main() {
 	cfg, _, err := loadConfig()
 	if err != nil {
 		pLog.Error(err)
+		os.Exit(1)
+	}
+
+	// Print the effective configuration and exit when only validating it,
+	// loading it validated it.
+	if cfg.ValidateConfig {
+		err := validateConfig(cfg, os.Stdout)
+		if logRotator != nil {
+			logRotator.Close()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "Configuration is valid.")
 		return
 	}
+
 	defer func() {
 		if logRotator != nil {
 			logRotator.Close()
 		}
 	}()
 
-	p, err := NewPool(cfg)
+	// Standbys replicate the primary pool until it fails, the pool is then
+	// started from the replicated database.
+	if cfg.Standby != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-interrupt:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		s, err := newStandby(cfg)
+		if err == nil {
+			err = s.run(ctx)
+		}
+		cancel()
+		if err != nil {
+			if err != context.Canceled {
+				pLog.Error(err)
+			}
+			return
+		}
+	}
+
+	var p *Pool
+	if cfg.Frontend != "" {
+		p, err = NewFrontend(cfg)
+	} else {
+		p, err = NewPool(cfg)
+	}
 	if err != nil {
 		pLog.Error(err)
 		return
@@ -238,16 +845,48 @@ func main() {
 	pLog.Infof("Home dir: %s", cfg.HomeDir)
 	pLog.Infof("Started dcrpool.")
 
+	// Reload the configuration on SIGHUP.
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
 	go func() {
-		select {
-		case <-p.ctx.Done():
-			return
-		case <-interrupt:
-			p.cancel()
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-interrupt:
+				p.cancel()
+				return
+			case <-reload:
+				err := p.hub.Reload("")
+				if err != nil {
+					pLog.Errorf("Failed to reload configuration: %v", err)
+				}
+			}
 		}
 	}()
 
-	p.serveAPI()
+	go p.notifySystemd()
+
+	p.serveProfiler()
+
+	// Stratum frontends only serve miners.
+	if cfg.Frontend == "" {
+		p.serveAPI()
+		err = p.serveAdminRPC()
+		if err != nil {
+			pLog.Errorf("Failed to start admin RPC server: %v", err)
+			p.cancel()
+		}
+		err = p.serveFrontendRPC()
+		if err != nil {
+			pLog.Errorf("Failed to start frontend RPC server: %v", err)
+			p.cancel()
+		}
+	}
 	p.hub.Run(p.ctx)
+	p.shutdownFrontendRPC()
+	p.shutdownAdminRPC()
 	p.shutdownAPI()
+	p.shutdownProfiler()
 }
@@ -0,0 +1,125 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/dnldd/dcrpool/util"
+)
+
+const (
+	// secretFilePrefix prefixes secrets read from a file.
+	secretFilePrefix = "file:"
+
+	// secretEnvPrefix prefixes secrets read from an environment variable.
+	secretEnvPrefix = "env:"
+
+	// secretCmdPrefix prefixes secrets fetched by the secrets command.
+	secretCmdPrefix = "cmd:"
+
+	// secretCmdTimeout is the time the secrets command has to print a
+	// secret.
+	secretCmdTimeout = time.Second * 30
+)
+
+// secretOption is an option holding a secret.
+type secretOption struct {
+	name  string
+	value *string
+}
+
+// secretOptions returns the options of the provided config holding secrets,
+// which may reference their secret.
+func secretOptions(cfg *config) []secretOption {
+	return []secretOption{
+		{"rpcuser", &cfg.RPCUser},
+		{"rpcpass", &cfg.RPCPass},
+		{"walletpass", &cfg.WalletPass},
+		{"backuppass", &cfg.BackupPass},
+		{"adminpass", &cfg.AdminPass},
+		{"smtpuser", &cfg.SMTPUser},
+		{"smtppass", &cfg.SMTPPass},
+		{"captchasecret", &cfg.CaptchaSecret},
+		{"frontendsecret", &cfg.FrontendSecret},
+	}
+}
+
+// isSecretRef returns whether the provided option value references a secret
+// rather than being the secret.
+func isSecretRef(value string) bool {
+	return strings.HasPrefix(value, secretFilePrefix) ||
+		strings.HasPrefix(value, secretEnvPrefix) ||
+		strings.HasPrefix(value, secretCmdPrefix)
+}
+
+// resolveSecret returns the secret referenced by the provided option value:
+// the contents of the file of a file:<path> reference, the value of the
+// variable of an env:<name> reference or the output of the secrets command
+// run with the name of a cmd:<name> reference as its argument. Trailing line
+// breaks are trimmed. Values not referencing a secret are returned as is.
+func resolveSecret(value string, command string) (string, error) {
+	var secret string
+	switch {
+	case strings.HasPrefix(value, secretFilePrefix):
+		path := util.CleanAndExpandPath(strings.TrimPrefix(value,
+			secretFilePrefix))
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		secret = string(b)
+
+	case strings.HasPrefix(value, secretEnvPrefix):
+		name := strings.TrimPrefix(value, secretEnvPrefix)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %v is not set", name)
+		}
+		secret = v
+
+	case strings.HasPrefix(value, secretCmdPrefix):
+		if command == "" {
+			return "", fmt.Errorf("no secrets command set for %v", value)
+		}
+
+		name := strings.TrimPrefix(value, secretCmdPrefix)
+		ctx, cancel := context.WithTimeout(context.Background(),
+			secretCmdTimeout)
+		defer cancel()
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, command, name)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			msg := strings.TrimSpace(stderr.String())
+			if msg != "" {
+				return "", fmt.Errorf("secrets command failed for %v: "+
+					"%v: %v", name, err, msg)
+			}
+			return "", fmt.Errorf("secrets command failed for %v: %v",
+				name, err)
+		}
+		secret = string(out)
+
+	default:
+		return value, nil
+	}
+
+	secret = strings.TrimRight(secret, "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("empty secret referenced by %v", value)
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+
+	"github.com/dnldd/dcrpool/network"
+)
+
+// standby replicates the database of the primary pool.
+type standby struct {
+	cfg    *config
+	client *http.Client
+	acked  int64
+}
+
+// newStandby creates the standby of the configured primary pool.
+func newStandby(cfg *config) (*standby, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(cfg.standbyCerts) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(cfg.standbyCerts) {
+			return nil, fmt.Errorf("invalid primary pool certificate")
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	lease := time.Duration(cfg.HALease) * time.Second
+	return &standby{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: lease,
+			Transport: &http.Transport{
+				TLSClientConfig: tlsCfg,
+			},
+		},
+	}, nil
+}
+
+// replicate stores a snapshot of the database of the primary, acknowledging
+// the previously stored snapshot. Snapshots are verified before replacing
+// the database of the standby.
+func (s *standby) replicate(ctx context.Context) error {
+	body, err := json.Marshal(&network.ReplicationRequest{
+		Pass:  s.cfg.BackupPass,
+		Acked: s.acked,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimSuffix(s.cfg.Standby, "/") + "/ha/replicate"
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("primary responded with status %v", resp.Status)
+	}
+
+	id, err := strconv.ParseInt(resp.Header.Get(network.SnapshotIDHeader),
+		10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot id: %v", err)
+	}
+
+	tmp := s.cfg.DBFile + ".replica"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("unable to store snapshot: %v", err)
+	}
+
+	db, err := bolt.Open(tmp, 0600, &bolt.Options{
+		Timeout:  time.Second,
+		ReadOnly: true,
+	})
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("invalid snapshot: %v", err)
+	}
+	db.Close()
+
+	err = os.Rename(tmp, s.cfg.DBFile)
+	if err != nil {
+		return err
+	}
+
+	s.acked = id
+	return nil
+}
+
+// run replicates the primary every third of its lease until it fails to for
+// the failover period, it then waits for the lease of the primary to expire
+// before returning so the standby takes over. Standbys only take over after
+// replicating the primary at least once.
+func (s *standby) run(ctx context.Context) error {
+	lease := time.Duration(s.cfg.HALease) * time.Second
+	failover := time.Duration(s.cfg.HAFailover) * time.Second
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+
+	pLog.Infof("Standby of %v, replicating its database.", s.cfg.Standby)
+
+	var lastReplicated time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case now := <-ticker.C:
+			err := s.replicate(ctx)
+			if err == nil {
+				if lastReplicated.IsZero() ||
+					now.Sub(lastReplicated) > lease {
+					pLog.Infof("Replicating primary pool %v", s.cfg.Standby)
+				}
+				lastReplicated = now
+				continue
+			}
+
+			pLog.Warnf("Failed to replicate primary pool: %v", err)
+			if lastReplicated.IsZero() || now.Sub(lastReplicated) < failover {
+				continue
+			}
+
+			// The lease of the primary is renewed by replication requests
+			// reaching it even when their responses are lost, it expires a
+			// lease period after the last request.
+			pLog.Warnf("Primary pool unreachable since %v, taking over "+
+				"once its lease expires in %v", lastReplicated, lease)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(lease + time.Second):
+			}
+
+			pLog.Infof("Taking over from primary pool %v", s.cfg.Standby)
+			return nil
+		}
+	}
+}
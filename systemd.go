@@ -0,0 +1,118 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sdNotify sends the provided state to the systemd service manager. States
+// are not sent when the pool is not run as a systemd notify service.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	conn, err := net.DialUnix("unixgram", nil,
+		&net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// sdWatchdogTimeout returns the systemd watchdog timeout of the pool, zero
+// when the watchdog is not enabled for the pool process.
+func sdWatchdogTimeout() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+
+	pid := os.Getenv("WATCHDOG_PID")
+	if pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+
+	timeout, err := strconv.ParseUint(usec, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(timeout) * time.Microsecond, nil
+}
+
+// notifySystemd notifies systemd once the pool has caught up with the chain
+// and serves miners, and when the pool stops. With the systemd watchdog
+// enabled the watchdog is pinged at half its timeout for as long as the work
+// handler of the pool keeps iterating, so systemd restarts a wedged pool. It
+// must be run as a goroutine.
+func (p *Pool) notifySystemd() {
+	if os.Getenv("NOTIFY_SOCKET") == "" {
+		return
+	}
+
+	timeout, err := sdWatchdogTimeout()
+	if err != nil {
+		pLog.Errorf("Invalid systemd watchdog timeout: %v", err)
+	}
+
+	interval := time.Second
+	if timeout > 0 {
+		interval = timeout / 2
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var ready bool
+	for {
+		select {
+		case <-p.ctx.Done():
+			err := sdNotify("STOPPING=1")
+			if err != nil {
+				pLog.Errorf("Failed to notify systemd: %v", err)
+			}
+			return
+
+		case <-ticker.C:
+			heartbeat := p.hub.Heartbeat()
+			if heartbeat.IsZero() {
+				continue
+			}
+
+			if !ready {
+				err := sdNotify("READY=1\nSTATUS=Serving miners")
+				if err != nil {
+					pLog.Errorf("Failed to notify systemd: %v", err)
+					return
+				}
+				ready = true
+				pLog.Debug("Notified systemd the pool is ready.")
+			}
+
+			if timeout == 0 {
+				continue
+			}
+
+			if time.Since(heartbeat) > timeout {
+				pLog.Warnf("Work handler stalled since %v, skipping systemd "+
+					"watchdog ping", heartbeat)
+				continue
+			}
+
+			err := sdNotify("WATCHDOG=1")
+			if err != nil {
+				pLog.Errorf("Failed to ping systemd watchdog: %v", err)
+			}
+		}
+	}
+}
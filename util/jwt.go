@@ -0,0 +1,65 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jwtHeader is the encoded header of all JSON web tokens signed, only
+// HMAC-SHA256 signatures are supported.
+var jwtHeader = base64.RawURLEncoding.EncodeToString(
+	[]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// jwtSignature returns the encoded HMAC-SHA256 signature of the provided
+// signing input.
+func jwtSignature(input string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(input))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignJWT encodes the provided claims as a JSON web token signed with the
+// provided key.
+func SignJWT(claims interface{}, key []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	input := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return input + "." + jwtSignature(input, key), nil
+}
+
+// VerifyJWT asserts the provided JSON web token was signed with the provided
+// key and decodes its claims. Claims such as expiry are left to the caller
+// to validate.
+func VerifyJWT(token string, key []byte, claims interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed token")
+	}
+
+	if parts[0] != jwtHeader {
+		return fmt.Errorf("unsupported token header")
+	}
+
+	expected := jwtSignature(parts[0]+"."+parts[1], key)
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return fmt.Errorf("invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(payload, claims)
+}
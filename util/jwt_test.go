@@ -0,0 +1,42 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"testing"
+)
+
+func TestJWT(t *testing.T) {
+	type claims struct {
+		Subject   string `json:"sub"`
+		ExpiresAt int64  `json:"exp"`
+	}
+
+	key := []byte("secret")
+	token, err := SignJWT(&claims{Subject: "account", ExpiresAt: 10}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded claims
+	err = VerifyJWT(token, key, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Subject != "account" || decoded.ExpiresAt != 10 {
+		t.Fatalf("unexpected claims %+v", decoded)
+	}
+
+	err = VerifyJWT(token, []byte("other"), &decoded)
+	if err == nil {
+		t.Fatal("expected token signed with another key to be rejected")
+	}
+
+	err = VerifyJWT(token+"x", key, &decoded)
+	if err == nil {
+		t.Fatal("expected tampered token to be rejected")
+	}
+}
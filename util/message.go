@@ -0,0 +1,71 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrec/secp256k1"
+	"github.com/decred/dcrd/dcrutil"
+	"github.com/decred/dcrd/wire"
+)
+
+// signedMessageMagic is the prefix of messages signed by dcrwallet.
+const signedMessageMagic = "Decred Signed Message:\n"
+
+// SignedMessageHash returns the hash signed by dcrwallet when signing the
+// provided message.
+func SignedMessageHash(message string) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, signedMessageMagic)
+	wire.WriteVarString(&buf, 0, message)
+	return chainhash.HashB(buf.Bytes())
+}
+
+// VerifyMessage asserts the provided base64 encoded signature of the
+// provided message was created with the key of the provided pay-to-pubkey-hash
+// address, as produced by dcrwallet's signmessage.
+func VerifyMessage(address string, signature string, message string, net *chaincfg.Params) (bool, error) {
+	addr, err := dcrutil.DecodeAddress(address)
+	if err != nil {
+		return false, fmt.Errorf("unable to decode address: %v", err)
+	}
+
+	if _, ok := addr.(*dcrutil.AddressPubKeyHash); !ok {
+		return false, fmt.Errorf("address (%v) is not a pay-to-pubkey-hash "+
+			"address", address)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, fmt.Errorf("malformed base64 encoding: %v", err)
+	}
+
+	pubKey, compressed, err := secp256k1.RecoverCompact(sig,
+		SignedMessageHash(message))
+	if err != nil {
+		// Mirror dcrd in reporting an unrecoverable signature as invalid
+		// rather than as an error.
+		return false, nil
+	}
+
+	var serializedPubKey []byte
+	if compressed {
+		serializedPubKey = pubKey.SerializeCompressed()
+	} else {
+		serializedPubKey = pubKey.SerializeUncompressed()
+	}
+
+	recovered, err := dcrutil.NewAddressSecpPubKey(serializedPubKey, net)
+	if err != nil {
+		return false, err
+	}
+
+	return recovered.EncodeAddress() == address, nil
+}
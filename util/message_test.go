@@ -0,0 +1,56 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+	"github.com/decred/dcrd/dcrec/secp256k1"
+	"github.com/decred/dcrd/dcrutil"
+)
+
+func TestVerifyMessage(t *testing.T) {
+	net := &chaincfg.SimNetParams
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pubKey := (*secp256k1.PublicKey)(&key.PublicKey)
+	addr, err := dcrutil.NewAddressSecpPubKey(pubKey.SerializeCompressed(),
+		net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := addr.EncodeAddress()
+
+	message := "dcrpool login challenge"
+	sig, err := secp256k1.SignCompact(key, SignedMessageHash(message), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := base64.StdEncoding.EncodeToString(sig)
+
+	valid, err := VerifyMessage(address, signature, message, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !valid {
+		t.Error("expected signature to be valid")
+	}
+
+	// Ensure a signature of a different message is rejected.
+	valid, err = VerifyMessage(address, signature, "another message", net)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if valid {
+		t.Error("expected signature of another message to be invalid")
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for ip, want := range map[string]bool{
+		"10.1.2.3":    true,
+		"192.168.1.1": true,
+		"192.168.1.2": false,
+		"::1":         true,
+		"::2":         false,
+	} {
+		if ContainsIP(nets, net.ParseIP(ip)) != want {
+			t.Fatalf("expected %v to be contained: %v", ip, want)
+		}
+	}
+
+	for _, entry := range []string{"10.0.0.0/33", "localhost"} {
+		_, err := ParseCIDRs([]string{entry})
+		if err == nil {
+			t.Fatalf("expected %v to be invalid", entry)
+		}
+	}
+}
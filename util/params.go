@@ -0,0 +1,30 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg"
+)
+
+// NetParams returns the chain parameters of the named network. A copy of the
+// parameters is returned so callers can adjust them for the network they
+// mine on without affecting other users of the network parameters.
+func NetParams(name string) (*chaincfg.Params, error) {
+	var params chaincfg.Params
+	switch name {
+	case chaincfg.MainNetParams.Name:
+		params = chaincfg.MainNetParams
+	case chaincfg.TestNet3Params.Name:
+		params = chaincfg.TestNet3Params
+	case chaincfg.SimNetParams.Name:
+		params = chaincfg.SimNetParams
+	default:
+		return nil, fmt.Errorf("unknown network provided %v", name)
+	}
+
+	return &params, nil
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg"
+)
+
+func TestNetParams(t *testing.T) {
+	for _, name := range []string{chaincfg.MainNetParams.Name,
+		chaincfg.TestNet3Params.Name, chaincfg.SimNetParams.Name} {
+		params, err := NetParams(name)
+		if err != nil {
+			t.Fatalf("unexpected error for %v: %v", name, err)
+		}
+		if params.Name != name {
+			t.Fatalf("expected %v params, got %v", name, params.Name)
+		}
+	}
+
+	params, err := NetParams(chaincfg.TestNet3Params.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.PowLimit = chaincfg.MainNetParams.PowLimit
+	if chaincfg.TestNet3Params.PowLimit == chaincfg.MainNetParams.PowLimit {
+		t.Fatal("adjusting returned params modified the network params")
+	}
+
+	_, err = NetParams("regnet")
+	if err == nil {
+		t.Fatal("expected an unknown network error")
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rollTimeFormat is the time format of the suffix of rolled log files.
+const rollTimeFormat = "20060102-150405.000"
+
+// LogRotation configures when log files are rolled and how long rolled log
+// files are retained, zero values disable their setting.
+type LogRotation struct {
+	// MaxSize is the size in bytes a log file is rolled at.
+	MaxSize int64
+
+	// Interval is the period log files are rolled at, log files are rolled
+	// with the first entry of every period.
+	Interval time.Duration
+
+	// Compress gzips rolled log files.
+	Compress bool
+
+	// MaxRolls is the number of rolled log files retained.
+	MaxRolls int
+
+	// Retention is the period rolled log files are retained for.
+	Retention time.Duration
+}
+
+// LogRotator writes log entries to a file, rolling it once it reaches its
+// maximum size or a new rotation period starts. Rolled files are named after
+// the log file and the time they were rolled at.
+type LogRotator struct {
+	cfg      LogRotation
+	filename string
+	out      *os.File
+	size     int64
+	lastTime time.Time
+	mtx      sync.Mutex
+	wg       sync.WaitGroup
+}
+
+// NewLogRotator opens the provided log file for appending entries, rolling
+// it per the provided rotation settings.
+func NewLogRotator(filename string, cfg LogRotation) (*LogRotator, error) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &LogRotator{
+		cfg:      cfg,
+		filename: filename,
+		out:      f,
+		size:     stat.Size(),
+		lastTime: stat.ModTime(),
+	}, nil
+}
+
+// due returns whether the log file must be rolled before writing an entry of
+// the provided length at the provided time.
+func (r *LogRotator) due(now time.Time, n int) bool {
+	if r.size == 0 {
+		return false
+	}
+
+	if r.cfg.MaxSize > 0 && r.size+int64(n) > r.cfg.MaxSize {
+		return true
+	}
+
+	return r.cfg.Interval > 0 &&
+		!now.Truncate(r.cfg.Interval).Equal(r.lastTime.Truncate(r.cfg.Interval))
+}
+
+// Write writes the provided log entry, rolling the log file beforehand when
+// due.
+func (r *LogRotator) Write(p []byte) (int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+	if r.due(now, len(p)) {
+		err := r.roll(now)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.out.Write(p)
+	r.size += int64(n)
+	r.lastTime = now
+	return n, err
+}
+
+// roll renames the log file after the provided time and opens a new log
+// file. The rolled file is compressed and old rolled files are removed in
+// the background.
+func (r *LogRotator) roll(now time.Time) error {
+	err := r.out.Close()
+	if err != nil {
+		return err
+	}
+
+	rolled := r.filename + "." + now.Format(rollTimeFormat)
+	for i := 1; fileExists(rolled) || fileExists(rolled+".gz"); i++ {
+		rolled = fmt.Sprintf("%s.%s.%d", r.filename,
+			now.Format(rollTimeFormat), i)
+	}
+
+	err = os.Rename(r.filename, rolled)
+	if err != nil {
+		return err
+	}
+
+	r.out, err = os.OpenFile(r.filename,
+		os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	r.size = 0
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if r.cfg.Compress {
+			err := compressFile(rolled)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to compress log file %s: %v\n",
+					rolled, err)
+			}
+		}
+
+		r.mtx.Lock()
+		defer r.mtx.Unlock()
+		err := r.prune(now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to remove rolled log files: %v\n",
+				err)
+		}
+	}()
+
+	return nil
+}
+
+// rolledFiles returns the rolled log files, oldest first.
+func (r *LogRotator) rolledFiles() ([]string, error) {
+	dir, base := filepath.Split(r.filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base+".") ||
+			strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// prune removes the rolled log files beyond the retained count and those
+// rolled before the retention period of the provided time.
+func (r *LogRotator) prune(now time.Time) error {
+	if r.cfg.MaxRolls == 0 && r.cfg.Retention == 0 {
+		return nil
+	}
+
+	files, err := r.rolledFiles()
+	if err != nil {
+		return err
+	}
+
+	for i, file := range files {
+		remove := r.cfg.MaxRolls > 0 && i < len(files)-r.cfg.MaxRolls
+		if !remove && r.cfg.Retention > 0 {
+			stat, err := os.Stat(file)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			remove = now.Sub(stat.ModTime()) > r.cfg.Retention
+		}
+
+		if remove {
+			err := os.Remove(file)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close closes the log file, waiting for rolled files to be compressed.
+func (r *LogRotator) Close() error {
+	r.mtx.Lock()
+	err := r.out.Close()
+	r.mtx.Unlock()
+
+	r.wg.Wait()
+	return err
+}
+
+// fileExists returns whether the provided file exists.
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// compressFile gzips the provided file and removes it. The modification time
+// of the file is kept, it is the time of its last entry.
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	stat, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := name + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	z := gzip.NewWriter(dst)
+	_, err = io.Copy(z, src)
+	if err == nil {
+		err = z.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	err = os.Chtimes(tmp, stat.ModTime(), stat.ModTime())
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	err = os.Rename(tmp, name+".gz")
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
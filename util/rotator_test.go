@@ -0,0 +1,79 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogRotator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "test.log")
+	r, err := NewLogRotator(filename, LogRotation{
+		MaxSize:  20,
+		Compress: true,
+		MaxRolls: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := []byte("0123456789abcdef\n")
+	for i := 0; i < 4; i++ {
+		_, err := r.Write(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.wg.Wait()
+	}
+
+	err = r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rolled, err := r.rolledFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rolled) != 2 {
+		t.Fatalf("expected 2 retained rolled files, got %v", rolled)
+	}
+	for _, file := range rolled {
+		if !strings.HasSuffix(file, ".gz") {
+			t.Fatalf("expected rolled file %v to be compressed", file)
+		}
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != string(entry) {
+		t.Fatalf("expected the log file to hold the last entry, got %q", b)
+	}
+
+	// Entries of a new rotation period roll the log file.
+	r.cfg = LogRotation{Interval: time.Hour}
+	r.size = int64(len(entry))
+	r.lastTime = time.Now().Add(-time.Hour)
+	if !r.due(time.Now(), len(entry)) {
+		t.Fatal("expected the log file to be due for rotation")
+	}
+	r.lastTime = time.Now().Truncate(time.Hour)
+	if r.due(time.Now(), len(entry)) {
+		t.Fatal("expected the log file not to be due for rotation")
+	}
+}
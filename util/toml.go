@@ -0,0 +1,375 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TOMLEntry is a key value pair of a TOML document. Values are strings,
+// int64s, float64s, bools or slices of those.
+type TOMLEntry struct {
+	Table []string
+	Key   string
+	Value interface{}
+	Line  int
+}
+
+// Path returns the dotted path of the entry, eg. stratum.ports.cpu.
+func (e *TOMLEntry) Path() string {
+	return strings.Join(append(append([]string{}, e.Table...), e.Key), ".")
+}
+
+// tomlParser parses the lines of a TOML document.
+type tomlParser struct {
+	table []string
+	seen  map[string]int
+	line  int
+}
+
+// ParseTOML parses the key value pairs of the provided TOML document, in
+// document order. The subset of TOML parsed covers tables, bare and quoted
+// keys, basic and literal strings, integers, floats, booleans and arrays of
+// those, which may span lines. Inline tables, arrays of tables, multi-line
+// strings and dates are not supported.
+func ParseTOML(r io.Reader) ([]*TOMLEntry, error) {
+	p := &tomlParser{seen: make(map[string]int)}
+	entries := make([]*TOMLEntry, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.line++
+		start := p.line
+		line, err := stripComment(scanner.Text())
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			err := p.parseTable(line)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		eq := keyEnd(line)
+		if eq < 0 {
+			return nil, p.errorf("expected a table or a key value pair")
+		}
+
+		key, err := parseKey(strings.TrimSpace(line[:eq]))
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+
+		// Arrays may span lines, they are joined until their brackets
+		// balance.
+		raw := strings.TrimSpace(line[eq+1:])
+		for strings.HasPrefix(raw, "[") && !balanced(raw) {
+			if !scanner.Scan() {
+				p.line = start
+				return nil, p.errorf("unterminated array of %v", key)
+			}
+			p.line++
+			next, err := stripComment(scanner.Text())
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			raw += " " + strings.TrimSpace(next)
+		}
+
+		value, err := parseTOMLValue(raw)
+		if err != nil {
+			p.line = start
+			return nil, p.errorf("invalid value of %v: %v", key, err)
+		}
+
+		entry := &TOMLEntry{
+			Table: p.table,
+			Key:   key,
+			Value: value,
+			Line:  start,
+		}
+		path := entry.Path()
+		if prev, ok := p.seen[path]; ok {
+			p.line = start
+			return nil, p.errorf("%v already set on line %v", path, prev)
+		}
+		p.seen[path] = start
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// errorf returns an error of the line being parsed.
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %v: %v", p.line, fmt.Sprintf(format, args...))
+}
+
+// parseTable parses a table header, keys that follow belong to the table.
+func (p *tomlParser) parseTable(line string) error {
+	if strings.HasPrefix(line, "[[") {
+		return p.errorf("arrays of tables are not supported")
+	}
+
+	if !strings.HasSuffix(line, "]") {
+		return p.errorf("unterminated table header")
+	}
+
+	table := make([]string, 0)
+	for _, part := range splitDotted(line[1 : len(line)-1]) {
+		name, err := parseKey(strings.TrimSpace(part))
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+		table = append(table, name)
+	}
+
+	path := strings.Join(table, ".")
+	if prev, ok := p.seen["["+path+"]"]; ok {
+		return p.errorf("table %v already defined on line %v", path, prev)
+	}
+	p.seen["["+path+"]"] = p.line
+	p.table = table
+
+	return nil
+}
+
+// stripComment removes the comment of the provided line, comments start
+// with a # outside of strings.
+func stripComment(line string) (string, error) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i], nil
+		}
+	}
+
+	if quote != 0 {
+		return "", fmt.Errorf("unterminated string")
+	}
+
+	return line, nil
+}
+
+// keyEnd returns the index of the = separating the key of the provided line
+// from its value, or -1 if there is none.
+func keyEnd(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '=':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// splitDotted splits the provided dotted table name outside of quotes.
+func splitDotted(name string) []string {
+	parts := make([]string, 0)
+	var quote byte
+	start := 0
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '.':
+			parts = append(parts, name[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, name[start:])
+}
+
+// parseKey parses a bare or quoted key. Bare keys consist of letters,
+// digits, underscores and dashes.
+func parseKey(key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("empty key")
+	}
+
+	if key[0] == '"' || key[0] == '\'' {
+		value, err := parseTOMLString(key)
+		if err != nil {
+			return "", fmt.Errorf("invalid key %v: %v", key, err)
+		}
+		return value, nil
+	}
+
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return "", fmt.Errorf("invalid key %q, dotted keys and "+
+				"spaces are not supported", key)
+		}
+	}
+
+	return key, nil
+}
+
+// balanced returns whether the brackets of the provided array are balanced
+// outside of strings.
+func balanced(raw string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '[':
+			depth++
+		case quote == 0 && c == ']':
+			depth--
+		}
+	}
+
+	return depth == 0
+}
+
+// parseTOMLString parses a basic or literal string.
+func parseTOMLString(raw string) (string, error) {
+	if len(raw) < 2 || raw[len(raw)-1] != raw[0] {
+		return "", fmt.Errorf("unterminated string")
+	}
+
+	if raw[0] == '\'' {
+		value := raw[1 : len(raw)-1]
+		if strings.Contains(value, "'") {
+			return "", fmt.Errorf("unexpected ' in literal string")
+		}
+		return value, nil
+	}
+
+	return strconv.Unquote(raw)
+}
+
+// parseTOMLValue parses a string, integer, float, boolean or array value.
+func parseTOMLValue(raw string) (interface{}, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+
+	switch {
+	case raw[0] == '"' || raw[0] == '\'':
+		return parseTOMLString(raw)
+
+	case raw[0] == '[':
+		return parseTOMLArray(raw)
+
+	case raw[0] == '{':
+		return nil, fmt.Errorf("inline tables are not supported")
+
+	case raw == "true":
+		return true, nil
+
+	case raw == "false":
+		return false, nil
+	}
+
+	num := strings.Replace(raw, "_", "", -1)
+	if i, err := strconv.ParseInt(num, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(num, 64); err == nil {
+		return f, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %q, strings must be quoted", raw)
+}
+
+// parseTOMLArray parses an array of values, trailing commas are allowed.
+func parseTOMLArray(raw string) ([]interface{}, error) {
+	if !strings.HasSuffix(raw, "]") {
+		return nil, fmt.Errorf("unexpected content after array")
+	}
+
+	values := make([]interface{}, 0)
+	inner := strings.TrimSpace(raw[1 : len(raw)-1])
+	for inner != "" {
+		// Find the end of the element, outside of strings and nested
+		// arrays.
+		end := len(inner)
+		depth := 0
+		var quote byte
+		for i := 0; i < len(inner); i++ {
+			c := inner[i]
+			switch {
+			case quote == '"' && c == '\\':
+				i++
+				continue
+			case quote != 0 && c == quote:
+				quote = 0
+			case quote == 0 && (c == '"' || c == '\''):
+				quote = c
+			case quote == 0 && c == '[':
+				depth++
+			case quote == 0 && c == ']':
+				depth--
+			case quote == 0 && depth == 0 && c == ',':
+				end = i
+			}
+			if end != len(inner) {
+				break
+			}
+		}
+
+		value, err := parseTOMLValue(strings.TrimSpace(inner[:end]))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := value.([]interface{}); ok {
+			return nil, fmt.Errorf("nested arrays are not supported")
+		}
+		values = append(values, value)
+
+		if end == len(inner) {
+			break
+		}
+		inner = strings.TrimSpace(inner[end+1:])
+	}
+
+	return values, nil
+}
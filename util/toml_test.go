@@ -0,0 +1,83 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	doc := `# dcrpool configuration
+activenet = "mainnet" # trailing comment
+
+[stratum]
+listen = '0.0.0.0'
+
+[stratum.ports]
+cpu = 5_550
+"antminerdr5" = 5554
+
+[payments]
+fee = 0.01
+solo = false
+feeaddrs = [
+	"Dsaddr1#", # hashes in strings are kept
+	"Dsaddr2",
+]
+`
+	entries, err := ParseTOML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []TOMLEntry{
+		{Key: "activenet", Value: "mainnet", Line: 2},
+		{Table: []string{"stratum"}, Key: "listen", Value: "0.0.0.0",
+			Line: 5},
+		{Table: []string{"stratum", "ports"}, Key: "cpu",
+			Value: int64(5550), Line: 8},
+		{Table: []string{"stratum", "ports"}, Key: "antminerdr5",
+			Value: int64(5554), Line: 9},
+		{Table: []string{"payments"}, Key: "fee", Value: 0.01, Line: 12},
+		{Table: []string{"payments"}, Key: "solo", Value: false, Line: 13},
+		{Table: []string{"payments"}, Key: "feeaddrs",
+			Value: []interface{}{"Dsaddr1#", "Dsaddr2"}, Line: 14},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %v entries, got %v", len(expected), len(entries))
+	}
+	for i, entry := range entries {
+		if !reflect.DeepEqual(*entry, expected[i]) {
+			t.Errorf("expected entry %+v, got %+v", expected[i], *entry)
+		}
+	}
+
+	if entries[2].Path() != "stratum.ports.cpu" {
+		t.Errorf("unexpected path %v", entries[2].Path())
+	}
+
+	for doc, line := range map[string]string{
+		"activenet = mainnet":           "line 1",
+		"a = 1\na = 2":                  "line 2",
+		"[a]\n[a]":                      "line 2",
+		"[[a]]":                         "line 1",
+		"a = {b = 1}":                   "line 1",
+		"a.b = 1":                       "line 1",
+		"a = \"unterminated":            "line 1",
+		"\n\na = [\n1,\n":               "line 3",
+		"[a\nb = 1":                     "line 1",
+		"a = [1, [2]]":                  "line 1",
+		"# comment\nkey value":          "line 2",
+		"a = 'literal'\nb = 'lit'eral'": "line 2",
+	} {
+		_, err := ParseTOML(strings.NewReader(doc))
+		if err == nil || !strings.HasPrefix(err.Error(), line) {
+			t.Errorf("expected an error on %v parsing %q, got %v", line,
+				doc, err)
+		}
+	}
+}
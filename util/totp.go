@@ -0,0 +1,111 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// totpPeriod is the time step of generated one-time passwords, in
+	// seconds.
+	totpPeriod = 30
+
+	// totpDigits is the number of digits of generated one-time passwords.
+	totpDigits = 6
+
+	// totpSkew is the number of time steps before and after the current
+	// one that are also accepted, to allow for clock drift.
+	totpSkew = 1
+
+	// totpSecretSize is the size of generated TOTP secrets, in bytes.
+	totpSecretSize = 20
+)
+
+// totpEncoding is the base32 encoding used for TOTP secrets.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random base32 encoded TOTP secret.
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// hotp computes the HMAC-based one-time password (RFC 4226) of the provided
+// key and counter.
+func hotp(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, code%1000000)
+}
+
+// TOTPCode returns the time-based one-time password (RFC 6238) of the
+// provided base32 encoded secret at the provided time.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(t.Unix())/totpPeriod), nil
+}
+
+// ValidateTOTP asserts the provided code is a valid time-based one-time
+// password of the provided base32 encoded secret at the provided time,
+// returning the time step it was issued for. Codes of time steps at or
+// below the provided last accepted time step are rejected so codes cannot
+// be replayed.
+func ValidateTOTP(secret string, code string, t time.Time, lastStep uint64) (uint64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.TrimSpace(code)
+	counter := int64(t.Unix()) / totpPeriod
+	for step := -totpSkew; step <= totpSkew; step++ {
+		current := counter + int64(step)
+		if current <= int64(lastStep) {
+			continue
+		}
+
+		expected := hotp(key, uint64(current))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return uint64(current), true
+		}
+	}
+
+	return 0, false
+}
+
+// TOTPURI returns the otpauth URI of the provided secret, which
+// authenticator apps accept as a QR code.
+func TOTPURI(secret string, issuer string, account string) string {
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, account))
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("digits", fmt.Sprintf("%d", totpDigits))
+	params.Set("period", fmt.Sprintf("%d", totpPeriod))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
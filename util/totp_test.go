@@ -0,0 +1,70 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// The RFC 6238 SHA1 test secret "12345678901234567890", base32 encoded.
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	// Test vectors from RFC 6238 appendix B, truncated to six digits.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, test := range tests {
+		now := time.Unix(test.unix, 0)
+		code, err := TOTPCode(secret, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != test.code {
+			t.Errorf("expected code %v at %v, got %v", test.code,
+				test.unix, code)
+		}
+
+		step, ok := ValidateTOTP(secret, test.code, now, 0)
+		if !ok {
+			t.Errorf("expected code %v to be valid at %v", test.code,
+				test.unix)
+		}
+
+		if step != uint64(test.unix)/totpPeriod {
+			t.Errorf("expected code %v to be of step %v, got %v",
+				test.code, uint64(test.unix)/totpPeriod, step)
+		}
+
+		// Codes remain valid within the allowed clock skew.
+		_, ok = ValidateTOTP(secret, test.code, now.Add(time.Second*30), 0)
+		if !ok {
+			t.Errorf("expected code %v to be valid within skew", test.code)
+		}
+
+		_, ok = ValidateTOTP(secret, test.code, now.Add(time.Minute*5), 0)
+		if ok {
+			t.Errorf("expected code %v to be invalid outside skew",
+				test.code)
+		}
+
+		// Ensure accepted codes cannot be replayed, within skew included.
+		_, ok = ValidateTOTP(secret, test.code, now.Add(time.Second*30),
+			step)
+		if ok {
+			t.Errorf("expected replayed code %v to be invalid", test.code)
+		}
+	}
+}
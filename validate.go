@@ -0,0 +1,99 @@
+// Copyright (c) 2018 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"time"
+
+	flags "github.com/jessevdk/go-flags"
+
+	"github.com/dnldd/dcrpool/network"
+)
+
+const (
+	// secretMask replaces the secrets of the printed effective
+	// configuration.
+	secretMask = "********"
+
+	// backendCheckTimeout is the time backends have to answer the checks
+	// of a configuration validation.
+	backendCheckTimeout = time.Second * 30
+)
+
+// writeEffectiveConfig writes the options of the provided config as ini by
+// their long names, secrets are masked. Options taking multiple values are
+// written once per value.
+func writeEffectiveConfig(w io.Writer, cfg *config) {
+	masked := *cfg
+	for _, secret := range secretOptions(&masked) {
+		if *secret.value != "" {
+			*secret.value = secretMask
+		}
+	}
+
+	parser := flags.NewParser(&masked, flags.None)
+	for _, group := range parser.Groups() {
+		for _, option := range group.Options() {
+			value := reflect.ValueOf(option.Value())
+			if value.Kind() != reflect.Slice {
+				fmt.Fprintf(w, "%s = %v\n", option.LongName, value)
+				continue
+			}
+
+			for i := 0; i < value.Len(); i++ {
+				fmt.Fprintf(w, "%s = %v\n", option.LongName,
+					value.Index(i))
+			}
+		}
+	}
+}
+
+// checkBackends asserts the dcrd backends and the wallet of the provided
+// config are reachable and on the active network, frontends only assert the
+// frontend RPC of their pool is reachable.
+func checkBackends(cfg *config) error {
+	if cfg.Frontend != "" {
+		conn, err := net.DialTimeout("tcp", cfg.Frontend, backendCheckTimeout)
+		if err != nil {
+			return fmt.Errorf("pool frontend RPC (%v) is unreachable: %v",
+				cfg.Frontend, err)
+		}
+		return conn.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		backendCheckTimeout)
+	defer cancel()
+
+	dcrdRPCCfg, dcrdBackupCfgs := dcrdRPCConfigs(cfg)
+	return network.CheckBackends(ctx, &network.HubConfig{
+		ActiveNet:         cfg.net,
+		DcrdRPCCfg:        dcrdRPCCfg,
+		DcrdBackupCfgs:    dcrdBackupCfgs,
+		WalletRPCCertFile: cfg.WalletRPCCert,
+		WalletGRPCHost:    cfg.WalletGRPCHost,
+		WalletClientCert:  cfg.WalletTLSCert,
+		WalletClientKey:   cfg.WalletTLSKey,
+		WalletKeyPEM:      cfg.walletTLSKey,
+		SoloPool:          cfg.SoloPool,
+	})
+}
+
+// validateConfig prints the effective configuration of the provided config,
+// validated as it was loaded, and checks its backends when requested.
+func validateConfig(cfg *config, w io.Writer) error {
+	writeEffectiveConfig(w, cfg)
+
+	if cfg.CheckBackends {
+		return checkBackends(cfg)
+	}
+
+	return nil
+}